@@ -0,0 +1,323 @@
+package acceptance
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/godepo/elephant"
+	"github.com/godepo/elephant/cache"
+	"github.com/godepo/elephant/chaos"
+	"github.com/godepo/elephant/clusterpg"
+	"github.com/godepo/elephant/elephanttest"
+	"github.com/godepo/elephant/shardedpg"
+	"github.com/godepo/elephant/singlepg"
+	"github.com/godepo/elephant/tenantpg"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func connect(t *testing.T, srv *elephanttest.Server) singlepg.DB {
+	t.Helper()
+	pool, err := pgxpool.New(context.Background(), srv.DSN())
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+	return singlepg.New(pool)
+}
+
+func constructor(t *testing.T, srv *elephanttest.Server) clusterpg.ConstructDB {
+	t.Helper()
+	return func() (clusterpg.Pool, error) {
+		return connect(t, srv), nil
+	}
+}
+
+func TestSinglePGOverWire(t *testing.T) {
+	t.Run("should be able to run transactional statements at one connection", func(t *testing.T) {
+		srv := elephanttest.NewServer(t)
+		srv.On(elephanttest.Regexp(`^INSERT INTO users`)).ReturnTag("INSERT 0 1")
+		srv.On(elephanttest.Regexp(`^SELECT count`)).ReturnRows(elephanttest.NewRows("count").Add(1))
+		db := connect(t, srv)
+
+		var count int
+		err := db.Transactional(context.Background(), func(ctx context.Context) error {
+			if _, err := db.Exec(ctx, "INSERT INTO users (name) VALUES ($1)", "bob"); err != nil {
+				return err
+			}
+			return db.QueryRow(ctx, "SELECT count(*) FROM users").Scan(&count)
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 1, count)
+
+		received := srv.Received()
+		require.Len(t, received, 4)
+		assert.Equal(t, "begin", received[0].SQL)
+		assert.True(t, received[1].InTx)
+		assert.True(t, received[2].InTx)
+		assert.Equal(t, "commit", received[3].SQL)
+	})
+
+	t.Run("should be able to roll back transaction when statement failed", func(t *testing.T) {
+		srv := elephanttest.NewServer(t)
+		srv.On(elephanttest.Regexp(`^INSERT INTO users`)).ReturnError("23505", "duplicate key")
+		db := connect(t, srv)
+
+		err := db.Transactional(context.Background(), func(ctx context.Context) error {
+			_, err := db.Exec(ctx, "INSERT INTO users (name) VALUES ($1)", "bob")
+			return err
+		})
+		var pgErr *pgconn.PgError
+		require.ErrorAs(t, err, &pgErr)
+		assert.Equal(t, "23505", pgErr.Code)
+	})
+}
+
+func TestSessionSettingsOverWire(t *testing.T) {
+	t.Run("should be able to apply session settings in implicit transaction of standalone query", func(t *testing.T) {
+		srv := elephanttest.NewServer(t)
+		srv.On(elephanttest.Regexp(`set_config`)).ReturnRows(elephanttest.NewRows("set_config").Add("42"))
+		srv.On(elephanttest.Regexp(`^SELECT id FROM documents`)).ReturnRows(elephanttest.NewRows("id").Add(1))
+		db := connect(t, srv)
+		ctx := elephant.With(context.Background(),
+			elephant.WithSessionSettings(map[string]string{"app.user_id": "42"}),
+		)
+
+		var id int
+		require.NoError(t, db.QueryRow(ctx, "SELECT id FROM documents").Scan(&id))
+		assert.Equal(t, 1, id)
+
+		received := srv.Received()
+		require.Len(t, received, 4)
+		assert.Equal(t, "begin", received[0].SQL)
+		assert.Contains(t, received[1].SQL, "set_config")
+		assert.True(t, received[2].InTx)
+		assert.Equal(t, "commit", received[3].SQL)
+	})
+}
+
+func TestClusterPGOverWire(t *testing.T) {
+	newCluster := func(t *testing.T) (*elephanttest.Server, *elephanttest.Server, clusterpg.Pool) {
+		t.Helper()
+		leader, follower := elephanttest.NewServer(t), elephanttest.NewServer(t)
+		cls, err := clusterpg.New().Leader(constructor(t, leader)).Follower(constructor(t, follower)).Go()
+		require.NoError(t, err)
+		return leader, follower, cls
+	}
+
+	t.Run("should be able to route reads to follower and writes to leader", func(t *testing.T) {
+		leader, follower, cls := newCluster(t)
+		follower.On(elephanttest.Regexp(`^SELECT`)).ReturnRows(elephanttest.NewRows("name").Add("bob"))
+		leader.On(elephanttest.Regexp(`^UPDATE`)).ReturnTag("UPDATE 1")
+
+		var name string
+		require.NoError(t, cls.QueryRow(context.Background(), "SELECT name FROM users").Scan(&name))
+		assert.Equal(t, "bob", name)
+
+		_, err := cls.Exec(elephant.With(context.Background(), elephant.WithCanWrite), "UPDATE users SET seen = true")
+		require.NoError(t, err)
+
+		assert.Len(t, follower.Received(), 1)
+		assert.Len(t, leader.Received(), 1)
+	})
+
+	t.Run("should be able to retry read on leader when follower dropped connection", func(t *testing.T) {
+		leader, follower, cls := newCluster(t)
+		follower.On(elephanttest.Regexp(`^SELECT`)).DropConnection()
+		leader.On(elephanttest.Regexp(`^SELECT`)).ReturnRows(elephanttest.NewRows("name").Add("bob"))
+
+		var name string
+		require.NoError(t, cls.QueryRow(context.Background(), "SELECT name FROM users").Scan(&name))
+		assert.Equal(t, "bob", name)
+		assert.Len(t, leader.Received(), 1)
+	})
+
+	t.Run("should be able to retry rows on leader when follower dropped connection", func(t *testing.T) {
+		leader, follower, cls := newCluster(t)
+		follower.On(elephanttest.Regexp(`^SELECT`)).DropConnection()
+		leader.On(elephanttest.Regexp(`^SELECT`)).ReturnRows(elephanttest.NewRows("name").Add("bob").Add("alice"))
+
+		rows, err := cls.Query(context.Background(), "SELECT name FROM users")
+		require.NoError(t, err)
+		names, err := pgx.CollectRows(rows, pgx.RowTo[string])
+		require.NoError(t, err)
+		assert.Equal(t, []string{"bob", "alice"}, names)
+		assert.Len(t, leader.Received(), 1)
+	})
+
+	t.Run("should be able to surface dropped follower connection when retries are disabled", func(t *testing.T) {
+		leader, follower := elephanttest.NewServer(t), elephanttest.NewServer(t)
+		follower.On(elephanttest.Regexp(`^SELECT`)).DropConnection()
+		cls, err := clusterpg.New().
+			Leader(constructor(t, leader)).
+			Follower(constructor(t, follower)).
+			ReadRetries(0).
+			Go()
+		require.NoError(t, err)
+
+		var name string
+		err = cls.QueryRow(context.Background(), "SELECT name FROM users").Scan(&name)
+		require.Error(t, err)
+		assert.Empty(t, leader.Received())
+	})
+
+	t.Run("should be able to keep working after leader connections were dropped", func(t *testing.T) {
+		leader, _, cls := newCluster(t)
+		leader.On(elephanttest.Regexp(`^UPDATE`)).ReturnTag("UPDATE 1")
+		ctx := elephant.With(context.Background(), elephant.WithCanWrite)
+
+		_, err := cls.Exec(ctx, "UPDATE users SET seen = true")
+		require.NoError(t, err)
+		leader.DropConnections()
+
+		_, err = cls.Exec(ctx, "UPDATE users SET seen = true")
+		if err != nil {
+			var pgErr *pgconn.PgError
+			require.False(t, errors.As(err, &pgErr), "dropped connection must not look like server error")
+			_, err = cls.Exec(ctx, "UPDATE users SET seen = true")
+		}
+		require.NoError(t, err)
+	})
+}
+
+func TestShardedPGOverWire(t *testing.T) {
+	t.Run("should be able to route statements to shard picked by sharding key", func(t *testing.T) {
+		shards := []*elephanttest.Server{elephanttest.NewServer(t), elephanttest.NewServer(t)}
+		for _, srv := range shards {
+			srv.On(elephanttest.Regexp(`^SELECT`)).ReturnRows(elephanttest.NewRows("n").Add(1))
+		}
+		db, err := shardedpg.New(2).
+			Shard(0, connect(t, shards[0])).
+			Shard(1, connect(t, shards[1])).
+			Picker(func(_ context.Context, key string) uint {
+				if key == "eu" {
+					return 1
+				}
+				return 0
+			}).
+			Go()
+		require.NoError(t, err)
+
+		var n int
+		ctx := elephant.With(context.Background(), elephant.WithShardingKey("eu"))
+		require.NoError(t, db.QueryRow(ctx, "SELECT 1").Scan(&n))
+
+		assert.Empty(t, shards[0].Received())
+		assert.Len(t, shards[1].Received(), 1)
+	})
+}
+
+func TestTenantPGOverWire(t *testing.T) {
+	t.Run("should be able to read schema tenant at leader of cluster shared pool", func(t *testing.T) {
+		sharedLeader, sharedFollower := elephanttest.NewServer(t), elephanttest.NewServer(t)
+		shared, err := clusterpg.New().Leader(constructor(t, sharedLeader)).Follower(constructor(t, sharedFollower)).Go()
+		require.NoError(t, err)
+		acmeLeader, acmeFollower := elephanttest.NewServer(t), elephanttest.NewServer(t)
+		acme, err := clusterpg.New().Leader(constructor(t, acmeLeader)).Follower(constructor(t, acmeFollower)).Go()
+		require.NoError(t, err)
+		sharedLeader.On(elephanttest.Regexp(`^SET LOCAL`)).ReturnTag("SET")
+		for _, srv := range []*elephanttest.Server{sharedLeader, acmeFollower} {
+			srv.On(elephanttest.Regexp(`^SELECT`)).ReturnRows(elephanttest.NewRows("name").Add("bob"))
+		}
+		db, err := tenantpg.New().Schemas(shared, tenantpg.SchemaPrefix("tenant_")).Database("acme", acme).Go()
+		require.NoError(t, err)
+
+		for _, tenant := range []string{"globex", "acme"} {
+			var name string
+			ctx := elephant.With(context.Background(), elephant.WithTenant(tenant))
+			require.NoError(t, db.QueryRow(ctx, "SELECT name FROM users").Scan(&name))
+			assert.Equal(t, "bob", name)
+		}
+
+		assert.Empty(t, sharedFollower.Received())
+		received := sharedLeader.Received()
+		require.Len(t, received, 4)
+		assert.Equal(t, "begin", received[0].SQL)
+		assert.True(t, received[2].InTx)
+		assert.Empty(t, acmeLeader.Received())
+		assert.Len(t, acmeFollower.Received(), 1)
+	})
+}
+
+func TestLifecycleOverWire(t *testing.T) {
+	t.Run("should be able to ping every node and report failed follower", func(t *testing.T) {
+		leader, follower := elephanttest.NewServer(t), elephanttest.NewServer(t)
+		db, closeDB, err := elephant.Open(context.Background(), elephant.Config{
+			Cluster: elephant.ClusterConfig{
+				Leader:    elephant.NodeConfig{DSN: leader.DSN()},
+				Followers: []elephant.NodeConfig{{DSN: follower.DSN()}},
+			},
+			Pool: elephant.PoolConfig{MaxConns: 4},
+		})
+		require.NoError(t, err)
+		defer closeDB()
+
+		pings, err := db.Ping(context.Background())
+		require.NoError(t, err)
+		require.Len(t, pings, 2)
+		assert.Equal(t, elephant.RoleLeader, pings[0].Node.Role)
+		assert.Equal(t, elephant.RoleFollower, pings[1].Node.Role)
+
+		stats := db.Stats()
+		require.Len(t, stats, 2)
+		assert.Equal(t, int32(8), stats.Total().MaxConns)
+
+		follower.Close()
+		_, err = db.Ping(context.Background())
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "follower [0]")
+	})
+
+	t.Run("should be able to ping and close decorated follower of cluster", func(t *testing.T) {
+		leader, follower := elephanttest.NewServer(t), elephanttest.NewServer(t)
+		followerPool, err := pgxpool.New(context.Background(), follower.DSN())
+		require.NoError(t, err)
+		inj := chaos.New()
+		cls, err := clusterpg.New().
+			Leader(constructor(t, leader)).
+			Follower(func() (clusterpg.Pool, error) {
+				return inj.Wrap(singlepg.New(followerPool), chaos.WithRole(chaos.RoleFollower)), nil
+			}).
+			Go()
+		require.NoError(t, err)
+
+		pings, err := cls.(elephant.Pinger).Ping(context.Background())
+		require.NoError(t, err)
+		require.Len(t, pings, 2)
+		assert.Equal(t, elephant.RoleFollower, pings[1].Node.Role)
+		assert.Len(t, cls.(elephant.Stater).Stats(), 2)
+
+		cls.(interface{ Close() }).Close()
+		require.Error(t, followerPool.Ping(context.Background()))
+	})
+}
+
+func TestCacheOverWire(t *testing.T) {
+	t.Run("should be able to bypass cache in transaction", func(t *testing.T) {
+		srv := elephanttest.NewServer(t)
+		srv.On(elephanttest.Regexp(`^SELECT count`)).ReturnRows(elephanttest.NewRows("count").Add(1))
+		db := cache.Wrap(connect(t, srv), cache.WithTTL(time.Minute))
+		count := func(ctx context.Context) {
+			var n int
+			require.NoError(t, db.QueryRow(ctx, "SELECT count(*) FROM users").Scan(&n))
+			assert.Equal(t, 1, n)
+		}
+
+		count(context.Background())
+		count(context.Background())
+		require.NoError(t, db.Transactional(context.Background(), func(ctx context.Context) error {
+			count(ctx)
+			return nil
+		}))
+
+		received := srv.Received()
+		require.Len(t, received, 4)
+		assert.False(t, received[0].InTx)
+		assert.Equal(t, "begin", received[1].SQL)
+		assert.True(t, received[2].InTx)
+		assert.Equal(t, "commit", received[3].SQL)
+	})
+}
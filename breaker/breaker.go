@@ -0,0 +1,83 @@
+// Package breaker provides circuit breaker decorator for followers of cluster pools and shards of sharded pools.
+// Cluster skips followers with open breaker, sharded pools fail fast with shardedpg.ErrShardUnavailable.
+package breaker
+
+import (
+	"time"
+
+	"github.com/godepo/elephant"
+	"github.com/godepo/elephant/internal/breaker"
+	"github.com/godepo/elephant/internal/pkg/passthrough"
+)
+
+var (
+	ErrOpen                = breaker.ErrOpen
+	ErrAcquireNotSupported = passthrough.ErrAcquireNotSupported
+	ErrBulkNotSupported    = passthrough.ErrBulkNotSupported
+	ErrListenNotSupported  = passthrough.ErrListenNotSupported
+	ErrLockNotSupported    = passthrough.ErrLockNotSupported
+)
+
+const (
+	StateClosed   = breaker.StateClosed
+	StateHalfOpen = breaker.StateHalfOpen
+	StateOpen     = breaker.StateOpen
+)
+
+type (
+	Pool   = breaker.Pool
+	DB     = breaker.DB
+	State  = breaker.State
+	Option = breaker.Option
+)
+
+// Wrap guards db by circuit breaker. By default breaker opens when half of 20 recent calls failed, stays open
+// for 5 seconds and closes after single successful probe call.
+func Wrap(db Pool, opts ...Option) *DB {
+	return breaker.Wrap(db, opts...)
+}
+
+func WithName(name string) Option {
+	return breaker.WithName(name)
+}
+
+func WithWindow(size, minCalls int) Option {
+	return breaker.WithWindow(size, minCalls)
+}
+
+func WithFailureRate(rate float64) Option {
+	return breaker.WithFailureRate(rate)
+}
+
+func WithSlowCalls(threshold time.Duration, rate float64) Option {
+	return breaker.WithSlowCalls(threshold, rate)
+}
+
+func WithOpenTimeout(timeout time.Duration) Option {
+	return breaker.WithOpenTimeout(timeout)
+}
+
+func WithHalfOpenProbes(probes int) Option {
+	return breaker.WithHalfOpenProbes(probes)
+}
+
+func WithFailureMatcher(fn func(err error) bool) Option {
+	return breaker.WithFailureMatcher(fn)
+}
+
+// WithCollector reports state of breaker to collector built by metrics.Collector with gauge, which is set by
+// BreakerState of elephant.BreakerMetricsBuilder.
+// Collectors which can't track state of breaker are ignored.
+func WithCollector(collector elephant.MetricsCollector) Option {
+	sc, ok := collector.(breaker.StateCollector)
+	if !ok {
+		return func(*breaker.Config) {}
+	}
+	return breaker.WithCollector(sc)
+}
+
+// IsFailure is default failure matcher, it reports connection errors, timeouts and server errors of classes
+// 08, 53, 57, 58 and XX as failures of node.
+func IsFailure(err error) bool {
+	return breaker.IsFailure(err)
+}
@@ -0,0 +1,69 @@
+package breaker
+
+import (
+	"context"
+	"net"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/godepo/elephant"
+	"github.com/godepo/elephant/elephanttest"
+	"github.com/godepo/elephant/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type gauge struct {
+	value float64
+}
+
+func (g *gauge) Set(value float64) {
+	g.value = value
+}
+
+func TestWrap(t *testing.T) {
+	t.Run("should be able to open breaker and report its state", func(t *testing.T) {
+		connErr := &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET}
+		pool := elephanttest.New(t)
+		pool.ExpectExec(elephanttest.Exact("UPDATE t SET n = 1")).ReturnError(connErr).Times(2)
+
+		state := &gauge{}
+		collector, err := metrics.Collector().(elephant.BreakerMetricsBuilder).
+			BreakerState(func(labels ...string) (elephant.Gauge, error) {
+				assert.Equal(t, []string{"shard-0"}, labels)
+				return state, nil
+			}).
+			QueryPerSecond(func(...string) (elephant.Counter, error) { return nil, nil }).
+			Latency(func(...string) (elephant.Histogram, error) { return nil, nil }).
+			Build()
+		require.NoError(t, err)
+
+		db := Wrap(pool,
+			WithName("shard-0"),
+			WithWindow(2, 2),
+			WithFailureRate(1),
+			WithSlowCalls(time.Minute, 1),
+			WithOpenTimeout(time.Minute),
+			WithHalfOpenProbes(1),
+			WithFailureMatcher(IsFailure),
+			WithCollector(collector),
+		)
+
+		for range 2 {
+			_, err = db.Exec(context.Background(), "UPDATE t SET n = 1")
+			require.ErrorIs(t, err, connErr)
+		}
+		assert.Equal(t, StateOpen, db.State())
+		assert.False(t, db.Available())
+		assert.InDelta(t, float64(StateOpen), state.value, 0)
+
+		_, err = db.Exec(context.Background(), "UPDATE t SET n = 1")
+		require.ErrorIs(t, err, ErrOpen)
+	})
+
+	t.Run("should be able to ignore collector without breaker state", func(t *testing.T) {
+		db := Wrap(elephanttest.New(t), WithCollector(nil))
+		assert.Equal(t, StateClosed, db.State())
+	})
+}
@@ -0,0 +1,47 @@
+// Package bulkhead provides decorator which limits count of concurrent in-flight statements of pool per metrics
+// label or priority, so burst of expensive statements can't starve critical paths of connections.
+package bulkhead
+
+import (
+	"time"
+
+	"github.com/godepo/elephant"
+	"github.com/godepo/elephant/internal/bulkhead"
+	"github.com/godepo/elephant/internal/pkg/passthrough"
+)
+
+var (
+	ErrOverloaded          = bulkhead.ErrOverloaded
+	ErrAcquireNotSupported = passthrough.ErrAcquireNotSupported
+	ErrBulkNotSupported    = passthrough.ErrBulkNotSupported
+	ErrListenNotSupported  = passthrough.ErrListenNotSupported
+	ErrLockNotSupported    = passthrough.ErrLockNotSupported
+)
+
+type (
+	Pool   = bulkhead.Pool
+	DB     = bulkhead.DB
+	Option = bulkhead.Option
+)
+
+// Wrap limits db by bulkhead. Statements are not limited until limits are set, statements wait for free slot
+// for one second by default.
+func Wrap(db Pool, opts ...Option) *DB {
+	return bulkhead.Wrap(db, opts...)
+}
+
+func WithLimit(limit int) Option {
+	return bulkhead.WithLimit(limit)
+}
+
+func WithLabelLimit(label string, limit int) Option {
+	return bulkhead.WithLabelLimit(label, limit)
+}
+
+func WithPriorityLimit(priority elephant.Priority, limit int) Option {
+	return bulkhead.WithPriorityLimit(priority, limit)
+}
+
+func WithQueueTimeout(timeout time.Duration) Option {
+	return bulkhead.WithQueueTimeout(timeout)
+}
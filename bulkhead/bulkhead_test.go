@@ -0,0 +1,43 @@
+package bulkhead
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/godepo/elephant"
+	"github.com/godepo/elephant/elephanttest"
+	"github.com/stretchr/testify/require"
+)
+
+type User struct {
+	ID int64
+}
+
+func TestWrap(t *testing.T) {
+	t.Run("should be able to shed reports and pass critical statements", func(t *testing.T) {
+		pool := elephanttest.New(t)
+		pool.ExpectQuery(elephanttest.Exact("SELECT id FROM users")).
+			ReturnRows(elephanttest.NewRows("id").Add(int64(1))).
+			Times(2)
+		db := Wrap(pool,
+			WithLimit(10),
+			WithLabelLimit("reports", 1),
+			WithPriorityLimit(elephant.PriorityLow, 1),
+			WithQueueTimeout(time.Millisecond),
+		)
+		reports := elephant.With(context.Background(), elephant.WithMetricsLabel("reports"))
+
+		rows, err := db.Query(reports, "SELECT id FROM users")
+		require.NoError(t, err)
+		defer rows.Close()
+
+		_, err = elephant.All[User](reports, db, "SELECT id FROM users")
+		require.ErrorIs(t, err, ErrOverloaded)
+
+		critical := elephant.With(reports, elephant.WithPriority(elephant.PriorityCritical))
+		users, err := elephant.All[User](critical, db, "SELECT id FROM users")
+		require.NoError(t, err)
+		require.Equal(t, []User{{ID: 1}}, users)
+	})
+}
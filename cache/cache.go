@@ -0,0 +1,51 @@
+// Package cache provides decorator which caches results of reads of pool by SQL and arguments. Results are
+// cached for ttl of context or metrics label, reads inside transaction bypass cache, and writes invalidate
+// results by tags of tables after surrounding transaction is committed.
+package cache
+
+import (
+	"time"
+
+	"github.com/godepo/elephant/internal/cache"
+	"github.com/godepo/elephant/internal/pkg/passthrough"
+)
+
+var (
+	ErrRowsClosed          = cache.ErrRowsClosed
+	ErrAcquireNotSupported = passthrough.ErrAcquireNotSupported
+	ErrBulkNotSupported    = passthrough.ErrBulkNotSupported
+	ErrListenNotSupported  = passthrough.ErrListenNotSupported
+	ErrLockNotSupported    = passthrough.ErrLockNotSupported
+)
+
+type (
+	Pool    = cache.Pool
+	DB      = cache.DB
+	Option  = cache.Option
+	Backend = cache.Backend
+	Result  = cache.Result
+	LRU     = cache.LRU
+)
+
+// Wrap caches results of reads of db in LRU of 1024 results by default. Reads are not cached until ttl is set
+// by WithTTL, WithLabelTTL or elephant.WithCacheTTL.
+func Wrap(db Pool, opts ...Option) *DB {
+	return cache.Wrap(db, opts...)
+}
+
+// NewLRU makes in-memory backend of capacity results.
+func NewLRU(capacity int) *LRU {
+	return cache.NewLRU(capacity)
+}
+
+func WithBackend(backend Backend) Option {
+	return cache.WithBackend(backend)
+}
+
+func WithTTL(ttl time.Duration) Option {
+	return cache.WithTTL(ttl)
+}
+
+func WithLabelTTL(label string, ttl time.Duration) Option {
+	return cache.WithLabelTTL(label, ttl)
+}
@@ -0,0 +1,101 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/godepo/elephant"
+	"github.com/godepo/elephant/elephanttest"
+	"github.com/godepo/elephant/singlepg"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type User struct {
+	ID   int64
+	Name string
+}
+
+func TestWrap(t *testing.T) {
+	t.Run("should be able to cache reads of label until write is committed", func(t *testing.T) {
+		srv := elephanttest.NewServer(t)
+		srv.On(elephanttest.Regexp(`^SELECT id, name FROM users`)).
+			ReturnRows(elephanttest.NewRows("id", "name").Add(int64(1), "alice")).Times(1)
+		srv.On(elephanttest.Regexp(`^SELECT id, name FROM users`)).
+			ReturnRows(elephanttest.NewRows("id", "name").Add(int64(1), "bob"))
+		srv.On(elephanttest.Regexp(`^UPDATE users`)).ReturnTag("UPDATE 1")
+		pool, err := pgxpool.New(context.Background(), srv.DSN())
+		require.NoError(t, err)
+		t.Cleanup(pool.Close)
+
+		db := Wrap(singlepg.New(pool), WithLabelTTL("users_by_id", time.Minute))
+		ctx := elephant.With(context.Background(),
+			elephant.WithMetricsLabel("users_by_id"),
+			elephant.WithCacheTags("users"),
+		)
+		find := func(ctx context.Context) User {
+			usr, err := elephant.One[User](ctx, db, "SELECT id, name FROM users WHERE id = $1", int64(1))
+			require.NoError(t, err)
+			return usr
+		}
+
+		assert.Equal(t, User{ID: 1, Name: "alice"}, find(ctx))
+		assert.Equal(t, User{ID: 1, Name: "alice"}, find(ctx))
+		require.Len(t, srv.Received(), 1)
+
+		err = db.Transactional(ctx, func(txCtx context.Context) error {
+			if _, err := db.Exec(txCtx, "UPDATE users SET name = $1 WHERE id = $2", "bob", int64(1)); err != nil {
+				return err
+			}
+			assert.Equal(t, User{ID: 1, Name: "alice"}, find(ctx))
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, User{ID: 1, Name: "bob"}, find(ctx))
+	})
+}
+
+func TestWrap_PassedError(t *testing.T) {
+	t.Run("should be able to invalidate reads, when passed error is returned after commit", func(t *testing.T) {
+		srv := elephanttest.NewServer(t)
+		srv.On(elephanttest.Regexp(`^SELECT id, name FROM users`)).
+			ReturnRows(elephanttest.NewRows("id", "name").Add(int64(1), "alice")).Times(1)
+		srv.On(elephanttest.Regexp(`^SELECT id, name FROM users`)).
+			ReturnRows(elephanttest.NewRows("id", "name").Add(int64(1), "bob"))
+		srv.On(elephanttest.Regexp(`^UPDATE users`)).ReturnTag("UPDATE 1")
+		pool, err := pgxpool.New(context.Background(), srv.DSN())
+		require.NoError(t, err)
+		t.Cleanup(pool.Close)
+
+		errNotified := errors.New("user is renamed, but not notified")
+		db := Wrap(singlepg.New(pool), WithTTL(time.Minute))
+		ctx := elephant.With(context.Background(),
+			elephant.WithCacheTags("users"),
+			elephant.WithFnTxPassMatcher(func(_ context.Context, err error) bool {
+				return errors.Is(err, errNotified)
+			}),
+		)
+		find := func() User {
+			usr, err := elephant.One[User](ctx, db, "SELECT id, name FROM users WHERE id = $1", int64(1))
+			require.NoError(t, err)
+			return usr
+		}
+
+		assert.Equal(t, "alice", find().Name)
+		err = db.Transactional(ctx, func(ctx context.Context) error {
+			if _, err := db.Exec(ctx, "UPDATE users SET name = $1 WHERE id = $2", "bob", int64(1)); err != nil {
+				return err
+			}
+			return errNotified
+		})
+		require.ErrorIs(t, err, errNotified)
+		assert.Equal(t, "bob", find().Name)
+
+		received := srv.Received()
+		require.Len(t, received, 5)
+		assert.Equal(t, "commit", received[3].SQL)
+	})
+}
@@ -0,0 +1,79 @@
+// Package chaos provides fault-injection decorator for elephant pools, which is meant for verification of retry,
+// timeout and failover handling in staging and tests.
+package chaos
+
+import (
+	"time"
+
+	"github.com/godepo/elephant/internal/chaos"
+	"github.com/godepo/elephant/internal/pkg/passthrough"
+)
+
+var (
+	ErrInjected            = chaos.ErrInjected
+	ErrAcquireNotSupported = passthrough.ErrAcquireNotSupported
+	ErrBulkNotSupported    = passthrough.ErrBulkNotSupported
+	ErrListenNotSupported  = passthrough.ErrListenNotSupported
+	ErrLockNotSupported    = passthrough.ErrLockNotSupported
+)
+
+const (
+	RoleLeader   = chaos.RoleLeader
+	RoleFollower = chaos.RoleFollower
+)
+
+type (
+	Pool       = chaos.Pool
+	DB         = chaos.DB
+	Injector   = chaos.Injector
+	Rule       = chaos.Rule
+	Fault      = chaos.Fault
+	Role       = chaos.Role
+	Option     = chaos.Option
+	NodeOption = chaos.NodeOption
+)
+
+// New makes injector, pools are decorated by Injector.Wrap. Injector is enabled unless WithDisabled is given.
+func New(opts ...Option) *Injector {
+	return chaos.New(opts...)
+}
+
+func WithRules(rules ...Rule) Option {
+	return chaos.WithRules(rules...)
+}
+
+func WithRandom(random func() float64) Option {
+	return chaos.WithRandom(random)
+}
+
+func WithDisabled() Option {
+	return chaos.WithDisabled()
+}
+
+func WithRole(role Role) NodeOption {
+	return chaos.WithRole(role)
+}
+
+func WithShard(id uint) NodeOption {
+	return chaos.WithShard(id)
+}
+
+func Latency(d time.Duration) Fault {
+	return chaos.Latency(d)
+}
+
+func ConnectionError() Fault {
+	return chaos.ConnectionError()
+}
+
+func PgError(code string) Fault {
+	return chaos.PgError(code)
+}
+
+func CommitFailure() Fault {
+	return chaos.CommitFailure()
+}
+
+func RowsFailure(afterRows int) Fault {
+	return chaos.RowsFailure(afterRows)
+}
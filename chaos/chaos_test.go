@@ -0,0 +1,71 @@
+package chaos
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/godepo/elephant/elephanttest"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	t.Run("should be able to inject faults into matched statements of wrapped pool", func(t *testing.T) {
+		pool := elephanttest.New(t)
+		pool.ExpectQuery(elephanttest.Exact("SELECT 1")).
+			ReturnRows(elephanttest.NewRows("n").Add(1).Add(2))
+
+		inj := New(
+			WithRules(
+				Rule{SQL: regexp.MustCompile(`^UPDATE`), Probability: 1, Fault: PgError("40001")},
+				Rule{SQL: regexp.MustCompile(`^SELECT`), Probability: 1, Fault: RowsFailure(1)},
+				Rule{Roles: []Role{RoleFollower}, Probability: 1, Fault: Latency(time.Millisecond)},
+			),
+			WithRandom(func() float64 { return 0 }),
+		)
+		db := inj.Wrap(pool, WithRole(RoleLeader), WithShard(0))
+
+		_, err := db.Exec(context.Background(), "UPDATE t SET n = 1")
+		var pgErr *pgconn.PgError
+		require.ErrorAs(t, err, &pgErr)
+		assert.Equal(t, "40001", pgErr.Code)
+
+		rows, err := db.Query(context.Background(), "SELECT 1")
+		require.NoError(t, err)
+		assert.True(t, rows.Next())
+		assert.False(t, rows.Next())
+		require.ErrorIs(t, rows.Err(), ErrInjected)
+	})
+
+	t.Run("should be able to pass statements through when disabled", func(t *testing.T) {
+		pool := elephanttest.New(t)
+		pool.ExpectExec(elephanttest.Exact("UPDATE t SET n = 1")).ReturnTag("UPDATE 1").Times(1)
+
+		inj := New(WithRules(Rule{Probability: 1, Fault: ConnectionError()}), WithDisabled())
+		db := inj.Wrap(pool)
+
+		tag, err := db.Exec(context.Background(), "UPDATE t SET n = 1")
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), tag.RowsAffected())
+
+		inj.Enable()
+		_, err = db.Exec(context.Background(), "UPDATE t SET n = 1")
+		require.ErrorIs(t, err, ErrInjected)
+	})
+
+	t.Run("should be able to fail commit of transactional", func(t *testing.T) {
+		pool := elephanttest.New(t)
+		pool.ExpectExec(elephanttest.Exact("UPDATE t SET n = 1")).InTx().ReturnTag("UPDATE 1")
+		db := New(WithRules(Rule{Probability: 1, Fault: CommitFailure()})).Wrap(pool)
+
+		err := db.Transactional(context.Background(), func(ctx context.Context) error {
+			_, err := db.Exec(ctx, "UPDATE t SET n = 1")
+			return err
+		})
+		require.ErrorIs(t, err, ErrInjected)
+		assert.Empty(t, pool.Committed())
+	})
+}
@@ -5,7 +5,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 
+	"github.com/godepo/elephant"
 	"github.com/godepo/elephant/internal/cluster"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
@@ -27,6 +29,8 @@ type ConstructDB func() (Pool, error)
 type Builder interface {
 	Leader(fn ConstructDB) Builder
 	Follower(fns ...ConstructDB) Builder
+	ReadRetries(retries int) Builder
+	HedgeCollector(collector elephant.MetricsCollector) Builder
 	Go() (Pool, error)
 }
 
@@ -41,6 +45,7 @@ func New() Builder {
 type builder struct {
 	leaderConstructor     ConstructDB
 	followersConstructors []ConstructDB
+	opts                  []cluster.Option
 }
 
 func (b builder) Leader(fn ConstructDB) Builder {
@@ -56,6 +61,27 @@ func (b builder) Follower(fns ...ConstructDB) Builder {
 	return b
 }
 
+// ReadRetries sets count of retries of reads outside transaction, which failed at follower with connection error.
+// Reads are retried at other followers, then at leader. One retry is made by default, zero disables retries.
+// Connection errors of query and of its first row are retried, errors of later rows are returned by rows, because
+// part of rows is already read by caller.
+func (b builder) ReadRetries(retries int) Builder {
+	b.opts = append(slices.Clone(b.opts), cluster.WithReadRetries(retries))
+	return b
+}
+
+// HedgeCollector reports outcomes of reads hedged by elephant.WithHedgedRead to collector built by
+// metrics.Collector with counter, which is set by HedgedReads of elephant.HedgeMetricsBuilder. Collectors which
+// can't track hedged reads are ignored.
+func (b builder) HedgeCollector(collector elephant.MetricsCollector) Builder {
+	hc, ok := collector.(cluster.HedgeCollector)
+	if !ok {
+		return b
+	}
+	b.opts = append(slices.Clone(b.opts), cluster.WithHedgeCollector(hc))
+	return b
+}
+
 func (b builder) Go() (Pool, error) {
 	if len(b.followersConstructors) == 0 {
 		return nil, fmt.Errorf("%w: at least one folower constructor is required", ErrInvalidClusterConfiguration)
@@ -74,5 +100,5 @@ func (b builder) Go() (Pool, error) {
 		fellows = append(fellows, follower)
 	}
 
-	return cluster.New(leader, fellows), nil
+	return cluster.New(leader, fellows, b.opts...), nil
 }
@@ -3,9 +3,15 @@ package clusterpg
 import (
 	"context"
 	"testing"
+	"time"
 
+	"github.com/godepo/elephant"
+	"github.com/godepo/elephant/internal/cluster"
 	"github.com/godepo/groat"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
 
@@ -86,7 +92,7 @@ func TestNew(t *testing.T) {
 			ArrangeFollower(tc.Deps.SecondFollowerPool),
 			ArrangeRows,
 		).When(ActFollowerQuery(1)).
-			Then(AssertNoError, AssertRows)
+			Then(AssertNoError, AssertPeekedRows)
 
 		tc.State.Result.Cluster, tc.State.Result.Error = tc.SUT.
 			Leader(tc.State.LeaderConstructor).
@@ -106,7 +112,7 @@ func TestNew(t *testing.T) {
 			ArrangeRows,
 		).
 			When(ActFollowerQuery(0)).
-			Then(AssertNoError, AssertRows)
+			Then(AssertNoError, AssertPeekedRows)
 
 		tc.State.Result.Cluster, tc.State.Result.Error = tc.SUT.
 			Leader(tc.State.LeaderConstructor).
@@ -173,3 +179,103 @@ func TestNew(t *testing.T) {
 			Go()
 	})
 }
+
+func TestBuilder_Capabilities(t *testing.T) {
+	t.Run("should be able to expose listener and advisory locks by type assertion", func(t *testing.T) {
+		cls, err := New().
+			Leader(func() (Pool, error) { return NewMockPool(t), nil }).
+			Follower(func() (Pool, error) { return NewMockPool(t), nil }).
+			Go()
+		require.NoError(t, err)
+
+		assert.Implements(t, (*elephant.Listener)(nil), cls)
+		assert.Implements(t, (*elephant.AdvisoryLocker)(nil), cls)
+	})
+}
+
+func TestBuilder_ReadRetries(t *testing.T) {
+	t.Run("should be able to retry read at leader", func(t *testing.T) {
+		leader, follower := NewMockPool(t), NewMockPool(t)
+		rows := NewMockRows(t)
+		ctx := context.Background()
+		follower.EXPECT().Query(ctx, testQuery).Return(nil, &pgconn.PgError{Code: "08006"})
+		leader.EXPECT().Query(ctx, testQuery).Return(rows, nil)
+		rows.EXPECT().Next().Return(true).Once()
+
+		cls, err := New().
+			Leader(func() (Pool, error) { return leader, nil }).
+			Follower(func() (Pool, error) { return follower, nil }).
+			ReadRetries(1).
+			Go()
+		require.NoError(t, err)
+
+		out, err := cls.Query(ctx, testQuery)
+		require.NoError(t, err)
+		assert.True(t, out.Next())
+	})
+
+	t.Run("should be able to disable retries", func(t *testing.T) {
+		follower := NewMockPool(t)
+		ctx := context.Background()
+		expErr := &pgconn.PgError{Code: "08006"}
+		follower.EXPECT().Query(ctx, testQuery).Return(nil, expErr)
+
+		cls, err := New().
+			Leader(func() (Pool, error) { return NewMockPool(t), nil }).
+			Follower(func() (Pool, error) { return follower, nil }).
+			ReadRetries(0).
+			Go()
+		require.NoError(t, err)
+
+		_, err = cls.Query(ctx, testQuery)
+		require.ErrorIs(t, err, expErr)
+	})
+}
+
+type queryMetrics struct{}
+
+func (queryMetrics) TrackQueryMetrics(context.Context, time.Time, error) {}
+
+type hedgeMetrics struct {
+	queryMetrics
+	outcomes []cluster.HedgeOutcome
+}
+
+func (h *hedgeMetrics) TrackHedgedRead(_ context.Context, outcome cluster.HedgeOutcome) {
+	h.outcomes = append(h.outcomes, outcome)
+}
+
+func TestBuilder_HedgeCollector(t *testing.T) {
+	ctx := elephant.With(context.Background(), elephant.WithHedgedRead(time.Minute))
+	newCluster := func(t *testing.T, collector elephant.MetricsCollector) Pool {
+		t.Helper()
+		first, second := NewMockPool(t), NewMockPool(t)
+		rows := NewMockRows(t)
+		cls, err := New().
+			Leader(func() (Pool, error) { return NewMockPool(t), nil }).
+			Follower(func() (Pool, error) { return first, nil }, func() (Pool, error) { return second, nil }).
+			HedgeCollector(collector).
+			Go()
+		require.NoError(t, err)
+		first.EXPECT().Query(mock.Anything, testQuery).Return(rows, nil).Maybe()
+		second.EXPECT().Query(mock.Anything, testQuery).Return(rows, nil).Maybe()
+		rows.EXPECT().Next().Return(true)
+		return cls
+	}
+
+	t.Run("should be able to report outcome of hedged read", func(t *testing.T) {
+		collector := &hedgeMetrics{}
+		cls := newCluster(t, collector)
+
+		_, err := cls.Query(ctx, testQuery)
+		require.NoError(t, err)
+		assert.Equal(t, []cluster.HedgeOutcome{cluster.HedgeNotSent}, collector.outcomes)
+	})
+
+	t.Run("should be able to ignore collector which can't track hedged reads", func(t *testing.T) {
+		cls := newCluster(t, queryMetrics{})
+
+		_, err := cls.Query(ctx, testQuery)
+		require.NoError(t, err)
+	})
+}
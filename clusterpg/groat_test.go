@@ -77,6 +77,7 @@ func ActFollowerQuery(selectedFollower int) groat.When[Deps, State] {
 	return func(t *testing.T, deps Deps, state State) State {
 		t.Helper()
 		state.Followers[selectedFollower].EXPECT().Query(state.Context, testQuery).Return(state.Rows, nil)
+		state.Rows.EXPECT().Next().Return(true).Once()
 		return state
 	}
 
@@ -99,6 +100,12 @@ func AssertRows(t *testing.T, state State) {
 	assert.Equal(t, state.Rows, state.Result.Rows)
 }
 
+// AssertPeekedRows checks that first row, which is read by cluster before rows are returned, is given to caller.
+func AssertPeekedRows(t *testing.T, state State) {
+	t.Helper()
+	assert.True(t, state.Result.Rows.Next())
+}
+
 func AssertNoError(t *testing.T, state State) {
 	require.ErrorIs(t, state.Result.Error, state.ExpectError)
 }
@@ -3,10 +3,43 @@ package elephant
 
 import (
 	"context"
+	"iter"
 	"time"
 
+	"github.com/godepo/elephant/internal/election"
+	"github.com/godepo/elephant/internal/locker"
+	"github.com/godepo/elephant/internal/pkg/lifecycle"
+	"github.com/godepo/elephant/internal/pkg/monads"
+	"github.com/godepo/elephant/internal/pkg/named"
 	"github.com/godepo/elephant/internal/pkg/pgcontext"
+	"github.com/godepo/elephant/internal/topology"
+	"github.com/godepo/elephant/internal/typed"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrLockNotAcquired    = locker.ErrLockNotAcquired
+	ErrLeadershipLost     = election.ErrLeadershipLost
+	ErrLeadershipResigned = election.ErrLeadershipResigned
+	ErrNotFound           = typed.ErrNotFound
+	ErrTooManyRows        = typed.ErrTooManyRows
+	ErrMissingNamedValue  = named.ErrMissingValue
+	ErrMixedPlaceholders  = named.ErrMixedPlaceholders
+	ErrInvalidConfig      = topology.ErrInvalidConfig
+	ErrNoMetricsCollector = topology.ErrNoMetricsCollector
+	ErrPingNotSupported   = lifecycle.ErrPingNotSupported
+)
+
+// Priority of statement is used by bulkhead pools: statements of low priority are rejected at once when limit
+// is reached, statements of critical priority bypass limits.
+type Priority = pgcontext.Priority
+
+const (
+	PriorityLow      = pgcontext.PriorityLow
+	PriorityNormal   = pgcontext.PriorityNormal
+	PriorityCritical = pgcontext.PriorityCritical
 )
 
 type (
@@ -20,8 +53,13 @@ type (
 		Observe(since float64)
 	}
 
+	Gauge interface {
+		Set(value float64)
+	}
+
 	HistogramCollector func(labels ...string) (Histogram, error)
 	CounterCollector   func(labels ...string) (Counter, error)
+	GaugeCollector     func(labels ...string) (Gauge, error)
 
 	ErrorsLogInterceptor func(err error)
 
@@ -29,6 +67,63 @@ type (
 		TrackQueryMetrics(ctx context.Context, begin time.Time, err error)
 	}
 
+	Notification = pgconn.Notification
+
+	// Listener is implemented by singlepg, clusterpg, shardedpg and metrics pools. Listen holds dedicated
+	// connection until ctx is done and reconnects with backoff when connection is lost. Cluster pools
+	// always listen at leader, sharded pools listen at shard picked from context.
+	Listener interface {
+		Listen(ctx context.Context, channel string) (<-chan Notification, error)
+		Notify(ctx context.Context, channel, payload string) error
+	}
+
+	// AdvisoryLocker is implemented by singlepg, clusterpg, shardedpg and metrics pools. Lock is bound to
+	// transaction from context, or held by session on dedicated connection when there is no transaction.
+	// Cluster pools always lock at leader, sharded pools lock at shard picked from context.
+	AdvisoryLocker interface {
+		WithAdvisoryLock(ctx context.Context, key string, fn func(ctx context.Context) error) error
+		WithTryAdvisoryLock(ctx context.Context, key string, fn func(ctx context.Context) error) error
+	}
+
+	// Acquirer is implemented by singlepg, clusterpg, shardedpg and metrics pools. Cluster pools acquire
+	// connection at leader, sharded pools at shard picked from context.
+	Acquirer interface {
+		Acquire(ctx context.Context) (*pgxpool.Conn, error)
+	}
+
+	// Querier is implemented by every elephant pool and is accepted by typed query helpers.
+	Querier = typed.Querier
+
+	// Streamer is implemented by every elephant pool and is accepted by Stream.
+	Streamer = typed.Streamer
+	// StreamTracker receives count of streamed rows and time to first row, metrics pool implements it. Streamed
+	// pool which implements it tracks its streams, tracker from WithStreamTracker overrides it.
+	StreamTracker = typed.StreamTracker
+
+	// Pinger is implemented by singlepg, clusterpg, shardedpg and tenantpg pools and by decorators which wrap them.
+	// Ping checks every node of topology, error joins errors of failed nodes.
+	Pinger interface {
+		Ping(ctx context.Context) (Pings, error)
+	}
+
+	// Stater is implemented by singlepg, clusterpg, shardedpg and tenantpg pools and by decorators which wrap them.
+	// Stats are stats of pgxpool of every node of topology, summed up by Total and ByShard.
+	Stater interface {
+		Stats() PoolStats
+	}
+
+	Node      = lifecycle.Node
+	NodeRole  = lifecycle.Role
+	NodePing  = lifecycle.Ping
+	Pings     = lifecycle.Pings
+	PoolStat  = lifecycle.Stat
+	NodeStat  = lifecycle.NodeStat
+	PoolStats = lifecycle.Stats
+
+	LeaderElector  = election.Elector
+	LeaderTerm     = election.Term
+	ElectionOption = election.Option
+
 	MetricsBuilder interface {
 		QueryPerSecond(collector CounterCollector) MetricsBuilder
 		Latency(collector HistogramCollector) MetricsBuilder
@@ -36,8 +131,86 @@ type (
 		ResultsInterceptor(interceptor Interceptor) MetricsBuilder
 		Build() (MetricsCollector, error)
 	}
+
+	// StreamMetricsBuilder is implemented by builder of metrics.Collector, it sets histograms of rows and time to
+	// first row of Stream iterations.
+	StreamMetricsBuilder interface {
+		MetricsBuilder
+		StreamRows(collector HistogramCollector) StreamMetricsBuilder
+		TimeToFirstRow(collector HistogramCollector) StreamMetricsBuilder
+	}
+
+	// BreakerMetricsBuilder is implemented by builder of metrics.Collector, BreakerState sets gauge of states of
+	// circuit breakers.
+	BreakerMetricsBuilder interface {
+		MetricsBuilder
+		BreakerState(collector GaugeCollector) BreakerMetricsBuilder
+	}
+
+	// HedgeMetricsBuilder is implemented by builder of metrics.Collector, HedgedReads sets counter of outcomes of
+	// hedged reads.
+	HedgeMetricsBuilder interface {
+		MetricsBuilder
+		HedgedReads(collector CounterCollector) HedgeMetricsBuilder
+	}
+
+	// LockMetricsBuilder is implemented by builder of metrics.Collector, LockWait sets histogram of advisory lock
+	// waits.
+	LockMetricsBuilder interface {
+		MetricsBuilder
+		LockWait(collector HistogramCollector) LockMetricsBuilder
+	}
+
+	// PoolMetricsBuilder is implemented by builder of metrics.Collector, it sets gauges of pgxpool stats which are
+	// exported every interval.
+	PoolMetricsBuilder interface {
+		MetricsBuilder
+		PoolConnections(collector GaugeCollector) PoolMetricsBuilder
+		PoolAcquireWait(collector GaugeCollector) PoolMetricsBuilder
+		PoolAcquireDuration(collector GaugeCollector) PoolMetricsBuilder
+		PoolEmptyAcquires(collector GaugeCollector) PoolMetricsBuilder
+		PoolStatsInterval(interval time.Duration) PoolMetricsBuilder
+	}
+)
+
+type (
+	// Config describes single, cluster or sharded topology which is opened by Open. It is loaded from YAML by
+	// ConfigFromYAML or ConfigFromFile, or from environment variables by ConfigFromEnv.
+	Config        = topology.Config
+	PoolConfig    = topology.PoolConfig
+	NodeConfig    = topology.Node
+	ShardConfig   = topology.Shard
+	ClusterConfig = topology.ClusterConfig
+	ShardedConfig = topology.ShardedConfig
+	MetricsConfig = topology.MetricsConfig
+	Topology      = topology.Kind
+	OpenOption    = topology.Option
+
+	// Pool is implemented by pools of every topology opened by Open.
+	Pool = topology.Pool
+)
+
+const (
+	RoleNode     = lifecycle.RoleNode
+	RoleLeader   = lifecycle.RoleLeader
+	RoleFollower = lifecycle.RoleFollower
+)
+
+const (
+	TopologySingle  = topology.KindSingle
+	TopologyCluster = topology.KindCluster
+	TopologySharded = topology.KindSharded
+
+	BalancerRoundRobin = topology.BalancerRoundRobin
+	BalancerRandom     = topology.BalancerRandom
+
+	PickerHash = topology.PickerHash
+	PickerJump = topology.PickerJump
 )
 
+// OptionalValue is result of Optional, it is empty when query returned no rows.
+type OptionalValue[T any] = monads.Optional[T]
+
 func With(ctx context.Context, opts ...pgcontext.OptionContext) context.Context {
 	return pgcontext.With(ctx, opts...)
 }
@@ -98,6 +271,155 @@ func TxOptionsFrom(ctx context.Context) (pgx.TxOptions, bool) {
 	return pgcontext.TxOptionsFrom(ctx)
 }
 
+// WithStreamTracker sets tracker, like metrics pool, which receives metrics of Stream instead of streamed pool.
+func WithStreamTracker(tracker StreamTracker) pgcontext.OptionContext {
+	return pgcontext.WithStreamTracker(tracker)
+}
+
+// WithFetchSize sets count of rows which Stream fetches from server-side cursor at once.
+func WithFetchSize(size int) pgcontext.OptionContext {
+	return pgcontext.WithFetchSize(size)
+}
+
+// WithHedgedRead enables hedged reads of clusterpg pools: read outside transaction is sent to second follower
+// when first one has not returned first row by delay, first result wins and other read is cancelled. Delay is
+// p95 of recent hedged reads when it is not positive.
+func WithHedgedRead(delay time.Duration) pgcontext.OptionContext {
+	return pgcontext.WithHedgedRead(delay)
+}
+
+// WithTenant sets tenant of statements, which tenantpg pools route by.
+func WithTenant(id string) pgcontext.OptionContext {
+	return pgcontext.WithTenant(id)
+}
+
+func TenantFrom(ctx context.Context) (string, bool) {
+	return pgcontext.TenantFrom(ctx)
+}
+
+// WithSessionSettings sets settings, like app.user_id of row-level security policies, which are applied at start of
+// transaction by set_config local to transaction. Statements outside transaction run in implicit transaction, so
+// settings never leak to next borrower of pooled connection.
+func WithSessionSettings(settings map[string]string) pgcontext.OptionContext {
+	return pgcontext.WithSessionSettings(settings)
+}
+
+func SessionSettingsFrom(ctx context.Context) (map[string]string, bool) {
+	return pgcontext.SessionSettingsFrom(ctx)
+}
+
+// WithCacheTTL sets time which result of read is cached by cache pools, it overrides ttl of metrics label. Read is
+// not cached when ttl is not positive.
+func WithCacheTTL(ttl time.Duration) pgcontext.OptionContext {
+	return pgcontext.WithCacheTTL(ttl)
+}
+
+func CacheTTLFrom(ctx context.Context) (time.Duration, bool) {
+	return pgcontext.CacheTTLFrom(ctx)
+}
+
+// WithCacheTags sets tags of tables which statement touches. Cached results of reads are tagged by them, and Exec
+// of cache pools invalidates results tagged by them, after commit when Exec runs in transaction.
+func WithCacheTags(tags ...string) pgcontext.OptionContext {
+	return pgcontext.WithCacheTags(tags...)
+}
+
+func CacheTagsFrom(ctx context.Context) ([]string, bool) {
+	return pgcontext.CacheTagsFrom(ctx)
+}
+
+func WithPriority(priority Priority) pgcontext.OptionContext {
+	return pgcontext.WithPriority(priority)
+}
+
+func PriorityFrom(ctx context.Context) Priority {
+	return pgcontext.PriorityFrom(ctx)
+}
+
 func WithFnTxPassMatcher(fn pgcontext.TxPassMatcher) pgcontext.OptionContext {
 	return pgcontext.WithFnTxPassMatcher(fn)
 }
+
+// WithAdvisoryLock runs fn while advisory lock hashed from key is held by db.
+func WithAdvisoryLock(ctx context.Context, db AdvisoryLocker, key string, fn func(ctx context.Context) error) error {
+	return db.WithAdvisoryLock(ctx, key, fn)
+}
+
+// WithTryAdvisoryLock runs fn while advisory lock hashed from key is held by db, or returns
+// ErrLockNotAcquired without waiting when lock is held by another session.
+func WithTryAdvisoryLock(ctx context.Context, db AdvisoryLocker, key string, fn func(ctx context.Context) error) error {
+	return db.WithTryAdvisoryLock(ctx, key, fn)
+}
+
+// NewLeaderElector makes elector which campaigns for leadership named by name at db. Leader holds session
+// advisory lock at dedicated connection, so only one candidate across all replicas is leader at a time.
+func NewLeaderElector(db Acquirer, name string, opts ...ElectionOption) *LeaderElector {
+	return election.New(election.FromAcquirer(db), name, opts...)
+}
+
+func WithElectionRetryInterval(interval time.Duration) ElectionOption {
+	return election.WithRetryInterval(interval)
+}
+
+func WithElectionCheckInterval(interval time.Duration) ElectionOption {
+	return election.WithCheckInterval(interval)
+}
+
+func WithElectionErrorHandler(handler func(err error)) ElectionOption {
+	return election.WithErrorHandler(handler)
+}
+
+// One returns exactly one row mapped to struct T by column names, like pgx.RowToStructByName.
+// ErrNotFound is returned when there are no rows and ErrTooManyRows when there are more than one.
+func One[T any](ctx context.Context, db Querier, query string, args ...any) (T, error) {
+	return typed.One[T](ctx, db, query, args...)
+}
+
+// All returns every row mapped to struct T by column names.
+func All[T any](ctx context.Context, db Querier, query string, args ...any) ([]T, error) {
+	return typed.All[T](ctx, db, query, args...)
+}
+
+// Optional returns row mapped to struct T by column names, or empty value when there are no rows.
+func Optional[T any](ctx context.Context, db Querier, query string, args ...any) (OptionalValue[T], error) {
+	return typed.Optional[T](ctx, db, query, args...)
+}
+
+// Scalar returns value of single column of exactly one row.
+func Scalar[T any](ctx context.Context, db Querier, query string, args ...any) (T, error) {
+	return typed.Scalar[T](ctx, db, query, args...)
+}
+
+// Stream iterates rows mapped to struct T by column names. Inside transaction rows are fetched from server-side
+// cursor by batches, so large result sets are read with bounded memory.
+func Stream[T any](ctx context.Context, db Streamer, query string, args ...any) iter.Seq2[T, error] {
+	return typed.Stream[T](ctx, db, query, args...)
+}
+
+// Open opens pool of topology described by cfg and returns function which closes every pgxpool of it.
+func Open(ctx context.Context, cfg Config, opts ...OpenOption) (Pool, func(), error) {
+	return topology.Open(ctx, cfg, opts...)
+}
+
+func ConfigFromYAML(data []byte) (Config, error) {
+	return topology.FromYAML(data)
+}
+
+func ConfigFromFile(path string) (Config, error) {
+	return topology.FromFile(path)
+}
+
+// ConfigFromEnv reads config from environment variables with prefix, like PG_LEADER_DSN and PG_FOLLOWER_DSNS.
+func ConfigFromEnv(prefix string) (Config, error) {
+	return topology.FromEnv(prefix)
+}
+
+// WithOpenMetrics sets collector built by metrics.Collector, which wraps pool when metrics are enabled by config.
+func WithOpenMetrics(collector MetricsCollector) OpenOption {
+	return topology.WithMetrics(collector)
+}
+
+// WithOpenPoolConfig sets fn which adjusts config of every pgxpool, like tracer or AfterConnect hook.
+func WithOpenPoolConfig(fn func(cfg *pgxpool.Config)) OpenOption {
+	return topology.WithPoolConfig(fn)
+}
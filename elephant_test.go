@@ -8,8 +8,10 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/jaswdr/faker/v2"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
+	"github.com/godepo/elephant/elephanttest"
 	"github.com/godepo/elephant/internal/pkg/pgcontext"
 	"github.com/jackc/pgx/v5"
 	"github.com/stretchr/testify/assert"
@@ -163,3 +165,158 @@ func TestWithTxOptions(t *testing.T) {
 		assert.Equal(t, exp, pubOpt)
 	})
 }
+
+func TestWithAdvisoryLock(t *testing.T) {
+	t.Run("should be able to run under lock of db", func(t *testing.T) {
+		db := NewMockAdvisoryLocker(t)
+		key := uuid.NewString()
+		db.EXPECT().WithAdvisoryLock(mock.Anything, key, mock.Anything).
+			RunAndReturn(func(ctx context.Context, _ string, fn func(ctx context.Context) error) error {
+				return fn(ctx)
+			})
+
+		var called bool
+		require.NoError(t, WithAdvisoryLock(context.Background(), db, key, func(context.Context) error {
+			called = true
+			return nil
+		}))
+		assert.True(t, called)
+	})
+}
+
+func TestWithTryAdvisoryLock(t *testing.T) {
+	t.Run("should be able to return not acquired from db", func(t *testing.T) {
+		db := NewMockAdvisoryLocker(t)
+		key := uuid.NewString()
+		db.EXPECT().WithTryAdvisoryLock(mock.Anything, key, mock.Anything).Return(ErrLockNotAcquired)
+
+		err := WithTryAdvisoryLock(context.Background(), db, key, func(context.Context) error {
+			return nil
+		})
+		require.ErrorIs(t, err, ErrLockNotAcquired)
+	})
+}
+
+func TestNewLeaderElector(t *testing.T) {
+	t.Run("should be able to campaign at db and report acquire errors", func(t *testing.T) {
+		db := NewMockAcquirer(t)
+		expErr := errors.New(uuid.NewString())
+		ctx, cancel := context.WithCancel(context.Background())
+		db.EXPECT().Acquire(mock.Anything).Return(nil, expErr)
+
+		var reported error
+		elector := NewLeaderElector(db, "cron",
+			WithElectionRetryInterval(time.Hour),
+			WithElectionCheckInterval(time.Second),
+			WithElectionErrorHandler(func(err error) {
+				reported = err
+				cancel()
+			}),
+		)
+
+		term, err := elector.Campaign(ctx)
+		require.ErrorIs(t, err, context.Canceled)
+		assert.Nil(t, term)
+		assert.ErrorIs(t, reported, expErr)
+	})
+}
+
+func TestTypedHelpers(t *testing.T) {
+	type user struct {
+		ID   int64  `db:"id"`
+		Name string `db:"name"`
+	}
+
+	t.Run("should be able to query rows by pool from context transaction", func(t *testing.T) {
+		pool := elephanttest.New(t)
+		pool.ExpectQuery(elephanttest.Regexp(`FROM users WHERE id`)).InTx().
+			ReturnRows(elephanttest.NewRows("id", "name").Add(int64(1), "bob")).Times(2)
+		pool.ExpectQuery(elephanttest.Regexp(`FROM users WHERE name`)).InTx().
+			ReturnRows(elephanttest.NewRows("id", "name"))
+		pool.ExpectQuery(elephanttest.Regexp(`FROM users$`)).InTx().
+			ReturnRows(elephanttest.NewRows("id", "name").Add(int64(1), "bob").Add(int64(2), "alice")).Times(2)
+		pool.ExpectQuery(elephanttest.Regexp(`count`)).InTx().
+			ReturnRows(elephanttest.NewRows("count").Add(int64(2)))
+
+		require.NoError(t, pool.Transactional(context.Background(), func(ctx context.Context) error {
+			one, err := One[user](ctx, pool, "SELECT id, name FROM users WHERE id = $1", 1)
+			require.NoError(t, err)
+			assert.Equal(t, user{ID: 1, Name: "bob"}, one)
+
+			opt, err := Optional[user](ctx, pool, "SELECT id, name FROM users WHERE id = $1", 1)
+			require.NoError(t, err)
+			assert.Equal(t, "bob", opt.Value.Name)
+
+			_, err = One[user](ctx, pool, "SELECT id, name FROM users WHERE name = $1", "eve")
+			require.ErrorIs(t, err, ErrNotFound)
+
+			_, err = One[user](ctx, pool, "SELECT id, name FROM users")
+			require.ErrorIs(t, err, ErrTooManyRows)
+
+			all, err := All[user](ctx, pool, "SELECT id, name FROM users")
+			require.NoError(t, err)
+			assert.Len(t, all, 2)
+
+			count, err := Scalar[int64](ctx, pool, "SELECT count(*) FROM users")
+			require.NoError(t, err)
+			assert.Equal(t, int64(2), count)
+			return nil
+		}))
+	})
+}
+
+func TestStream(t *testing.T) {
+	type user struct {
+		ID   int64  `db:"id"`
+		Name string `db:"name"`
+	}
+
+	t.Run("should be able to stream rows from cursor of context transaction", func(t *testing.T) {
+		pool := elephanttest.New(t)
+		pool.ExpectExec(elephanttest.Regexp(`^DECLARE .* CURSOR FOR SELECT id, name FROM users$`)).InTx().
+			ReturnTag("DECLARE CURSOR")
+		pool.ExpectQuery(elephanttest.Regexp(`^FETCH FORWARD 1 FROM`)).InTx().
+			ReturnRows(elephanttest.NewRows("id", "name").Add(int64(1), "bob"))
+		pool.ExpectExec(elephanttest.Regexp(`^CLOSE`)).InTx().ReturnTag("CLOSE CURSOR")
+
+		require.NoError(t, pool.Transactional(context.Background(), func(ctx context.Context) error {
+			ctx = With(ctx, WithFetchSize(1))
+			for u, err := range Stream[user](ctx, pool, "SELECT id, name FROM users") {
+				require.NoError(t, err)
+				assert.Equal(t, user{ID: 1, Name: "bob"}, u)
+				break
+			}
+			return nil
+		}))
+	})
+}
+
+func TestOpen(t *testing.T) {
+	type user struct {
+		ID   int64  `db:"id"`
+		Name string `db:"name"`
+	}
+
+	t.Run("should be able to open cluster from yaml", func(t *testing.T) {
+		leader, follower := elephanttest.NewServer(t), elephanttest.NewServer(t)
+		follower.On(elephanttest.Exact("SELECT id, name FROM users")).
+			ReturnRows(elephanttest.NewRows("id", "name").Add(int64(1), "bob"))
+		cfg, err := ConfigFromYAML([]byte(`
+cluster:
+  leader:
+    dsn: ` + leader.DSN() + `
+  followers:
+    - dsn: ` + follower.DSN() + `
+`))
+		require.NoError(t, err)
+
+		db, closeFn, err := Open(context.Background(), cfg)
+		require.NoError(t, err)
+		defer closeFn()
+
+		users, err := All[user](context.Background(), db, "SELECT id, name FROM users")
+		require.NoError(t, err)
+		assert.Equal(t, []user{{ID: 1, Name: "bob"}}, users)
+		assert.Empty(t, leader.Received())
+	})
+}
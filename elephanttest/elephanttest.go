@@ -0,0 +1,390 @@
+// Package elephanttest provides scriptable fake pool for unit tests of code built on elephant pools.
+// Tests register expected statements matched by exact text, regexp or fingerprint, supply result rows
+// as Go values and assert whether statements ran inside transaction. Transactions of fake pool are put to
+// context like transactions of real pools, so they work with elephant.TransactionFrom, and nested
+// transactions are simulated as savepoints.
+package elephanttest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/godepo/elephant/internal/pkg/pgcontext"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+var (
+	ErrUnexpectedStatement = errors.New("elephanttest: unexpected statement")
+	ErrNotSupported        = errors.New("elephanttest: not supported by fake pool")
+)
+
+// Outcome is what happened to statement after it ran.
+type Outcome string
+
+const (
+	OutcomeAutocommit Outcome = "autocommit"
+	OutcomePending    Outcome = "pending"
+	OutcomeCommitted  Outcome = "committed"
+	OutcomeRolledBack Outcome = "rolled back"
+	OutcomeFailed     Outcome = "failed"
+)
+
+// Statement is record of statement which ran at fake pool. TxDepth is 0 outside transaction, 1 in
+// transaction and grows by one per savepoint.
+type Statement struct {
+	SQL     string
+	Args    []any
+	TxDepth int
+	Outcome Outcome
+	Err     error
+}
+
+// InTx reports whether statement ran inside transaction.
+func (s Statement) InTx() bool {
+	return s.TxDepth > 0
+}
+
+type kind int
+
+const (
+	kindQuery kind = iota
+	kindExec
+)
+
+func (k kind) String() string {
+	if k == kindExec {
+		return "exec"
+	}
+	return "query"
+}
+
+type txMode int
+
+const (
+	txAny txMode = iota
+	txInside
+	txOutside
+)
+
+type anyArg struct{}
+
+// AnyArg matches any argument value in WithArgs.
+var AnyArg = anyArg{} //nolint:gochecknoglobals
+
+// Expectation is expected statement and its result.
+type Expectation struct {
+	kind    kind
+	matcher Matcher
+	args    []any
+	hasArgs bool
+	tx      txMode
+	rows    *Rows
+	tag     pgconn.CommandTag
+	err     error
+	times   int
+	calls   int
+}
+
+// WithArgs expects statement arguments. Use AnyArg to skip check of argument.
+func (e *Expectation) WithArgs(args ...any) *Expectation {
+	e.args = args
+	e.hasArgs = true
+	return e
+}
+
+// InTx expects statement to run inside transaction.
+func (e *Expectation) InTx() *Expectation {
+	e.tx = txInside
+	return e
+}
+
+// OutsideTx expects statement to run outside of transaction.
+func (e *Expectation) OutsideTx() *Expectation {
+	e.tx = txOutside
+	return e
+}
+
+// ReturnRows sets rows returned by Query and QueryRow.
+func (e *Expectation) ReturnRows(rows *Rows) *Expectation {
+	e.rows = rows
+	return e
+}
+
+// ReturnTag sets command tag returned by Exec, like "UPDATE 2".
+func (e *Expectation) ReturnTag(tag string) *Expectation {
+	e.tag = pgconn.NewCommandTag(tag)
+	return e
+}
+
+// ReturnError makes statement fail with err. Transaction where statement failed is aborted until it is
+// rolled back, like in PostgreSQL.
+func (e *Expectation) ReturnError(err error) *Expectation {
+	e.err = err
+	return e
+}
+
+// Times sets how many times statement is expected, it is expected once by default.
+func (e *Expectation) Times(n int) *Expectation {
+	e.times = n
+	return e
+}
+
+// AnyTimes allows statement to run any number of times, including zero.
+func (e *Expectation) AnyTimes() *Expectation {
+	e.times = -1
+	return e
+}
+
+func (e *Expectation) String() string {
+	return fmt.Sprintf("%s %s", e.kind, e.matcher)
+}
+
+func (e *Expectation) exhausted() bool {
+	return e.times >= 0 && e.calls >= e.times
+}
+
+func (e *Expectation) unmet() bool {
+	return e.times >= 0 && e.calls < e.times
+}
+
+func (e *Expectation) match(k kind, sql string, args []any) bool {
+	if e.kind != k || e.exhausted() || !e.matcher.Match(sql) {
+		return false
+	}
+	if !e.hasArgs {
+		return true
+	}
+	if len(e.args) != len(args) {
+		return false
+	}
+	for i, arg := range e.args {
+		if arg == AnyArg {
+			continue
+		}
+		if !reflect.DeepEqual(arg, args[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Pool is fake elephant pool. It routes statements to transaction from context like real pools.
+type Pool struct {
+	t            testing.TB
+	mu           sync.Mutex
+	expectations []*Expectation
+	statements   []*Statement
+}
+
+// New makes fake pool, which reports unexpected statements to t and checks that every expected
+// statement ran when test finishes.
+func New(t testing.TB) *Pool {
+	t.Helper()
+	pool := &Pool{t: t}
+	t.Cleanup(func() {
+		if err := pool.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+	return pool
+}
+
+// ExpectQuery registers statement expected by Query or QueryRow.
+func (p *Pool) ExpectQuery(matcher Matcher) *Expectation {
+	return p.expect(kindQuery, matcher)
+}
+
+// ExpectExec registers statement expected by Exec.
+func (p *Pool) ExpectExec(matcher Matcher) *Expectation {
+	return p.expect(kindExec, matcher)
+}
+
+func (p *Pool) expect(k kind, matcher Matcher) *Expectation {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	exp := &Expectation{kind: k, matcher: matcher, times: 1, tag: pgconn.NewCommandTag("")}
+	p.expectations = append(p.expectations, exp)
+	return exp
+}
+
+// ExpectationsWereMet returns error listing expected statements which didn't run enough times.
+func (p *Pool) ExpectationsWereMet() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var unmet []string
+	for _, exp := range p.expectations {
+		if exp.unmet() {
+			unmet = append(unmet, fmt.Sprintf("%s: ran %d of %d times", exp, exp.calls, exp.times))
+		}
+	}
+	if len(unmet) > 0 {
+		return fmt.Errorf("elephanttest: expected statements didn't run:\n%s", strings.Join(unmet, "\n"))
+	}
+	return nil
+}
+
+// Statements returns records of every statement which ran at pool.
+func (p *Pool) Statements() []Statement {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	out := make([]Statement, 0, len(p.statements))
+	for _, stmt := range p.statements {
+		out = append(out, *stmt)
+	}
+	return out
+}
+
+// Committed returns statements which effects are persisted: successful statements outside of transaction and
+// statements of committed transactions, except rolled back savepoints.
+func (p *Pool) Committed() []Statement {
+	var out []Statement
+	for _, stmt := range p.Statements() {
+		if stmt.Outcome == OutcomeAutocommit || stmt.Outcome == OutcomeCommitted {
+			out = append(out, stmt)
+		}
+	}
+	return out
+}
+
+func (p *Pool) Begin(ctx context.Context) (pgx.Tx, error) {
+	if tx, ok := pgcontext.TransactionFrom(ctx); ok {
+		return tx.Begin(ctx)
+	}
+	return newTx(p, nil), nil
+}
+
+func (p *Pool) BeginTx(ctx context.Context, _ pgx.TxOptions) (pgx.Tx, error) {
+	return p.Begin(ctx)
+}
+
+func (p *Pool) Query(ctx context.Context, query string, args ...any) (pgx.Rows, error) {
+	if tx, ok := pgcontext.TransactionFrom(ctx); ok {
+		return tx.Query(ctx, query, args...)
+	}
+	return p.query(nil, query, args)
+}
+
+func (p *Pool) QueryRow(ctx context.Context, query string, args ...any) pgx.Row {
+	if tx, ok := pgcontext.TransactionFrom(ctx); ok {
+		return tx.QueryRow(ctx, query, args...)
+	}
+	return p.queryRow(nil, query, args)
+}
+
+func (p *Pool) Exec(ctx context.Context, query string, args ...any) (pgconn.CommandTag, error) {
+	if tx, ok := pgcontext.TransactionFrom(ctx); ok {
+		return tx.Exec(ctx, query, args...)
+	}
+	return p.exec(nil, query, args)
+}
+
+// Transactional runs fn in transaction put to context, or in savepoint when transaction is already in context.
+// Transaction is committed when fn returns nil or error accepted by pass matcher from context.
+func (p *Pool) Transactional(ctx context.Context, fn func(ctx context.Context) error) (out error) {
+	tx, err := p.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("can't begin transaction: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			out = errors.Join(out, err)
+		}
+	}()
+
+	err = fn(pgcontext.With(ctx, pgcontext.WithTransaction(tx)))
+	if err != nil {
+		matcher, ok := pgcontext.TxPassMatcherFrom(ctx)
+		if !ok || !matcher(ctx, err) {
+			return err
+		}
+	}
+	if commitErr := tx.Commit(ctx); commitErr != nil {
+		return fmt.Errorf("can't commit transaction: %w", commitErr)
+	}
+	return err
+}
+
+func (p *Pool) query(tx *Tx, query string, args []any) (pgx.Rows, error) {
+	exp, err := p.run(tx, kindQuery, query, args)
+	if err != nil {
+		return nil, err
+	}
+	return newRows(exp.rows, nil), nil
+}
+
+func (p *Pool) queryRow(tx *Tx, query string, args []any) pgx.Row {
+	exp, err := p.run(tx, kindQuery, query, args)
+	if err != nil {
+		return row{rows: newRows(nil, err)}
+	}
+	return row{rows: newRows(exp.rows, nil)}
+}
+
+func (p *Pool) exec(tx *Tx, query string, args []any) (pgconn.CommandTag, error) {
+	exp, err := p.run(tx, kindExec, query, args)
+	if err != nil {
+		return pgconn.CommandTag{}, err
+	}
+	return exp.tag, nil
+}
+
+func (p *Pool) run(tx *Tx, k kind, query string, args []any) (*Expectation, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	stmt := &Statement{SQL: query, Args: args, Outcome: OutcomeAutocommit}
+	p.statements = append(p.statements, stmt)
+	if tx != nil {
+		stmt.TxDepth = tx.depth
+		stmt.Outcome = OutcomePending
+		if err := tx.track(stmt); err != nil {
+			return nil, p.fail(stmt, tx, err)
+		}
+	}
+
+	exp := p.find(k, query, args)
+	if exp == nil {
+		p.t.Errorf("elephanttest: unexpected %s %q with args %v", k, query, args)
+		return nil, p.fail(stmt, tx, fmt.Errorf("%w: %s", ErrUnexpectedStatement, query))
+	}
+	exp.calls++
+
+	switch {
+	case exp.tx == txInside && tx == nil:
+		p.t.Errorf("elephanttest: %s %q expected inside transaction", k, query)
+	case exp.tx == txOutside && tx != nil:
+		p.t.Errorf("elephanttest: %s %q expected outside of transaction", k, query)
+	}
+
+	if exp.err != nil {
+		return nil, p.fail(stmt, tx, exp.err)
+	}
+	return exp, nil
+}
+
+func (p *Pool) find(k kind, query string, args []any) *Expectation {
+	for _, exp := range p.expectations {
+		if exp.match(k, query, args) {
+			return exp
+		}
+	}
+	return nil
+}
+
+func (p *Pool) fail(stmt *Statement, tx *Tx, err error) error {
+	stmt.Outcome = OutcomeFailed
+	stmt.Err = err
+	if tx != nil {
+		tx.aborted = true
+	}
+	return err
+}
@@ -0,0 +1,229 @@
+package elephanttest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/godepo/elephant"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type reporter struct {
+	testing.TB
+	reported []string
+}
+
+func (r *reporter) Errorf(format string, args ...any) {
+	r.reported = append(r.reported, fmt.Sprintf(format, args...))
+}
+
+func (r *reporter) Error(args ...any) {
+	r.reported = append(r.reported, fmt.Sprint(args...))
+}
+
+type user struct {
+	ID   int64
+	Name string
+}
+
+func TestPool_Query(t *testing.T) {
+	t.Run("should be able to return rows as go values", func(t *testing.T) {
+		pool := New(t)
+		pool.ExpectQuery(Exact("SELECT id, name FROM users WHERE id > $1")).WithArgs(10).
+			ReturnRows(NewRows("id", "name").Add(11, "bob").Add(int64(12), "alice"))
+
+		rows, err := pool.Query(context.Background(), "SELECT id, name FROM users WHERE id > $1", 10)
+		require.NoError(t, err)
+		users, err := pgx.CollectRows(rows, pgx.RowToStructByName[user])
+		require.NoError(t, err)
+		assert.Equal(t, []user{{ID: 11, Name: "bob"}, {ID: 12, Name: "alice"}}, users)
+	})
+
+	t.Run("should be able to return no rows to query row", func(t *testing.T) {
+		pool := New(t)
+		pool.ExpectQuery(Regexp(`^SELECT name FROM users`)).WithArgs(AnyArg)
+
+		var name string
+		err := pool.QueryRow(context.Background(), "SELECT name FROM users WHERE id = $1", 1).Scan(&name)
+		require.ErrorIs(t, err, pgx.ErrNoRows)
+	})
+
+	t.Run("should be able to scan nullable values", func(t *testing.T) {
+		pool := New(t)
+		pool.ExpectQuery(Fingerprint("select name, age from users where id = 1")).
+			ReturnRows(NewRows("name", "age").Add("bob", nil))
+
+		var (
+			name *string
+			age  int
+		)
+		err := pool.QueryRow(context.Background(), "SELECT name, age FROM users WHERE id = $1", 7).Scan(&name, &age)
+		require.NoError(t, err)
+		require.NotNil(t, name)
+		assert.Equal(t, "bob", *name)
+		assert.Zero(t, age)
+	})
+
+	t.Run("should be able to fail scan of incompatible value", func(t *testing.T) {
+		pool := New(t)
+		pool.ExpectQuery(Exact("SELECT 1")).ReturnRows(NewRows("n").Add(1))
+
+		var n string
+		require.Error(t, pool.QueryRow(context.Background(), "SELECT 1").Scan(&n))
+	})
+
+	t.Run("should be able to return expected error", func(t *testing.T) {
+		expErr := errors.New("boom")
+		pool := New(t)
+		pool.ExpectQuery(Exact("SELECT 1")).ReturnError(expErr)
+
+		_, err := pool.Query(context.Background(), "SELECT 1")
+		require.ErrorIs(t, err, expErr)
+		assert.Equal(t, OutcomeFailed, pool.Statements()[0].Outcome)
+	})
+}
+
+func TestPool_Exec(t *testing.T) {
+	t.Run("should be able to return command tag", func(t *testing.T) {
+		pool := New(t)
+		pool.ExpectExec(Regexp(`^UPDATE users`)).ReturnTag("UPDATE 2").Times(2)
+
+		for range 2 {
+			tag, err := pool.Exec(context.Background(), "UPDATE users SET name = $1", "bob")
+			require.NoError(t, err)
+			assert.Equal(t, int64(2), tag.RowsAffected())
+		}
+		assert.Len(t, pool.Committed(), 2)
+	})
+
+	t.Run("should be able to report unexpected statement", func(t *testing.T) {
+		rep := &reporter{TB: t}
+		pool := New(rep)
+		pool.ExpectExec(Exact("DELETE FROM users")).AnyTimes()
+
+		_, err := pool.Exec(context.Background(), "DELETE FROM orders")
+		require.ErrorIs(t, err, ErrUnexpectedStatement)
+		assert.Len(t, rep.reported, 1)
+	})
+
+	t.Run("should be able to report unmet expectations", func(t *testing.T) {
+		pool := New(&reporter{TB: t})
+		pool.ExpectExec(Exact("DELETE FROM users"))
+
+		err := pool.ExpectationsWereMet()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "DELETE FROM users")
+	})
+}
+
+func TestPool_Transactional(t *testing.T) {
+	t.Run("should be able to run statements in transaction from context", func(t *testing.T) {
+		pool := New(t)
+		pool.ExpectExec(Exact("INSERT INTO users VALUES ($1)")).InTx()
+		pool.ExpectQuery(Exact("SELECT count(*) FROM users")).OutsideTx()
+
+		err := pool.Transactional(context.Background(), func(ctx context.Context) error {
+			tx, ok := elephant.TransactionFrom(ctx)
+			require.True(t, ok)
+			assert.Equal(t, 1, tx.(*Tx).Depth())
+			_, err := pool.Exec(ctx, "INSERT INTO users VALUES ($1)", 1)
+			return err
+		})
+		require.NoError(t, err)
+		_, err = pool.Query(context.Background(), "SELECT count(*) FROM users")
+		require.NoError(t, err)
+
+		stmts := pool.Statements()
+		assert.True(t, stmts[0].InTx())
+		assert.Equal(t, OutcomeCommitted, stmts[0].Outcome)
+		assert.Equal(t, OutcomeAutocommit, stmts[1].Outcome)
+	})
+
+	t.Run("should be able to report statement outside of expected transaction", func(t *testing.T) {
+		rep := &reporter{TB: t}
+		pool := New(rep)
+		pool.ExpectExec(Exact("INSERT INTO users VALUES ($1)")).InTx()
+
+		_, err := pool.Exec(context.Background(), "INSERT INTO users VALUES ($1)", 1)
+		require.NoError(t, err)
+		assert.Len(t, rep.reported, 1)
+	})
+
+	t.Run("should be able to roll back savepoint", func(t *testing.T) {
+		expErr := errors.New("nested failed")
+		pool := New(t)
+		pool.ExpectExec(Regexp(`^INSERT`)).Times(3)
+
+		ctx := context.Background()
+		err := pool.Transactional(ctx, func(ctx context.Context) error {
+			_, _ = pool.Exec(ctx, "INSERT INTO users VALUES (1)")
+			nestedErr := pool.Transactional(ctx, func(ctx context.Context) error {
+				_, _ = pool.Exec(ctx, "INSERT INTO users VALUES (2)")
+				return expErr
+			})
+			require.ErrorIs(t, nestedErr, expErr)
+			_, err := pool.Exec(ctx, "INSERT INTO users VALUES (3)")
+			return err
+		})
+		require.NoError(t, err)
+
+		stmts := pool.Statements()
+		assert.Equal(t, 2, stmts[1].TxDepth)
+		assert.Equal(t, OutcomeRolledBack, stmts[1].Outcome)
+		committed := pool.Committed()
+		require.Len(t, committed, 2)
+		assert.Equal(t, "INSERT INTO users VALUES (3)", committed[1].SQL)
+	})
+
+	t.Run("should be able to abort transaction after failed statement", func(t *testing.T) {
+		pool := New(t)
+		pool.ExpectExec(Exact("INSERT INTO users VALUES (1)")).ReturnError(errors.New("duplicate key"))
+
+		tx, err := pool.Begin(context.Background())
+		require.NoError(t, err)
+		_, err = tx.Exec(context.Background(), "INSERT INTO users VALUES (1)")
+		require.Error(t, err)
+
+		_, err = tx.Exec(context.Background(), "INSERT INTO users VALUES (2)")
+		var pgErr *pgconn.PgError
+		require.ErrorAs(t, err, &pgErr)
+		assert.Equal(t, codeInFailedTransaction, pgErr.Code)
+
+		require.ErrorIs(t, tx.Commit(context.Background()), pgx.ErrTxCommitRollback)
+		require.ErrorIs(t, tx.Rollback(context.Background()), pgx.ErrTxClosed)
+	})
+
+	t.Run("should be able to commit when error is passed by matcher", func(t *testing.T) {
+		expErr := errors.New("business error")
+		pool := New(t)
+		pool.ExpectExec(Exact("UPDATE users SET seen = true"))
+
+		ctx := elephant.With(context.Background(), elephant.WithFnTxPassMatcher(
+			func(_ context.Context, err error) bool { return errors.Is(err, expErr) },
+		))
+		err := pool.Transactional(ctx, func(ctx context.Context) error {
+			_, _ = pool.Exec(ctx, "UPDATE users SET seen = true")
+			return expErr
+		})
+		require.ErrorIs(t, err, expErr)
+		assert.Len(t, pool.Committed(), 1)
+	})
+}
+
+func TestTx_unsupported(t *testing.T) {
+	tx, err := New(t).BeginTx(context.Background(), pgx.TxOptions{})
+	require.NoError(t, err)
+
+	_, err = tx.CopyFrom(context.Background(), pgx.Identifier{"users"}, nil, nil)
+	require.ErrorIs(t, err, ErrNotSupported)
+	_, err = tx.Prepare(context.Background(), "q", "SELECT 1")
+	require.ErrorIs(t, err, ErrNotSupported)
+	_, err = tx.SendBatch(context.Background(), &pgx.Batch{}).Exec()
+	require.ErrorIs(t, err, ErrNotSupported)
+	assert.Nil(t, tx.Conn())
+}
@@ -0,0 +1,64 @@
+package elephanttest
+
+import (
+	"regexp"
+
+	"github.com/godepo/elephant/internal/pkg/fingerprint"
+)
+
+// Matcher decides whether statement is expected.
+type Matcher interface {
+	Match(sql string) bool
+	String() string
+}
+
+type exact string
+
+// Exact matches statement with the same text.
+func Exact(sql string) Matcher {
+	return exact(sql)
+}
+
+func (m exact) Match(sql string) bool {
+	return string(m) == sql
+}
+
+func (m exact) String() string {
+	return "exact " + string(m)
+}
+
+type pattern struct {
+	expr *regexp.Regexp
+}
+
+// Regexp matches statement by regular expression. It panics when expression can't be compiled.
+func Regexp(expr string) Matcher {
+	return pattern{expr: regexp.MustCompile(expr)}
+}
+
+func (m pattern) Match(sql string) bool {
+	return m.expr.MatchString(sql)
+}
+
+func (m pattern) String() string {
+	return "regexp " + m.expr.String()
+}
+
+type sameFingerprint struct {
+	sql         string
+	fingerprint string
+}
+
+// Fingerprint matches statement which differs from sql only by literals, placeholders, comments,
+// whitespace or keyword case.
+func Fingerprint(sql string) Matcher {
+	return sameFingerprint{sql: sql, fingerprint: fingerprint.Fingerprint(sql)}
+}
+
+func (m sameFingerprint) Match(sql string) bool {
+	return fingerprint.Fingerprint(sql) == m.fingerprint
+}
+
+func (m sameFingerprint) String() string {
+	return "fingerprint of " + fingerprint.Normalize(m.sql)
+}
@@ -0,0 +1,164 @@
+package elephanttest
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Rows is result set given by fake pool. Values are assigned to scan destinations as Go values, so they
+// must be assignable or convertible to destination types.
+type Rows struct {
+	columns []string
+	values  [][]any
+}
+
+// NewRows makes empty result set with columns.
+func NewRows(columns ...string) *Rows {
+	return &Rows{columns: columns}
+}
+
+// Add appends row to result set. It panics when count of values differs from count of columns.
+func (r *Rows) Add(values ...any) *Rows {
+	if len(values) != len(r.columns) {
+		panic(fmt.Sprintf("elephanttest: row has %d values for %d columns", len(values), len(r.columns)))
+	}
+	r.values = append(r.values, values)
+	return r
+}
+
+type rows struct {
+	set    *Rows
+	idx    int
+	err    error
+	closed bool
+}
+
+func newRows(set *Rows, err error) *rows {
+	if set == nil {
+		set = NewRows()
+	}
+	return &rows{set: set, idx: -1, err: err}
+}
+
+func (r *rows) Close() {
+	r.closed = true
+}
+
+func (r *rows) Err() error {
+	return r.err
+}
+
+func (r *rows) CommandTag() pgconn.CommandTag {
+	return pgconn.NewCommandTag(fmt.Sprintf("SELECT %d", len(r.set.values)))
+}
+
+func (r *rows) FieldDescriptions() []pgconn.FieldDescription {
+	fields := make([]pgconn.FieldDescription, 0, len(r.set.columns))
+	for _, col := range r.set.columns {
+		fields = append(fields, pgconn.FieldDescription{Name: col})
+	}
+	return fields
+}
+
+func (r *rows) Next() bool {
+	if r.closed || r.err != nil {
+		return false
+	}
+	r.idx++
+	if r.idx >= len(r.set.values) {
+		r.closed = true
+		return false
+	}
+	return true
+}
+
+func (r *rows) Scan(dest ...any) error {
+	if r.idx < 0 || r.idx >= len(r.set.values) {
+		return fmt.Errorf("elephanttest: scan called without current row")
+	}
+	values := r.set.values[r.idx]
+	if len(dest) != len(values) {
+		return fmt.Errorf("elephanttest: scan of %d values to %d destinations", len(values), len(dest))
+	}
+	for i, value := range values {
+		if err := assign(dest[i], value); err != nil {
+			return fmt.Errorf("elephanttest: can't scan column %s: %w", r.set.columns[i], err)
+		}
+	}
+	return nil
+}
+
+func (r *rows) Values() ([]any, error) {
+	if r.idx < 0 || r.idx >= len(r.set.values) {
+		return nil, fmt.Errorf("elephanttest: values called without current row")
+	}
+	return r.set.values[r.idx], nil
+}
+
+func (r *rows) RawValues() [][]byte {
+	return nil
+}
+
+func (r *rows) Conn() *pgx.Conn {
+	return nil
+}
+
+type row struct {
+	rows *rows
+}
+
+func (r row) Scan(dest ...any) error {
+	defer r.rows.Close()
+	if !r.rows.Next() {
+		if r.rows.Err() != nil {
+			return r.rows.Err()
+		}
+		return pgx.ErrNoRows
+	}
+	return r.rows.Scan(dest...)
+}
+
+func assign(dest, value any) error {
+	if dest == nil {
+		return nil
+	}
+	if scanner, ok := dest.(sql.Scanner); ok {
+		return scanner.Scan(value)
+	}
+
+	ptr := reflect.ValueOf(dest)
+	if ptr.Kind() != reflect.Pointer || ptr.IsNil() {
+		return fmt.Errorf("destination %T is not pointer", dest)
+	}
+	return assignValue(ptr.Elem(), value)
+}
+
+func assignValue(target reflect.Value, value any) error {
+	if value == nil {
+		target.SetZero()
+		return nil
+	}
+
+	src := reflect.ValueOf(value)
+	switch {
+	case src.Type().AssignableTo(target.Type()):
+		target.Set(src)
+	case target.Kind() == reflect.Pointer:
+		elem := reflect.New(target.Type().Elem())
+		if err := assignValue(elem.Elem(), value); err != nil {
+			return err
+		}
+		target.Set(elem)
+	case target.Kind() == reflect.Interface && src.Type().Implements(target.Type()):
+		target.Set(src)
+	case src.Type().ConvertibleTo(target.Type()) && (target.Kind() != reflect.String || src.Kind() == reflect.String):
+		target.Set(src.Convert(target.Type()))
+	default:
+		return fmt.Errorf("value of %T is not assignable to %s", value, target.Type())
+	}
+	return nil
+}
@@ -0,0 +1,546 @@
+package elephanttest
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/godepo/elephant/internal/pkg/fingerprint"
+	"github.com/jackc/pgx/v5/pgproto3"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	codeInternalError = "XX000"
+
+	txIdle   = 'I'
+	txActive = 'T'
+	txFailed = 'E'
+)
+
+var (
+	placeholders = regexp.MustCompile(`\$(\d+)`)
+	errDropped   = errors.New("elephanttest: connection dropped by script")
+)
+
+// Response is scripted answer of Server to matched statement.
+type Response struct {
+	matcher Matcher
+	rows    *Rows
+	tag     string
+	code    string
+	message string
+	delay   time.Duration
+	drop    bool
+	times   int
+	calls   int
+}
+
+// ReturnRows answers statement with rows. Column types are inferred from Go values.
+func (r *Response) ReturnRows(rows *Rows) *Response {
+	r.rows = rows
+	return r
+}
+
+// ReturnTag answers statement with command tag, like "UPDATE 2".
+func (r *Response) ReturnTag(tag string) *Response {
+	r.tag = tag
+	return r
+}
+
+// ReturnError answers statement with error with given SQLSTATE code.
+func (r *Response) ReturnError(code, message string) *Response {
+	r.code = code
+	r.message = message
+	return r
+}
+
+// Delay makes server wait before answering statement.
+func (r *Response) Delay(delay time.Duration) *Response {
+	r.delay = delay
+	return r
+}
+
+// DropConnection makes server close connection instead of answering statement.
+func (r *Response) DropConnection() *Response {
+	r.drop = true
+	return r
+}
+
+// Times limits how many statements are answered by response, it answers any number of statements by default.
+func (r *Response) Times(n int) *Response {
+	r.times = n
+	return r
+}
+
+func (r *Response) exhausted() bool {
+	return r.times >= 0 && r.calls >= r.times
+}
+
+// Received is statement which server received.
+type Received struct {
+	SQL  string
+	Args []any
+	InTx bool
+}
+
+// Server speaks enough of PostgreSQL wire protocol for pgx and pgxpool to connect, run simple and extended
+// queries and transactions. Statements are answered by scripted responses, transaction control statements
+// are answered by server itself unless they are scripted.
+type Server struct {
+	t         testing.TB
+	listener  net.Listener
+	mu        sync.Mutex
+	responses []*Response
+	received  []Received
+	conns     map[net.Conn]struct{}
+	wg        sync.WaitGroup
+	processID uint32
+}
+
+// NewServer starts server at random local port. Server is closed when test finishes.
+func NewServer(t testing.TB) *Server {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("elephanttest: can't listen: %v", err)
+	}
+
+	srv := &Server{t: t, listener: listener, conns: map[net.Conn]struct{}{}}
+	srv.wg.Add(1)
+	go srv.accept()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// DSN returns connection string of server.
+func (s *Server) DSN() string {
+	return fmt.Sprintf("postgres://elephant@%s/elephant?sslmode=disable", s.listener.Addr())
+}
+
+// On scripts response to statements matched by matcher. Responses are tried in order they were scripted.
+func (s *Server) On(matcher Matcher) *Response {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	resp := &Response{matcher: matcher, times: -1}
+	s.responses = append(s.responses, resp)
+	return resp
+}
+
+// Received returns statements which server received.
+func (s *Server) Received() []Received {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Received(nil), s.received...)
+}
+
+// DropConnections closes every open connection, like crashed or restarted server.
+func (s *Server) DropConnections() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for conn := range s.conns {
+		_ = conn.Close()
+	}
+}
+
+// Close stops server and closes every open connection.
+func (s *Server) Close() {
+	_ = s.listener.Close()
+	s.DropConnections()
+	s.wg.Wait()
+}
+
+func (s *Server) accept() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		s.mu.Lock()
+		s.conns[conn] = struct{}{}
+		s.processID++
+		processID := s.processID
+		s.mu.Unlock()
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer s.forget(conn)
+			sess := &session{
+				srv:        s,
+				conn:       conn,
+				backend:    pgproto3.NewBackend(conn, conn),
+				types:      pgtype.NewMap(),
+				statements: map[string]string{},
+				portals:    map[string]portal{},
+				txStatus:   txIdle,
+			}
+			_ = sess.serve(processID)
+		}()
+	}
+}
+
+func (s *Server) forget(conn net.Conn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_ = conn.Close()
+	delete(s.conns, conn)
+}
+
+func (s *Server) receive(query string, args []any, inTx bool) *Response {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.received = append(s.received, Received{SQL: query, Args: args, InTx: inTx})
+	resp := s.lookup(query)
+	if resp != nil {
+		resp.calls++
+	}
+	return resp
+}
+
+func (s *Server) peek(query string) *Response {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.lookup(query)
+}
+
+func (s *Server) lookup(query string) *Response {
+	for _, resp := range s.responses {
+		if !resp.exhausted() && resp.matcher.Match(query) {
+			return resp
+		}
+	}
+	return nil
+}
+
+type portal struct {
+	query   string
+	args    []any
+	formats []int16
+}
+
+type session struct {
+	srv        *Server
+	conn       net.Conn
+	backend    *pgproto3.Backend
+	types      *pgtype.Map
+	statements map[string]string
+	portals    map[string]portal
+	txStatus   byte
+	failed     bool
+}
+
+func (s *session) serve(processID uint32) error {
+	if err := s.startup(processID); err != nil {
+		return err
+	}
+
+	for {
+		msg, err := s.backend.Receive()
+		if err != nil {
+			return err
+		}
+		if _, ok := msg.(*pgproto3.Sync); !ok && s.failed {
+			continue
+		}
+
+		switch msg := msg.(type) {
+		case *pgproto3.Query:
+			if err := s.execute(msg.String, nil, nil, true); err != nil {
+				return err
+			}
+			s.failed = false
+			s.backend.Send(&pgproto3.ReadyForQuery{TxStatus: s.txStatus})
+			err = s.backend.Flush()
+		case *pgproto3.Parse:
+			s.statements[msg.Name] = msg.Query
+			s.backend.Send(&pgproto3.ParseComplete{})
+		case *pgproto3.Describe:
+			s.describe(msg)
+		case *pgproto3.Bind:
+			s.portals[msg.DestinationPortal] = portal{
+				query:   s.statements[msg.PreparedStatement],
+				args:    bindArgs(msg),
+				formats: msg.ResultFormatCodes,
+			}
+			s.backend.Send(&pgproto3.BindComplete{})
+		case *pgproto3.Execute:
+			p := s.portals[msg.Portal]
+			err = s.execute(p.query, p.args, p.formats, false)
+		case *pgproto3.Sync:
+			s.failed = false
+			s.backend.Send(&pgproto3.ReadyForQuery{TxStatus: s.txStatus})
+			err = s.backend.Flush()
+		case *pgproto3.Flush:
+			err = s.backend.Flush()
+		case *pgproto3.Close:
+			s.backend.Send(&pgproto3.CloseComplete{})
+		case *pgproto3.Terminate:
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (s *session) startup(processID uint32) error {
+	for {
+		msg, err := s.backend.ReceiveStartupMessage()
+		if err != nil {
+			return err
+		}
+
+		switch msg.(type) {
+		case *pgproto3.SSLRequest, *pgproto3.GSSEncRequest:
+			if _, err := s.conn.Write([]byte("N")); err != nil {
+				return err
+			}
+		case *pgproto3.StartupMessage:
+			s.backend.Send(&pgproto3.AuthenticationOk{})
+			for name, value := range map[string]string{
+				"server_version":              "16.0",
+				"server_encoding":             "UTF8",
+				"client_encoding":             "UTF8",
+				"DateStyle":                   "ISO, MDY",
+				"TimeZone":                    "UTC",
+				"integer_datetimes":           "on",
+				"standard_conforming_strings": "on",
+			} {
+				s.backend.Send(&pgproto3.ParameterStatus{Name: name, Value: value})
+			}
+			s.backend.Send(&pgproto3.BackendKeyData{ProcessID: processID, SecretKey: processID})
+			s.backend.Send(&pgproto3.ReadyForQuery{TxStatus: txIdle})
+			return s.backend.Flush()
+		default:
+			return fmt.Errorf("elephanttest: unexpected startup message %T", msg)
+		}
+	}
+}
+
+func (s *session) describe(msg *pgproto3.Describe) {
+	query, formats := s.statements[msg.Name], []int16(nil)
+	if msg.ObjectType == 'S' {
+		count := 0
+		for _, match := range placeholders.FindAllStringSubmatch(query, -1) {
+			n, _ := strconv.Atoi(match[1])
+			count = max(count, n)
+		}
+		s.backend.Send(&pgproto3.ParameterDescription{ParameterOIDs: make([]uint32, count)})
+	} else {
+		p := s.portals[msg.Name]
+		query, formats = p.query, p.formats
+	}
+
+	resp := s.srv.peek(query)
+	if resp == nil || resp.rows == nil {
+		s.backend.Send(&pgproto3.NoData{})
+		return
+	}
+	s.backend.Send(rowDescription(resp.rows, formats))
+}
+
+func (s *session) execute(query string, args []any, formats []int16, describe bool) error {
+	resp := s.srv.receive(query, args, s.txStatus != txIdle)
+	if resp == nil {
+		if s.control(query) {
+			return nil
+		}
+		s.srv.t.Errorf("elephanttest: server received unexpected statement %q with args %v", query, args)
+		s.fail(codeInternalError, "elephanttest: unexpected statement: "+query)
+		return nil
+	}
+
+	if resp.delay > 0 {
+		time.Sleep(resp.delay)
+	}
+	if resp.drop {
+		return errDropped
+	}
+	if s.txStatus == txFailed {
+		s.fail(codeInFailedTransaction, "current transaction is aborted, commands ignored until end of transaction block")
+		return nil
+	}
+	if resp.code != "" {
+		s.fail(resp.code, resp.message)
+		return nil
+	}
+	if resp.rows == nil {
+		s.backend.Send(&pgproto3.CommandComplete{CommandTag: []byte(commandTag(resp.tag, query))})
+		return nil
+	}
+
+	if describe {
+		s.backend.Send(rowDescription(resp.rows, formats))
+	}
+	for _, values := range resp.rows.values {
+		row, err := s.dataRow(resp.rows, values, formats)
+		if err != nil {
+			s.fail(codeInternalError, err.Error())
+			return nil
+		}
+		s.backend.Send(row)
+	}
+	s.backend.Send(&pgproto3.CommandComplete{CommandTag: fmt.Appendf(nil, "SELECT %d", len(resp.rows.values))})
+	return nil
+}
+
+// control answers transaction control statements which were not scripted.
+func (s *session) control(query string) bool {
+	words := strings.Fields(fingerprint.Normalize(query))
+	if len(words) == 0 {
+		s.backend.Send(&pgproto3.EmptyQueryResponse{})
+		return true
+	}
+
+	tag := ""
+	switch {
+	case words[0] == "begin" || words[0] == "start":
+		tag, s.txStatus = "BEGIN", txActive
+	case words[0] == "commit" || words[0] == "end":
+		tag = "COMMIT"
+		if s.txStatus == txFailed {
+			tag = "ROLLBACK"
+		}
+		s.txStatus = txIdle
+	case (words[0] == "rollback" || words[0] == "abort") && len(words) > 1 && words[1] == "to":
+		tag, s.txStatus = "ROLLBACK", txActive
+	case words[0] == "rollback" || words[0] == "abort":
+		tag, s.txStatus = "ROLLBACK", txIdle
+	case words[0] == "savepoint" && s.txStatus == txActive:
+		tag = "SAVEPOINT"
+	case words[0] == "release" && s.txStatus == txActive:
+		tag = "RELEASE"
+	default:
+		return false
+	}
+	s.backend.Send(&pgproto3.CommandComplete{CommandTag: []byte(tag)})
+	return true
+}
+
+func (s *session) fail(code, message string) {
+	s.failed = true
+	if s.txStatus == txActive {
+		s.txStatus = txFailed
+	}
+	s.backend.Send(&pgproto3.ErrorResponse{Severity: "ERROR", Code: code, Message: message})
+}
+
+func (s *session) dataRow(rows *Rows, values []any, formats []int16) (*pgproto3.DataRow, error) {
+	out := make([][]byte, len(values))
+	for i, value := range values {
+		if value == nil {
+			continue
+		}
+		oid, value := columnType(rows, i), wireValue(value)
+		buf, err := s.types.Encode(oid, formatOf(formats, i), value, nil)
+		if err != nil {
+			return nil, fmt.Errorf("elephanttest: can't encode column %s: %w", rows.columns[i], err)
+		}
+		out[i] = buf
+	}
+	return &pgproto3.DataRow{Values: out}, nil
+}
+
+func rowDescription(rows *Rows, formats []int16) *pgproto3.RowDescription {
+	fields := make([]pgproto3.FieldDescription, 0, len(rows.columns))
+	for i, col := range rows.columns {
+		fields = append(fields, pgproto3.FieldDescription{
+			Name:         []byte(col),
+			DataTypeOID:  columnType(rows, i),
+			DataTypeSize: -1,
+			TypeModifier: -1,
+			Format:       formatOf(formats, i),
+		})
+	}
+	return &pgproto3.RowDescription{Fields: fields}
+}
+
+func columnType(rows *Rows, col int) uint32 {
+	for _, values := range rows.values {
+		switch values[col].(type) {
+		case nil:
+			continue
+		case bool:
+			return pgtype.BoolOID
+		case int16:
+			return pgtype.Int2OID
+		case int32:
+			return pgtype.Int4OID
+		case int, int64:
+			return pgtype.Int8OID
+		case float32:
+			return pgtype.Float4OID
+		case float64:
+			return pgtype.Float8OID
+		case []byte:
+			return pgtype.ByteaOID
+		case time.Time:
+			return pgtype.TimestamptzOID
+		default:
+			return pgtype.TextOID
+		}
+	}
+	return pgtype.TextOID
+}
+
+// wireValue stringifies values which have no inferred PostgreSQL type.
+func wireValue(value any) any {
+	switch value.(type) {
+	case bool, int16, int32, int, int64, float32, float64, []byte, time.Time, string:
+		return value
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+func formatOf(formats []int16, col int) int16 {
+	switch len(formats) {
+	case 0:
+		return pgtype.TextFormatCode
+	case 1:
+		return formats[0]
+	default:
+		return formats[col]
+	}
+}
+
+func bindArgs(msg *pgproto3.Bind) []any {
+	args := make([]any, len(msg.Parameters))
+	for i, param := range msg.Parameters {
+		if param == nil {
+			continue
+		}
+		if formatOf(msg.ParameterFormatCodes, i) == pgtype.BinaryFormatCode {
+			args[i] = append([]byte(nil), param...)
+			continue
+		}
+		args[i] = string(param)
+	}
+	return args
+}
+
+func commandTag(tag, query string) string {
+	if tag != "" {
+		return tag
+	}
+	words := strings.Fields(query)
+	if len(words) == 0 {
+		return ""
+	}
+	return strings.ToUpper(words[0])
+}
@@ -0,0 +1,152 @@
+package elephanttest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func connect(t *testing.T, srv *Server) *pgxpool.Pool {
+	t.Helper()
+	pool, err := pgxpool.New(context.Background(), srv.DSN())
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+	return pool
+}
+
+func TestServer(t *testing.T) {
+	t.Run("should be able to answer extended query with rows", func(t *testing.T) {
+		srv := NewServer(t)
+		created := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+		srv.On(Fingerprint("SELECT id, name, created_at, score FROM users WHERE id > $1")).
+			ReturnRows(NewRows("id", "name", "created_at", "score").
+				Add(1, "bob", created, 1.5).
+				Add(2, nil, created, 2.5))
+		pool := connect(t, srv)
+
+		rows, err := pool.Query(context.Background(), "SELECT id, name, created_at, score FROM users WHERE id > $1", 0)
+		require.NoError(t, err)
+		type user struct {
+			ID        int64
+			Name      *string
+			CreatedAt time.Time
+			Score     float64
+		}
+		users, err := pgx.CollectRows(rows, pgx.RowToStructByName[user])
+		require.NoError(t, err)
+		require.Len(t, users, 2)
+		assert.Equal(t, "bob", *users[0].Name)
+		assert.Nil(t, users[1].Name)
+		assert.True(t, created.Equal(users[1].CreatedAt))
+
+		received := srv.Received()
+		assert.Equal(t, []any{"0"}, received[len(received)-1].Args)
+	})
+
+	t.Run("should be able to answer exec with command tag", func(t *testing.T) {
+		srv := NewServer(t)
+		srv.On(Regexp(`^UPDATE users`)).ReturnTag("UPDATE 3")
+		pool := connect(t, srv)
+
+		tag, err := pool.Exec(context.Background(), "UPDATE users SET name = $1", "bob")
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), tag.RowsAffected())
+	})
+
+	t.Run("should be able to inject error with sqlstate", func(t *testing.T) {
+		srv := NewServer(t)
+		srv.On(Regexp(`^INSERT`)).ReturnError("23505", "duplicate key value violates unique constraint")
+		pool := connect(t, srv)
+
+		_, err := pool.Exec(context.Background(), "INSERT INTO users VALUES ($1)", 1)
+		var pgErr *pgconn.PgError
+		require.ErrorAs(t, err, &pgErr)
+		assert.Equal(t, "23505", pgErr.Code)
+	})
+
+	t.Run("should be able to run transaction with savepoints", func(t *testing.T) {
+		srv := NewServer(t)
+		srv.On(Regexp(`^INSERT`)).ReturnError("23505", "duplicate").Times(1)
+		srv.On(Regexp(`^UPDATE`)).ReturnTag("UPDATE 1")
+		pool := connect(t, srv)
+		ctx := context.Background()
+
+		tx, err := pool.Begin(ctx)
+		require.NoError(t, err)
+		nested, err := tx.Begin(ctx)
+		require.NoError(t, err)
+		_, err = nested.Exec(ctx, "INSERT INTO users VALUES ($1)", 1)
+		require.Error(t, err)
+		_, err = nested.Exec(ctx, "UPDATE users SET seen = true")
+		require.Error(t, err, "transaction must be aborted after failed statement")
+		require.NoError(t, nested.Rollback(ctx))
+		_, err = tx.Exec(ctx, "UPDATE users SET seen = true")
+		require.NoError(t, err)
+		require.NoError(t, tx.Commit(ctx))
+
+		for _, rec := range srv.Received() {
+			if rec.SQL == "UPDATE users SET seen = true" {
+				assert.True(t, rec.InTx)
+			}
+		}
+	})
+
+	t.Run("should be able to delay answer", func(t *testing.T) {
+		srv := NewServer(t)
+		srv.On(Exact("SELECT pg_sleep(1)")).Delay(200 * time.Millisecond)
+		pool := connect(t, srv)
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		_, err := pool.Exec(ctx, "SELECT pg_sleep(1)")
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, context.DeadlineExceeded) || pgconn.Timeout(err))
+	})
+
+	t.Run("should be able to drop connection", func(t *testing.T) {
+		srv := NewServer(t)
+		srv.On(Exact("SELECT 1")).DropConnection().Times(1)
+		srv.On(Exact("SELECT 1")).ReturnRows(NewRows("n").Add(1))
+		pool := connect(t, srv)
+
+		_, err := pool.Exec(context.Background(), "SELECT 1")
+		require.Error(t, err)
+
+		var n int
+		require.NoError(t, pool.QueryRow(context.Background(), "SELECT 1").Scan(&n))
+		assert.Equal(t, 1, n)
+	})
+
+	t.Run("should be able to drop every connection", func(t *testing.T) {
+		srv := NewServer(t)
+		srv.On(Exact("SELECT 1")).ReturnRows(NewRows("n").Add(1))
+		pool := connect(t, srv)
+		conn, err := pool.Acquire(context.Background())
+		require.NoError(t, err)
+		defer conn.Release()
+
+		srv.DropConnections()
+
+		_, err = conn.Exec(context.Background(), "SELECT 1")
+		require.Error(t, err)
+	})
+
+	t.Run("should be able to report unexpected statement", func(t *testing.T) {
+		rep := &reporter{TB: t}
+		srv := NewServer(rep)
+		pool := connect(t, srv)
+
+		_, err := pool.Exec(context.Background(), "DELETE FROM users")
+		var pgErr *pgconn.PgError
+		require.ErrorAs(t, err, &pgErr)
+		assert.Equal(t, codeInternalError, pgErr.Code)
+		assert.Len(t, rep.reported, 1)
+	})
+}
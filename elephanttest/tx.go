@@ -0,0 +1,152 @@
+package elephanttest
+
+import (
+	"context"
+
+	"github.com/godepo/elephant/internal/pkg/failure"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const codeInFailedTransaction = "25P02"
+
+// Tx is fake transaction. Nested transactions begun by Begin are savepoints of parent transaction.
+type Tx struct {
+	pool       *Pool
+	parent     *Tx
+	depth      int
+	statements []*Statement
+	aborted    bool
+	closed     bool
+}
+
+func newTx(pool *Pool, parent *Tx) *Tx {
+	depth := 1
+	if parent != nil {
+		depth = parent.depth + 1
+	}
+	return &Tx{pool: pool, parent: parent, depth: depth}
+}
+
+// Depth returns 1 for transaction and grows by one per savepoint.
+func (tx *Tx) Depth() int {
+	return tx.depth
+}
+
+func (tx *Tx) Begin(context.Context) (pgx.Tx, error) {
+	tx.pool.mu.Lock()
+	defer tx.pool.mu.Unlock()
+
+	if err := tx.usable(); err != nil {
+		return nil, err
+	}
+	return newTx(tx.pool, tx), nil
+}
+
+// Commit releases savepoint or commits transaction. Aborted transaction is rolled back and
+// pgx.ErrTxCommitRollback is returned, aborted savepoint fails and aborts parent transaction.
+func (tx *Tx) Commit(context.Context) error {
+	tx.pool.mu.Lock()
+	defer tx.pool.mu.Unlock()
+
+	if tx.closed {
+		return pgx.ErrTxClosed
+	}
+	if tx.aborted {
+		tx.rollback()
+		if tx.parent != nil {
+			tx.parent.aborted = true
+			return errInFailedTransaction()
+		}
+		return pgx.ErrTxCommitRollback
+	}
+
+	tx.closed = true
+	if tx.parent != nil {
+		tx.parent.statements = append(tx.parent.statements, tx.statements...)
+		return nil
+	}
+	for _, stmt := range tx.statements {
+		stmt.Outcome = OutcomeCommitted
+	}
+	return nil
+}
+
+// Rollback rolls back to savepoint or rolls back transaction.
+func (tx *Tx) Rollback(context.Context) error {
+	tx.pool.mu.Lock()
+	defer tx.pool.mu.Unlock()
+
+	if tx.closed {
+		return pgx.ErrTxClosed
+	}
+	tx.rollback()
+	return nil
+}
+
+func (tx *Tx) rollback() {
+	tx.closed = true
+	for _, stmt := range tx.statements {
+		stmt.Outcome = OutcomeRolledBack
+	}
+}
+
+func (tx *Tx) CopyFrom(context.Context, pgx.Identifier, []string, pgx.CopyFromSource) (int64, error) {
+	return 0, ErrNotSupported
+}
+
+func (tx *Tx) SendBatch(context.Context, *pgx.Batch) pgx.BatchResults {
+	return failure.BatchResults(ErrNotSupported)
+}
+
+func (tx *Tx) LargeObjects() pgx.LargeObjects {
+	return pgx.LargeObjects{}
+}
+
+func (tx *Tx) Prepare(context.Context, string, string) (*pgconn.StatementDescription, error) {
+	return nil, ErrNotSupported
+}
+
+func (tx *Tx) Exec(_ context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	return tx.pool.exec(tx, sql, args)
+}
+
+func (tx *Tx) Query(_ context.Context, sql string, args ...any) (pgx.Rows, error) {
+	return tx.pool.query(tx, sql, args)
+}
+
+func (tx *Tx) QueryRow(_ context.Context, sql string, args ...any) pgx.Row {
+	return tx.pool.queryRow(tx, sql, args)
+}
+
+func (tx *Tx) Conn() *pgx.Conn {
+	return nil
+}
+
+func (tx *Tx) track(stmt *Statement) error {
+	if err := tx.usable(); err != nil {
+		return err
+	}
+	tx.statements = append(tx.statements, stmt)
+	return nil
+}
+
+func (tx *Tx) usable() error {
+	if tx.closed {
+		return pgx.ErrTxClosed
+	}
+	for cur := tx; cur != nil; cur = cur.parent {
+		if cur.aborted {
+			return errInFailedTransaction()
+		}
+	}
+	return nil
+}
+
+func errInFailedTransaction() error {
+	return &pgconn.PgError{
+		Severity: "ERROR",
+		Code:     codeInFailedTransaction,
+		Message:  "current transaction is aborted, commands ignored until end of transaction block",
+	}
+}
@@ -0,0 +1,42 @@
+package idempotency
+
+import (
+	"time"
+
+	"github.com/godepo/elephant/internal/idempotency"
+)
+
+var (
+	ErrInProgress    = idempotency.ErrInProgress
+	ErrNoTransaction = idempotency.ErrNoTransaction
+)
+
+type (
+	DB     = idempotency.DB
+	Result = idempotency.Result
+	Option = idempotency.Option
+	Store  = idempotency.Store
+)
+
+// New makes idempotency store on db, which is any elephant pool. For sharded pools key is stored at shard
+// picked by sharding key from context, or by idempotency key itself.
+func New(db DB, opts ...Option) *Store {
+	return idempotency.New(db, opts...)
+}
+
+// Schema returns DDL of keys table.
+func Schema(table string) string {
+	return idempotency.Schema(table)
+}
+
+func WithTable(table string) Option {
+	return idempotency.WithTable(table)
+}
+
+func WithTTL(ttl time.Duration) Option {
+	return idempotency.WithTTL(ttl)
+}
+
+func WithFailFast() Option {
+	return idempotency.WithFailFast()
+}
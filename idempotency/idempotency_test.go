@@ -0,0 +1,16 @@
+package idempotency
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	store := New(nil, WithTable("api.keys"), WithTTL(time.Hour), WithFailFast())
+	require.NotNil(t, store)
+
+	assert.Contains(t, Schema("api.keys"), `"api"."keys"`)
+}
@@ -0,0 +1,356 @@
+// Package breaker implements circuit breaker decorator for members of cluster and sharded pools. Breaker opens when
+// rate of failed or slow calls in window of recent calls is reached, rejects calls while it is open and lets probe
+// calls through after open timeout. Cluster skips followers with open breaker, sharded pools fail fast with
+// ErrShardUnavailable.
+//
+//go:generate go tool mockery
+package breaker
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// State of circuit breaker, value of state is reported to gauge of metrics collector.
+type State int
+
+const (
+	StateClosed State = iota
+	StateHalfOpen
+	StateOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateHalfOpen:
+		return "half-open"
+	case StateOpen:
+		return "open"
+	default:
+		return "unknown"
+	}
+}
+
+var ErrOpen = errors.New("circuit breaker is open")
+
+// StateCollector receives state of breaker every time it is changed, it is implemented by metrics collector.
+type StateCollector interface {
+	TrackBreakerState(node string, state State)
+}
+
+type Config struct {
+	name           string
+	window         int
+	minCalls       int
+	failureRate    float64
+	slowCall       time.Duration
+	slowRate       float64
+	openTimeout    time.Duration
+	halfOpenProbes int
+	isFailure      func(err error) bool
+	collector      StateCollector
+	now            func() time.Time
+}
+
+type Option func(cfg *Config)
+
+// WithName sets name of node which is reported with state of breaker.
+func WithName(name string) Option {
+	return func(cfg *Config) {
+		cfg.name = name
+	}
+}
+
+// WithWindow sets count of recent calls which rates are calculated by, and count of calls in window which is
+// required before breaker may open.
+func WithWindow(size, minCalls int) Option {
+	return func(cfg *Config) {
+		cfg.window = size
+		cfg.minCalls = minCalls
+	}
+}
+
+// WithFailureRate sets rate of failed calls in window which opens breaker.
+func WithFailureRate(rate float64) Option {
+	return func(cfg *Config) {
+		cfg.failureRate = rate
+	}
+}
+
+// WithSlowCalls sets latency from which call is slow and rate of slow calls in window which opens breaker.
+// Slow calls are not tracked by default.
+func WithSlowCalls(threshold time.Duration, rate float64) Option {
+	return func(cfg *Config) {
+		cfg.slowCall = threshold
+		cfg.slowRate = rate
+	}
+}
+
+// WithOpenTimeout sets time which breaker stays open before probe calls are let through.
+func WithOpenTimeout(timeout time.Duration) Option {
+	return func(cfg *Config) {
+		cfg.openTimeout = timeout
+	}
+}
+
+// WithHalfOpenProbes sets count of successful probe calls which close half-open breaker.
+func WithHalfOpenProbes(probes int) Option {
+	return func(cfg *Config) {
+		cfg.halfOpenProbes = probes
+	}
+}
+
+// WithFailureMatcher replaces IsFailure, which decides whether error of call is failure of node.
+func WithFailureMatcher(fn func(err error) bool) Option {
+	return func(cfg *Config) {
+		cfg.isFailure = fn
+	}
+}
+
+// WithCollector sets collector which receives state of breaker.
+func WithCollector(collector StateCollector) Option {
+	return func(cfg *Config) {
+		cfg.collector = collector
+	}
+}
+
+// WithClock replaces time.Now, which is used to measure latency of calls and open timeout.
+func WithClock(now func() time.Time) Option {
+	return func(cfg *Config) {
+		cfg.now = now
+	}
+}
+
+// IsFailure reports errors of connection, timeouts and server errors of classes 08, 53, 57, 58 and XX as failures
+// of node. Errors of statements, like constraint violations or missing rows, are not failures of node.
+func IsFailure(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, ErrOpen) {
+		return false
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code[:min(2, len(pgErr.Code))] {
+		case "08", "53", "57", "58", "XX":
+			return true
+		default:
+			return false
+		}
+	}
+	var (
+		netErr     net.Error
+		connectErr *pgconn.ConnectError
+	)
+	return errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.As(err, &netErr) ||
+		errors.As(err, &connectErr) ||
+		pgconn.Timeout(err)
+}
+
+// Breaker is state machine of single node.
+type Breaker struct {
+	cfg Config
+
+	mu         sync.Mutex
+	state      State
+	outcomes   []outcome
+	next       int
+	calls      int
+	failures   int
+	slow       int
+	openedAt   time.Time
+	probes     int
+	probedAt   time.Time
+	successful int
+}
+
+type outcome struct {
+	failed bool
+	slow   bool
+}
+
+func New(opts ...Option) *Breaker {
+	cfg := Config{
+		window:         20,
+		minCalls:       10,
+		failureRate:    0.5,
+		openTimeout:    5 * time.Second,
+		halfOpenProbes: 1,
+		isFailure:      IsFailure,
+		now:            time.Now,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	cfg.window = max(cfg.window, 1)
+	cfg.minCalls = min(max(cfg.minCalls, 1), cfg.window)
+	cfg.halfOpenProbes = max(cfg.halfOpenProbes, 1)
+
+	b := &Breaker{
+		cfg:      cfg,
+		outcomes: make([]outcome, cfg.window),
+	}
+	b.report(StateClosed)
+	return b
+}
+
+// State returns current state, open breaker is reported as half-open when open timeout is passed.
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == StateOpen && b.cooledDown() {
+		return StateHalfOpen
+	}
+	return b.state
+}
+
+// Available reports whether call would be let through now.
+func (b *Breaker) Available() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case StateOpen:
+		return b.cooledDown()
+	case StateHalfOpen:
+		return b.probes < b.cfg.halfOpenProbes || b.probeExpired()
+	default:
+		return true
+	}
+}
+
+// allow returns ErrOpen when call is rejected, or start time of call which must be finished by done.
+func (b *Breaker) allow() (time.Time, error) {
+	b.mu.Lock()
+	changed := b.state
+	switch b.state {
+	case StateOpen:
+		if !b.cooledDown() {
+			b.mu.Unlock()
+			return time.Time{}, ErrOpen
+		}
+		b.state, b.probes, b.successful = StateHalfOpen, 0, 0
+		fallthrough
+	case StateHalfOpen:
+		if b.probes >= b.cfg.halfOpenProbes && !b.probeExpired() {
+			b.mu.Unlock()
+			return time.Time{}, ErrOpen
+		}
+		if b.probeExpired() {
+			b.probes = 0
+		}
+		b.probes++
+		b.probedAt = b.cfg.now()
+	default:
+	}
+	state := b.state
+	b.mu.Unlock()
+
+	if state != changed {
+		b.report(state)
+	}
+	return b.cfg.now(), nil
+}
+
+// done records outcome of call which is started at begin, latency is not checked when begin is zero.
+func (b *Breaker) done(begin time.Time, err error) {
+	res := outcome{
+		failed: b.cfg.isFailure(err),
+		slow:   b.cfg.slowCall > 0 && !begin.IsZero() && b.cfg.now().Sub(begin) >= b.cfg.slowCall,
+	}
+
+	b.mu.Lock()
+	changed := b.state
+	switch b.state {
+	case StateHalfOpen:
+		b.probes = max(b.probes-1, 0)
+		if res.failed || res.slow {
+			b.open()
+			break
+		}
+		b.successful++
+		if b.successful >= b.cfg.halfOpenProbes {
+			b.close()
+		}
+	case StateClosed:
+		b.push(res)
+		if b.tripped() {
+			b.open()
+		}
+	default:
+		// calls which are let through before breaker is opened don't change its state
+	}
+	state := b.state
+	b.mu.Unlock()
+
+	if state != changed {
+		b.report(state)
+	}
+}
+
+func (b *Breaker) push(res outcome) {
+	if b.calls == len(b.outcomes) {
+		prev := b.outcomes[b.next]
+		b.failures -= boolToInt(prev.failed)
+		b.slow -= boolToInt(prev.slow)
+	} else {
+		b.calls++
+	}
+	b.outcomes[b.next] = res
+	b.next = (b.next + 1) % len(b.outcomes)
+	b.failures += boolToInt(res.failed)
+	b.slow += boolToInt(res.slow)
+}
+
+func (b *Breaker) tripped() bool {
+	if b.calls < b.cfg.minCalls {
+		return false
+	}
+	calls := float64(b.calls)
+	if float64(b.failures)/calls >= b.cfg.failureRate {
+		return true
+	}
+	return b.cfg.slowCall > 0 && float64(b.slow)/calls >= b.cfg.slowRate
+}
+
+func (b *Breaker) open() {
+	b.state = StateOpen
+	b.openedAt = b.cfg.now()
+}
+
+func (b *Breaker) close() {
+	b.state = StateClosed
+	b.outcomes = make([]outcome, len(b.outcomes))
+	b.next, b.calls, b.failures, b.slow = 0, 0, 0, 0
+}
+
+func (b *Breaker) cooledDown() bool {
+	return b.cfg.now().Sub(b.openedAt) >= b.cfg.openTimeout
+}
+
+// probeExpired reports probe which outcome is not known after open timeout, like row which is never scanned,
+// so it does not keep breaker half-open forever.
+func (b *Breaker) probeExpired() bool {
+	return b.probes > 0 && b.cfg.now().Sub(b.probedAt) >= b.cfg.openTimeout
+}
+
+func (b *Breaker) report(state State) {
+	if b.cfg.collector != nil {
+		b.cfg.collector.TrackBreakerState(b.cfg.name, state)
+	}
+}
+
+func boolToInt(v bool) int {
+	if v {
+		return 1
+	}
+	return 0
+}
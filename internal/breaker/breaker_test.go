@@ -0,0 +1,217 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	return c.now
+}
+
+func (c *clock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func newClock() *clock {
+	return &clock{now: time.Unix(1_700_000_000, 0)}
+}
+
+type states struct {
+	node   string
+	states []State
+}
+
+func (s *states) TrackBreakerState(node string, state State) {
+	s.node = node
+	s.states = append(s.states, state)
+}
+
+var errConn = &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET}
+
+func call(b *Breaker, err error) error {
+	begin, allowErr := b.allow()
+	if allowErr != nil {
+		return allowErr
+	}
+	b.done(begin, err)
+	return nil
+}
+
+func TestIsFailure(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil"},
+		{name: "no rows", err: pgx.ErrNoRows},
+		{name: "canceled", err: fmt.Errorf("wrap: %w", context.Canceled)},
+		{name: "open breaker", err: ErrOpen},
+		{name: "statement error", err: &pgconn.PgError{Code: "23505"}},
+		{name: "unknown error", err: errors.New(uuid.NewString())},
+		{name: "connection exception", err: &pgconn.PgError{Code: "08006"}, want: true},
+		{name: "admin shutdown", err: &pgconn.PgError{Code: "57P01"}, want: true},
+		{name: "too many connections", err: &pgconn.PgError{Code: "53300"}, want: true},
+		{name: "network error", err: fmt.Errorf("wrap: %w", errConn), want: true},
+		{name: "deadline", err: context.DeadlineExceeded, want: true},
+		{name: "unexpected eof", err: io.ErrUnexpectedEOF, want: true},
+	}
+	for _, tc := range cases {
+		t.Run("should be able to match "+tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, IsFailure(tc.err))
+		})
+	}
+}
+
+func TestBreaker(t *testing.T) {
+	t.Run("should be able to open by failure rate", func(t *testing.T) {
+		clk := newClock()
+		b := New(WithWindow(4, 4), WithFailureRate(0.5), WithClock(clk.Now))
+
+		require.NoError(t, call(b, nil))
+		require.NoError(t, call(b, errConn))
+		require.NoError(t, call(b, pgx.ErrNoRows))
+		assert.Equal(t, StateClosed, b.State())
+
+		require.NoError(t, call(b, errConn))
+		assert.Equal(t, StateOpen, b.State())
+		assert.False(t, b.Available())
+		require.ErrorIs(t, call(b, nil), ErrOpen)
+	})
+
+	t.Run("should be able to slide window", func(t *testing.T) {
+		clk := newClock()
+		b := New(WithWindow(2, 2), WithFailureRate(1), WithClock(clk.Now))
+
+		for _, err := range []error{errConn, nil, errConn, nil, errConn} {
+			require.NoError(t, call(b, err))
+		}
+		assert.Equal(t, StateClosed, b.State())
+
+		require.NoError(t, call(b, errConn))
+		assert.Equal(t, StateOpen, b.State())
+	})
+
+	t.Run("should be able to open by slow calls", func(t *testing.T) {
+		clk := newClock()
+		b := New(WithWindow(2, 2), WithSlowCalls(time.Second, 1), WithClock(clk.Now))
+
+		for range 2 {
+			begin, err := b.allow()
+			require.NoError(t, err)
+			clk.Advance(time.Second)
+			b.done(begin, nil)
+		}
+		assert.Equal(t, StateOpen, b.State())
+	})
+
+	t.Run("should be able to close after successful probes", func(t *testing.T) {
+		clk := newClock()
+		collector := &states{}
+		b := New(
+			WithName("follower"),
+			WithWindow(1, 1),
+			WithOpenTimeout(time.Second),
+			WithHalfOpenProbes(2),
+			WithCollector(collector),
+			WithClock(clk.Now),
+		)
+
+		require.NoError(t, call(b, errConn))
+		clk.Advance(time.Second)
+		assert.True(t, b.Available())
+		assert.Equal(t, StateHalfOpen, b.State())
+
+		first, err := b.allow()
+		require.NoError(t, err)
+		second, err := b.allow()
+		require.NoError(t, err)
+		_, err = b.allow()
+		require.ErrorIs(t, err, ErrOpen)
+		assert.False(t, b.Available())
+
+		b.done(first, nil)
+		assert.Equal(t, StateHalfOpen, b.State())
+		b.done(second, nil)
+		assert.Equal(t, StateClosed, b.State())
+
+		require.NoError(t, call(b, nil))
+		assert.Equal(t, StateClosed, b.State())
+		assert.Equal(t, "follower", collector.node)
+		assert.Equal(t, []State{StateClosed, StateOpen, StateHalfOpen, StateClosed}, collector.states)
+	})
+
+	t.Run("should be able to reopen after failed probe", func(t *testing.T) {
+		clk := newClock()
+		b := New(WithWindow(1, 1), WithOpenTimeout(time.Second), WithClock(clk.Now))
+
+		require.NoError(t, call(b, errConn))
+		clk.Advance(time.Second)
+		require.NoError(t, call(b, errConn))
+		assert.Equal(t, StateOpen, b.State())
+		require.ErrorIs(t, call(b, nil), ErrOpen)
+	})
+
+	t.Run("should be able to let new probe through when outcome of probe is lost", func(t *testing.T) {
+		clk := newClock()
+		b := New(WithWindow(1, 1), WithOpenTimeout(time.Second), WithClock(clk.Now))
+
+		require.NoError(t, call(b, errConn))
+		clk.Advance(time.Second)
+		_, err := b.allow()
+		require.NoError(t, err)
+		assert.False(t, b.Available())
+
+		clk.Advance(time.Second)
+		assert.True(t, b.Available())
+		require.NoError(t, call(b, nil))
+		assert.Equal(t, StateClosed, b.State())
+	})
+
+	t.Run("should be able to ignore outcome of call let through before opening", func(t *testing.T) {
+		clk := newClock()
+		b := New(WithWindow(1, 1), WithClock(clk.Now))
+
+		begin, err := b.allow()
+		require.NoError(t, err)
+		require.NoError(t, call(b, errConn))
+		b.done(begin, nil)
+		assert.Equal(t, StateOpen, b.State())
+	})
+
+	t.Run("should be able to use failure matcher", func(t *testing.T) {
+		expErr := errors.New(uuid.NewString())
+		b := New(WithWindow(1, 1), WithFailureMatcher(func(err error) bool {
+			return errors.Is(err, expErr)
+		}))
+
+		require.NoError(t, call(b, errConn))
+		assert.Equal(t, StateClosed, b.State())
+		require.NoError(t, call(b, expErr))
+		assert.Equal(t, StateOpen, b.State())
+	})
+}
+
+func TestState_String(t *testing.T) {
+	assert.Equal(t, "closed", StateClosed.String())
+	assert.Equal(t, "half-open", StateHalfOpen.String())
+	assert.Equal(t, "open", StateOpen.String())
+	assert.Equal(t, "unknown", State(42).String())
+}
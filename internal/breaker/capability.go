@@ -0,0 +1,137 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/godepo/elephant/internal/notify"
+	"github.com/godepo/elephant/internal/pkg/failure"
+	"github.com/godepo/elephant/internal/pkg/passthrough"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func (d *DB) Acquire(ctx context.Context) (*pgxpool.Conn, error) {
+	acq, err := passthrough.AsAcquirer(d.db)
+	if err != nil {
+		return nil, err
+	}
+	begin, err := d.allow()
+	if err != nil {
+		return nil, err
+	}
+	conn, err := acq.Acquire(ctx)
+	d.done(begin, err)
+	return conn, err
+}
+
+func (d *DB) CopyFrom(
+	ctx context.Context,
+	tableName pgx.Identifier,
+	columnNames []string,
+	rowSrc pgx.CopyFromSource,
+) (int64, error) {
+	bulk, err := passthrough.AsBulk(d.db)
+	if err != nil {
+		return 0, err
+	}
+	if inTx(ctx) {
+		return bulk.CopyFrom(ctx, tableName, columnNames, rowSrc)
+	}
+	if _, err := d.allow(); err != nil {
+		return 0, err
+	}
+	count, err := bulk.CopyFrom(ctx, tableName, columnNames, rowSrc)
+	d.done(time.Time{}, err)
+	return count, err
+}
+
+// SendBatch records error of batch when results are closed.
+func (d *DB) SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults {
+	bulk, err := passthrough.AsBulk(d.db)
+	if err != nil {
+		return failure.BatchResults(err)
+	}
+	if inTx(ctx) {
+		return bulk.SendBatch(ctx, b)
+	}
+	if _, err := d.allow(); err != nil {
+		return failure.BatchResults(err)
+	}
+	return guardedBatch{BatchResults: bulk.SendBatch(ctx, b), breaker: d.Breaker}
+}
+
+// Listen is rejected while breaker is open, subscription itself is not tracked by breaker.
+func (d *DB) Listen(ctx context.Context, channel string) (<-chan pgconn.Notification, error) {
+	lst, err := passthrough.AsListener(d.db)
+	if err != nil {
+		return nil, err
+	}
+	if !d.Available() {
+		return nil, ErrOpen
+	}
+	return lst.Listen(ctx, channel)
+}
+
+// Notify sends notification through Exec, so it is guarded by breaker.
+func (d *DB) Notify(ctx context.Context, channel, payload string) error {
+	_, err := d.Exec(ctx, notify.Query, channel, payload)
+	return err
+}
+
+func (d *DB) WithAdvisoryLock(ctx context.Context, key string, fn func(ctx context.Context) error) error {
+	lck, err := passthrough.AsLocker(d.db)
+	if err != nil {
+		return err
+	}
+	return d.lock(ctx, key, fn, lck.WithAdvisoryLock)
+}
+
+func (d *DB) WithTryAdvisoryLock(ctx context.Context, key string, fn func(ctx context.Context) error) error {
+	lck, err := passthrough.AsLocker(d.db)
+	if err != nil {
+		return err
+	}
+	return d.lock(ctx, key, fn, lck.WithTryAdvisoryLock)
+}
+
+// lock records error of lock, error returned by fn is not outcome of node.
+func (d *DB) lock(
+	ctx context.Context,
+	key string,
+	fn func(ctx context.Context) error,
+	lock func(ctx context.Context, key string, fn func(ctx context.Context) error) error,
+) error {
+	if inTx(ctx) {
+		return lock(ctx, key, fn)
+	}
+	if _, err := d.allow(); err != nil {
+		return err
+	}
+
+	var fnErr error
+	err := lock(ctx, key, func(ctx context.Context) error {
+		fnErr = fn(ctx)
+		return fnErr
+	})
+
+	res := err
+	if fnErr != nil && errors.Is(err, fnErr) {
+		res = nil
+	}
+	d.done(time.Time{}, res)
+	return err
+}
+
+type guardedBatch struct {
+	pgx.BatchResults
+	breaker *Breaker
+}
+
+func (b guardedBatch) Close() error {
+	err := b.BatchResults.Close()
+	b.breaker.done(time.Time{}, err)
+	return err
+}
@@ -0,0 +1,86 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type capablePool struct {
+	*MockPool
+	*MockAcquirer
+	*MockBulkPool
+	*MockListener
+	*MockAdvisoryLocker
+}
+
+func newCapablePool(t *testing.T) capablePool {
+	t.Helper()
+	return capablePool{
+		MockPool:           NewMockPool(t),
+		MockAcquirer:       NewMockAcquirer(t),
+		MockBulkPool:       NewMockBulkPool(t),
+		MockListener:       NewMockListener(t),
+		MockAdvisoryLocker: NewMockAdvisoryLocker(t),
+	}
+}
+
+func TestDB_Capabilities(t *testing.T) {
+	t.Run("should be able to delegate to wrapped pool", func(t *testing.T) {
+		pool := newCapablePool(t)
+		db := Wrap(pool)
+		ctx := context.Background()
+		expErr := errors.New(uuid.NewString())
+		fn := func(context.Context) error { return nil }
+		batch := &pgx.Batch{}
+		results := NewMockBatchResults(t)
+
+		pool.MockAcquirer.EXPECT().Acquire(ctx).Return(nil, expErr)
+		pool.MockBulkPool.EXPECT().CopyFrom(ctx, pgx.Identifier{"t"}, []string{"id"}, mock.Anything).Return(3, nil)
+		pool.MockBulkPool.EXPECT().SendBatch(ctx, batch).Return(results)
+		results.EXPECT().Close().Return(nil)
+		pool.MockListener.EXPECT().Listen(ctx, "events").Return(nil, expErr)
+		pool.MockAdvisoryLocker.EXPECT().WithAdvisoryLock(ctx, "key", mock.Anything).Return(expErr)
+		pool.MockAdvisoryLocker.EXPECT().WithTryAdvisoryLock(ctx, "key", mock.Anything).Return(expErr)
+		pool.MockPool.EXPECT().Exec(ctx, mock.Anything, []any{"events", "payload"}).
+			Return(pgconn.NewCommandTag("SELECT 1"), nil)
+
+		_, err := db.Acquire(ctx)
+		require.ErrorIs(t, err, expErr)
+		count, err := db.CopyFrom(ctx, pgx.Identifier{"t"}, []string{"id"}, pgx.CopyFromRows(nil))
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), count)
+		require.NoError(t, db.SendBatch(ctx, batch).Close())
+		_, err = db.Listen(ctx, "events")
+		require.ErrorIs(t, err, expErr)
+		require.ErrorIs(t, db.WithAdvisoryLock(ctx, "key", fn), expErr)
+		require.ErrorIs(t, db.WithTryAdvisoryLock(ctx, "key", fn), expErr)
+		require.NoError(t, db.Notify(ctx, "events", "payload"))
+	})
+
+	t.Run("should be able to reject calls when breaker is open", func(t *testing.T) {
+		pool := newCapablePool(t)
+		db := openedDB(t, pool.MockPool)
+		db.db = pool
+		ctx := context.Background()
+		fn := func(context.Context) error { return nil }
+
+		_, err := db.Acquire(ctx)
+		require.ErrorIs(t, err, ErrOpen)
+		_, err = db.CopyFrom(ctx, pgx.Identifier{"t"}, []string{"id"}, pgx.CopyFromRows(nil))
+		require.ErrorIs(t, err, ErrOpen)
+		require.ErrorIs(t, db.SendBatch(ctx, &pgx.Batch{}).Close(), ErrOpen)
+		_, err = db.Listen(ctx, "events")
+		require.ErrorIs(t, err, ErrOpen)
+		require.ErrorIs(t, db.WithAdvisoryLock(ctx, "key", fn), ErrOpen)
+		require.ErrorIs(t, db.WithTryAdvisoryLock(ctx, "key", fn), ErrOpen)
+		require.ErrorIs(t, db.Notify(ctx, "events", "payload"), ErrOpen)
+	})
+}
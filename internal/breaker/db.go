@@ -0,0 +1,167 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/godepo/elephant/internal/pkg/failure"
+	"github.com/godepo/elephant/internal/pkg/pgcontext"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Pool interface defines database operations of wrapped pool.
+type Pool interface {
+	BeginTx(ctx context.Context, opts pgx.TxOptions) (pgx.Tx, error)
+	Begin(ctx context.Context) (pgx.Tx, error)
+	Query(ctx context.Context, query string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, query string, args ...interface{}) pgx.Row
+	Exec(ctx context.Context, query string, args ...interface{}) (pgconn.CommandTag, error)
+	Transactional(ctx context.Context, fn func(ctx context.Context) error) (out error)
+}
+
+// DB is pool guarded by circuit breaker.
+type DB struct {
+	*Breaker
+	db Pool
+}
+
+// Wrap guards db by new breaker. Statements of transaction from context are passed to db as is, so transaction
+// which is already begun is not interrupted by breaker.
+func Wrap(db Pool, opts ...Option) *DB {
+	return &DB{
+		Breaker: New(opts...),
+		db:      db,
+	}
+}
+
+func inTx(ctx context.Context) bool {
+	_, ok := pgcontext.TransactionFrom(ctx)
+	return ok
+}
+
+func (d *DB) Begin(ctx context.Context) (pgx.Tx, error) {
+	begin, err := d.allow()
+	if err != nil {
+		return nil, err
+	}
+	tx, err := d.db.Begin(ctx)
+	d.done(begin, err)
+	return tx, err
+}
+
+func (d *DB) BeginTx(ctx context.Context, opts pgx.TxOptions) (pgx.Tx, error) {
+	begin, err := d.allow()
+	if err != nil {
+		return nil, err
+	}
+	tx, err := d.db.BeginTx(ctx, opts)
+	d.done(begin, err)
+	return tx, err
+}
+
+// Query records outcome when rows are read to end or closed, so errors of rows are outcome of node too.
+func (d *DB) Query(ctx context.Context, query string, args ...interface{}) (pgx.Rows, error) {
+	if inTx(ctx) {
+		return d.db.Query(ctx, query, args...)
+	}
+	begin, err := d.allow()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := d.db.Query(ctx, query, args...)
+	if err != nil {
+		d.done(begin, err)
+		return nil, err
+	}
+	return guardedRows{Rows: rows, once: &sync.Once{}, breaker: d.Breaker, begin: begin}, nil
+}
+
+// QueryRow records outcome when row is scanned.
+func (d *DB) QueryRow(ctx context.Context, query string, args ...interface{}) pgx.Row {
+	if inTx(ctx) {
+		return d.db.QueryRow(ctx, query, args...)
+	}
+	begin, err := d.allow()
+	if err != nil {
+		return failure.Row(err)
+	}
+	return guardedRow{row: d.db.QueryRow(ctx, query, args...), breaker: d.Breaker, begin: begin}
+}
+
+func (d *DB) Exec(ctx context.Context, query string, args ...interface{}) (pgconn.CommandTag, error) {
+	if inTx(ctx) {
+		return d.db.Exec(ctx, query, args...)
+	}
+	begin, err := d.allow()
+	if err != nil {
+		return pgconn.CommandTag{}, err
+	}
+	tag, err := d.db.Exec(ctx, query, args...)
+	d.done(begin, err)
+	return tag, err
+}
+
+// Transactional records error of transaction, error returned by fn is not outcome of node. Latency of transaction
+// depends on fn, so it is not checked for slow calls.
+func (d *DB) Transactional(ctx context.Context, fn func(ctx context.Context) error) (out error) {
+	if inTx(ctx) {
+		return d.db.Transactional(ctx, fn)
+	}
+	if _, err := d.allow(); err != nil {
+		return err
+	}
+
+	var fnErr error
+	err := d.db.Transactional(ctx, func(ctx context.Context) error {
+		fnErr = fn(ctx)
+		return fnErr
+	})
+
+	res := err
+	if fnErr != nil && errors.Is(err, fnErr) {
+		res = nil
+	}
+	d.done(time.Time{}, res)
+	return err
+}
+
+type guardedRow struct {
+	row     pgx.Row
+	breaker *Breaker
+	begin   time.Time
+}
+
+func (r guardedRow) Scan(dest ...any) error {
+	err := r.row.Scan(dest...)
+	r.breaker.done(r.begin, err)
+	return err
+}
+
+type guardedRows struct {
+	pgx.Rows
+	once    *sync.Once
+	breaker *Breaker
+	begin   time.Time
+}
+
+func (r guardedRows) Next() bool {
+	next := r.Rows.Next()
+	if !next {
+		r.done()
+	}
+	return next
+}
+
+func (r guardedRows) Close() {
+	r.Rows.Close()
+	r.done()
+}
+
+func (r guardedRows) done() {
+	r.once.Do(func() {
+		r.breaker.done(r.begin, r.Rows.Err())
+	})
+}
@@ -0,0 +1,156 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/godepo/elephant/internal/pkg/pgcontext"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func openedDB(t *testing.T, pool *MockPool) *DB {
+	t.Helper()
+	db := Wrap(pool, WithWindow(1, 1), WithOpenTimeout(time.Hour))
+	pool.EXPECT().Exec(mock.Anything, "SELECT 1").Return(pgconn.CommandTag{}, errConn).Once()
+	_, err := db.Exec(context.Background(), "SELECT 1")
+	require.ErrorIs(t, err, errConn)
+	require.Equal(t, StateOpen, db.State())
+	return db
+}
+
+func TestDB(t *testing.T) {
+	t.Run("should be able to delegate to wrapped pool", func(t *testing.T) {
+		pool := NewMockPool(t)
+		rows, row := NewMockRows(t), NewMockRow(t)
+		db := Wrap(pool, WithWindow(1, 1))
+		ctx := context.Background()
+		tag := pgconn.NewCommandTag("UPDATE 1")
+
+		pool.EXPECT().Begin(ctx).Return(nil, nil)
+		pool.EXPECT().BeginTx(ctx, pgx.TxOptions{}).Return(nil, nil)
+		pool.EXPECT().Query(ctx, "SELECT $1", []any{1}).Return(rows, nil)
+		pool.EXPECT().QueryRow(ctx, "SELECT 1").Return(row)
+		pool.EXPECT().Exec(ctx, "UPDATE t").Return(tag, nil)
+		row.EXPECT().Scan().Return(pgx.ErrNoRows)
+
+		_, err := db.Begin(ctx)
+		require.NoError(t, err)
+		_, err = db.BeginTx(ctx, pgx.TxOptions{})
+		require.NoError(t, err)
+		rows.EXPECT().Close().Return()
+		rows.EXPECT().Err().Return(nil)
+		out, err := db.Query(ctx, "SELECT $1", 1)
+		require.NoError(t, err)
+		out.Close()
+		require.ErrorIs(t, db.QueryRow(ctx, "SELECT 1").Scan(), pgx.ErrNoRows)
+		res, err := db.Exec(ctx, "UPDATE t")
+		require.NoError(t, err)
+		assert.Equal(t, tag, res)
+		assert.Equal(t, StateClosed, db.State())
+	})
+
+	t.Run("should be able to record error of rows when rows are read to end", func(t *testing.T) {
+		pool := NewMockPool(t)
+		rows := NewMockRows(t)
+		db := Wrap(pool, WithWindow(1, 1), WithOpenTimeout(time.Hour))
+		ctx := context.Background()
+
+		pool.EXPECT().Query(ctx, "SELECT 1").Return(rows, nil)
+		rows.EXPECT().Next().Return(true).Once()
+		rows.EXPECT().Next().Return(false).Once()
+		rows.EXPECT().Err().Return(errConn)
+		rows.EXPECT().Close().Return()
+
+		out, err := db.Query(ctx, "SELECT 1")
+		require.NoError(t, err)
+		assert.Equal(t, StateClosed, db.State())
+		assert.True(t, out.Next())
+		assert.False(t, out.Next())
+		assert.Equal(t, StateOpen, db.State())
+		out.Close()
+		assert.Equal(t, StateOpen, db.State())
+	})
+
+	t.Run("should be able to record slow rows when they are closed", func(t *testing.T) {
+		pool := NewMockPool(t)
+		rows := NewMockRows(t)
+		now := time.Now()
+		db := Wrap(pool, WithWindow(1, 1), WithSlowCalls(time.Second, 1), WithOpenTimeout(time.Hour),
+			WithClock(func() time.Time { return now }))
+		ctx := context.Background()
+
+		pool.EXPECT().Query(ctx, "SELECT 1").Return(rows, nil)
+		rows.EXPECT().Close().Return()
+		rows.EXPECT().Err().Return(nil)
+
+		out, err := db.Query(ctx, "SELECT 1")
+		require.NoError(t, err)
+		now = now.Add(time.Minute)
+		out.Close()
+		assert.Equal(t, StateOpen, db.State())
+	})
+
+	t.Run("should be able to reject calls when breaker is open", func(t *testing.T) {
+		db := openedDB(t, NewMockPool(t))
+		ctx := context.Background()
+
+		_, err := db.Begin(ctx)
+		require.ErrorIs(t, err, ErrOpen)
+		_, err = db.BeginTx(ctx, pgx.TxOptions{})
+		require.ErrorIs(t, err, ErrOpen)
+		_, err = db.Query(ctx, "SELECT 1")
+		require.ErrorIs(t, err, ErrOpen)
+		require.ErrorIs(t, db.QueryRow(ctx, "SELECT 1").Scan(), ErrOpen)
+		_, err = db.Exec(ctx, "SELECT 1")
+		require.ErrorIs(t, err, ErrOpen)
+		require.ErrorIs(t, db.Transactional(ctx, func(context.Context) error { return nil }), ErrOpen)
+	})
+
+	t.Run("should be able to pass statements of transaction from context", func(t *testing.T) {
+		pool := NewMockPool(t)
+		db := openedDB(t, pool)
+		ctx := pgcontext.With(context.Background(), pgcontext.WithTransaction(NewMockTx(t)))
+		row := NewMockRow(t)
+
+		pool.EXPECT().Query(ctx, "SELECT 1").Return(nil, nil)
+		pool.EXPECT().QueryRow(ctx, "SELECT 1").Return(row)
+		pool.EXPECT().Exec(ctx, "SELECT 1").Return(pgconn.CommandTag{}, nil)
+		pool.EXPECT().Transactional(ctx, mock.Anything).Return(nil)
+
+		_, err := db.Query(ctx, "SELECT 1")
+		require.NoError(t, err)
+		assert.Equal(t, row, db.QueryRow(ctx, "SELECT 1"))
+		_, err = db.Exec(ctx, "SELECT 1")
+		require.NoError(t, err)
+		require.NoError(t, db.Transactional(ctx, func(context.Context) error { return nil }))
+	})
+
+	t.Run("should be able to record failure of transaction but not error of fn", func(t *testing.T) {
+		pool := NewMockPool(t)
+		db := Wrap(pool, WithWindow(1, 1))
+		expErr := errors.New(uuid.NewString())
+		passThrough := func(ctx context.Context, fn func(ctx context.Context) error) error {
+			return fn(ctx)
+		}
+		pool.EXPECT().Transactional(mock.Anything, mock.Anything).RunAndReturn(passThrough).Once()
+		pool.EXPECT().Transactional(mock.Anything, mock.Anything).Return(errConn).Once()
+
+		err := db.Transactional(context.Background(), func(context.Context) error {
+			return errConn
+		})
+		require.ErrorIs(t, err, errConn)
+		assert.Equal(t, StateClosed, db.State())
+
+		require.ErrorIs(t, db.Transactional(context.Background(), func(context.Context) error {
+			return expErr
+		}), errConn)
+		assert.Equal(t, StateOpen, db.State())
+	})
+}
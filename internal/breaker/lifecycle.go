@@ -0,0 +1,22 @@
+package breaker
+
+import (
+	"context"
+
+	"github.com/godepo/elephant/internal/pkg/lifecycle"
+)
+
+// Close closes wrapped pool when it can be closed.
+func (d *DB) Close() {
+	lifecycle.CloseMember(d.db)
+}
+
+// Ping delegates to wrapped pool, pings bypass breaker, so they check node while circuit is open.
+func (d *DB) Ping(ctx context.Context) (lifecycle.Pings, error) {
+	pings := lifecycle.PingMember(ctx, d.db)
+	return pings, pings.Err()
+}
+
+func (d *DB) Stats() lifecycle.Stats {
+	return lifecycle.MemberStats(d.db)
+}
@@ -0,0 +1,160 @@
+// Package bulkhead implements decorator which limits count of concurrent in-flight statements of pool, so burst
+// of expensive statements can't take every connection of pool and starve critical paths. Statements are put into
+// compartments by metrics label or priority, and wait for free slot of compartment up to queue timeout. Statements
+// of low priority are rejected at once when compartment is full, statements of critical priority bypass limits.
+//
+//go:generate go tool mockery
+package bulkhead
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/godepo/elephant/internal/pkg/pgcontext"
+)
+
+const defaultQueueTimeout = time.Second
+
+var ErrOverloaded = errors.New("bulkhead pool is overloaded")
+
+type Config struct {
+	limit        int
+	labels       map[string]int
+	priorities   map[pgcontext.Priority]int
+	queueTimeout time.Duration
+}
+
+type Option func(cfg *Config)
+
+// WithLimit sets limit of in-flight statements, which are not put into compartment of label or priority.
+// Statements are not limited by default.
+func WithLimit(limit int) Option {
+	return func(cfg *Config) {
+		cfg.limit = limit
+	}
+}
+
+// WithLabelLimit sets limit of in-flight statements which metrics labels contain label.
+func WithLabelLimit(label string, limit int) Option {
+	return func(cfg *Config) {
+		cfg.labels[label] = limit
+	}
+}
+
+// WithPriorityLimit sets limit of in-flight statements of priority, which are not put into compartment of label.
+func WithPriorityLimit(priority pgcontext.Priority, limit int) Option {
+	return func(cfg *Config) {
+		cfg.priorities[priority] = limit
+	}
+}
+
+// WithQueueTimeout sets time which statement waits for free slot of compartment before it is rejected with
+// ErrOverloaded. Statements are rejected at once when timeout is not positive.
+func WithQueueTimeout(timeout time.Duration) Option {
+	return func(cfg *Config) {
+		cfg.queueTimeout = timeout
+	}
+}
+
+// compartment is semaphore of statements which share limit.
+type compartment chan struct{}
+
+func newCompartment(limit int) compartment {
+	if limit <= 0 {
+		return nil
+	}
+	return make(compartment, limit)
+}
+
+// Bulkhead is set of compartments of single pool.
+type Bulkhead struct {
+	cfg        Config
+	pool       compartment
+	labels     map[string]compartment
+	priorities map[pgcontext.Priority]compartment
+}
+
+func New(opts ...Option) *Bulkhead {
+	cfg := Config{
+		labels:       map[string]int{},
+		priorities:   map[pgcontext.Priority]int{},
+		queueTimeout: defaultQueueTimeout,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	b := &Bulkhead{
+		cfg:        cfg,
+		pool:       newCompartment(cfg.limit),
+		labels:     make(map[string]compartment, len(cfg.labels)),
+		priorities: make(map[pgcontext.Priority]compartment, len(cfg.priorities)),
+	}
+	for label, limit := range cfg.labels {
+		if cmp := newCompartment(limit); cmp != nil {
+			b.labels[label] = cmp
+		}
+	}
+	for priority, limit := range cfg.priorities {
+		if cmp := newCompartment(limit); cmp != nil {
+			b.priorities[priority] = cmp
+		}
+	}
+	return b
+}
+
+// compartment returns compartment of first metrics label which has limit, compartment of priority or compartment
+// of pool. Nil is returned for statements which are not limited.
+func (b *Bulkhead) compartment(ctx context.Context, priority pgcontext.Priority) compartment {
+	labels, _ := pgcontext.MetricsLabelsFrom(ctx)
+	for _, label := range labels {
+		if cmp, ok := b.labels[label]; ok {
+			return cmp
+		}
+	}
+	if cmp, ok := b.priorities[priority]; ok {
+		return cmp
+	}
+	return b.pool
+}
+
+// acquire takes slot of compartment of statement, returned release must be called when statement is finished.
+func (b *Bulkhead) acquire(ctx context.Context) (func(), error) {
+	priority := pgcontext.PriorityFrom(ctx)
+	if priority >= pgcontext.PriorityCritical {
+		return func() {}, nil
+	}
+	cmp := b.compartment(ctx, priority)
+	if cmp == nil {
+		return func() {}, nil
+	}
+
+	select {
+	case cmp <- struct{}{}:
+		return cmp.release(), nil
+	default:
+	}
+	if priority < pgcontext.PriorityNormal || b.cfg.queueTimeout <= 0 {
+		return nil, ErrOverloaded
+	}
+
+	timer := time.NewTimer(b.cfg.queueTimeout)
+	defer timer.Stop()
+	select {
+	case cmp <- struct{}{}:
+		return cmp.release(), nil
+	case <-timer.C:
+		return nil, ErrOverloaded
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%w: %w", ErrOverloaded, context.Cause(ctx))
+	}
+}
+
+func (c compartment) release() func() {
+	return sync.OnceFunc(func() {
+		<-c
+	})
+}
@@ -0,0 +1,96 @@
+package bulkhead
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/godepo/elephant/internal/pkg/pgcontext"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBulkhead(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should be able to pass statements, when limits are not set", func(t *testing.T) {
+		b := New(WithLimit(0))
+		for range 10 {
+			_, err := b.acquire(ctx)
+			require.NoError(t, err)
+		}
+	})
+
+	t.Run("should be able to reject statement after queue timeout", func(t *testing.T) {
+		b := New(WithLimit(1), WithQueueTimeout(time.Millisecond))
+		release, err := b.acquire(ctx)
+		require.NoError(t, err)
+
+		_, err = b.acquire(ctx)
+		require.ErrorIs(t, err, ErrOverloaded)
+
+		release()
+		release()
+		next, err := b.acquire(ctx)
+		require.NoError(t, err)
+		next()
+	})
+
+	t.Run("should be able to queue statement until slot is released", func(t *testing.T) {
+		b := New(WithLimit(1), WithQueueTimeout(time.Minute))
+		release, err := b.acquire(ctx)
+		require.NoError(t, err)
+
+		go func() {
+			time.Sleep(time.Millisecond)
+			release()
+		}()
+		_, err = b.acquire(ctx)
+		require.NoError(t, err)
+	})
+
+	t.Run("should be able to stop queueing when context is done", func(t *testing.T) {
+		b := New(WithLimit(1), WithQueueTimeout(time.Minute))
+		_, err := b.acquire(ctx)
+		require.NoError(t, err)
+
+		canceled, cancel := context.WithCancel(ctx)
+		cancel()
+		_, err = b.acquire(canceled)
+		require.ErrorIs(t, err, ErrOverloaded)
+		require.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("should be able to shed low priority and pass critical statements", func(t *testing.T) {
+		b := New(WithLimit(1), WithQueueTimeout(time.Minute))
+		_, err := b.acquire(ctx)
+		require.NoError(t, err)
+
+		_, err = b.acquire(pgcontext.With(ctx, pgcontext.WithPriority(pgcontext.PriorityLow)))
+		require.ErrorIs(t, err, ErrOverloaded)
+		_, err = b.acquire(pgcontext.With(ctx, pgcontext.WithPriority(pgcontext.PriorityCritical)))
+		require.NoError(t, err)
+	})
+
+	t.Run("should be able to limit compartments of labels and priorities", func(t *testing.T) {
+		b := New(
+			WithLimit(1),
+			WithLabelLimit("reports", 1),
+			WithLabelLimit("unlimited", 0),
+			WithPriorityLimit(pgcontext.PriorityLow, 1),
+			WithQueueTimeout(0),
+		)
+		reports := pgcontext.With(ctx, pgcontext.WithMetricsLabel("users", "reports"))
+		low := pgcontext.With(ctx, pgcontext.WithPriority(pgcontext.PriorityLow))
+
+		for _, ctx := range []context.Context{ctx, reports, low} {
+			_, err := b.acquire(ctx)
+			require.NoError(t, err)
+		}
+		for _, ctx := range []context.Context{ctx, reports, low} {
+			_, err := b.acquire(ctx)
+			require.ErrorIs(t, err, ErrOverloaded)
+		}
+		assert.Len(t, b.labels, 1)
+	})
+}
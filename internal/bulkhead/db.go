@@ -0,0 +1,229 @@
+package bulkhead
+
+import (
+	"context"
+
+	"github.com/godepo/elephant/internal/notify"
+	"github.com/godepo/elephant/internal/pkg/failure"
+	"github.com/godepo/elephant/internal/pkg/passthrough"
+	"github.com/godepo/elephant/internal/pkg/pgcontext"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Pool interface defines database operations of wrapped pool.
+type Pool interface {
+	BeginTx(ctx context.Context, opts pgx.TxOptions) (pgx.Tx, error)
+	Begin(ctx context.Context) (pgx.Tx, error)
+	Query(ctx context.Context, query string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, query string, args ...interface{}) pgx.Row
+	Exec(ctx context.Context, query string, args ...interface{}) (pgconn.CommandTag, error)
+	Transactional(ctx context.Context, fn func(ctx context.Context) error) (out error)
+}
+
+// DB is pool limited by bulkhead. Dedicated connections, subscriptions and advisory locks are not limited, because
+// their release can't be tracked, statements of fn under advisory lock are limited like other statements.
+type DB struct {
+	*Bulkhead
+	passthrough.Delegate
+	db Pool
+}
+
+// Wrap limits db by new bulkhead. Transaction holds single slot until it is finished, statements of transaction
+// from context are passed to db as is.
+func Wrap(db Pool, opts ...Option) *DB {
+	return &DB{
+		Bulkhead: New(opts...),
+		Delegate: passthrough.New(db),
+		db:       db,
+	}
+}
+
+func inTx(ctx context.Context) bool {
+	_, ok := pgcontext.TransactionFrom(ctx)
+	return ok
+}
+
+// Begin holds slot until transaction is committed or rolled back. Nested transaction of transaction from context
+// takes no slot, because outer transaction holds it.
+func (d *DB) Begin(ctx context.Context) (pgx.Tx, error) {
+	if inTx(ctx) {
+		return d.db.Begin(ctx)
+	}
+	release, err := d.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := d.db.Begin(ctx)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	return limitedTx{Tx: tx, release: release}, nil
+}
+
+// BeginTx holds slot until transaction is committed or rolled back, nested transaction takes no slot like Begin.
+func (d *DB) BeginTx(ctx context.Context, opts pgx.TxOptions) (pgx.Tx, error) {
+	if inTx(ctx) {
+		return d.db.BeginTx(ctx, opts)
+	}
+	release, err := d.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := d.db.BeginTx(ctx, opts)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	return limitedTx{Tx: tx, release: release}, nil
+}
+
+// Query holds slot until rows are read or closed.
+func (d *DB) Query(ctx context.Context, query string, args ...interface{}) (pgx.Rows, error) {
+	if inTx(ctx) {
+		return d.db.Query(ctx, query, args...)
+	}
+	release, err := d.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := d.db.Query(ctx, query, args...)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	return limitedRows{Rows: rows, release: release}, nil
+}
+
+// QueryRow holds slot until row is scanned.
+func (d *DB) QueryRow(ctx context.Context, query string, args ...interface{}) pgx.Row {
+	if inTx(ctx) {
+		return d.db.QueryRow(ctx, query, args...)
+	}
+	release, err := d.acquire(ctx)
+	if err != nil {
+		return failure.Row(err)
+	}
+	return limitedRow{row: d.db.QueryRow(ctx, query, args...), release: release}
+}
+
+func (d *DB) Exec(ctx context.Context, query string, args ...interface{}) (pgconn.CommandTag, error) {
+	if inTx(ctx) {
+		return d.db.Exec(ctx, query, args...)
+	}
+	release, err := d.acquire(ctx)
+	if err != nil {
+		return pgconn.CommandTag{}, err
+	}
+	defer release()
+	return d.db.Exec(ctx, query, args...)
+}
+
+func (d *DB) Transactional(ctx context.Context, fn func(ctx context.Context) error) (out error) {
+	if inTx(ctx) {
+		return d.db.Transactional(ctx, fn)
+	}
+	release, err := d.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return d.db.Transactional(ctx, fn)
+}
+
+func (d *DB) CopyFrom(
+	ctx context.Context,
+	tableName pgx.Identifier,
+	columnNames []string,
+	rowSrc pgx.CopyFromSource,
+) (int64, error) {
+	bulk, err := passthrough.AsBulk(d.db)
+	if err != nil {
+		return 0, err
+	}
+	if inTx(ctx) {
+		return bulk.CopyFrom(ctx, tableName, columnNames, rowSrc)
+	}
+	release, err := d.acquire(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer release()
+	return bulk.CopyFrom(ctx, tableName, columnNames, rowSrc)
+}
+
+// SendBatch holds slot until results are closed.
+func (d *DB) SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults {
+	bulk, err := passthrough.AsBulk(d.db)
+	if err != nil {
+		return failure.BatchResults(err)
+	}
+	if inTx(ctx) {
+		return bulk.SendBatch(ctx, b)
+	}
+	release, err := d.acquire(ctx)
+	if err != nil {
+		return failure.BatchResults(err)
+	}
+	return limitedBatch{BatchResults: bulk.SendBatch(ctx, b), release: release}
+}
+
+// Notify sends notification through Exec, so it is limited like other statements.
+func (d *DB) Notify(ctx context.Context, channel, payload string) error {
+	_, err := d.Exec(ctx, notify.Query, channel, payload)
+	return err
+}
+
+type limitedRows struct {
+	pgx.Rows
+	release func()
+}
+
+func (r limitedRows) Next() bool {
+	if r.Rows.Next() {
+		return true
+	}
+	r.release()
+	return false
+}
+
+func (r limitedRows) Close() {
+	r.Rows.Close()
+	r.release()
+}
+
+type limitedRow struct {
+	row     pgx.Row
+	release func()
+}
+
+func (r limitedRow) Scan(dest ...any) error {
+	defer r.release()
+	return r.row.Scan(dest...)
+}
+
+type limitedTx struct {
+	pgx.Tx
+	release func()
+}
+
+func (tx limitedTx) Commit(ctx context.Context) error {
+	defer tx.release()
+	return tx.Tx.Commit(ctx)
+}
+
+func (tx limitedTx) Rollback(ctx context.Context) error {
+	defer tx.release()
+	return tx.Tx.Rollback(ctx)
+}
+
+type limitedBatch struct {
+	pgx.BatchResults
+	release func()
+}
+
+func (b limitedBatch) Close() error {
+	defer b.release()
+	return b.BatchResults.Close()
+}
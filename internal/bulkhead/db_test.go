@@ -0,0 +1,238 @@
+package bulkhead
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/godepo/elephant/internal/pkg/passthrough"
+	"github.com/godepo/elephant/internal/pkg/pgcontext"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// requireFull checks that single slot of db is taken.
+func requireFull(t *testing.T, db *DB) {
+	t.Helper()
+	_, err := db.Exec(context.Background(), "SELECT 1")
+	require.ErrorIs(t, err, ErrOverloaded)
+}
+
+func TestDB(t *testing.T) {
+	ctx := context.Background()
+	tag := pgconn.NewCommandTag("UPDATE 1")
+
+	t.Run("should be able to hold slot until rows are closed", func(t *testing.T) {
+		pool := NewMockPool(t)
+		rows := NewMockRows(t)
+		db := Wrap(pool, WithLimit(1), WithQueueTimeout(0))
+		pool.EXPECT().Query(ctx, "SELECT $1", []any{1}).Return(rows, nil)
+		pool.EXPECT().Exec(ctx, "UPDATE t").Return(tag, nil)
+		rows.EXPECT().Next().Return(true).Once()
+		rows.EXPECT().Next().Return(false).Once()
+		rows.EXPECT().Close().Return()
+
+		out, err := db.Query(ctx, "SELECT $1", 1)
+		require.NoError(t, err)
+		assert.True(t, out.Next())
+		requireFull(t, db)
+		assert.False(t, out.Next())
+		out.Close()
+
+		res, err := db.Exec(ctx, "UPDATE t")
+		require.NoError(t, err)
+		assert.Equal(t, tag, res)
+	})
+
+	t.Run("should be able to hold slot until row is scanned", func(t *testing.T) {
+		pool := NewMockPool(t)
+		row := NewMockRow(t)
+		db := Wrap(pool, WithLimit(1), WithQueueTimeout(0))
+		pool.EXPECT().QueryRow(ctx, "SELECT 1").Return(row)
+		pool.EXPECT().Exec(ctx, "UPDATE t").Return(tag, nil)
+		row.EXPECT().Scan().Return(pgx.ErrNoRows)
+
+		out := db.QueryRow(ctx, "SELECT 1")
+		requireFull(t, db)
+		require.ErrorIs(t, out.Scan(), pgx.ErrNoRows)
+		require.ErrorIs(t, db.QueryRow(ctx, "SELECT 1").Scan(), pgx.ErrNoRows)
+
+		_, err := db.Exec(ctx, "UPDATE t")
+		require.NoError(t, err)
+	})
+
+	t.Run("should be able to hold slot until transaction is finished", func(t *testing.T) {
+		pool := NewMockPool(t)
+		first, second := NewMockTx(t), NewMockTx(t)
+		db := Wrap(pool, WithLimit(1), WithQueueTimeout(0))
+		pool.EXPECT().Begin(ctx).Return(first, nil)
+		pool.EXPECT().BeginTx(ctx, pgx.TxOptions{}).Return(second, nil)
+		first.EXPECT().Commit(ctx).Return(nil)
+		second.EXPECT().Rollback(ctx).Return(nil)
+
+		tx, err := db.Begin(ctx)
+		require.NoError(t, err)
+		requireFull(t, db)
+		require.NoError(t, tx.Commit(ctx))
+
+		tx, err = db.BeginTx(ctx, pgx.TxOptions{})
+		require.NoError(t, err)
+		requireFull(t, db)
+		require.NoError(t, tx.Rollback(ctx))
+
+		pool.EXPECT().Transactional(ctx, mock.Anything).RunAndReturn(
+			func(ctx context.Context, fn func(ctx context.Context) error) error {
+				requireFull(t, db)
+				return fn(ctx)
+			},
+		)
+		require.NoError(t, db.Transactional(ctx, func(context.Context) error { return nil }))
+		pool.EXPECT().Exec(ctx, "UPDATE t").Return(tag, nil)
+		_, err = db.Exec(ctx, "UPDATE t")
+		require.NoError(t, err)
+	})
+
+	t.Run("should be able to release slot when wrapped pool failed", func(t *testing.T) {
+		pool := NewMockPool(t)
+		db := Wrap(pool, WithLimit(1), WithQueueTimeout(0))
+		expErr := errors.New(uuid.NewString())
+		pool.EXPECT().Begin(ctx).Return(nil, expErr)
+		pool.EXPECT().BeginTx(ctx, pgx.TxOptions{}).Return(nil, expErr)
+		pool.EXPECT().Query(ctx, "SELECT 1").Return(nil, expErr)
+		pool.EXPECT().Exec(ctx, "SELECT 1").Return(pgconn.CommandTag{}, expErr)
+
+		_, err := db.Begin(ctx)
+		require.ErrorIs(t, err, expErr)
+		_, err = db.BeginTx(ctx, pgx.TxOptions{})
+		require.ErrorIs(t, err, expErr)
+		_, err = db.Query(ctx, "SELECT 1")
+		require.ErrorIs(t, err, expErr)
+		_, err = db.Exec(ctx, "SELECT 1")
+		require.ErrorIs(t, err, expErr)
+	})
+
+	t.Run("should be able to reject statements when bulkhead is full", func(t *testing.T) {
+		pool := NewMockPool(t)
+		db := Wrap(pool, WithLimit(1), WithQueueTimeout(0))
+		pool.EXPECT().Begin(ctx).Return(NewMockTx(t), nil)
+		_, err := db.Begin(ctx)
+		require.NoError(t, err)
+
+		_, err = db.Begin(ctx)
+		require.ErrorIs(t, err, ErrOverloaded)
+		_, err = db.BeginTx(ctx, pgx.TxOptions{})
+		require.ErrorIs(t, err, ErrOverloaded)
+		_, err = db.Query(ctx, "SELECT 1")
+		require.ErrorIs(t, err, ErrOverloaded)
+		require.ErrorIs(t, db.QueryRow(ctx, "SELECT 1").Scan(), ErrOverloaded)
+		requireFull(t, db)
+		require.ErrorIs(t, db.Transactional(ctx, func(context.Context) error { return nil }), ErrOverloaded)
+	})
+
+	t.Run("should be able to pass statements of transaction from context", func(t *testing.T) {
+		pool := NewMockPool(t)
+		db := Wrap(pool, WithLimit(1), WithQueueTimeout(0))
+		pool.EXPECT().Begin(context.Background()).Return(NewMockTx(t), nil)
+		_, err := db.Begin(context.Background())
+		require.NoError(t, err)
+
+		ctx := pgcontext.With(context.Background(), pgcontext.WithTransaction(NewMockTx(t)))
+		row := NewMockRow(t)
+		pool.EXPECT().Query(ctx, "SELECT 1").Return(nil, nil)
+		pool.EXPECT().QueryRow(ctx, "SELECT 1").Return(row)
+		pool.EXPECT().Exec(ctx, "SELECT 1").Return(pgconn.CommandTag{}, nil)
+		pool.EXPECT().Transactional(ctx, mock.Anything).Return(nil)
+
+		_, err = db.Query(ctx, "SELECT 1")
+		require.NoError(t, err)
+		assert.Equal(t, row, db.QueryRow(ctx, "SELECT 1"))
+		_, err = db.Exec(ctx, "SELECT 1")
+		require.NoError(t, err)
+		require.NoError(t, db.Transactional(ctx, func(context.Context) error { return nil }))
+	})
+
+	t.Run("should be able to begin nested transaction of transaction from context", func(t *testing.T) {
+		pool := NewMockPool(t)
+		outer, first, second := NewMockTx(t), NewMockTx(t), NewMockTx(t)
+		db := Wrap(pool, WithLimit(1), WithQueueTimeout(0))
+		pool.EXPECT().Begin(context.Background()).Return(outer, nil)
+		_, err := db.Begin(context.Background())
+		require.NoError(t, err)
+
+		ctx := pgcontext.With(context.Background(), pgcontext.WithTransaction(outer))
+		pool.EXPECT().Begin(ctx).Return(first, nil)
+		pool.EXPECT().BeginTx(ctx, pgx.TxOptions{}).Return(second, nil)
+
+		tx, err := db.Begin(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, first, tx)
+		tx, err = db.BeginTx(ctx, pgx.TxOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, second, tx)
+		requireFull(t, db)
+	})
+}
+
+type bulkPool struct {
+	*MockPool
+	*MockBulkPool
+}
+
+func TestDB_Bulk(t *testing.T) {
+	t.Run("should be able to hold slot until batch results are closed", func(t *testing.T) {
+		pool := bulkPool{MockPool: NewMockPool(t), MockBulkPool: NewMockBulkPool(t)}
+		db := Wrap(pool, WithLimit(1), WithQueueTimeout(0))
+		ctx := context.Background()
+		batch := &pgx.Batch{}
+		results := NewMockBatchResults(t)
+		pool.MockBulkPool.EXPECT().CopyFrom(ctx, pgx.Identifier{"t"}, []string{"id"}, mock.Anything).Return(3, nil)
+		pool.MockBulkPool.EXPECT().SendBatch(ctx, batch).Return(results)
+		results.EXPECT().Close().Return(nil)
+		pool.MockPool.EXPECT().Exec(ctx, mock.Anything, []any{"events", "payload"}).
+			Return(pgconn.NewCommandTag("SELECT 1"), nil)
+
+		count, err := db.CopyFrom(ctx, pgx.Identifier{"t"}, []string{"id"}, pgx.CopyFromRows(nil))
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), count)
+		res := db.SendBatch(ctx, batch)
+		requireFull(t, db)
+		require.NoError(t, res.Close())
+		require.NoError(t, db.Notify(ctx, "events", "payload"))
+	})
+
+	t.Run("should be able to reject bulk operations when bulkhead is full", func(t *testing.T) {
+		pool := bulkPool{MockPool: NewMockPool(t), MockBulkPool: NewMockBulkPool(t)}
+		db := Wrap(pool, WithLimit(1), WithQueueTimeout(0))
+		ctx := context.Background()
+		pool.MockPool.EXPECT().Begin(ctx).Return(NewMockTx(t), nil)
+		_, err := db.Begin(ctx)
+		require.NoError(t, err)
+
+		_, err = db.CopyFrom(ctx, pgx.Identifier{"t"}, []string{"id"}, pgx.CopyFromRows(nil))
+		require.ErrorIs(t, err, ErrOverloaded)
+		require.ErrorIs(t, db.SendBatch(ctx, &pgx.Batch{}).Close(), ErrOverloaded)
+		require.ErrorIs(t, db.Notify(ctx, "events", "payload"), ErrOverloaded)
+
+		txCtx := pgcontext.With(ctx, pgcontext.WithTransaction(NewMockTx(t)))
+		batch := &pgx.Batch{}
+		results := NewMockBatchResults(t)
+		pool.MockBulkPool.EXPECT().CopyFrom(txCtx, pgx.Identifier{"t"}, []string{"id"}, mock.Anything).Return(1, nil)
+		pool.MockBulkPool.EXPECT().SendBatch(txCtx, batch).Return(results)
+
+		_, err = db.CopyFrom(txCtx, pgx.Identifier{"t"}, []string{"id"}, pgx.CopyFromRows(nil))
+		require.NoError(t, err)
+		assert.Equal(t, results, db.SendBatch(txCtx, batch))
+	})
+
+	t.Run("should be able to fail when wrapped pool has no bulk operations", func(t *testing.T) {
+		db := Wrap(NewMockPool(t))
+
+		_, err := db.CopyFrom(context.Background(), pgx.Identifier{"t"}, []string{"id"}, pgx.CopyFromRows(nil))
+		require.ErrorIs(t, err, passthrough.ErrBulkNotSupported)
+		require.ErrorIs(t, db.SendBatch(context.Background(), &pgx.Batch{}).Close(), passthrough.ErrBulkNotSupported)
+	})
+}
@@ -0,0 +1,111 @@
+// Package cache implements decorator which caches results of reads of pool by SQL and arguments. Results are
+// cached for ttl of context or metrics label and tagged by tables which reads declare in context. Reads inside
+// transaction bypass cache, and Exec invalidates results tagged by its tags, after commit when it runs in
+// transaction.
+//
+//go:generate go tool mockery
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/godepo/elephant/internal/pkg/pgcontext"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Result is result of read which is kept by backend. Rows are raw values in formats of fields.
+type Result struct {
+	Fields     []pgconn.FieldDescription
+	Rows       [][][]byte
+	CommandTag string
+}
+
+// Backend keeps results of reads by key. It must be safe for concurrent use. Backend handles its failures by
+// itself: result which is not got is read from pool.
+type Backend interface {
+	Get(ctx context.Context, key string) (Result, bool)
+	Set(ctx context.Context, key string, res Result, ttl time.Duration, tags []string)
+	Invalidate(ctx context.Context, tags []string)
+}
+
+type Config struct {
+	backend Backend
+	ttl     time.Duration
+	labels  map[string]time.Duration
+}
+
+type Option func(cfg *Config)
+
+// WithBackend sets backend of results, in-memory LRU of defaultCapacity results is used by default.
+func WithBackend(backend Backend) Option {
+	return func(cfg *Config) {
+		cfg.backend = backend
+	}
+}
+
+// WithTTL sets ttl of reads which ttl is not set by context or metrics label. Such reads are not cached by
+// default.
+func WithTTL(ttl time.Duration) Option {
+	return func(cfg *Config) {
+		cfg.ttl = ttl
+	}
+}
+
+// WithLabelTTL sets ttl of reads which metrics labels contain label.
+func WithLabelTTL(label string, ttl time.Duration) Option {
+	return func(cfg *Config) {
+		cfg.labels[label] = ttl
+	}
+}
+
+// ttlOf returns time which result of read is cached: ttl of context, ttl of first metrics label which has it or
+// default ttl. Reads inside transaction are not cached.
+func (cfg Config) ttlOf(ctx context.Context) time.Duration {
+	if inTx(ctx) {
+		return 0
+	}
+	if ttl, ok := pgcontext.CacheTTLFrom(ctx); ok {
+		return ttl
+	}
+	labels, _ := pgcontext.MetricsLabelsFrom(ctx)
+	for _, label := range labels {
+		if ttl, ok := cfg.labels[label]; ok {
+			return ttl
+		}
+	}
+	return cfg.ttl
+}
+
+func inTx(ctx context.Context) bool {
+	_, ok := pgcontext.TransactionFrom(ctx)
+	return ok
+}
+
+// keyOf hashes query, arguments and options of context which change result of query: tenant, shard and session
+// settings. Arguments are keyed by their types and values, see writeArg.
+func keyOf(ctx context.Context, query string, args []any) string {
+	hash := sha256.New()
+	_, _ = fmt.Fprintf(hash, "%q", query)
+	for _, arg := range args {
+		_, _ = fmt.Fprint(hash, "|")
+		writeArg(hash, reflect.ValueOf(arg), nil)
+	}
+	if tenant, ok := pgcontext.TenantFrom(ctx); ok {
+		_, _ = fmt.Fprintf(hash, "|tenant=%q", tenant)
+	}
+	if shard, ok := pgcontext.ShardIDFrom(ctx); ok {
+		_, _ = fmt.Fprintf(hash, "|shard=%d", shard)
+	}
+	if key, ok := pgcontext.ShardingKeyFrom(ctx); ok {
+		_, _ = fmt.Fprintf(hash, "|sharding_key=%q", key)
+	}
+	if settings, ok := pgcontext.SessionSettingsFrom(ctx); ok {
+		_, _ = fmt.Fprintf(hash, "|settings=%#v", settings)
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,99 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/godepo/elephant/internal/pkg/pgcontext"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig_ttlOf(t *testing.T) {
+	cfg := Config{ttl: time.Second, labels: map[string]time.Duration{"users": time.Minute}}
+
+	t.Run("should be able to prefer ttl of context", func(t *testing.T) {
+		ctx := pgcontext.With(context.Background(),
+			pgcontext.WithCacheTTL(time.Hour), pgcontext.WithMetricsLabel("users"))
+		assert.Equal(t, time.Hour, cfg.ttlOf(ctx))
+	})
+
+	t.Run("should be able to use ttl of first label which has it", func(t *testing.T) {
+		ctx := pgcontext.With(context.Background(), pgcontext.WithMetricsLabel("orders", "users"))
+		assert.Equal(t, time.Minute, cfg.ttlOf(ctx))
+	})
+
+	t.Run("should be able to use default ttl", func(t *testing.T) {
+		assert.Equal(t, time.Second, cfg.ttlOf(context.Background()))
+	})
+
+	t.Run("should be able to bypass cache in transaction", func(t *testing.T) {
+		ctx := pgcontext.With(context.Background(),
+			pgcontext.WithTransaction(NewMockTx(t)), pgcontext.WithCacheTTL(time.Hour))
+		assert.Zero(t, cfg.ttlOf(ctx))
+	})
+}
+
+func TestKeyOf(t *testing.T) {
+	ctx := context.Background()
+	id, same := 42, 42
+
+	t.Run("should be able to key by query and values of arguments", func(t *testing.T) {
+		exp := keyOf(ctx, "SELECT $1", []any{42})
+		assert.Equal(t, exp, keyOf(ctx, "SELECT $1", []any{&id}))
+		assert.Equal(t, keyOf(ctx, "SELECT $1", []any{&id}), keyOf(ctx, "SELECT $1", []any{&same}))
+		assert.NotEqual(t, exp, keyOf(ctx, "SELECT $1", []any{"42"}))
+		assert.NotEqual(t, exp, keyOf(ctx, "SELECT $1", []any{int64(42)}))
+		assert.NotEqual(t, exp, keyOf(ctx, "SELECT $2", []any{42}))
+		assert.NotEqual(t, keyOf(ctx, "SELECT $1", []any{(*int)(nil)}), keyOf(ctx, "SELECT $1", []any{0}))
+	})
+
+	t.Run("should be able to key by values of pointers nested in arguments", func(t *testing.T) {
+		type filter struct {
+			ID   *int
+			Tags []*string
+			Meta map[string]*int
+		}
+		tag, other := "a", "b"
+		newFilter := func(id *int, tag *string) filter {
+			return filter{ID: id, Tags: []*string{tag}, Meta: map[string]*int{"id": id}}
+		}
+		exp := keyOf(ctx, "SELECT $1", []any{newFilter(&id, &tag)})
+
+		assert.Equal(t, exp, keyOf(ctx, "SELECT $1", []any{newFilter(&same, &tag)}))
+		assert.Equal(t, exp, keyOf(ctx, "SELECT $1", []any{&filter{ID: &same, Tags: []*string{&tag},
+			Meta: map[string]*int{"id": &id}}}))
+		assert.NotEqual(t, exp, keyOf(ctx, "SELECT $1", []any{newFilter(&id, &other)}))
+		assert.NotEqual(t, exp, keyOf(ctx, "SELECT $1", []any{newFilter(nil, &tag)}))
+
+		changed := 43
+		assert.NotEqual(t, exp, keyOf(ctx, "SELECT $1", []any{newFilter(&changed, &tag)}))
+	})
+
+	t.Run("should be able to key by cyclic arguments", func(t *testing.T) {
+		type node struct {
+			Next *node
+		}
+		cyclic := &node{}
+		cyclic.Next = cyclic
+		assert.NotEqual(t, keyOf(ctx, "SELECT $1", []any{cyclic}), keyOf(ctx, "SELECT $1", []any{&node{}}))
+	})
+
+	t.Run("should be able to key by time without monotonic clock", func(t *testing.T) {
+		now := time.Now()
+		assert.Equal(t, keyOf(ctx, "SELECT $1", []any{now}), keyOf(ctx, "SELECT $1", []any{now.Round(0)}))
+	})
+
+	t.Run("should be able to key by tenant, shard and session settings", func(t *testing.T) {
+		exp := keyOf(ctx, "SELECT 1", nil)
+		for _, opt := range []pgcontext.OptionContext{
+			pgcontext.WithTenant("acme"),
+			pgcontext.WithShardID(1),
+			pgcontext.WithShardingKey("user-1"),
+			pgcontext.WithSessionSettings(map[string]string{"app.user_id": "42"}),
+		} {
+			assert.NotEqual(t, exp, keyOf(pgcontext.With(ctx, opt), "SELECT 1", nil))
+		}
+		assert.Equal(t, exp, keyOf(pgcontext.With(ctx, pgcontext.WithMetricsLabel("users")), "SELECT 1", nil))
+	})
+}
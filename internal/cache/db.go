@@ -0,0 +1,305 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/godepo/elephant/internal/notify"
+	"github.com/godepo/elephant/internal/pkg/failure"
+	"github.com/godepo/elephant/internal/pkg/passthrough"
+	"github.com/godepo/elephant/internal/pkg/pgcontext"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Pool interface defines database operations of wrapped pool.
+type Pool interface {
+	BeginTx(ctx context.Context, opts pgx.TxOptions) (pgx.Tx, error)
+	Begin(ctx context.Context) (pgx.Tx, error)
+	Query(ctx context.Context, query string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, query string, args ...interface{}) pgx.Row
+	Exec(ctx context.Context, query string, args ...interface{}) (pgconn.CommandTag, error)
+	Transactional(ctx context.Context, fn func(ctx context.Context) error) (out error)
+}
+
+// DB is pool which caches results of reads. Dedicated connections, subscriptions and advisory locks are passed to
+// wrapped pool, statements of dedicated connections are not seen by cache.
+type DB struct {
+	passthrough.Delegate
+	cfg Config
+	db  Pool
+	// mu orders results which are cached with invalidations, epoch is changed by every invalidation, so result
+	// which was read while its tags could be invalidated is not cached.
+	mu    sync.RWMutex
+	epoch atomic.Uint64
+}
+
+// Wrap caches results of reads of db. Reads are not cached until ttl is set by option or context.
+func Wrap(db Pool, opts ...Option) *DB {
+	cfg := Config{labels: map[string]time.Duration{}}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.backend == nil {
+		cfg.backend = NewLRU(defaultCapacity)
+	}
+	return &DB{Delegate: passthrough.New(db), cfg: cfg, db: db}
+}
+
+// Begin returns transaction which invalidates tags of its Exec when it is committed.
+func (d *DB) Begin(ctx context.Context) (pgx.Tx, error) {
+	tx, err := d.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &cachedTx{Tx: tx, db: d, pending: &pending{}}, nil
+}
+
+// BeginTx returns transaction which invalidates tags of its Exec when it is committed.
+func (d *DB) BeginTx(ctx context.Context, opts pgx.TxOptions) (pgx.Tx, error) {
+	tx, err := d.db.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &cachedTx{Tx: tx, db: d, pending: &pending{}}, nil
+}
+
+// Query returns cached rows, when ttl of read is positive. Rows are read at once and cached when they are not.
+func (d *DB) Query(ctx context.Context, query string, args ...interface{}) (pgx.Rows, error) {
+	ttl := d.cfg.ttlOf(ctx)
+	if ttl <= 0 {
+		return d.db.Query(ctx, query, args...)
+	}
+	res, err := d.read(ctx, ttl, query, args)
+	if err != nil {
+		return nil, err
+	}
+	return newRows(res), nil
+}
+
+// QueryRow returns cached row, when ttl of read is positive.
+func (d *DB) QueryRow(ctx context.Context, query string, args ...interface{}) pgx.Row {
+	ttl := d.cfg.ttlOf(ctx)
+	if ttl <= 0 {
+		return d.db.QueryRow(ctx, query, args...)
+	}
+	res, err := d.read(ctx, ttl, query, args)
+	if err != nil {
+		return failure.Row(err)
+	}
+	return cachedRow{rows: newRows(res)}
+}
+
+// Exec invalidates results tagged by tags of context, when statement succeeds. Results are invalidated after
+// commit, when statement runs in transaction of Transactional or Begin of DB.
+func (d *DB) Exec(ctx context.Context, query string, args ...interface{}) (pgconn.CommandTag, error) {
+	tag, err := d.db.Exec(ctx, query, args...)
+	if err != nil {
+		return tag, err
+	}
+	d.invalidate(ctx)
+	return tag, nil
+}
+
+// Transactional invalidates tags of Exec of fn when transaction is committed, nested calls are passed to db as is.
+// Transaction is committed with error of fn too, when error is passed by matcher of pgcontext.WithFnTxPassMatcher.
+func (d *DB) Transactional(ctx context.Context, fn func(ctx context.Context) error) (out error) {
+	if _, ok := pendingFrom(ctx); ok || inTx(ctx) {
+		return d.db.Transactional(ctx, fn)
+	}
+	pend := &pending{}
+	var fnErr error
+	err := d.db.Transactional(context.WithValue(ctx, pendingKey{}, pend), func(ctx context.Context) error {
+		fnErr = fn(ctx)
+		return fnErr
+	})
+	if err == nil || committed(ctx, fnErr, err) {
+		d.flush(ctx, pend.tags())
+	}
+	return err
+}
+
+// committed reports whether transaction is committed, though err is returned: error of fn is passed by matcher
+// and it is not wrapped by error of commit.
+func committed(ctx context.Context, fnErr, err error) bool {
+	pass, ok := pgcontext.TxPassMatcherFrom(ctx)
+	return ok && fnErr != nil && errors.Is(err, fnErr) && pass(ctx, fnErr)
+}
+
+// CopyFrom invalidates results tagged by tags of context like Exec.
+func (d *DB) CopyFrom(
+	ctx context.Context,
+	tableName pgx.Identifier,
+	columnNames []string,
+	rowSrc pgx.CopyFromSource,
+) (int64, error) {
+	bulk, err := passthrough.AsBulk(d.db)
+	if err != nil {
+		return 0, err
+	}
+	res, err := bulk.CopyFrom(ctx, tableName, columnNames, rowSrc)
+	if err != nil {
+		return res, err
+	}
+	d.invalidate(ctx)
+	return res, nil
+}
+
+// SendBatch is not cached and invalidates results tagged by tags of context, when results are closed.
+func (d *DB) SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults {
+	bulk, err := passthrough.AsBulk(d.db)
+	if err != nil {
+		return failure.BatchResults(err)
+	}
+	return invalidatingBatch{BatchResults: bulk.SendBatch(ctx, b), ctx: ctx, db: d}
+}
+
+// Notify sends notification through Exec, so it invalidates results tagged by tags of context.
+func (d *DB) Notify(ctx context.Context, channel, payload string) error {
+	_, err := d.Exec(ctx, notify.Query, channel, payload)
+	return err
+}
+
+func (d *DB) read(ctx context.Context, ttl time.Duration, query string, args []any) (Result, error) {
+	key := keyOf(ctx, query, args)
+	if res, ok := d.cfg.backend.Get(ctx, key); ok {
+		return res, nil
+	}
+
+	epoch := d.epoch.Load()
+	rows, err := d.db.Query(ctx, query, args...)
+	if err != nil {
+		return Result{}, err
+	}
+	res, err := collect(rows)
+	if err != nil {
+		return Result{}, err
+	}
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.epoch.Load() == epoch {
+		tags, _ := pgcontext.CacheTagsFrom(ctx)
+		d.cfg.backend.Set(ctx, key, res, ttl, tags)
+	}
+	return res, nil
+}
+
+// invalidate defers invalidation of tags of context to commit of transaction, or invalidates them at once.
+func (d *DB) invalidate(ctx context.Context) {
+	tags, ok := pgcontext.CacheTagsFrom(ctx)
+	if !ok {
+		return
+	}
+	if pend, ok := pendingFrom(ctx); ok {
+		pend.add(tags)
+		return
+	}
+	d.flush(ctx, tags)
+}
+
+// flush invalidates tags even when ctx is cancelled, because statements which changed tables are committed.
+func (d *DB) flush(ctx context.Context, tags []string) {
+	if len(tags) == 0 {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.epoch.Add(1)
+	d.cfg.backend.Invalidate(context.WithoutCancel(ctx), tags)
+}
+
+type pendingKey struct{}
+
+// pending is set of tags which are invalidated after commit of transaction.
+type pending struct {
+	mu  sync.Mutex
+	set map[string]struct{}
+}
+
+func pendingFrom(ctx context.Context) (*pending, bool) {
+	if pend, ok := ctx.Value(pendingKey{}).(*pending); ok {
+		return pend, true
+	}
+	if tx, ok := pgcontext.TransactionFrom(ctx); ok {
+		if cached, ok := tx.(*cachedTx); ok {
+			return cached.pending, true
+		}
+	}
+	return nil, false
+}
+
+func (p *pending) add(tags []string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.set == nil {
+		p.set = make(map[string]struct{}, len(tags))
+	}
+	for _, tag := range tags {
+		p.set[tag] = struct{}{}
+	}
+}
+
+func (p *pending) tags() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return slices.Sorted(maps.Keys(p.set))
+}
+
+// cachedTx collects tags of its Exec. Tags of nested transactions, which are savepoints, are invalidated by commit
+// of outermost transaction.
+type cachedTx struct {
+	pgx.Tx
+	db      *DB
+	pending *pending
+	nested  bool
+}
+
+func (tx *cachedTx) Begin(ctx context.Context) (pgx.Tx, error) {
+	nested, err := tx.Tx.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &cachedTx{Tx: nested, db: tx.db, pending: tx.pending, nested: true}, nil
+}
+
+func (tx *cachedTx) Exec(ctx context.Context, query string, args ...any) (pgconn.CommandTag, error) {
+	tag, err := tx.Tx.Exec(ctx, query, args...)
+	if err != nil {
+		return tag, err
+	}
+	if tags, ok := pgcontext.CacheTagsFrom(ctx); ok {
+		tx.pending.add(tags)
+	}
+	return tag, nil
+}
+
+func (tx *cachedTx) Commit(ctx context.Context) error {
+	if err := tx.Tx.Commit(ctx); err != nil {
+		return err
+	}
+	if tx.nested {
+		return nil
+	}
+	tx.db.flush(ctx, tx.pending.tags())
+	return nil
+}
+
+type invalidatingBatch struct {
+	pgx.BatchResults
+	ctx context.Context
+	db  *DB
+}
+
+func (b invalidatingBatch) Close() error {
+	if err := b.BatchResults.Close(); err != nil {
+		return err
+	}
+	b.db.invalidate(b.ctx)
+	return nil
+}
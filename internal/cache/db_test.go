@@ -0,0 +1,345 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/godepo/elephant/internal/pkg/passthrough"
+	"github.com/godepo/elephant/internal/pkg/pgcontext"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingBackend records ttl and tags of last cached result.
+type recordingBackend struct {
+	*LRU
+	ttl  time.Duration
+	tags []string
+}
+
+func (b *recordingBackend) Set(ctx context.Context, key string, res Result, ttl time.Duration, tags []string) {
+	b.ttl, b.tags = ttl, tags
+	b.LRU.Set(ctx, key, res, ttl, tags)
+}
+
+// expectRead expects single read of pool which returns one row with value.
+func expectRead(t *testing.T, pool *MockPool, query string, value string) *MockPool_Query_Call {
+	t.Helper()
+	rows := NewMockRows(t)
+	rows.EXPECT().Next().Return(true).Once()
+	rows.EXPECT().Next().Return(false).Once()
+	rows.EXPECT().RawValues().Return([][]byte{[]byte(value)})
+	rows.EXPECT().Err().Return(nil)
+	rows.EXPECT().FieldDescriptions().Return(fields[1:])
+	rows.EXPECT().CommandTag().Return(pgconn.NewCommandTag("SELECT 1"))
+	rows.EXPECT().Close().Return()
+	call := pool.EXPECT().Query(mock.Anything, query, []any{1}).Return(rows, nil)
+	call.Once()
+	return call
+}
+
+func scan(t *testing.T, db *DB, ctx context.Context) string {
+	t.Helper()
+	var name string
+	require.NoError(t, db.QueryRow(ctx, "SELECT name FROM users WHERE id = $1", 1).Scan(&name))
+	return name
+}
+
+func TestDB(t *testing.T) {
+	const query = "SELECT name FROM users WHERE id = $1"
+	users := pgcontext.WithCacheTags("users")
+	read := pgcontext.With(context.Background(), pgcontext.WithCacheTTL(time.Minute), users)
+	write := pgcontext.With(context.Background(), users)
+	tag := pgconn.NewCommandTag("UPDATE 1")
+
+	t.Run("should be able to cache rows and row of read", func(t *testing.T) {
+		pool := NewMockPool(t)
+		db := Wrap(pool)
+		expectRead(t, pool, query, "alice")
+
+		rows, err := db.Query(read, query, 1)
+		require.NoError(t, err)
+		names, err := pgx.CollectRows(rows, pgx.RowTo[string])
+		require.NoError(t, err)
+		assert.Equal(t, []string{"alice"}, names)
+		assert.Equal(t, "alice", scan(t, db, read))
+	})
+
+	t.Run("should be able to bypass cache, when ttl is not set or read is in transaction", func(t *testing.T) {
+		pool := NewMockPool(t)
+		rows, row := NewMockRows(t), NewMockRow(t)
+		db := Wrap(pool)
+		inTx := pgcontext.With(read, pgcontext.WithTransaction(NewMockTx(t)))
+		pool.EXPECT().Query(inTx, query, []any{1}).Return(rows, nil)
+		pool.EXPECT().QueryRow(write, query, []any{1}).Return(row)
+
+		out, err := db.Query(inTx, query, 1)
+		require.NoError(t, err)
+		assert.Equal(t, rows, out)
+		assert.Equal(t, row, db.QueryRow(write, query, 1))
+	})
+
+	t.Run("should be able to use ttl of label and tags of context", func(t *testing.T) {
+		pool := NewMockPool(t)
+		backend := &recordingBackend{LRU: NewLRU(1)}
+		db := Wrap(pool, WithBackend(backend), WithTTL(time.Second), WithLabelTTL("users", time.Hour))
+		expectRead(t, pool, query, "alice")
+
+		scan(t, db, pgcontext.With(write, pgcontext.WithMetricsLabel("users")))
+		assert.Equal(t, time.Hour, backend.ttl)
+		assert.Equal(t, []string{"users"}, backend.tags)
+	})
+
+	t.Run("should be able to fail by error of read", func(t *testing.T) {
+		pool := NewMockPool(t)
+		db := Wrap(pool)
+		expErr := errors.New(uuid.NewString())
+		pool.EXPECT().Query(mock.Anything, query, []any{1}).Return(nil, expErr)
+
+		_, err := db.Query(read, query, 1)
+		require.ErrorIs(t, err, expErr)
+		require.ErrorIs(t, db.QueryRow(read, query, 1).Scan(), expErr)
+	})
+
+	t.Run("should be able to invalidate results by tags of exec", func(t *testing.T) {
+		pool := NewMockPool(t)
+		db := Wrap(pool)
+		expectRead(t, pool, query, "alice")
+		pool.EXPECT().Exec(write, "UPDATE users SET name = 'bob'").Return(tag, nil)
+		expectRead(t, pool, query, "bob")
+
+		assert.Equal(t, "alice", scan(t, db, read))
+		res, err := db.Exec(write, "UPDATE users SET name = 'bob'")
+		require.NoError(t, err)
+		assert.Equal(t, tag, res)
+		assert.Equal(t, "bob", scan(t, db, read))
+	})
+
+	t.Run("should be able to keep results, when exec fails", func(t *testing.T) {
+		pool := NewMockPool(t)
+		db := Wrap(pool)
+		expErr := errors.New(uuid.NewString())
+		expectRead(t, pool, query, "alice")
+		pool.EXPECT().Exec(write, "UPDATE users").Return(pgconn.CommandTag{}, expErr)
+
+		scan(t, db, read)
+		_, err := db.Exec(write, "UPDATE users")
+		require.ErrorIs(t, err, expErr)
+		assert.Equal(t, "alice", scan(t, db, read))
+	})
+
+	t.Run("should be able to invalidate results after commit of transactional", func(t *testing.T) {
+		pool := NewMockPool(t)
+		lru := NewLRU(10)
+		db := Wrap(pool, WithBackend(lru))
+		expectRead(t, pool, query, "alice")
+		pool.EXPECT().Exec(mock.Anything, "UPDATE users").Return(tag, nil)
+		pool.EXPECT().Transactional(mock.Anything, mock.Anything).
+			RunAndReturn(func(ctx context.Context, fn func(ctx context.Context) error) error {
+				return fn(pgcontext.With(ctx, pgcontext.WithTransaction(NewMockTx(t))))
+			})
+
+		scan(t, db, read)
+		err := db.Transactional(write, func(ctx context.Context) error {
+			return db.Transactional(ctx, func(ctx context.Context) error {
+				_, err := db.Exec(ctx, "UPDATE users")
+				assert.Equal(t, 1, lru.Len())
+				return err
+			})
+		})
+		require.NoError(t, err)
+		assert.Zero(t, lru.Len())
+	})
+
+	t.Run("should be able to keep results, when transactional fails", func(t *testing.T) {
+		pool := NewMockPool(t)
+		lru := NewLRU(10)
+		db := Wrap(pool, WithBackend(lru))
+		expErr := errors.New(uuid.NewString())
+		expectRead(t, pool, query, "alice")
+		pool.EXPECT().Exec(mock.Anything, "UPDATE users").Return(tag, nil)
+		pool.EXPECT().Transactional(mock.Anything, mock.Anything).
+			RunAndReturn(func(ctx context.Context, fn func(ctx context.Context) error) error {
+				return fn(ctx)
+			})
+
+		scan(t, db, read)
+		err := db.Transactional(write, func(ctx context.Context) error {
+			_, err := db.Exec(ctx, "UPDATE users")
+			require.NoError(t, err)
+			return expErr
+		})
+		require.ErrorIs(t, err, expErr)
+		assert.Equal(t, 1, lru.Len())
+	})
+
+	t.Run("should be able to invalidate results, when error of transactional is passed by matcher", func(t *testing.T) {
+		pool := NewMockPool(t)
+		lru := NewLRU(10)
+		db := Wrap(pool, WithBackend(lru))
+		passed, commitErr := errors.New(uuid.NewString()), errors.New(uuid.NewString())
+		ctx := pgcontext.With(write, pgcontext.WithFnTxPassMatcher(func(_ context.Context, err error) bool {
+			return errors.Is(err, passed)
+		}))
+		expectRead(t, pool, query, "alice")
+		pool.EXPECT().Exec(mock.Anything, "UPDATE users").Return(tag, nil)
+		pool.EXPECT().Transactional(mock.Anything, mock.Anything).
+			RunAndReturn(func(ctx context.Context, fn func(ctx context.Context) error) error {
+				_ = fn(ctx)
+				return fmt.Errorf("can't commit: %w", commitErr)
+			}).Once()
+		pool.EXPECT().Transactional(mock.Anything, mock.Anything).
+			RunAndReturn(func(ctx context.Context, fn func(ctx context.Context) error) error {
+				return fn(ctx)
+			}).Once()
+
+		scan(t, db, read)
+		err := db.Transactional(ctx, func(ctx context.Context) error {
+			return passed
+		})
+		require.ErrorIs(t, err, commitErr)
+		assert.Equal(t, 1, lru.Len())
+
+		err = db.Transactional(ctx, func(ctx context.Context) error {
+			_, err := db.Exec(ctx, "UPDATE users")
+			require.NoError(t, err)
+			return passed
+		})
+		require.ErrorIs(t, err, passed)
+		assert.Zero(t, lru.Len())
+	})
+
+	t.Run("should be able to invalidate results after commit of transaction", func(t *testing.T) {
+		pool := NewMockPool(t)
+		lru := NewLRU(10)
+		db := Wrap(pool, WithBackend(lru))
+		tx, nested := NewMockTx(t), NewMockTx(t)
+		expectRead(t, pool, query, "alice")
+		pool.EXPECT().Begin(write).Return(tx, nil)
+		tx.EXPECT().Begin(write).Return(nested, nil)
+		nested.EXPECT().Exec(write, "UPDATE users").Return(tag, nil)
+		nested.EXPECT().Commit(write).Return(nil)
+		pool.EXPECT().Exec(mock.Anything, "DELETE FROM users").Return(tag, nil)
+		tx.EXPECT().Commit(write).Return(nil)
+
+		scan(t, db, read)
+		out, err := db.Begin(write)
+		require.NoError(t, err)
+		sp, err := out.Begin(write)
+		require.NoError(t, err)
+		_, err = sp.Exec(write, "UPDATE users")
+		require.NoError(t, err)
+		require.NoError(t, sp.Commit(write))
+		_, err = db.Exec(pgcontext.With(write, pgcontext.WithTransaction(out)), "DELETE FROM users")
+		require.NoError(t, err)
+		assert.Equal(t, 1, lru.Len())
+
+		require.NoError(t, out.Commit(write))
+		assert.Zero(t, lru.Len())
+	})
+
+	t.Run("should be able to keep results, when transaction is rolled back or fails", func(t *testing.T) {
+		pool := NewMockPool(t)
+		lru := NewLRU(10)
+		db := Wrap(pool, WithBackend(lru))
+		tx := NewMockTx(t)
+		expErr := errors.New(uuid.NewString())
+		expectRead(t, pool, query, "alice")
+		pool.EXPECT().BeginTx(write, pgx.TxOptions{}).Return(tx, nil)
+		pool.EXPECT().Begin(write).Return(nil, expErr)
+		tx.EXPECT().Exec(write, "UPDATE users").Return(tag, nil)
+		tx.EXPECT().Commit(write).Return(expErr)
+		tx.EXPECT().Rollback(write).Return(nil)
+
+		scan(t, db, read)
+		out, err := db.BeginTx(write, pgx.TxOptions{})
+		require.NoError(t, err)
+		_, err = out.Exec(write, "UPDATE users")
+		require.NoError(t, err)
+		require.ErrorIs(t, out.Commit(write), expErr)
+		require.NoError(t, out.Rollback(write))
+		assert.Equal(t, 1, lru.Len())
+
+		_, err = db.Begin(write)
+		require.ErrorIs(t, err, expErr)
+	})
+
+	t.Run("should be able to skip result, which was read while it was invalidated", func(t *testing.T) {
+		pool := NewMockPool(t)
+		lru := NewLRU(10)
+		db := Wrap(pool, WithBackend(lru))
+		expectRead(t, pool, query, "alice").Run(func(context.Context, string, ...interface{}) {
+			db.flush(context.Background(), []string{"orders"})
+		})
+
+		assert.Equal(t, "alice", scan(t, db, read))
+		assert.Zero(t, lru.Len())
+	})
+}
+
+type bulkPool struct {
+	*MockPool
+	*MockBulkPool
+}
+
+func TestDB_Bulk(t *testing.T) {
+	t.Run("should be able to invalidate results by tags of bulk operations", func(t *testing.T) {
+		pool := bulkPool{MockPool: NewMockPool(t), MockBulkPool: NewMockBulkPool(t)}
+		lru := NewLRU(10)
+		db := Wrap(pool, WithBackend(lru))
+		ctx := pgcontext.With(context.Background(), pgcontext.WithCacheTags("users"))
+		expErr := errors.New(uuid.NewString())
+		batch := &pgx.Batch{}
+		failed, results := NewMockBatchResults(t), NewMockBatchResults(t)
+		cache := func() {
+			lru.Set(ctx, "key", Result{}, time.Minute, []string{"users"})
+		}
+
+		pool.MockBulkPool.EXPECT().CopyFrom(ctx, pgx.Identifier{"t"}, []string{"id"}, mock.Anything).
+			Return(0, expErr).Once()
+		pool.MockBulkPool.EXPECT().CopyFrom(ctx, pgx.Identifier{"t"}, []string{"id"}, mock.Anything).Return(3, nil)
+		pool.MockBulkPool.EXPECT().SendBatch(ctx, batch).Return(failed).Once()
+		pool.MockBulkPool.EXPECT().SendBatch(ctx, batch).Return(results)
+		failed.EXPECT().Close().Return(expErr)
+		results.EXPECT().Close().Return(nil)
+
+		cache()
+		_, err := db.CopyFrom(ctx, pgx.Identifier{"t"}, []string{"id"}, pgx.CopyFromRows(nil))
+		require.ErrorIs(t, err, expErr)
+		require.ErrorIs(t, db.SendBatch(ctx, batch).Close(), expErr)
+		assert.Equal(t, 1, lru.Len())
+
+		count, err := db.CopyFrom(ctx, pgx.Identifier{"t"}, []string{"id"}, pgx.CopyFromRows(nil))
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), count)
+		assert.Zero(t, lru.Len())
+
+		cache()
+		require.NoError(t, db.SendBatch(ctx, batch).Close())
+		assert.Zero(t, lru.Len())
+	})
+
+	t.Run("should be able to send notify through Exec", func(t *testing.T) {
+		pool := NewMockPool(t)
+		db := Wrap(pool)
+		ctx := context.Background()
+		pool.EXPECT().Exec(ctx, mock.Anything, []any{"events", "payload"}).Return(pgconn.NewCommandTag("SELECT 1"), nil)
+
+		require.NoError(t, db.Notify(ctx, "events", "payload"))
+	})
+
+	t.Run("should be able to fail when wrapped pool has no bulk operations", func(t *testing.T) {
+		db := Wrap(NewMockPool(t))
+
+		_, err := db.CopyFrom(context.Background(), pgx.Identifier{"t"}, []string{"id"}, pgx.CopyFromRows(nil))
+		require.ErrorIs(t, err, passthrough.ErrBulkNotSupported)
+		require.ErrorIs(t, db.SendBatch(context.Background(), &pgx.Batch{}).Close(), passthrough.ErrBulkNotSupported)
+	})
+}
@@ -0,0 +1,87 @@
+package cache
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+	"time"
+)
+
+// writeArg writes type and value of argument to w. Pointers are dereferenced at any depth, including pointers in
+// fields of structs and in elements of slices and maps, so arguments which point to equal values are keyed equally.
+// Values of driver.Valuer are keyed by values which are sent to database. Pointers which are already visited are
+// written as cycle.
+func writeArg(w io.Writer, val reflect.Value, visited []uintptr) {
+	for val.Kind() == reflect.Pointer || val.Kind() == reflect.Interface {
+		if val.IsNil() {
+			_, _ = fmt.Fprintf(w, "%s=nil", val.Type())
+			return
+		}
+		if val.Kind() == reflect.Pointer {
+			if slices.Contains(visited, val.Pointer()) {
+				_, _ = fmt.Fprint(w, "cycle")
+				return
+			}
+			visited = append(visited, val.Pointer())
+		}
+		val = val.Elem()
+	}
+	if !val.IsValid() {
+		_, _ = fmt.Fprint(w, "nil")
+		return
+	}
+	if val.CanInterface() {
+		switch arg := val.Interface().(type) {
+		case time.Time:
+			_, _ = fmt.Fprintf(w, "%T=%#v", arg, arg)
+			return
+		case driver.Valuer:
+			if res, err := arg.Value(); err == nil {
+				_, _ = fmt.Fprintf(w, "%T=%T=%#v", arg, res, res)
+				return
+			}
+		}
+	}
+
+	switch val.Kind() {
+	case reflect.Struct:
+		_, _ = fmt.Fprintf(w, "%s{", val.Type())
+		for i := range val.NumField() {
+			_, _ = fmt.Fprintf(w, "%s:", val.Type().Field(i).Name)
+			writeArg(w, val.Field(i), visited)
+			_, _ = fmt.Fprint(w, ",")
+		}
+		_, _ = fmt.Fprint(w, "}")
+	case reflect.Slice, reflect.Array:
+		if val.Kind() == reflect.Slice && val.IsNil() {
+			_, _ = fmt.Fprintf(w, "%s=nil", val.Type())
+			return
+		}
+		_, _ = fmt.Fprintf(w, "%s[", val.Type())
+		for i := range val.Len() {
+			writeArg(w, val.Index(i), visited)
+			_, _ = fmt.Fprint(w, ",")
+		}
+		_, _ = fmt.Fprint(w, "]")
+	case reflect.Map:
+		if val.IsNil() {
+			_, _ = fmt.Fprintf(w, "%s=nil", val.Type())
+			return
+		}
+		entries := make([]string, 0, val.Len())
+		for iter := val.MapRange(); iter.Next(); {
+			var entry bytes.Buffer
+			writeArg(&entry, iter.Key(), visited)
+			entry.WriteString(":")
+			writeArg(&entry, iter.Value(), visited)
+			entries = append(entries, entry.String())
+		}
+		slices.Sort(entries)
+		_, _ = fmt.Fprintf(w, "%s%q", val.Type(), entries)
+	default:
+		_, _ = fmt.Fprintf(w, "%s=%#v", val.Type(), val)
+	}
+}
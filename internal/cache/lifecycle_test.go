@@ -0,0 +1,51 @@
+package cache
+
+import (
+	"context"
+	"testing"
+
+	"github.com/godepo/elephant/internal/pkg/lifecycle"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type lifecyclePool struct {
+	*MockPool
+	closed bool
+}
+
+func (p *lifecyclePool) Ping(context.Context) (lifecycle.Pings, error) {
+	return lifecycle.Pings{{Node: lifecycle.Node{Role: lifecycle.RoleLeader}}}, nil
+}
+
+func (p *lifecyclePool) Stats() lifecycle.Stats {
+	return lifecycle.Stats{{Node: lifecycle.Node{Role: lifecycle.RoleLeader}, Stat: lifecycle.Stat{MaxConns: 10}}}
+}
+
+func (p *lifecyclePool) Close() {
+	p.closed = true
+}
+
+func TestDB_Lifecycle(t *testing.T) {
+	t.Run("should be able to delegate to wrapped pool", func(t *testing.T) {
+		pool := &lifecyclePool{MockPool: NewMockPool(t)}
+		db := Wrap(pool)
+
+		pings, err := db.Ping(context.Background())
+		require.NoError(t, err)
+		assert.Len(t, pings, 1)
+		assert.Equal(t, int32(10), db.Stats().Total().MaxConns)
+		db.Close()
+		assert.True(t, pool.closed)
+	})
+
+	t.Run("should be able to fail ping when wrapped pool can't be pinged", func(t *testing.T) {
+		pool := NewMockPool(t)
+		db := Wrap(pool)
+
+		_, err := db.Ping(context.Background())
+		require.ErrorIs(t, err, lifecycle.ErrPingNotSupported)
+		assert.Empty(t, db.Stats())
+		db.Close()
+	})
+}
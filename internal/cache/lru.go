@@ -0,0 +1,110 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+const defaultCapacity = 1024
+
+// LRU is in-memory backend which evicts least recently used result, when count of results exceeds capacity.
+type LRU struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List
+	entries  map[string]*list.Element
+	tags     map[string]map[string]struct{}
+	now      func() time.Time
+}
+
+type entry struct {
+	key     string
+	res     Result
+	expires time.Time
+	tags    []string
+}
+
+// NewLRU makes LRU of capacity results, defaultCapacity is used when capacity is not positive.
+func NewLRU(capacity int) *LRU {
+	if capacity <= 0 {
+		capacity = defaultCapacity
+	}
+	return &LRU{
+		capacity: capacity,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+		tags:     make(map[string]map[string]struct{}),
+		now:      time.Now,
+	}
+}
+
+func (lru *LRU) Get(_ context.Context, key string) (Result, bool) {
+	lru.mu.Lock()
+	defer lru.mu.Unlock()
+
+	el, ok := lru.entries[key]
+	if !ok {
+		return Result{}, false
+	}
+	ent := el.Value.(*entry)
+	if !lru.now().Before(ent.expires) {
+		lru.remove(el)
+		return Result{}, false
+	}
+	lru.order.MoveToFront(el)
+	return ent.res, true
+}
+
+func (lru *LRU) Set(_ context.Context, key string, res Result, ttl time.Duration, tags []string) {
+	lru.mu.Lock()
+	defer lru.mu.Unlock()
+
+	if el, ok := lru.entries[key]; ok {
+		lru.remove(el)
+	}
+	lru.entries[key] = lru.order.PushFront(&entry{
+		key:     key,
+		res:     res,
+		expires: lru.now().Add(ttl),
+		tags:    tags,
+	})
+	for _, tag := range tags {
+		if lru.tags[tag] == nil {
+			lru.tags[tag] = make(map[string]struct{})
+		}
+		lru.tags[tag][key] = struct{}{}
+	}
+	if lru.order.Len() > lru.capacity {
+		lru.remove(lru.order.Back())
+	}
+}
+
+func (lru *LRU) Invalidate(_ context.Context, tags []string) {
+	lru.mu.Lock()
+	defer lru.mu.Unlock()
+
+	for _, tag := range tags {
+		for key := range lru.tags[tag] {
+			lru.remove(lru.entries[key])
+		}
+	}
+}
+
+func (lru *LRU) Len() int {
+	lru.mu.Lock()
+	defer lru.mu.Unlock()
+	return lru.order.Len()
+}
+
+func (lru *LRU) remove(el *list.Element) {
+	ent := lru.order.Remove(el).(*entry)
+	delete(lru.entries, ent.key)
+	for _, tag := range ent.tags {
+		delete(lru.tags[tag], ent.key)
+		if len(lru.tags[tag]) == 0 {
+			delete(lru.tags, tag)
+		}
+	}
+}
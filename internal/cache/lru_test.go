@@ -0,0 +1,65 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLRU(t *testing.T) {
+	ctx := context.Background()
+	res := Result{Rows: [][][]byte{{[]byte("1")}}, CommandTag: "SELECT 1"}
+
+	t.Run("should be able to get result until it is expired", func(t *testing.T) {
+		now := time.Now()
+		lru := NewLRU(0)
+		lru.now = func() time.Time { return now }
+		lru.Set(ctx, "key", res, time.Second, nil)
+
+		out, ok := lru.Get(ctx, "key")
+		require.True(t, ok)
+		assert.Equal(t, res, out)
+
+		now = now.Add(time.Second)
+		_, ok = lru.Get(ctx, "key")
+		assert.False(t, ok)
+		assert.Zero(t, lru.Len())
+	})
+
+	t.Run("should be able to evict least recently used result", func(t *testing.T) {
+		lru := NewLRU(2)
+		lru.Set(ctx, "first", res, time.Minute, nil)
+		lru.Set(ctx, "second", res, time.Minute, nil)
+		_, ok := lru.Get(ctx, "first")
+		require.True(t, ok)
+
+		lru.Set(ctx, "third", res, time.Minute, nil)
+		assert.Equal(t, 2, lru.Len())
+		_, ok = lru.Get(ctx, "second")
+		assert.False(t, ok)
+		_, ok = lru.Get(ctx, "first")
+		assert.True(t, ok)
+	})
+
+	t.Run("should be able to invalidate results by tags", func(t *testing.T) {
+		lru := NewLRU(10)
+		for i := range 3 {
+			lru.Set(ctx, fmt.Sprint("users", i), res, time.Minute, []string{"users"})
+		}
+		lru.Set(ctx, "orders", res, time.Minute, []string{"orders", "users"})
+		lru.Set(ctx, "orders", res, time.Minute, []string{"orders"})
+		lru.Set(ctx, "plain", res, time.Minute, nil)
+
+		lru.Invalidate(ctx, []string{"users", "unknown"})
+		assert.Equal(t, 2, lru.Len())
+		assert.Empty(t, lru.tags["users"])
+
+		lru.Invalidate(ctx, []string{"orders"})
+		assert.Equal(t, 1, lru.Len())
+		assert.Empty(t, lru.tags)
+	})
+}
@@ -0,0 +1,149 @@
+package cache
+
+import (
+	"errors"
+	"slices"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var ErrRowsClosed = errors.New("cached rows are closed")
+
+// collect reads rows into result, raw values are copied, because rows reuse their buffers.
+func collect(rows pgx.Rows) (Result, error) {
+	defer rows.Close()
+
+	var res Result
+	for rows.Next() {
+		raw := rows.RawValues()
+		row := make([][]byte, len(raw))
+		for i, val := range raw {
+			if val != nil {
+				row[i] = slices.Clone(val)
+			}
+		}
+		res.Rows = append(res.Rows, row)
+	}
+	if err := rows.Err(); err != nil {
+		return Result{}, err
+	}
+	res.Fields = slices.Clone(rows.FieldDescriptions())
+	res.CommandTag = rows.CommandTag().String()
+	return res, nil
+}
+
+// cachedRows replays result, values are decoded by default types of pgx.
+type cachedRows struct {
+	res     Result
+	typeMap *pgtype.Map
+	row     int
+	closed  bool
+	err     error
+}
+
+func newRows(res Result) *cachedRows {
+	return &cachedRows{res: res, typeMap: pgtype.NewMap(), row: -1}
+}
+
+func (r *cachedRows) Close() {
+	r.closed = true
+}
+
+func (r *cachedRows) Err() error {
+	return r.err
+}
+
+func (r *cachedRows) CommandTag() pgconn.CommandTag {
+	return pgconn.NewCommandTag(r.res.CommandTag)
+}
+
+func (r *cachedRows) FieldDescriptions() []pgconn.FieldDescription {
+	return r.res.Fields
+}
+
+func (r *cachedRows) Next() bool {
+	if r.closed {
+		return false
+	}
+	r.row++
+	if r.row >= len(r.res.Rows) {
+		r.closed = true
+		return false
+	}
+	return true
+}
+
+func (r *cachedRows) Scan(dest ...any) error {
+	if r.closed {
+		return ErrRowsClosed
+	}
+	if err := pgx.ScanRow(r.typeMap, r.res.Fields, r.res.Rows[r.row], dest...); err != nil {
+		r.fail(err)
+		return err
+	}
+	return nil
+}
+
+func (r *cachedRows) Values() ([]any, error) {
+	if r.closed {
+		return nil, ErrRowsClosed
+	}
+	values := make([]any, 0, len(r.res.Fields))
+	for i, fd := range r.res.Fields {
+		buf := r.res.Rows[r.row][i]
+		if buf == nil {
+			values = append(values, nil)
+			continue
+		}
+		dt, ok := r.typeMap.TypeForOID(fd.DataTypeOID)
+		if !ok {
+			if fd.Format == pgx.TextFormatCode {
+				values = append(values, string(buf))
+			} else {
+				values = append(values, slices.Clone(buf))
+			}
+			continue
+		}
+		val, err := dt.Codec.DecodeValue(r.typeMap, fd.DataTypeOID, fd.Format, buf)
+		if err != nil {
+			r.fail(err)
+			return nil, err
+		}
+		values = append(values, val)
+	}
+	return values, nil
+}
+
+func (r *cachedRows) RawValues() [][]byte {
+	if r.closed {
+		return nil
+	}
+	return r.res.Rows[r.row]
+}
+
+// Conn is nil, because cached rows are not read from connection.
+func (r *cachedRows) Conn() *pgx.Conn {
+	return nil
+}
+
+func (r *cachedRows) fail(err error) {
+	r.err = err
+	r.closed = true
+}
+
+type cachedRow struct {
+	rows pgx.Rows
+}
+
+func (r cachedRow) Scan(dest ...any) error {
+	defer r.rows.Close()
+	if !r.rows.Next() {
+		if err := r.rows.Err(); err != nil {
+			return err
+		}
+		return pgx.ErrNoRows
+	}
+	return r.rows.Scan(dest...)
+}
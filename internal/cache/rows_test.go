@@ -0,0 +1,106 @@
+package cache
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var fields = []pgconn.FieldDescription{
+	{Name: "id", DataTypeOID: pgtype.Int4OID, Format: pgx.TextFormatCode},
+	{Name: "name", DataTypeOID: pgtype.TextOID, Format: pgx.TextFormatCode},
+}
+
+func TestCollect(t *testing.T) {
+	t.Run("should be able to copy raw values of rows", func(t *testing.T) {
+		rows := NewMockRows(t)
+		buf := []byte("1")
+		rows.EXPECT().Next().Return(true).Once()
+		rows.EXPECT().Next().Return(false).Once()
+		rows.EXPECT().RawValues().Return([][]byte{buf, nil})
+		rows.EXPECT().Err().Return(nil)
+		rows.EXPECT().FieldDescriptions().Return(fields)
+		rows.EXPECT().CommandTag().Return(pgconn.NewCommandTag("SELECT 1"))
+		rows.EXPECT().Close().Return()
+
+		res, err := collect(rows)
+		require.NoError(t, err)
+		buf[0] = '2'
+		assert.Equal(t, Result{Fields: fields, Rows: [][][]byte{{[]byte("1"), nil}}, CommandTag: "SELECT 1"}, res)
+	})
+
+	t.Run("should be able to fail by error of rows", func(t *testing.T) {
+		rows := NewMockRows(t)
+		expErr := errors.New(uuid.NewString())
+		rows.EXPECT().Next().Return(false)
+		rows.EXPECT().Err().Return(expErr)
+		rows.EXPECT().Close().Return()
+
+		_, err := collect(rows)
+		require.ErrorIs(t, err, expErr)
+	})
+}
+
+func TestCachedRows(t *testing.T) {
+	res := Result{
+		Fields:     fields,
+		Rows:       [][][]byte{{[]byte("1"), []byte("alice")}, {[]byte("2"), nil}},
+		CommandTag: "SELECT 2",
+	}
+
+	t.Run("should be able to replay rows", func(t *testing.T) {
+		type user struct {
+			ID   int32
+			Name *string
+		}
+		out, err := pgx.CollectRows(newRows(res), pgx.RowToStructByName[user])
+		require.NoError(t, err)
+		require.Len(t, out, 2)
+		assert.Equal(t, int32(1), out[0].ID)
+		assert.Equal(t, "alice", *out[0].Name)
+		assert.Nil(t, out[1].Name)
+	})
+
+	t.Run("should be able to return values", func(t *testing.T) {
+		rows := newRows(res)
+		assert.Equal(t, fields, rows.FieldDescriptions())
+		assert.Equal(t, "SELECT 2", rows.CommandTag().String())
+		assert.Nil(t, rows.Conn())
+
+		require.True(t, rows.Next())
+		values, err := rows.Values()
+		require.NoError(t, err)
+		assert.Equal(t, []any{int32(1), "alice"}, values)
+		assert.Equal(t, res.Rows[0], rows.RawValues())
+
+		require.True(t, rows.Next())
+		require.False(t, rows.Next())
+		require.NoError(t, rows.Err())
+		require.ErrorIs(t, rows.Scan(), ErrRowsClosed)
+		_, err = rows.Values()
+		require.ErrorIs(t, err, ErrRowsClosed)
+		assert.Nil(t, rows.RawValues())
+	})
+
+	t.Run("should be able to fail and close rows at scan error", func(t *testing.T) {
+		rows := newRows(res)
+		require.True(t, rows.Next())
+		var id uuid.UUID
+		require.Error(t, rows.Scan(&id, new(string)))
+		require.Error(t, rows.Err())
+		assert.False(t, rows.Next())
+	})
+
+	t.Run("should be able to scan first row", func(t *testing.T) {
+		var id int
+		require.NoError(t, cachedRow{rows: newRows(res)}.Scan(&id, new(*string)))
+		assert.Equal(t, 1, id)
+		require.ErrorIs(t, cachedRow{rows: newRows(Result{Fields: fields})}.Scan(), pgx.ErrNoRows)
+	})
+}
@@ -0,0 +1,320 @@
+// Package chaos implements fault-injection decorator for elephant pools. Injector holds rules, which are matched
+// by metrics label, SQL pattern, shard ID or cluster role of decorated node, and injects latency, connection
+// errors, PostgreSQL errors, commit failures or partial rows iteration failures with given probability.
+// Rules can be replaced and injection can be switched off at runtime.
+//
+//go:generate go tool mockery
+package chaos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"regexp"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/godepo/elephant/internal/pkg/pgcontext"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// ErrInjected is wrapped by every error produced by injector.
+var ErrInjected = errors.New("chaos: injected fault")
+
+const injectedMessage = "injected by chaos"
+
+type Role string
+
+const (
+	RoleLeader   Role = "leader"
+	RoleFollower Role = "follower"
+)
+
+type kind int
+
+const (
+	kindLatency kind = iota + 1
+	kindConnection
+	kindPgError
+	kindCommit
+	kindRows
+)
+
+// Fault describes what is injected when rule fires.
+type Fault struct {
+	kind      kind
+	latency   time.Duration
+	code      string
+	afterRows int
+}
+
+// Latency delays statement, begin or commit by d. Delay is interrupted when context is done.
+func Latency(d time.Duration) Fault {
+	return Fault{kind: kindLatency, latency: d}
+}
+
+// ConnectionError fails statement or begin with connection reset error, statement is not sent to wrapped pool.
+func ConnectionError() Fault {
+	return Fault{kind: kindConnection}
+}
+
+// PgError fails statement or begin with *pgconn.PgError of given SQLSTATE code.
+func PgError(code string) Fault {
+	return Fault{kind: kindPgError, code: code}
+}
+
+// CommitFailure rolls back transaction and fails commit with pgx.ErrTxCommitRollback.
+func CommitFailure() Fault {
+	return Fault{kind: kindCommit}
+}
+
+// RowsFailure breaks rows iteration by connection error after given count of rows was read.
+func RowsFailure(afterRows int) Fault {
+	return Fault{kind: kindRows, afterRows: afterRows}
+}
+
+// Rule fires Fault with Probability when all of its non-empty matchers are matched. Probability 1 and above
+// fires every time, zero never fires.
+type Rule struct {
+	// Labels matches when any of metrics labels from context is in list.
+	Labels []string
+	// SQL matches statement text. Begin and commit are matched as "BEGIN" and "COMMIT".
+	SQL *regexp.Regexp
+	// Shards matches shard ID of decorated node, or shard ID from context when node has no shard.
+	Shards []uint
+	// Roles matches cluster role of decorated node.
+	Roles       []Role
+	Probability float64
+	Fault       Fault
+}
+
+type Config struct {
+	rules    []Rule
+	random   func() float64
+	disabled bool
+}
+
+type Option func(cfg *Config)
+
+// WithRules sets rules which injector starts with.
+func WithRules(rules ...Rule) Option {
+	return func(cfg *Config) {
+		cfg.rules = rules
+	}
+}
+
+// WithRandom replaces source of random numbers in [0, 1), which are compared with rule probability.
+func WithRandom(random func() float64) Option {
+	return func(cfg *Config) {
+		cfg.random = random
+	}
+}
+
+// WithDisabled makes injector start switched off until Enable is called.
+func WithDisabled() Option {
+	return func(cfg *Config) {
+		cfg.disabled = true
+	}
+}
+
+// Injector holds rules shared by every node it wraps.
+type Injector struct {
+	enabled atomic.Bool
+	mu      sync.RWMutex
+	rules   []Rule
+	random  func() float64
+}
+
+func New(opts ...Option) *Injector {
+	cfg := Config{
+		random: rand.Float64,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	inj := &Injector{
+		rules:  slices.Clone(cfg.rules),
+		random: cfg.random,
+	}
+	inj.enabled.Store(!cfg.disabled)
+	return inj
+}
+
+// Enable switches injection on.
+func (inj *Injector) Enable() {
+	inj.enabled.Store(true)
+}
+
+// Disable switches injection off, decorated pools pass everything through to wrapped ones.
+func (inj *Injector) Disable() {
+	inj.enabled.Store(false)
+}
+
+func (inj *Injector) Enabled() bool {
+	return inj.enabled.Load()
+}
+
+// SetRules replaces rules of injector.
+func (inj *Injector) SetRules(rules ...Rule) {
+	inj.mu.Lock()
+	defer inj.mu.Unlock()
+	inj.rules = slices.Clone(rules)
+}
+
+func (inj *Injector) Rules() []Rule {
+	inj.mu.RLock()
+	defer inj.mu.RUnlock()
+	return slices.Clone(inj.rules)
+}
+
+type node struct {
+	role    Role
+	shardID uint
+	sharded bool
+}
+
+type NodeOption func(n *node)
+
+// WithRole sets cluster role of decorated node.
+func WithRole(role Role) NodeOption {
+	return func(n *node) {
+		n.role = role
+	}
+}
+
+// WithShard sets shard ID of decorated node.
+func WithShard(id uint) NodeOption {
+	return func(n *node) {
+		n.shardID = id
+		n.sharded = true
+	}
+}
+
+// fire returns faults of rules which are matched and fired for statement.
+func (inj *Injector) fire(ctx context.Context, n node, query string) []Fault {
+	if !inj.Enabled() {
+		return nil
+	}
+
+	inj.mu.RLock()
+	defer inj.mu.RUnlock()
+
+	var faults []Fault
+	for _, rule := range inj.rules {
+		if !rule.match(ctx, n, query) {
+			continue
+		}
+		if rule.Probability < 1 && inj.random() >= rule.Probability {
+			continue
+		}
+		faults = append(faults, rule.Fault)
+	}
+	return faults
+}
+
+func (rule Rule) match(ctx context.Context, n node, query string) bool {
+	if len(rule.Labels) > 0 {
+		labels, _ := pgcontext.MetricsLabelsFrom(ctx)
+		if !slices.ContainsFunc(labels, func(label string) bool {
+			return slices.Contains(rule.Labels, label)
+		}) {
+			return false
+		}
+	}
+	if rule.SQL != nil && !rule.SQL.MatchString(query) {
+		return false
+	}
+	if len(rule.Shards) > 0 {
+		id, ok := n.shardID, n.sharded
+		if !ok {
+			id, ok = pgcontext.ShardIDFrom(ctx)
+		}
+		if !ok || !slices.Contains(rule.Shards, id) {
+			return false
+		}
+	}
+	if len(rule.Roles) > 0 && !slices.Contains(rule.Roles, n.role) {
+		return false
+	}
+	return true
+}
+
+// inject sleeps for latency faults and returns first error of error faults, which are kept of given kinds.
+func inject(ctx context.Context, faults []Fault, kinds ...kind) error {
+	var out error
+	for _, fault := range faults {
+		if !slices.Contains(kinds, fault.kind) {
+			continue
+		}
+		switch fault.kind {
+		case kindLatency:
+			if err := sleep(ctx, fault.latency); err != nil {
+				return err
+			}
+		case kindConnection:
+			out = firstErr(out, connectionError())
+		case kindPgError:
+			out = firstErr(out, pgError(fault.code))
+		case kindCommit:
+			out = firstErr(out, commitError())
+		case kindRows:
+		}
+	}
+	return out
+}
+
+func firstErr(prev, next error) error {
+	if prev != nil {
+		return prev
+	}
+	return next
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func connectionError() error {
+	return fmt.Errorf("%w: %w", ErrInjected, &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET})
+}
+
+func pgError(code string) error {
+	return fmt.Errorf("%w: %w", ErrInjected, &pgconn.PgError{
+		Severity: "ERROR",
+		Code:     code,
+		Message:  injectedMessage,
+	})
+}
+
+func commitError() error {
+	return fmt.Errorf("%w: %w", ErrInjected, pgx.ErrTxCommitRollback)
+}
+
+// rowsFault returns smallest count of rows after which iteration is broken.
+func rowsFault(faults []Fault) (int, bool) {
+	after, found := 0, false
+	for _, fault := range faults {
+		if fault.kind != kindRows {
+			continue
+		}
+		if !found || fault.afterRows < after {
+			after, found = fault.afterRows, true
+		}
+	}
+	return after, found
+}
@@ -0,0 +1,163 @@
+package chaos
+
+import (
+	"context"
+	"errors"
+	"net"
+	"regexp"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/godepo/elephant/internal/pkg/pgcontext"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRule_match(t *testing.T) {
+	label := uuid.NewString()
+	labeled := pgcontext.With(context.Background(), pgcontext.WithMetricsLabel(uuid.NewString(), label))
+
+	cases := []struct {
+		name string
+		rule Rule
+		ctx  context.Context
+		node node
+		sql  string
+		want bool
+	}{
+		{name: "empty rule matches everything", ctx: context.Background(), want: true},
+		{name: "label in context", rule: Rule{Labels: []string{label}}, ctx: labeled, want: true},
+		{name: "label not in context", rule: Rule{Labels: []string{label}}, ctx: context.Background()},
+		{
+			name: "sql pattern", rule: Rule{SQL: regexp.MustCompile(`(?i)^select`)},
+			ctx: context.Background(), sql: "SELECT 1", want: true,
+		},
+		{
+			name: "sql pattern mismatch", rule: Rule{SQL: regexp.MustCompile(`(?i)^update`)},
+			ctx: context.Background(), sql: "SELECT 1",
+		},
+		{
+			name: "shard of node", rule: Rule{Shards: []uint{2}},
+			ctx: context.Background(), node: node{shardID: 2, sharded: true}, want: true,
+		},
+		{
+			name: "shard of node wins over context", rule: Rule{Shards: []uint{2}},
+			ctx: pgcontext.With(context.Background(), pgcontext.WithShardID(2)), node: node{shardID: 1, sharded: true},
+		},
+		{
+			name: "shard from context", rule: Rule{Shards: []uint{2}},
+			ctx: pgcontext.With(context.Background(), pgcontext.WithShardID(2)), want: true,
+		},
+		{name: "shard is unknown", rule: Rule{Shards: []uint{0}}, ctx: context.Background()},
+		{
+			name: "role of node", rule: Rule{Roles: []Role{RoleFollower}},
+			ctx: context.Background(), node: node{role: RoleFollower}, want: true,
+		},
+		{
+			name: "role mismatch", rule: Rule{Roles: []Role{RoleFollower}},
+			ctx: context.Background(), node: node{role: RoleLeader},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run("should be able to match "+tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, tc.rule.match(tc.ctx, tc.node, tc.sql))
+		})
+	}
+}
+
+func TestInjector(t *testing.T) {
+	t.Run("should be able to fire rules by probability", func(t *testing.T) {
+		random := 0.5
+		inj := New(
+			WithRules(
+				Rule{Probability: 0.4, Fault: ConnectionError()},
+				Rule{Probability: 0.6, Fault: PgError("40001")},
+				Rule{Fault: CommitFailure()},
+			),
+			WithRandom(func() float64 { return random }),
+		)
+
+		assert.Equal(t, []Fault{PgError("40001")}, inj.fire(context.Background(), node{}, ""))
+	})
+
+	t.Run("should be able to switch injection at runtime", func(t *testing.T) {
+		inj := New(WithRules(Rule{Probability: 1, Fault: ConnectionError()}), WithDisabled())
+		assert.False(t, inj.Enabled())
+		assert.Empty(t, inj.fire(context.Background(), node{}, ""))
+
+		inj.Enable()
+		assert.True(t, inj.Enabled())
+		assert.Len(t, inj.fire(context.Background(), node{}, ""), 1)
+
+		inj.Disable()
+		assert.Empty(t, inj.fire(context.Background(), node{}, ""))
+	})
+
+	t.Run("should be able to replace rules at runtime", func(t *testing.T) {
+		inj := New()
+		assert.Empty(t, inj.fire(context.Background(), node{}, ""))
+
+		rule := Rule{Probability: 1, Fault: Latency(time.Millisecond)}
+		inj.SetRules(rule)
+		assert.Equal(t, []Rule{rule}, inj.Rules())
+		assert.Equal(t, []Fault{rule.Fault}, inj.fire(context.Background(), node{}, ""))
+	})
+}
+
+func TestInject(t *testing.T) {
+	t.Run("should be able to return connection error", func(t *testing.T) {
+		err := inject(context.Background(), []Fault{ConnectionError()}, kindConnection)
+		require.ErrorIs(t, err, ErrInjected)
+		require.ErrorIs(t, err, syscall.ECONNRESET)
+		var opErr *net.OpError
+		assert.ErrorAs(t, err, &opErr)
+	})
+
+	t.Run("should be able to return pg error with code", func(t *testing.T) {
+		err := inject(context.Background(), []Fault{PgError("57P01"), ConnectionError()}, kindPgError, kindConnection)
+		var pgErr *pgconn.PgError
+		require.ErrorAs(t, err, &pgErr)
+		assert.Equal(t, "57P01", pgErr.Code)
+	})
+
+	t.Run("should be able to return commit error", func(t *testing.T) {
+		err := inject(context.Background(), []Fault{CommitFailure()}, kindCommit)
+		require.ErrorIs(t, err, ErrInjected)
+		require.ErrorIs(t, err, pgx.ErrTxCommitRollback)
+	})
+
+	t.Run("should be able to skip faults of other kinds", func(t *testing.T) {
+		require.NoError(t, inject(context.Background(), []Fault{CommitFailure(), RowsFailure(0)}, kindConnection))
+	})
+
+	t.Run("should be able to sleep for latency", func(t *testing.T) {
+		begin := time.Now()
+		require.NoError(t, inject(context.Background(), []Fault{Latency(10 * time.Millisecond)}, kindLatency))
+		assert.GreaterOrEqual(t, time.Since(begin), 10*time.Millisecond)
+	})
+
+	t.Run("should be able to interrupt latency by context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		err := inject(ctx, []Fault{Latency(time.Hour)}, kindLatency)
+		require.True(t, errors.Is(err, context.Canceled))
+	})
+}
+
+func TestRowsFault(t *testing.T) {
+	t.Run("should be able to pick earliest rows failure", func(t *testing.T) {
+		after, ok := rowsFault([]Fault{ConnectionError(), RowsFailure(3), RowsFailure(1)})
+		require.True(t, ok)
+		assert.Equal(t, 1, after)
+	})
+
+	t.Run("should be able to report absence of rows failure", func(t *testing.T) {
+		_, ok := rowsFault([]Fault{ConnectionError()})
+		assert.False(t, ok)
+	})
+}
@@ -0,0 +1,224 @@
+package chaos
+
+import (
+	"context"
+
+	"github.com/godepo/elephant/internal/notify"
+	"github.com/godepo/elephant/internal/pkg/failure"
+	"github.com/godepo/elephant/internal/pkg/passthrough"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	beginStatement  = "BEGIN"
+	commitStatement = "COMMIT"
+	copyStatement   = "COPY"
+)
+
+// Pool interface defines database operations of wrapped pool.
+type Pool interface {
+	BeginTx(ctx context.Context, opts pgx.TxOptions) (pgx.Tx, error)
+	Begin(ctx context.Context) (pgx.Tx, error)
+	Query(ctx context.Context, query string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, query string, args ...interface{}) pgx.Row
+	Exec(ctx context.Context, query string, args ...interface{}) (pgconn.CommandTag, error)
+	Transactional(ctx context.Context, fn func(ctx context.Context) error) (out error)
+}
+
+// DB is pool decorated by injector. Batches, subscriptions and advisory locks are passed to wrapped pool without
+// faults.
+type DB struct {
+	passthrough.Delegate
+	inj  *Injector
+	db   Pool
+	node node
+}
+
+// Wrap decorates db by injector. Role and shard of node are matched by rules, so every member of cluster or
+// sharded pool should be wrapped separately before it is added to topology.
+func (inj *Injector) Wrap(db Pool, opts ...NodeOption) *DB {
+	out := &DB{
+		Delegate: passthrough.New(db),
+		inj:      inj,
+		db:       db,
+	}
+	for _, opt := range opts {
+		opt(&out.node)
+	}
+	return out
+}
+
+func (d *DB) begin(ctx context.Context, begin func() (pgx.Tx, error)) (pgx.Tx, error) {
+	faults := d.inj.fire(ctx, d.node, beginStatement)
+	if err := inject(ctx, faults, kindLatency, kindConnection, kindPgError); err != nil {
+		return nil, err
+	}
+	tx, err := begin()
+	if err != nil {
+		return nil, err
+	}
+	return &chaosTx{Tx: tx, db: d}, nil
+}
+
+func (d *DB) Begin(ctx context.Context) (pgx.Tx, error) {
+	return d.begin(ctx, func() (pgx.Tx, error) {
+		return d.db.Begin(ctx)
+	})
+}
+
+func (d *DB) BeginTx(ctx context.Context, opts pgx.TxOptions) (pgx.Tx, error) {
+	return d.begin(ctx, func() (pgx.Tx, error) {
+		return d.db.BeginTx(ctx, opts)
+	})
+}
+
+// Query injects statement faults before query is sent and breaks iteration of returned rows by rows faults.
+func (d *DB) Query(ctx context.Context, query string, args ...interface{}) (pgx.Rows, error) {
+	faults := d.inj.fire(ctx, d.node, query)
+	if err := inject(ctx, faults, kindLatency, kindConnection, kindPgError); err != nil {
+		return nil, err
+	}
+	rows, err := d.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	if after, ok := rowsFault(faults); ok {
+		return &brokenRows{Rows: rows, left: after}, nil
+	}
+	return rows, nil
+}
+
+// QueryRow injects statement faults, rows failure after zero rows fails Scan.
+func (d *DB) QueryRow(ctx context.Context, query string, args ...interface{}) pgx.Row {
+	faults := d.inj.fire(ctx, d.node, query)
+	if err := inject(ctx, faults, kindLatency, kindConnection, kindPgError); err != nil {
+		return failure.Row(err)
+	}
+	row := d.db.QueryRow(ctx, query, args...)
+	if after, ok := rowsFault(faults); ok && after == 0 {
+		return brokenRow{row: row}
+	}
+	return row
+}
+
+func (d *DB) Exec(ctx context.Context, query string, args ...interface{}) (pgconn.CommandTag, error) {
+	faults := d.inj.fire(ctx, d.node, query)
+	if err := inject(ctx, faults, kindLatency, kindConnection, kindPgError); err != nil {
+		return pgconn.CommandTag{}, err
+	}
+	return d.db.Exec(ctx, query, args...)
+}
+
+// Transactional injects begin faults before transaction starts. Commit faults are injected after fn succeeded,
+// so wrapped pool rolls transaction back and returns injected error.
+func (d *DB) Transactional(ctx context.Context, fn func(ctx context.Context) error) (out error) {
+	faults := d.inj.fire(ctx, d.node, beginStatement)
+	if err := inject(ctx, faults, kindLatency, kindConnection, kindPgError); err != nil {
+		return err
+	}
+	return d.db.Transactional(ctx, func(ctx context.Context) error {
+		if err := fn(ctx); err != nil {
+			return err
+		}
+		return inject(ctx, d.inj.fire(ctx, d.node, commitStatement), kindLatency, kindCommit)
+	})
+}
+
+// Acquire delegates to wrapped pool, begin faults are injected before connection is acquired.
+func (d *DB) Acquire(ctx context.Context) (*pgxpool.Conn, error) {
+	acq, err := passthrough.AsAcquirer(d.db)
+	if err != nil {
+		return nil, err
+	}
+	faults := d.inj.fire(ctx, d.node, beginStatement)
+	if err := inject(ctx, faults, kindLatency, kindConnection); err != nil {
+		return nil, err
+	}
+	return acq.Acquire(ctx)
+}
+
+// CopyFrom injects statement faults, which are matched as "COPY".
+func (d *DB) CopyFrom(
+	ctx context.Context,
+	tableName pgx.Identifier,
+	columnNames []string,
+	rowSrc pgx.CopyFromSource,
+) (int64, error) {
+	bulk, err := passthrough.AsBulk(d.db)
+	if err != nil {
+		return 0, err
+	}
+	faults := d.inj.fire(ctx, d.node, copyStatement)
+	if err := inject(ctx, faults, kindLatency, kindConnection, kindPgError); err != nil {
+		return 0, err
+	}
+	return bulk.CopyFrom(ctx, tableName, columnNames, rowSrc)
+}
+
+// Notify sends notification through Exec, so statement faults are injected.
+func (d *DB) Notify(ctx context.Context, channel, payload string) error {
+	_, err := d.Exec(ctx, notify.Query, channel, payload)
+	return err
+}
+
+type chaosTx struct {
+	pgx.Tx
+	db *DB
+}
+
+func (tx *chaosTx) Begin(ctx context.Context) (pgx.Tx, error) {
+	return tx.db.begin(ctx, func() (pgx.Tx, error) {
+		return tx.Tx.Begin(ctx)
+	})
+}
+
+// Commit rolls transaction back when commit fault is fired.
+func (tx *chaosTx) Commit(ctx context.Context) error {
+	err := inject(ctx, tx.db.inj.fire(ctx, tx.db.node, commitStatement), kindLatency, kindCommit)
+	if err != nil {
+		_ = tx.Tx.Rollback(ctx)
+		return err
+	}
+	return tx.Tx.Commit(ctx)
+}
+
+type brokenRows struct {
+	pgx.Rows
+	left int
+	err  error
+}
+
+func (rows *brokenRows) Next() bool {
+	if rows.err != nil {
+		return false
+	}
+	if rows.left == 0 {
+		rows.err = connectionError()
+		rows.Rows.Close()
+		return false
+	}
+	if !rows.Rows.Next() {
+		return false
+	}
+	rows.left--
+	return true
+}
+
+func (rows *brokenRows) Err() error {
+	if rows.err != nil {
+		return rows.err
+	}
+	return rows.Rows.Err()
+}
+
+type brokenRow struct {
+	row pgx.Row
+}
+
+// Scan releases wrapped row and fails with connection error.
+func (row brokenRow) Scan(...any) error {
+	_ = row.row.Scan()
+	return connectionError()
+}
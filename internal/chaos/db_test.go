@@ -0,0 +1,287 @@
+package chaos
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/godepo/elephant/internal/pkg/passthrough"
+	"github.com/godepo/elephant/internal/pkg/pgcontext"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func always(fault Fault) Rule {
+	return Rule{Probability: 1, Fault: fault}
+}
+
+func TestDB_Query(t *testing.T) {
+	t.Run("should be able to fail query without sending it", func(t *testing.T) {
+		pool := NewMockPool(t)
+		db := New(WithRules(always(PgError("40001")))).Wrap(pool)
+
+		rows, err := db.Query(context.Background(), "SELECT 1")
+		var pgErr *pgconn.PgError
+		require.ErrorAs(t, err, &pgErr)
+		assert.Equal(t, "40001", pgErr.Code)
+		assert.Nil(t, rows)
+	})
+
+	t.Run("should be able to pass query when rule is not matched", func(t *testing.T) {
+		pool := NewMockPool(t)
+		rows := NewMockRows(t)
+		rule := always(ConnectionError())
+		rule.SQL = regexp.MustCompile(`^UPDATE`)
+		db := New(WithRules(rule)).Wrap(pool)
+		pool.EXPECT().Query(mock.Anything, "SELECT $1", []any{1}).Return(rows, nil)
+
+		out, err := db.Query(context.Background(), "SELECT $1", 1)
+		require.NoError(t, err)
+		assert.Equal(t, rows, out)
+	})
+
+	t.Run("should be able to break rows iteration", func(t *testing.T) {
+		pool := NewMockPool(t)
+		rows := NewMockRows(t)
+		db := New(WithRules(always(RowsFailure(1)))).Wrap(pool)
+		pool.EXPECT().Query(mock.Anything, "SELECT 1").Return(rows, nil)
+		rows.EXPECT().Next().Return(true).Once()
+		rows.EXPECT().Close().Return()
+
+		out, err := db.Query(context.Background(), "SELECT 1")
+		require.NoError(t, err)
+		assert.True(t, out.Next())
+		assert.False(t, out.Next())
+		assert.False(t, out.Next())
+		require.ErrorIs(t, out.Err(), ErrInjected)
+	})
+
+	t.Run("should be able to return wrapped rows error before failure", func(t *testing.T) {
+		pool := NewMockPool(t)
+		rows := NewMockRows(t)
+		expErr := errors.New(uuid.NewString())
+		db := New(WithRules(always(RowsFailure(5)))).Wrap(pool)
+		pool.EXPECT().Query(mock.Anything, "SELECT 1").Return(rows, nil)
+		rows.EXPECT().Next().Return(false)
+		rows.EXPECT().Err().Return(expErr)
+
+		out, err := db.Query(context.Background(), "SELECT 1")
+		require.NoError(t, err)
+		assert.False(t, out.Next())
+		require.ErrorIs(t, out.Err(), expErr)
+	})
+
+	t.Run("should be able to return error of wrapped pool", func(t *testing.T) {
+		pool := NewMockPool(t)
+		expErr := errors.New(uuid.NewString())
+		db := New(WithRules(always(RowsFailure(0)))).Wrap(pool)
+		pool.EXPECT().Query(mock.Anything, "SELECT 1").Return(nil, expErr)
+
+		_, err := db.Query(context.Background(), "SELECT 1")
+		require.ErrorIs(t, err, expErr)
+	})
+}
+
+func TestDB_QueryRow(t *testing.T) {
+	t.Run("should be able to fail scan by injected error", func(t *testing.T) {
+		db := New(WithRules(always(ConnectionError()))).Wrap(NewMockPool(t))
+
+		var out int
+		require.ErrorIs(t, db.QueryRow(context.Background(), "SELECT 1").Scan(&out), ErrInjected)
+	})
+
+	t.Run("should be able to release row and fail scan by rows failure", func(t *testing.T) {
+		pool := NewMockPool(t)
+		row := NewMockRow(t)
+		db := New(WithRules(always(RowsFailure(0)))).Wrap(pool)
+		pool.EXPECT().QueryRow(mock.Anything, "SELECT 1").Return(row)
+		row.EXPECT().Scan().Return(nil)
+
+		var out int
+		require.ErrorIs(t, db.QueryRow(context.Background(), "SELECT 1").Scan(&out), ErrInjected)
+	})
+
+	t.Run("should be able to return row of wrapped pool", func(t *testing.T) {
+		pool := NewMockPool(t)
+		row := NewMockRow(t)
+		db := New(WithRules(always(RowsFailure(1)))).Wrap(pool)
+		pool.EXPECT().QueryRow(mock.Anything, "SELECT 1").Return(row)
+
+		assert.Equal(t, row, db.QueryRow(context.Background(), "SELECT 1"))
+	})
+}
+
+func TestDB_Exec(t *testing.T) {
+	t.Run("should be able to fail exec on shard of node", func(t *testing.T) {
+		rule := always(ConnectionError())
+		rule.Shards = []uint{1}
+		inj := New(WithRules(rule))
+		first, second := NewMockPool(t), NewMockPool(t)
+		tag := pgconn.NewCommandTag("UPDATE 1")
+		first.EXPECT().Exec(mock.Anything, "UPDATE t").Return(tag, nil)
+
+		out, err := inj.Wrap(first, WithShard(0)).Exec(context.Background(), "UPDATE t")
+		require.NoError(t, err)
+		assert.Equal(t, tag, out)
+
+		_, err = inj.Wrap(second, WithShard(1)).Exec(context.Background(), "UPDATE t")
+		require.ErrorIs(t, err, ErrInjected)
+	})
+
+	t.Run("should be able to fail exec on role of node with label", func(t *testing.T) {
+		rule := always(PgError("57P01"))
+		rule.Roles = []Role{RoleLeader}
+		rule.Labels = []string{"billing"}
+		db := New(WithRules(rule)).Wrap(NewMockPool(t), WithRole(RoleLeader))
+		ctx := pgcontext.With(context.Background(), pgcontext.WithMetricsLabel("billing"))
+
+		_, err := db.Exec(ctx, "UPDATE t")
+		require.ErrorIs(t, err, ErrInjected)
+	})
+}
+
+func TestDB_Begin(t *testing.T) {
+	t.Run("should be able to fail begin", func(t *testing.T) {
+		rule := always(ConnectionError())
+		rule.SQL = regexp.MustCompile(`^BEGIN$`)
+		db := New(WithRules(rule)).Wrap(NewMockPool(t))
+
+		_, err := db.Begin(context.Background())
+		require.ErrorIs(t, err, ErrInjected)
+		_, err = db.BeginTx(context.Background(), pgx.TxOptions{})
+		require.ErrorIs(t, err, ErrInjected)
+	})
+
+	t.Run("should be able to return error of wrapped pool", func(t *testing.T) {
+		pool := NewMockPool(t)
+		expErr := errors.New(uuid.NewString())
+		db := New().Wrap(pool)
+		pool.EXPECT().Begin(mock.Anything).Return(nil, expErr)
+
+		_, err := db.Begin(context.Background())
+		require.ErrorIs(t, err, expErr)
+	})
+
+	t.Run("should be able to roll back and fail commit", func(t *testing.T) {
+		pool := NewMockPool(t)
+		tx := NewMockTx(t)
+		db := New(WithRules(always(CommitFailure()))).Wrap(pool)
+		pool.EXPECT().BeginTx(mock.Anything, pgx.TxOptions{}).Return(tx, nil)
+		tx.EXPECT().Rollback(mock.Anything).Return(nil)
+
+		out, err := db.BeginTx(context.Background(), pgx.TxOptions{})
+		require.NoError(t, err)
+		require.ErrorIs(t, out.Commit(context.Background()), pgx.ErrTxCommitRollback)
+	})
+
+	t.Run("should be able to commit nested transaction", func(t *testing.T) {
+		pool := NewMockPool(t)
+		tx := NewMockTx(t)
+		nested := NewMockTx(t)
+		db := New().Wrap(pool)
+		pool.EXPECT().Begin(mock.Anything).Return(tx, nil)
+		tx.EXPECT().Begin(mock.Anything).Return(nested, nil)
+		nested.EXPECT().Commit(mock.Anything).Return(nil)
+
+		out, err := db.Begin(context.Background())
+		require.NoError(t, err)
+		sp, err := out.Begin(context.Background())
+		require.NoError(t, err)
+		require.NoError(t, sp.Commit(context.Background()))
+	})
+}
+
+func TestDB_Transactional(t *testing.T) {
+	passThrough := func(ctx context.Context, fn func(ctx context.Context) error) error {
+		return fn(ctx)
+	}
+
+	t.Run("should be able to fail before transaction starts", func(t *testing.T) {
+		db := New(WithRules(always(ConnectionError()))).Wrap(NewMockPool(t))
+
+		err := db.Transactional(context.Background(), func(context.Context) error {
+			t.Fatal("fn must not be called")
+			return nil
+		})
+		require.ErrorIs(t, err, ErrInjected)
+	})
+
+	t.Run("should be able to fail commit after fn", func(t *testing.T) {
+		pool := NewMockPool(t)
+		db := New(WithRules(always(CommitFailure()))).Wrap(pool)
+		pool.EXPECT().Transactional(mock.Anything, mock.Anything).RunAndReturn(passThrough)
+
+		var called bool
+		err := db.Transactional(context.Background(), func(context.Context) error {
+			called = true
+			return nil
+		})
+		require.ErrorIs(t, err, pgx.ErrTxCommitRollback)
+		assert.True(t, called)
+	})
+
+	t.Run("should be able to return error of fn", func(t *testing.T) {
+		pool := NewMockPool(t)
+		expErr := errors.New(uuid.NewString())
+		db := New(WithRules(always(CommitFailure()))).Wrap(pool)
+		pool.EXPECT().Transactional(mock.Anything, mock.Anything).RunAndReturn(passThrough)
+
+		err := db.Transactional(context.Background(), func(context.Context) error {
+			return expErr
+		})
+		require.ErrorIs(t, err, expErr)
+	})
+}
+
+type bulkPool struct {
+	*MockPool
+	*MockAcquirer
+	*MockBulkPool
+}
+
+func TestDB_Capabilities(t *testing.T) {
+	t.Run("should be able to inject faults into acquire and copy", func(t *testing.T) {
+		pool := bulkPool{MockPool: NewMockPool(t), MockAcquirer: NewMockAcquirer(t), MockBulkPool: NewMockBulkPool(t)}
+		db := New(WithRules(always(ConnectionError()))).Wrap(pool)
+
+		_, err := db.Acquire(context.Background())
+		require.ErrorIs(t, err, ErrInjected)
+		_, err = db.CopyFrom(context.Background(), pgx.Identifier{"t"}, []string{"id"}, pgx.CopyFromRows(nil))
+		require.ErrorIs(t, err, ErrInjected)
+	})
+
+	t.Run("should be able to delegate acquire and copy without faults", func(t *testing.T) {
+		pool := bulkPool{MockPool: NewMockPool(t), MockAcquirer: NewMockAcquirer(t), MockBulkPool: NewMockBulkPool(t)}
+		db := New().Wrap(pool)
+		ctx := context.Background()
+		expErr := errors.New(uuid.NewString())
+		pool.MockAcquirer.EXPECT().Acquire(ctx).Return(nil, expErr)
+		pool.MockBulkPool.EXPECT().CopyFrom(ctx, pgx.Identifier{"t"}, []string{"id"}, mock.Anything).Return(3, nil)
+
+		_, err := db.Acquire(ctx)
+		require.ErrorIs(t, err, expErr)
+		count, err := db.CopyFrom(ctx, pgx.Identifier{"t"}, []string{"id"}, pgx.CopyFromRows(nil))
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), count)
+	})
+
+	t.Run("should be able to inject faults into notify", func(t *testing.T) {
+		db := New(WithRules(always(ConnectionError()))).Wrap(NewMockPool(t))
+
+		require.ErrorIs(t, db.Notify(context.Background(), "events", "payload"), ErrInjected)
+	})
+
+	t.Run("should be able to fail when wrapped pool has no capabilities", func(t *testing.T) {
+		db := New().Wrap(NewMockPool(t))
+
+		_, err := db.Acquire(context.Background())
+		require.ErrorIs(t, err, passthrough.ErrAcquireNotSupported)
+		_, err = db.CopyFrom(context.Background(), pgx.Identifier{"t"}, []string{"id"}, pgx.CopyFromRows(nil))
+		require.ErrorIs(t, err, passthrough.ErrBulkNotSupported)
+	})
+}
@@ -0,0 +1,17 @@
+package cluster
+
+import (
+	"context"
+
+	"github.com/godepo/elephant/internal/pkg/passthrough"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Acquire takes dedicated connection at leader.
+func (cls *Cluster) Acquire(ctx context.Context) (*pgxpool.Conn, error) {
+	acq, err := passthrough.AsAcquirer(cls.leader)
+	if err != nil {
+		return nil, err
+	}
+	return acq.Acquire(ctx)
+}
@@ -0,0 +1,37 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/godepo/elephant/internal/pkg/passthrough"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type acquireLeader struct {
+	*MockPool
+	*MockAcquirer
+}
+
+func TestCluster_Acquire(t *testing.T) {
+	t.Run("should be able to acquire connection at leader", func(t *testing.T) {
+		acq := NewMockAcquirer(t)
+		cls := New(acquireLeader{MockPool: NewMockPool(t), MockAcquirer: acq}, []Pool{NewMockPool(t)})
+		expErr := errors.New(uuid.NewString())
+		acq.EXPECT().Acquire(context.Background()).Return(nil, expErr)
+
+		conn, err := cls.Acquire(context.Background())
+		require.ErrorIs(t, err, expErr)
+		assert.Nil(t, conn)
+	})
+
+	t.Run("should be able to fail when leader can't acquire connection", func(t *testing.T) {
+		cls := New(NewMockPool(t), []Pool{NewMockPool(t)})
+
+		_, err := cls.Acquire(context.Background())
+		require.ErrorIs(t, err, passthrough.ErrAcquireNotSupported)
+	})
+}
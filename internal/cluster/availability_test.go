@@ -0,0 +1,47 @@
+package cluster
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type guardedPool struct {
+	*MockPool
+	available bool
+}
+
+func (p guardedPool) Available() bool {
+	return p.available
+}
+
+func TestCluster_Availability(t *testing.T) {
+	t.Run("should be able to skip unavailable follower", func(t *testing.T) {
+		down, up := guardedPool{MockPool: NewMockPool(t)}, guardedPool{MockPool: NewMockPool(t), available: true}
+		cls := New(NewMockPool(t), []Pool{down, up, down})
+		ctx := context.Background()
+		up.EXPECT().Query(ctx, "SELECT 1").Return(emptyRows(t), nil).Times(3)
+		up.EXPECT().Transactional(ctx, mock.Anything).Return(nil)
+
+		for range 3 {
+			out, err := cls.Query(ctx, "SELECT 1")
+			require.NoError(t, err)
+			assert.False(t, out.Next())
+		}
+		require.NoError(t, cls.Transactional(ctx, func(context.Context) error { return nil }))
+	})
+
+	t.Run("should be able to read from leader when no follower is available", func(t *testing.T) {
+		leader := NewMockPool(t)
+		cls := New(leader, []Pool{guardedPool{MockPool: NewMockPool(t)}})
+		ctx := context.Background()
+		leader.EXPECT().Query(ctx, "SELECT 1").Return(emptyRows(t), nil)
+
+		out, err := cls.Query(ctx, "SELECT 1")
+		require.NoError(t, err)
+		assert.False(t, out.Next())
+	})
+}
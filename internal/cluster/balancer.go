@@ -1,6 +1,9 @@
 package cluster
 
-import "sync/atomic"
+import (
+	"math/rand/v2"
+	"sync/atomic"
+)
 
 type roundRobin struct {
 	next *atomic.Int64
@@ -20,3 +23,14 @@ func (r *roundRobin) Balance(fellows []Pool) Pool {
 	ix := int(r.next.Add(1)) % len(fellows)
 	return fellows[ix]
 }
+
+// RandomLoadBalancer picks follower at random, it spreads reads of many cluster clients more evenly than round
+// robin, which counters of clients start at same follower.
+func RandomLoadBalancer() LoadBalancer {
+	return func(fellows []Pool) Pool {
+		if len(fellows) == 0 {
+			return nil
+		}
+		return fellows[rand.IntN(len(fellows))]
+	}
+}
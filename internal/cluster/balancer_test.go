@@ -71,3 +71,19 @@ func TestDefaultLoadBalancer(t *testing.T) {
 		tc.State.Result = tc.SUT(tc.State.Fellows)
 	})
 }
+
+func TestRandomLoadBalancer(t *testing.T) {
+	t.Run("should be able to return nil at empty fellows list", func(t *testing.T) {
+		require.Nil(t, RandomLoadBalancer()(nil))
+	})
+
+	t.Run("should be able to pick every fellow", func(t *testing.T) {
+		fellows := []Pool{NewMockPool(t), NewMockPool(t)}
+		balancer := RandomLoadBalancer()
+		picked := map[Pool]bool{}
+		for range 200 {
+			picked[balancer(fellows)] = true
+		}
+		assert.Len(t, picked, 2)
+	})
+}
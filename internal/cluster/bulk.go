@@ -0,0 +1,39 @@
+package cluster
+
+import (
+	"context"
+
+	"github.com/godepo/elephant/internal/pkg/failure"
+	"github.com/godepo/elephant/internal/pkg/passthrough"
+	"github.com/godepo/elephant/internal/pkg/pgcontext"
+	"github.com/jackc/pgx/v5"
+)
+
+// bulkSelector returns transaction from context or leader, because bulk operations always write data.
+func (cls *Cluster) bulkSelector(ctx context.Context) (passthrough.BulkPool, error) {
+	if tx, ok := pgcontext.TransactionFrom(ctx); ok {
+		return tx, nil
+	}
+	return passthrough.AsBulk(cls.leader)
+}
+
+func (cls *Cluster) CopyFrom(
+	ctx context.Context,
+	tableName pgx.Identifier,
+	columnNames []string,
+	rowSrc pgx.CopyFromSource,
+) (int64, error) {
+	db, err := cls.bulkSelector(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return db.CopyFrom(ctx, tableName, columnNames, rowSrc)
+}
+
+func (cls *Cluster) SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults {
+	db, err := cls.bulkSelector(ctx)
+	if err != nil {
+		return failure.BatchResults(err)
+	}
+	return db.SendBatch(ctx, b)
+}
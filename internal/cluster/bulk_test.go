@@ -0,0 +1,75 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/godepo/elephant/internal/pkg/failure"
+	"github.com/godepo/elephant/internal/pkg/passthrough"
+	"github.com/godepo/elephant/internal/pkg/pgcontext"
+	"github.com/jackc/pgx/v5"
+	"github.com/jaswdr/faker/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type bulkLeader struct {
+	*MockPool
+	*MockBulkPool
+}
+
+func TestCluster_CopyFrom(t *testing.T) {
+	table := pgx.Identifier{"public", "users"}
+	columns := []string{"id", "name"}
+
+	t.Run("should be able to copy rows at leader", func(t *testing.T) {
+		bulk := NewMockBulkPool(t)
+		cls := New(bulkLeader{MockPool: NewMockPool(t), MockBulkPool: bulk}, []Pool{NewMockPool(t)})
+		src := pgx.CopyFromRows([][]any{{1, "first"}, {2, "second"}})
+		bulk.EXPECT().CopyFrom(mock.Anything, table, columns, src).Return(2, nil)
+
+		count, err := cls.CopyFrom(context.Background(), table, columns, src)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), count)
+	})
+
+	t.Run("should be able to copy rows at transaction from context", func(t *testing.T) {
+		tx := NewMockTx(t)
+		cls := New(NewMockPool(t), []Pool{NewMockPool(t)})
+		src := pgx.CopyFromRows([][]any{{1, "first"}})
+		ctx := pgcontext.With(context.Background(), pgcontext.WithTransaction(tx))
+		tx.EXPECT().CopyFrom(ctx, table, columns, src).Return(1, nil)
+
+		count, err := cls.CopyFrom(ctx, table, columns, src)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), count)
+	})
+
+	t.Run("should be able to fail when leader does not support bulk operations", func(t *testing.T) {
+		cls := New(NewMockPool(t), []Pool{NewMockPool(t)})
+
+		count, err := cls.CopyFrom(context.Background(), table, columns, pgx.CopyFromRows(nil))
+		require.ErrorIs(t, err, passthrough.ErrBulkNotSupported)
+		assert.Zero(t, count)
+	})
+}
+
+func TestCluster_SendBatch(t *testing.T) {
+	t.Run("should be able to send batch to leader", func(t *testing.T) {
+		bulk := NewMockBulkPool(t)
+		cls := New(bulkLeader{MockPool: NewMockPool(t), MockBulkPool: bulk}, []Pool{NewMockPool(t)})
+		batch := &pgx.Batch{}
+		expErr := errors.New(faker.New().RandomStringWithLength(10))
+		bulk.EXPECT().SendBatch(mock.Anything, batch).Return(failure.BatchResults(expErr))
+
+		assert.ErrorIs(t, cls.SendBatch(context.Background(), batch).Close(), expErr)
+	})
+
+	t.Run("should be able to fail when leader does not support bulk operations", func(t *testing.T) {
+		cls := New(NewMockPool(t), []Pool{NewMockPool(t)})
+
+		assert.ErrorIs(t, cls.SendBatch(context.Background(), &pgx.Batch{}).Close(), passthrough.ErrBulkNotSupported)
+	})
+}
@@ -3,7 +3,10 @@ package cluster
 
 import (
 	"context"
+	"slices"
 
+	"github.com/godepo/elephant/internal/pkg/failure"
+	"github.com/godepo/elephant/internal/pkg/named"
 	"github.com/godepo/elephant/internal/pkg/pgcontext"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
@@ -26,8 +29,16 @@ type DB interface {
 
 type LoadBalancer func(fellows []Pool) Pool
 
+// Availability is implemented by members which can be temporarily unavailable, like pools guarded by circuit
+// breaker. Load balancer picks from available followers only, reads go to leader when no follower is available.
+type Availability interface {
+	Available() bool
+}
+
 type Config struct {
-	loadBalancer LoadBalancer
+	loadBalancer   LoadBalancer
+	readRetries    int
+	hedgeCollector HedgeCollector
 }
 
 type Option func(opt *Config)
@@ -38,9 +49,18 @@ func WithLoadBalancer(balancer LoadBalancer) Option {
 	}
 }
 
+// WithReadRetries sets count of retries of read which failed with connection error, one retry is made by default
+// and zero disables retries.
+func WithReadRetries(retries int) Option {
+	return func(opt *Config) {
+		opt.readRetries = max(retries, 0)
+	}
+}
+
 func New(leader Pool, fellows []Pool, opts ...Option) *Cluster {
 	cfg := Config{
 		loadBalancer: DefaultLoadBalancer(),
+		readRetries:  defaultReadRetries,
 	}
 
 	for _, opt := range opts {
@@ -51,6 +71,7 @@ func New(leader Pool, fellows []Pool, opts ...Option) *Cluster {
 		leader:  leader,
 		fellows: fellows,
 		cfg:     cfg,
+		latency: &latencies{},
 	}
 }
 
@@ -58,6 +79,7 @@ type Cluster struct {
 	leader  Pool
 	fellows []Pool
 	cfg     Config
+	latency *latencies
 }
 
 func (cls *Cluster) selector(ctx context.Context) DB {
@@ -67,7 +89,27 @@ func (cls *Cluster) selector(ctx context.Context) DB {
 	if pgcontext.CanWriteFrom(ctx) {
 		return cls.leader
 	}
-	return cls.cfg.loadBalancer(cls.fellows)
+	return cls.follower()
+}
+
+func (cls *Cluster) follower() Pool {
+	fellows := cls.available()
+	if len(fellows) == 0 {
+		return cls.leader
+	}
+	return cls.cfg.loadBalancer(fellows)
+}
+
+func (cls *Cluster) available() []Pool {
+	if !slices.ContainsFunc(cls.fellows, unavailable) {
+		return cls.fellows
+	}
+	return slices.DeleteFunc(slices.Clone(cls.fellows), unavailable)
+}
+
+func unavailable(pool Pool) bool {
+	av, ok := pool.(Availability)
+	return ok && !av.Available()
 }
 
 func (cls *Cluster) BeginTx(ctx context.Context, opts pgx.TxOptions) (pgx.Tx, error) {
@@ -86,15 +128,62 @@ func (cls *Cluster) Begin(ctx context.Context) (pgx.Tx, error) {
 	return cls.leader.Begin(ctx)
 }
 
+// Query binds named parameters before routing, so every member receives positional query. Reads from followers
+// which failed with connection error are retried on other node, reads with hedging enabled by context are raced
+// at two followers. First row of retried read is read before rows are returned, so connection error of first row
+// is retried too, errors of later rows are returned by rows as is.
 func (cls *Cluster) Query(ctx context.Context, query string, args ...interface{}) (pgx.Rows, error) {
-	return cls.selector(ctx).Query(ctx, query, args...)
+	query, args, err := named.Bind(query, args)
+	if err != nil {
+		return nil, err
+	}
+	if delay, ok := cls.hedgeDelay(ctx); ok {
+		return cls.hedgedQuery(ctx, delay, query, args)
+	}
+	if !cls.canRetry(ctx) {
+		return cls.selector(ctx).Query(ctx, query, args...)
+	}
+
+	var rows pgx.Rows
+	err = cls.retryRead(ctx, func(db Pool) error {
+		res, err := db.Query(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		rows, err = peekRows(res)
+		return err
+	})
+	return rows, err
 }
 
+// QueryRow retries and hedges reads like Query, hedged reads are sent when row is scanned.
 func (cls *Cluster) QueryRow(ctx context.Context, query string, args ...interface{}) pgx.Row {
-	return cls.selector(ctx).QueryRow(ctx, query, args...)
+	query, args, err := named.Bind(query, args)
+	if err != nil {
+		return failure.Row(err)
+	}
+	if delay, ok := cls.hedgeDelay(ctx); ok {
+		return hedgedRow{cls: cls, ctx: ctx, delay: delay, query: query, args: args}
+	}
+	if !cls.canRetry(ctx) {
+		return cls.selector(ctx).QueryRow(ctx, query, args...)
+	}
+	node := cls.follower()
+	return retriedRow{
+		row:   node.QueryRow(ctx, query, args...),
+		node:  node,
+		ctx:   ctx,
+		cls:   cls,
+		query: query,
+		args:  args,
+	}
 }
 
 func (cls *Cluster) Exec(ctx context.Context, query string, args ...interface{}) (pgconn.CommandTag, error) {
+	query, args, err := named.Bind(query, args)
+	if err != nil {
+		return pgconn.CommandTag{}, err
+	}
 	return cls.selector(ctx).Exec(ctx, query, args...)
 }
 
@@ -103,5 +192,5 @@ func (cls *Cluster) Transactional(ctx context.Context, fn func(ctx context.Conte
 	if ok || pgcontext.CanWriteFrom(ctx) {
 		return cls.leader.Transactional(ctx, fn)
 	}
-	return cls.cfg.loadBalancer(cls.fellows).Transactional(ctx, fn)
+	return cls.follower().Transactional(ctx, fn)
 }
@@ -169,6 +169,10 @@ func ActQuery(fellowNum int) groat.When[Deps, State] {
 		default:
 			deps.Fellows[fellowNum].EXPECT().
 				Query(state.ctx, state.Expect.Query, state.Expect.Args).Return(deps.Rows, nil)
+			deps.Rows.EXPECT().Next().Return(false)
+			deps.Rows.EXPECT().Err().Return(nil)
+			state.Expect.Rows = &peekedRows{Rows: deps.Rows, peeked: true}
+			return state
 		}
 		state.Expect.Rows = deps.Rows
 		return state
@@ -273,5 +277,9 @@ func AssertRows(t *testing.T, state State) {
 
 func AssertRow(t *testing.T, state State) {
 	t.Helper()
+	if row, ok := state.Result.Row.(retriedRow); ok {
+		assert.Equal(t, state.Expect.Row, row.row)
+		return
+	}
 	assert.Equal(t, state.Expect.Row, state.Result.Row)
 }
@@ -0,0 +1,277 @@
+package cluster
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/godepo/elephant/internal/pkg/pgcontext"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	// defaultHedgeDelay is used instead of p95 until enough hedged reads are observed.
+	defaultHedgeDelay = 10 * time.Millisecond
+	hedgeWindow       = 256
+	hedgeMinSamples   = 20
+)
+
+// HedgeOutcome is reported to HedgeCollector for every hedged read.
+type HedgeOutcome string
+
+const (
+	// HedgeNotSent is outcome of read which returned first row before hedge delay.
+	HedgeNotSent HedgeOutcome = "not_sent"
+	// HedgePrimaryWon is outcome of read which was hedged, but first follower returned first row earlier.
+	HedgePrimaryWon HedgeOutcome = "primary_won"
+	// HedgeWon is outcome of read which was returned by second follower.
+	HedgeWon HedgeOutcome = "hedge_won"
+	// HedgeFailed is outcome of read which failed at every raced follower.
+	HedgeFailed HedgeOutcome = "failed"
+)
+
+// HedgeCollector receives outcome of every hedged read, it is implemented by metrics collector.
+type HedgeCollector interface {
+	TrackHedgedRead(ctx context.Context, outcome HedgeOutcome)
+}
+
+// WithHedgeCollector sets collector which receives outcome of every hedged read.
+func WithHedgeCollector(collector HedgeCollector) Option {
+	return func(opt *Config) {
+		opt.hedgeCollector = collector
+	}
+}
+
+// hedgeDelay returns delay of hedge, when hedged read is enabled by context for read outside transaction and
+// two followers at least are available.
+func (cls *Cluster) hedgeDelay(ctx context.Context) (time.Duration, bool) {
+	delay, ok := pgcontext.HedgedReadFrom(ctx)
+	if !ok || pgcontext.CanWriteFrom(ctx) {
+		return 0, false
+	}
+	if _, inTx := pgcontext.TransactionFrom(ctx); inTx || len(cls.available()) < 2 {
+		return 0, false
+	}
+	if delay <= 0 {
+		delay = cls.latency.p95()
+	}
+	return delay, true
+}
+
+type attempt struct {
+	idx    int
+	rows   pgx.Rows
+	has    bool
+	err    error
+	cancel context.CancelFunc
+}
+
+func (a attempt) release() {
+	if a.rows != nil {
+		a.rows.Close()
+	}
+	a.cancel()
+}
+
+// hedgedQuery sends query to follower picked by load balancer and, when first row is not returned by delay or
+// follower failed with connection error, to second follower. First returned rows win and other attempt is
+// cancelled. Read which failed at both followers with connection error is retried like other reads.
+func (cls *Cluster) hedgedQuery(ctx context.Context, delay time.Duration, query string, args []any) (pgx.Rows, error) {
+	results := make(chan attempt, 2)
+	var (
+		tried   []Pool
+		cancels []context.CancelFunc
+	)
+	start := func(node Pool) {
+		attemptCtx, cancel := context.WithCancel(ctx)
+		tried = append(tried, node)
+		cancels = append(cancels, cancel)
+		go cls.race(attemptCtx, attempt{idx: len(tried) - 1, cancel: cancel}, node, query, args, results)
+	}
+	hedge := func() {
+		if node := cls.untried(tried); node != nil {
+			start(node)
+		}
+	}
+
+	start(cls.follower())
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	var err error
+	for finished := 0; finished < len(tried); {
+		select {
+		case <-timer.C:
+			if len(tried) == 1 {
+				hedge()
+			}
+		case res := <-results:
+			finished++
+			if res.err == nil {
+				cls.abandon(cancels, res.idx, results, len(tried)-finished)
+				cls.trackHedge(ctx, outcome(res.idx, len(tried)))
+				return &hedgedRows{Rows: res.rows, primed: true, has: res.has, cancel: res.cancel}, nil
+			}
+			res.cancel()
+			err = res.err
+			if !IsConnectionError(err) || ctx.Err() != nil {
+				cls.abandon(cancels, res.idx, results, len(tried)-finished)
+				cls.trackHedge(ctx, HedgeFailed)
+				return nil, err
+			}
+			if len(tried) == 1 {
+				hedge()
+			}
+		}
+	}
+	cls.trackHedge(ctx, HedgeFailed)
+
+	var rows pgx.Rows
+	err = cls.retryReadAfter(ctx, tried, err, func(db Pool) error {
+		var err error
+		rows, err = db.Query(ctx, query, args...)
+		return err
+	})
+	return rows, err
+}
+
+// race runs query at node and waits for its first row, so attempts are raced by time to first row.
+func (cls *Cluster) race(
+	ctx context.Context,
+	res attempt,
+	node Pool,
+	query string,
+	args []any,
+	results chan<- attempt,
+) {
+	begin := time.Now()
+	res.rows, res.err = node.Query(ctx, query, args...)
+	if res.err == nil {
+		res.has = res.rows.Next()
+		if !res.has && res.rows.Err() != nil {
+			res.err = res.rows.Err()
+			res.rows.Close()
+			res.rows = nil
+		}
+	}
+	if res.err == nil {
+		cls.latency.observe(time.Since(begin))
+	}
+	results <- res
+}
+
+// abandon cancels attempts other than winner and releases their results when they are finished.
+func (cls *Cluster) abandon(cancels []context.CancelFunc, winner int, results <-chan attempt, pending int) {
+	for i, cancel := range cancels {
+		if i != winner {
+			cancel()
+		}
+	}
+	if pending == 0 {
+		return
+	}
+	go func() {
+		for range pending {
+			(<-results).release()
+		}
+	}()
+}
+
+func outcome(winner, attempts int) HedgeOutcome {
+	switch {
+	case attempts == 1:
+		return HedgeNotSent
+	case winner == 0:
+		return HedgePrimaryWon
+	default:
+		return HedgeWon
+	}
+}
+
+func (cls *Cluster) trackHedge(ctx context.Context, outcome HedgeOutcome) {
+	if cls.cfg.hedgeCollector != nil {
+		cls.cfg.hedgeCollector.TrackHedgedRead(ctx, outcome)
+	}
+}
+
+// hedgedRows replays first row, which is already fetched while attempts were raced.
+type hedgedRows struct {
+	pgx.Rows
+	primed bool
+	has    bool
+	cancel context.CancelFunc
+}
+
+func (r *hedgedRows) Next() bool {
+	if r.primed {
+		r.primed = false
+		return r.has
+	}
+	return r.Rows.Next()
+}
+
+func (r *hedgedRows) Close() {
+	r.Rows.Close()
+	r.cancel()
+}
+
+// hedgedRow runs hedged query when row is scanned.
+type hedgedRow struct {
+	cls   *Cluster
+	ctx   context.Context
+	delay time.Duration
+	query string
+	args  []any
+}
+
+func (r hedgedRow) Scan(dest ...any) error {
+	rows, err := r.cls.hedgedQuery(r.ctx, r.delay, r.query, r.args)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		return pgx.ErrNoRows
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return err
+	}
+	rows.Close()
+	return rows.Err()
+}
+
+// latencies keeps window of recent times to first row of hedged reads, adaptive hedge delay is their p95.
+type latencies struct {
+	mu      sync.Mutex
+	samples []time.Duration
+	next    int
+}
+
+func (l *latencies) observe(latency time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.samples) < hedgeWindow {
+		l.samples = append(l.samples, latency)
+		return
+	}
+	l.samples[l.next] = latency
+	l.next = (l.next + 1) % hedgeWindow
+}
+
+func (l *latencies) p95() time.Duration {
+	l.mu.Lock()
+	if len(l.samples) < hedgeMinSamples {
+		l.mu.Unlock()
+		return defaultHedgeDelay
+	}
+	sorted := slices.Clone(l.samples)
+	l.mu.Unlock()
+
+	slices.Sort(sorted)
+	return sorted[(len(sorted)*95+99)/100-1]
+}
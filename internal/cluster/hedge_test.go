@@ -0,0 +1,181 @@
+package cluster
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/godepo/elephant/internal/pkg/pgcontext"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type hedges struct {
+	mu       sync.Mutex
+	outcomes []HedgeOutcome
+}
+
+func (h *hedges) TrackHedgedRead(_ context.Context, outcome HedgeOutcome) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.outcomes = append(h.outcomes, outcome)
+}
+
+// stalled blocks query until it is cancelled.
+func stalled(ctx context.Context, _ string, _ ...interface{}) {
+	<-ctx.Done()
+}
+
+func TestCluster_HedgedRead(t *testing.T) {
+	ctx := pgcontext.With(context.Background(), pgcontext.WithHedgedRead(time.Millisecond))
+
+	newCluster := func(t *testing.T) (*MockPool, *MockPool, *MockPool, *hedges, *Cluster) {
+		t.Helper()
+		leader, first, second := NewMockPool(t), NewMockPool(t), NewMockPool(t)
+		collector := &hedges{}
+		cls := New(leader, []Pool{first, second},
+			WithLoadBalancer(firstBalancer), WithHedgeCollector(collector), WithReadRetries(2))
+		return leader, first, second, collector, cls
+	}
+
+	t.Run("should be able to return rows of first follower before hedge delay", func(t *testing.T) {
+		_, first, _, collector, cls := newCluster(t)
+		rows := NewMockRows(t)
+		first.EXPECT().Query(mock.Anything, "SELECT 1").Return(rows, nil)
+		rows.EXPECT().Next().Return(true).Once()
+		rows.EXPECT().Next().Return(false).Once()
+		rows.EXPECT().Close().Return()
+
+		out, err := cls.Query(pgcontext.With(ctx, pgcontext.WithHedgedRead(time.Minute)), "SELECT 1")
+		require.NoError(t, err)
+		assert.True(t, out.Next())
+		assert.False(t, out.Next())
+		out.Close()
+		assert.Equal(t, []HedgeOutcome{HedgeNotSent}, collector.outcomes)
+	})
+
+	t.Run("should be able to return rows of second follower when first one is slow", func(t *testing.T) {
+		_, first, second, collector, cls := newCluster(t)
+		rows := NewMockRows(t)
+		first.EXPECT().Query(mock.Anything, "SELECT 1").Run(stalled).Return(nil, context.Canceled)
+		second.EXPECT().Query(mock.Anything, "SELECT 1").Return(rows, nil)
+		rows.EXPECT().Next().Return(false)
+		rows.EXPECT().Err().Return(nil)
+
+		out, err := cls.Query(ctx, "SELECT 1")
+		require.NoError(t, err)
+		assert.False(t, out.Next())
+		assert.Equal(t, []HedgeOutcome{HedgeWon}, collector.outcomes)
+	})
+
+	t.Run("should be able to hedge at once when first follower failed with connection error", func(t *testing.T) {
+		_, first, second, collector, cls := newCluster(t)
+		rows := NewMockRows(t)
+		first.EXPECT().Query(mock.Anything, "SELECT 1").Return(nil, errRefused)
+		second.EXPECT().Query(mock.Anything, "SELECT 1").Return(rows, nil)
+		rows.EXPECT().Next().Return(true)
+
+		out, err := cls.Query(pgcontext.With(ctx, pgcontext.WithHedgedRead(time.Minute)), "SELECT 1")
+		require.NoError(t, err)
+		assert.True(t, out.Next())
+		assert.Equal(t, []HedgeOutcome{HedgeWon}, collector.outcomes)
+	})
+
+	t.Run("should be able to retry at leader when both followers failed", func(t *testing.T) {
+		leader, first, second, collector, cls := newCluster(t)
+		rows := NewMockRows(t)
+		first.EXPECT().Query(mock.Anything, "SELECT 1").Return(nil, errRefused)
+		second.EXPECT().Query(mock.Anything, "SELECT 1").Return(nil, errRefused)
+		leader.EXPECT().Query(ctx, "SELECT 1").Return(rows, nil)
+
+		out, err := cls.Query(ctx, "SELECT 1")
+		require.NoError(t, err)
+		assert.Equal(t, rows, out)
+		assert.Equal(t, []HedgeOutcome{HedgeFailed}, collector.outcomes)
+	})
+
+	t.Run("should be able to return statement error without hedge", func(t *testing.T) {
+		_, first, _, collector, cls := newCluster(t)
+		exp := &pgconn.PgError{Code: "42P01"}
+		first.EXPECT().Query(mock.Anything, "SELECT 1").Return(nil, exp)
+
+		_, err := cls.Query(pgcontext.With(ctx, pgcontext.WithHedgedRead(time.Minute)), "SELECT 1")
+		require.ErrorIs(t, err, exp)
+		assert.Equal(t, []HedgeOutcome{HedgeFailed}, collector.outcomes)
+	})
+
+	t.Run("should be able to scan row of hedged read", func(t *testing.T) {
+		_, first, second, collector, cls := newCluster(t)
+		rows := NewMockRows(t)
+		first.EXPECT().Query(mock.Anything, "SELECT 1").Run(stalled).Return(nil, context.Canceled)
+		second.EXPECT().Query(mock.Anything, "SELECT 1").Return(rows, nil)
+		rows.EXPECT().Next().Return(true)
+		rows.EXPECT().Scan(mock.Anything).RunAndReturn(func(dest ...any) error {
+			*dest[0].(*int) = 1
+			return nil
+		})
+		rows.EXPECT().Close().Return()
+		rows.EXPECT().Err().Return(nil)
+
+		var out int
+		require.NoError(t, cls.QueryRow(ctx, "SELECT 1").Scan(&out))
+		assert.Equal(t, 1, out)
+		assert.Equal(t, []HedgeOutcome{HedgeWon}, collector.outcomes)
+	})
+
+	t.Run("should be able to return no rows from hedged row", func(t *testing.T) {
+		_, first, _, _, cls := newCluster(t)
+		rows := NewMockRows(t)
+		first.EXPECT().Query(mock.Anything, "SELECT 1").Return(rows, nil)
+		rows.EXPECT().Next().Return(false)
+		rows.EXPECT().Err().Return(nil)
+		rows.EXPECT().Close().Return()
+
+		var out int
+		err := cls.QueryRow(pgcontext.With(ctx, pgcontext.WithHedgedRead(time.Minute)), "SELECT 1").Scan(&out)
+		require.ErrorIs(t, err, pgx.ErrNoRows)
+	})
+
+	t.Run("should be able to skip hedging of writes, transactions and single follower", func(t *testing.T) {
+		leader, first, _, collector, cls := newCluster(t)
+		tx := NewMockTx(t)
+		rows := emptyRows(t)
+		leader.EXPECT().Query(mock.Anything, "SELECT 1").Return(rows, nil)
+		tx.EXPECT().Query(mock.Anything, "SELECT 1").Return(rows, nil)
+		first.EXPECT().Query(mock.Anything, "SELECT 1").Return(rows, nil)
+
+		_, err := cls.Query(pgcontext.WithCanWrite(ctx), "SELECT 1")
+		require.NoError(t, err)
+		_, err = cls.Query(pgcontext.With(ctx, pgcontext.WithTransaction(tx)), "SELECT 1")
+		require.NoError(t, err)
+		_, err = New(leader, []Pool{first}).Query(ctx, "SELECT 1")
+		require.NoError(t, err)
+		assert.Empty(t, collector.outcomes)
+	})
+}
+
+func TestLatencies(t *testing.T) {
+	t.Run("should be able to use default delay until enough reads are observed", func(t *testing.T) {
+		l := &latencies{}
+		for range hedgeMinSamples - 1 {
+			l.observe(time.Second)
+		}
+		assert.Equal(t, defaultHedgeDelay, l.p95())
+	})
+
+	t.Run("should be able to return p95 of recent reads", func(t *testing.T) {
+		l := &latencies{}
+		for i := range hedgeWindow {
+			l.observe(time.Duration(i) * time.Hour)
+		}
+		for i := range hedgeWindow {
+			l.observe(time.Duration(hedgeWindow-i) * time.Millisecond)
+		}
+		assert.Len(t, l.samples, hedgeWindow)
+		assert.Equal(t, time.Duration(hedgeWindow*95/100+1)*time.Millisecond, l.p95())
+	})
+}
@@ -0,0 +1,35 @@
+package cluster
+
+import (
+	"context"
+
+	"github.com/godepo/elephant/internal/pkg/lifecycle"
+)
+
+func (cls *Cluster) members() []Pool {
+	return append([]Pool{cls.leader}, cls.fellows...)
+}
+
+// member gives role to node of member with index ix, leader is first member.
+func member(ix int, node lifecycle.Node) lifecycle.Node {
+	if ix == 0 {
+		return node.Member(lifecycle.RoleLeader, 0)
+	}
+	return node.Member(lifecycle.RoleFollower, ix-1)
+}
+
+// Close closes leader and followers.
+func (cls *Cluster) Close() {
+	lifecycle.CloseMembers(cls.members())
+}
+
+// Ping checks leader and followers concurrently, error joins errors of failed nodes.
+func (cls *Cluster) Ping(ctx context.Context) (lifecycle.Pings, error) {
+	pings := lifecycle.PingMembers(ctx, cls.members(), member)
+	return pings, pings.Err()
+}
+
+// Stats returns stats of leader and followers.
+func (cls *Cluster) Stats() lifecycle.Stats {
+	return lifecycle.MembersStats(cls.members(), member)
+}
@@ -0,0 +1,65 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/godepo/elephant/internal/pkg/lifecycle"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type node struct {
+	*MockPool
+	err    error
+	conns  int32
+	closed bool
+}
+
+func (n *node) Ping(context.Context) (lifecycle.Pings, error) {
+	pings := lifecycle.Pings{{Node: lifecycle.Node{Role: lifecycle.RoleNode}, Err: n.err}}
+	return pings, pings.Err()
+}
+
+func (n *node) Stats() lifecycle.Stats {
+	return lifecycle.Stats{{Node: lifecycle.Node{Role: lifecycle.RoleNode}, Stat: lifecycle.Stat{TotalConns: n.conns}}}
+}
+
+func (n *node) Close() {
+	n.closed = true
+}
+
+func TestCluster_Lifecycle(t *testing.T) {
+	expErr := errors.New(uuid.NewString())
+	leader := &node{MockPool: NewMockPool(t), conns: 1}
+	first := &node{MockPool: NewMockPool(t), conns: 2, err: expErr}
+	second := &node{MockPool: NewMockPool(t), conns: 3}
+	cls := New(leader, []Pool{first, second})
+
+	t.Run("should be able to ping leader and followers", func(t *testing.T) {
+		pings, err := cls.Ping(context.Background())
+		require.ErrorIs(t, err, expErr)
+		assert.ErrorContains(t, err, "follower [0]")
+
+		require.Len(t, pings, 3)
+		assert.Equal(t, lifecycle.RoleLeader, pings[0].Node.Role)
+		assert.Equal(t, lifecycle.Node{Role: lifecycle.RoleFollower, Index: 1}, pings[2].Node)
+		assert.ErrorIs(t, pings[1].Err, expErr)
+	})
+
+	t.Run("should be able to return stats of leader and followers", func(t *testing.T) {
+		stats := cls.Stats()
+		require.Len(t, stats, 3)
+		assert.Equal(t, lifecycle.Node{Role: lifecycle.RoleFollower}, stats[1].Node)
+		assert.Equal(t, int32(6), stats.Total().TotalConns)
+	})
+
+	t.Run("should be able to close leader and followers", func(t *testing.T) {
+		cls.Close()
+		assert.True(t, leader.closed)
+		assert.True(t, first.closed)
+		assert.True(t, second.closed)
+	})
+}
@@ -0,0 +1,25 @@
+package cluster
+
+import (
+	"context"
+
+	"github.com/godepo/elephant/internal/pkg/passthrough"
+)
+
+// WithAdvisoryLock runs fn under advisory lock taken at leader, because locks are not shared between nodes.
+func (cls *Cluster) WithAdvisoryLock(ctx context.Context, key string, fn func(ctx context.Context) error) error {
+	lck, err := passthrough.AsLocker(cls.leader)
+	if err != nil {
+		return err
+	}
+	return lck.WithAdvisoryLock(ctx, key, fn)
+}
+
+// WithTryAdvisoryLock runs fn under advisory lock taken at leader without waiting.
+func (cls *Cluster) WithTryAdvisoryLock(ctx context.Context, key string, fn func(ctx context.Context) error) error {
+	lck, err := passthrough.AsLocker(cls.leader)
+	if err != nil {
+		return err
+	}
+	return lck.WithTryAdvisoryLock(ctx, key, fn)
+}
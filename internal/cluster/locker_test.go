@@ -0,0 +1,65 @@
+package cluster
+
+import (
+	"context"
+	"testing"
+
+	"github.com/godepo/elephant/internal/pkg/passthrough"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type lockLeader struct {
+	*MockPool
+	*MockAdvisoryLocker
+}
+
+func runFn(ctx context.Context, _ string, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func TestCluster_WithAdvisoryLock(t *testing.T) {
+	t.Run("should be able to lock at leader", func(t *testing.T) {
+		lck := NewMockAdvisoryLocker(t)
+		cls := New(lockLeader{MockPool: NewMockPool(t), MockAdvisoryLocker: lck}, []Pool{NewMockPool(t)})
+		lck.EXPECT().WithAdvisoryLock(mock.Anything, "jobs", mock.Anything).RunAndReturn(runFn)
+
+		var called bool
+		require.NoError(t, cls.WithAdvisoryLock(context.Background(), "jobs", func(context.Context) error {
+			called = true
+			return nil
+		}))
+		assert.True(t, called)
+	})
+
+	t.Run("should be able to fail when leader does not support locks", func(t *testing.T) {
+		cls := New(NewMockPool(t), []Pool{NewMockPool(t)})
+
+		err := cls.WithAdvisoryLock(context.Background(), "jobs", func(context.Context) error {
+			return nil
+		})
+		require.ErrorIs(t, err, passthrough.ErrLockNotSupported)
+	})
+}
+
+func TestCluster_WithTryAdvisoryLock(t *testing.T) {
+	t.Run("should be able to try lock at leader", func(t *testing.T) {
+		lck := NewMockAdvisoryLocker(t)
+		cls := New(lockLeader{MockPool: NewMockPool(t), MockAdvisoryLocker: lck}, []Pool{NewMockPool(t)})
+		lck.EXPECT().WithTryAdvisoryLock(mock.Anything, "jobs", mock.Anything).RunAndReturn(runFn)
+
+		require.NoError(t, cls.WithTryAdvisoryLock(context.Background(), "jobs", func(context.Context) error {
+			return nil
+		}))
+	})
+
+	t.Run("should be able to fail when leader does not support locks", func(t *testing.T) {
+		cls := New(NewMockPool(t), []Pool{NewMockPool(t)})
+
+		err := cls.WithTryAdvisoryLock(context.Background(), "jobs", func(context.Context) error {
+			return nil
+		})
+		require.ErrorIs(t, err, passthrough.ErrLockNotSupported)
+	})
+}
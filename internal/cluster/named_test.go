@@ -0,0 +1,49 @@
+package cluster
+
+import (
+	"context"
+	"testing"
+
+	"github.com/godepo/elephant/internal/pkg/named"
+	"github.com/godepo/elephant/internal/pkg/pgcontext"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCluster_NamedParameters(t *testing.T) {
+	params := map[string]any{"id": 1, "name": "bob"}
+
+	t.Run("should be able to route positional query", func(t *testing.T) {
+		leader, fellow := NewMockPool(t), NewMockPool(t)
+		rows, row := emptyRows(t), NewMockRow(t)
+		cls := New(leader, []Pool{fellow})
+		ctx := context.Background()
+		tag := pgconn.NewCommandTag("UPDATE 1")
+
+		fellow.EXPECT().Query(ctx, "SELECT name FROM users WHERE id = $1", []any{1}).Return(rows, nil)
+		fellow.EXPECT().QueryRow(ctx, "SELECT $1, $2", []any{"bob", 1}).Return(row)
+		leader.EXPECT().Exec(pgcontext.WithCanWrite(ctx), "UPDATE users SET name = $1 WHERE id = $2", []any{"bob", 1}).
+			Return(tag, nil)
+
+		out, err := cls.Query(ctx, "SELECT name FROM users WHERE id = :id", params)
+		require.NoError(t, err)
+		assert.False(t, out.Next())
+		row.EXPECT().Scan().Return(nil)
+		require.NoError(t, cls.QueryRow(ctx, "SELECT :name, :id", params).Scan())
+		res, err := cls.Exec(pgcontext.WithCanWrite(ctx), "UPDATE users SET name = @name WHERE id = @id", params)
+		require.NoError(t, err)
+		assert.Equal(t, tag, res)
+	})
+
+	t.Run("should be able to fail without routing", func(t *testing.T) {
+		cls := New(NewMockPool(t), []Pool{NewMockPool(t)})
+		ctx := context.Background()
+
+		_, err := cls.Query(ctx, "SELECT :missing", params)
+		require.ErrorIs(t, err, named.ErrMissingValue)
+		require.ErrorIs(t, cls.QueryRow(ctx, "SELECT :missing", params).Scan(), named.ErrMissingValue)
+		_, err = cls.Exec(ctx, "SELECT :missing", params)
+		require.ErrorIs(t, err, named.ErrMissingValue)
+	})
+}
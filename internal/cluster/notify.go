@@ -0,0 +1,25 @@
+package cluster
+
+import (
+	"context"
+
+	"github.com/godepo/elephant/internal/notify"
+	"github.com/godepo/elephant/internal/pkg/passthrough"
+	"github.com/godepo/elephant/internal/pkg/pgcontext"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Listen subscribes to channel at leader, because notifications are not replicated to followers.
+func (cls *Cluster) Listen(ctx context.Context, channel string) (<-chan pgconn.Notification, error) {
+	lst, err := passthrough.AsListener(cls.leader)
+	if err != nil {
+		return nil, err
+	}
+	return lst.Listen(ctx, channel)
+}
+
+// Notify sends notification through transaction from context or leader.
+func (cls *Cluster) Notify(ctx context.Context, channel, payload string) error {
+	_, err := cls.Exec(pgcontext.With(ctx, pgcontext.WithCanWrite), notify.Query, channel, payload)
+	return err
+}
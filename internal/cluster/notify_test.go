@@ -0,0 +1,63 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/godepo/elephant/internal/notify"
+	"github.com/godepo/elephant/internal/pkg/passthrough"
+	"github.com/godepo/elephant/internal/pkg/pgcontext"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jaswdr/faker/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type listenLeader struct {
+	*MockPool
+	*MockListener
+}
+
+func TestCluster_Listen(t *testing.T) {
+	t.Run("should be able to listen at leader", func(t *testing.T) {
+		lst := NewMockListener(t)
+		cls := New(listenLeader{MockPool: NewMockPool(t), MockListener: lst}, []Pool{NewMockPool(t)})
+		exp := make(chan pgconn.Notification)
+		lst.EXPECT().Listen(mock.Anything, "events").Return(exp, nil)
+
+		ch, err := cls.Listen(context.Background(), "events")
+		require.NoError(t, err)
+		assert.Equal(t, (<-chan pgconn.Notification)(exp), ch)
+	})
+
+	t.Run("should be able to fail when leader does not support listen", func(t *testing.T) {
+		cls := New(NewMockPool(t), []Pool{NewMockPool(t)})
+
+		ch, err := cls.Listen(context.Background(), "events")
+		require.ErrorIs(t, err, passthrough.ErrListenNotSupported)
+		assert.Nil(t, ch)
+	})
+}
+
+func TestCluster_Notify(t *testing.T) {
+	t.Run("should be able to notify through leader", func(t *testing.T) {
+		leader := NewMockPool(t)
+		cls := New(leader, []Pool{NewMockPool(t)})
+		leader.EXPECT().Exec(mock.Anything, notify.Query, []any{"events", "payload"}).
+			Return(pgconn.NewCommandTag("SELECT 1"), nil)
+
+		require.NoError(t, cls.Notify(context.Background(), "events", "payload"))
+	})
+
+	t.Run("should be able to notify through transaction from context", func(t *testing.T) {
+		tx := NewMockTx(t)
+		cls := New(NewMockPool(t), []Pool{NewMockPool(t)})
+		expErr := errors.New(faker.New().RandomStringWithLength(10))
+		tx.EXPECT().Exec(mock.Anything, notify.Query, []any{"events", "payload"}).Return(pgconn.CommandTag{}, expErr)
+
+		ctx := pgcontext.With(context.Background(), pgcontext.WithTransaction(tx))
+		require.ErrorIs(t, cls.Notify(ctx, "events", "payload"), expErr)
+	})
+}
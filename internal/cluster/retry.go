@@ -0,0 +1,148 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"reflect"
+	"syscall"
+
+	"github.com/godepo/elephant/internal/pkg/pgcontext"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const defaultReadRetries = 1
+
+// canRetry reports reads which can be served by another node: reads outside transaction, which are not
+// routed to leader for writing.
+func (cls *Cluster) canRetry(ctx context.Context) bool {
+	if cls.cfg.readRetries == 0 || pgcontext.CanWriteFrom(ctx) {
+		return false
+	}
+	_, ok := pgcontext.TransactionFrom(ctx)
+	return !ok
+}
+
+// retryRead runs read at follower picked by load balancer and retries it at followers which are not tried yet,
+// or at leader when every follower is tried, while read fails with connection error.
+func (cls *Cluster) retryRead(ctx context.Context, read func(db Pool) error) error {
+	return cls.retryReadAfter(ctx, nil, nil, read)
+}
+
+// retryReadAfter continues retries of read which already failed with err at nodes from tried.
+func (cls *Cluster) retryReadAfter(ctx context.Context, tried []Pool, err error, read func(db Pool) error) error {
+	for len(tried) <= cls.cfg.readRetries {
+		if len(tried) > 0 && (!IsConnectionError(err) || ctx.Err() != nil) {
+			return err
+		}
+		node := cls.untried(tried)
+		if node == nil {
+			return err
+		}
+		tried = append(tried, node)
+		err = read(node)
+		if err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+func (cls *Cluster) untried(tried []Pool) Pool {
+	fellows := make([]Pool, 0, len(cls.fellows))
+	for _, fellow := range cls.fellows {
+		if !unavailable(fellow) && !contains(tried, fellow) {
+			fellows = append(fellows, fellow)
+		}
+	}
+	if len(fellows) > 0 {
+		return cls.cfg.loadBalancer(fellows)
+	}
+	if contains(tried, cls.leader) {
+		return nil
+	}
+	return cls.leader
+}
+
+// contains compares pools by identity, pools of types which can't be compared are never equal.
+func contains(pools []Pool, pool Pool) bool {
+	if !reflect.TypeOf(pool).Comparable() {
+		return false
+	}
+	for _, p := range pools {
+		if p == pool {
+			return true
+		}
+	}
+	return false
+}
+
+// IsConnectionError reports errors after which read can be safely sent to another node: refused or reset
+// connections, errors of connecting, errors which pgx reports as safe to retry and SQLSTATE of class 08.
+func IsConnectionError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return len(pgErr.Code) >= 2 && pgErr.Code[:2] == "08"
+	}
+	var (
+		opErr      *net.OpError
+		connectErr *pgconn.ConnectError
+	)
+	return errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.As(err, &connectErr) ||
+		(errors.As(err, &opErr) && !opErr.Timeout()) ||
+		pgconn.SafeToRetry(err)
+}
+
+// peekRows reads first row of rows, so connection error which is reported by first row can be retried like error of
+// query. Rows are closed when first row failed with connection error, other errors are left to reader of rows.
+func peekRows(rows pgx.Rows) (pgx.Rows, error) {
+	next := rows.Next()
+	if !next && IsConnectionError(rows.Err()) {
+		rows.Close()
+		return nil, rows.Err()
+	}
+	return &peekedRows{Rows: rows, peeked: true, next: next}, nil
+}
+
+// peekedRows is rows which first row is already read by peekRows.
+type peekedRows struct {
+	pgx.Rows
+	peeked bool
+	next   bool
+}
+
+func (r *peekedRows) Next() bool {
+	if r.peeked {
+		r.peeked = false
+		return r.next
+	}
+	return r.next && r.Rows.Next()
+}
+
+// retriedRow is row queried at node, which is queried again at other nodes when scan fails with connection error.
+type retriedRow struct {
+	row   pgx.Row
+	node  Pool
+	ctx   context.Context
+	cls   *Cluster
+	query string
+	args  []any
+}
+
+func (r retriedRow) Scan(dest ...any) error {
+	err := r.row.Scan(dest...)
+	if err == nil {
+		return nil
+	}
+	return r.cls.retryReadAfter(r.ctx, []Pool{r.node}, err, func(db Pool) error {
+		return db.QueryRow(r.ctx, r.query, r.args...).Scan(dest...)
+	})
+}
@@ -0,0 +1,203 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+	"testing"
+
+	"github.com/godepo/elephant/internal/pkg/pgcontext"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errRefused = &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}
+
+// firstBalancer picks first of given fellows, so order of retries is predictable.
+func firstBalancer(fellows []Pool) Pool {
+	return fellows[0]
+}
+
+// emptyRows is rows without rows, first row of them is peeked by retried read.
+func emptyRows(t *testing.T) *MockRows {
+	rows := NewMockRows(t)
+	rows.EXPECT().Next().Return(false)
+	rows.EXPECT().Err().Return(nil)
+	return rows
+}
+
+func TestIsConnectionError(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil"},
+		{name: "no rows", err: pgx.ErrNoRows},
+		{name: "statement error", err: &pgconn.PgError{Code: "42P01"}},
+		{name: "unknown error", err: errors.New(uuid.NewString())},
+		{name: "canceled", err: context.Canceled},
+		{name: "timeout", err: &net.OpError{Op: "read", Net: "tcp", Err: timeoutErr{}}},
+		{name: "connection exception", err: &pgconn.PgError{Code: "08006"}, want: true},
+		{name: "refused", err: fmt.Errorf("wrap: %w", errRefused), want: true},
+		{name: "reset", err: syscall.ECONNRESET, want: true},
+	}
+	for _, tc := range cases {
+		t.Run("should be able to match "+tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, IsConnectionError(tc.err))
+		})
+	}
+}
+
+type timeoutErr struct{}
+
+func (timeoutErr) Error() string   { return "i/o timeout" }
+func (timeoutErr) Timeout() bool   { return true }
+func (timeoutErr) Temporary() bool { return true }
+
+func TestCluster_RetryRead(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should be able to retry query at other follower and leader", func(t *testing.T) {
+		leader, first, second := NewMockPool(t), NewMockPool(t), NewMockPool(t)
+		rows := NewMockRows(t)
+		cls := New(leader, []Pool{first, second}, WithLoadBalancer(firstBalancer), WithReadRetries(2))
+		first.EXPECT().Query(ctx, "SELECT 1").Return(nil, errRefused)
+		second.EXPECT().Query(ctx, "SELECT 1").Return(nil, &pgconn.PgError{Code: "08006"})
+		leader.EXPECT().Query(ctx, "SELECT 1").Return(rows, nil)
+		rows.EXPECT().Next().Return(true).Once()
+		rows.EXPECT().Next().Return(false).Once()
+
+		out, err := cls.Query(ctx, "SELECT 1")
+		require.NoError(t, err)
+		assert.True(t, out.Next())
+		assert.False(t, out.Next())
+	})
+
+	t.Run("should be able to retry query which failed at first row", func(t *testing.T) {
+		leader, fellow := NewMockPool(t), NewMockPool(t)
+		broken, rows := NewMockRows(t), NewMockRows(t)
+		cls := New(leader, []Pool{fellow})
+		fellow.EXPECT().Query(ctx, "SELECT 1").Return(broken, nil)
+		broken.EXPECT().Next().Return(false)
+		broken.EXPECT().Err().Return(errRefused)
+		broken.EXPECT().Close().Return()
+		leader.EXPECT().Query(ctx, "SELECT 1").Return(rows, nil)
+		rows.EXPECT().Next().Return(false).Once()
+		rows.EXPECT().Err().Return(nil)
+
+		out, err := cls.Query(ctx, "SELECT 1")
+		require.NoError(t, err)
+		assert.False(t, out.Next())
+		assert.False(t, out.Next())
+	})
+
+	t.Run("should be able to leave error of first row, which is not connection error, to rows", func(t *testing.T) {
+		fellow := NewMockPool(t)
+		rows := NewMockRows(t)
+		expErr := &pgconn.PgError{Code: "22012"}
+		cls := New(NewMockPool(t), []Pool{fellow})
+		fellow.EXPECT().Query(ctx, "SELECT 1").Return(rows, nil)
+		rows.EXPECT().Next().Return(false).Once()
+		rows.EXPECT().Err().Return(expErr)
+
+		out, err := cls.Query(ctx, "SELECT 1")
+		require.NoError(t, err)
+		assert.False(t, out.Next())
+		require.ErrorIs(t, out.Err(), expErr)
+	})
+
+	t.Run("should be able to bound attempts", func(t *testing.T) {
+		first, second := NewMockPool(t), NewMockPool(t)
+		cls := New(NewMockPool(t), []Pool{first, second}, WithLoadBalancer(firstBalancer), WithReadRetries(1))
+		first.EXPECT().Query(ctx, "SELECT 1").Return(nil, errRefused)
+		second.EXPECT().Query(ctx, "SELECT 1").Return(nil, errRefused)
+
+		_, err := cls.Query(ctx, "SELECT 1")
+		require.ErrorIs(t, err, syscall.ECONNREFUSED)
+	})
+
+	t.Run("should be able to stop when every node is tried", func(t *testing.T) {
+		leader, fellow := NewMockPool(t), NewMockPool(t)
+		cls := New(leader, []Pool{fellow}, WithReadRetries(5))
+		fellow.EXPECT().Query(ctx, "SELECT 1").Return(nil, errRefused)
+		leader.EXPECT().Query(ctx, "SELECT 1").Return(nil, errRefused)
+
+		_, err := cls.Query(ctx, "SELECT 1")
+		require.ErrorIs(t, err, syscall.ECONNREFUSED)
+	})
+
+	t.Run("should be able to return error which is not connection error", func(t *testing.T) {
+		fellow := NewMockPool(t)
+		expErr := &pgconn.PgError{Code: "42P01"}
+		cls := New(NewMockPool(t), []Pool{fellow, NewMockPool(t)}, WithLoadBalancer(firstBalancer))
+		fellow.EXPECT().Query(ctx, "SELECT 1").Return(nil, expErr)
+
+		_, err := cls.Query(ctx, "SELECT 1")
+		require.ErrorIs(t, err, expErr)
+	})
+
+	t.Run("should be able to never retry writes and transactions", func(t *testing.T) {
+		leader, fellow, tx := NewMockPool(t), NewMockPool(t), NewMockTx(t)
+		cls := New(leader, []Pool{fellow, NewMockPool(t)}, WithLoadBalancer(firstBalancer))
+		txCtx := pgcontext.With(ctx, pgcontext.WithTransaction(tx))
+		writeCtx := pgcontext.WithCanWrite(ctx)
+		tx.EXPECT().Query(txCtx, "SELECT 1").Return(nil, errRefused)
+		leader.EXPECT().Query(writeCtx, "SELECT 1").Return(nil, errRefused)
+		fellow.EXPECT().Exec(ctx, "UPDATE t").Return(pgconn.CommandTag{}, errRefused)
+
+		_, err := cls.Query(txCtx, "SELECT 1")
+		require.ErrorIs(t, err, syscall.ECONNREFUSED)
+		_, err = cls.Query(writeCtx, "SELECT 1")
+		require.ErrorIs(t, err, syscall.ECONNREFUSED)
+		_, err = cls.Exec(ctx, "UPDATE t")
+		require.ErrorIs(t, err, syscall.ECONNREFUSED)
+	})
+
+	t.Run("should be able to retry once by default", func(t *testing.T) {
+		first, second := NewMockPool(t), NewMockPool(t)
+		cls := New(NewMockPool(t), []Pool{first, second}, WithLoadBalancer(firstBalancer))
+		first.EXPECT().Query(ctx, "SELECT 1").Return(nil, errRefused)
+		second.EXPECT().Query(ctx, "SELECT 1").Return(nil, errRefused)
+
+		_, err := cls.Query(ctx, "SELECT 1")
+		require.ErrorIs(t, err, syscall.ECONNREFUSED)
+	})
+
+	t.Run("should be able to disable retries", func(t *testing.T) {
+		fellow := NewMockPool(t)
+		cls := New(NewMockPool(t), []Pool{fellow, NewMockPool(t)}, WithLoadBalancer(firstBalancer), WithReadRetries(0))
+		fellow.EXPECT().Query(ctx, "SELECT 1").Return(nil, errRefused)
+
+		_, err := cls.Query(ctx, "SELECT 1")
+		require.ErrorIs(t, err, syscall.ECONNREFUSED)
+	})
+
+	t.Run("should be able to retry scan of row at other follower", func(t *testing.T) {
+		first, second := NewMockPool(t), NewMockPool(t)
+		broken, row := NewMockRow(t), NewMockRow(t)
+		cls := New(NewMockPool(t), []Pool{first, second}, WithLoadBalancer(firstBalancer))
+		first.EXPECT().QueryRow(ctx, "SELECT $1", []any{1}).Return(broken)
+		second.EXPECT().QueryRow(ctx, "SELECT $1", []any{1}).Return(row)
+		broken.EXPECT().Scan().Return(errRefused)
+		row.EXPECT().Scan().Return(nil)
+
+		require.NoError(t, cls.QueryRow(ctx, "SELECT $1", 1).Scan())
+	})
+
+	t.Run("should be able to skip unavailable follower on retry", func(t *testing.T) {
+		leader, first := NewMockPool(t), NewMockPool(t)
+		cls := New(leader, []Pool{first, guardedPool{MockPool: NewMockPool(t)}}, WithLoadBalancer(firstBalancer))
+		first.EXPECT().Query(ctx, "SELECT 1").Return(nil, errRefused)
+		leader.EXPECT().Query(ctx, "SELECT 1").Return(emptyRows(t), nil)
+
+		out, err := cls.Query(ctx, "SELECT 1")
+		require.NoError(t, err)
+		assert.False(t, out.Next())
+	})
+}
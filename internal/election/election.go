@@ -0,0 +1,248 @@
+// Package election implements leader election on PostgreSQL session advisory locks. Candidate campaigns
+// by taking lock hashed from election name at dedicated connection and holds leadership while
+// connection is alive. Leadership is lost when connection drops, and is given up on context cancel.
+//
+//go:generate go tool mockery
+package election
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/godepo/elephant/internal/locker"
+	"github.com/godepo/elephant/internal/pkg/passthrough"
+)
+
+var (
+	ErrLeadershipLost     = errors.New("leadership lost")
+	ErrLeadershipResigned = errors.New("leadership resigned")
+)
+
+const (
+	defaultRetryInterval = 5 * time.Second
+	defaultCheckInterval = time.Second
+	releaseTimeout       = time.Second
+	queryHeartbeat       = "SELECT 1"
+)
+
+type Config struct {
+	retryInterval time.Duration
+	checkInterval time.Duration
+	errorHandler  func(err error)
+}
+
+type Option func(cfg *Config)
+
+// WithRetryInterval sets how often candidate tries to take leadership while another one holds it.
+func WithRetryInterval(interval time.Duration) Option {
+	return func(cfg *Config) {
+		cfg.retryInterval = interval
+	}
+}
+
+// WithCheckInterval sets how often leader checks that its connection is alive.
+func WithCheckInterval(interval time.Duration) Option {
+	return func(cfg *Config) {
+		cfg.checkInterval = interval
+	}
+}
+
+// WithErrorHandler sets handler for connection errors which happen during campaign.
+func WithErrorHandler(handler func(err error)) Option {
+	return func(cfg *Config) {
+		cfg.errorHandler = handler
+	}
+}
+
+type Elector struct {
+	connect locker.Connector
+	name    string
+	key     int64
+	cfg     Config
+}
+
+// FromAcquirer makes connector which takes sessions from db.
+func FromAcquirer(db passthrough.Acquirer) locker.Connector {
+	return func(ctx context.Context) (locker.Session, error) {
+		conn, err := db.Acquire(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return conn, nil
+	}
+}
+
+func New(connect locker.Connector, name string, opts ...Option) *Elector {
+	cfg := Config{
+		retryInterval: defaultRetryInterval,
+		checkInterval: defaultCheckInterval,
+		errorHandler:  func(error) {},
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return &Elector{
+		connect: connect,
+		name:    name,
+		key:     locker.Key(name),
+		cfg:     cfg,
+	}
+}
+
+// Name returns name of election.
+func (e *Elector) Name() string {
+	return e.name
+}
+
+// Campaign blocks until leadership is taken or ctx is done. Term is held until ctx is done, Resign is
+// called or connection is lost.
+func (e *Elector) Campaign(ctx context.Context) (*Term, error) {
+	for {
+		term, err := e.try(ctx)
+		if err == nil {
+			return term, nil
+		}
+		if !errors.Is(err, locker.ErrLockNotAcquired) {
+			e.cfg.errorHandler(err)
+		}
+
+		timer := time.NewTimer(e.cfg.retryInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// Run campaigns for leadership and runs fn while it is held. Context of fn is canceled when leadership
+// is lost, after that Run campaigns again. Run returns error of fn when leadership was held to the end,
+// or context error when ctx is done.
+func (e *Elector) Run(ctx context.Context, fn func(ctx context.Context) error) error {
+	for {
+		term, err := e.Campaign(ctx)
+		if err != nil {
+			return err
+		}
+
+		err = term.run(ctx, fn)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if !errors.Is(term.Err(), ErrLeadershipLost) {
+			return err
+		}
+	}
+}
+
+func (e *Elector) try(ctx context.Context) (*Term, error) {
+	session, err := e.connect(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("can't acquire connection for election %s: %w", e.name, err)
+	}
+	if err := locker.TrySessionLock(ctx, session, e.key); err != nil {
+		session.Release()
+		return nil, err
+	}
+
+	term := &Term{
+		session: session,
+		key:     e.key,
+		lost:    make(chan struct{}),
+		resign:  make(chan struct{}),
+	}
+	go term.hold(ctx, e.cfg.checkInterval)
+	return term, nil
+}
+
+// Term is leadership held by candidate.
+type Term struct {
+	session locker.Session
+	key     int64
+	lost    chan struct{}
+	resign  chan struct{}
+	once    sync.Once
+	err     error
+}
+
+// Lost returns channel which is closed when leadership is over.
+func (t *Term) Lost() <-chan struct{} {
+	return t.lost
+}
+
+// Err returns reason why leadership is over: ErrLeadershipLost wrapping connection error, ErrLeadershipResigned
+// or context error. It returns nil while leadership is held.
+func (t *Term) Err() error {
+	select {
+	case <-t.lost:
+		return t.err
+	default:
+		return nil
+	}
+}
+
+// Resign gives up leadership and waits until lock is released.
+func (t *Term) Resign() {
+	t.once.Do(func() {
+		close(t.resign)
+	})
+	<-t.lost
+}
+
+func (t *Term) run(ctx context.Context, fn func(ctx context.Context) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	go func() {
+		select {
+		case <-t.lost:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	err := fn(ctx)
+	t.Resign()
+	return err
+}
+
+func (t *Term) hold(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			t.finish(ctx, ctx.Err())
+			return
+		case <-t.resign:
+			t.finish(ctx, ErrLeadershipResigned)
+			return
+		case <-ticker.C:
+			if _, err := t.session.Exec(ctx, queryHeartbeat); err != nil {
+				if ctx.Err() != nil {
+					t.finish(ctx, ctx.Err())
+					return
+				}
+				t.finish(ctx, fmt.Errorf("%w: %w", ErrLeadershipLost, err))
+				return
+			}
+		}
+	}
+}
+
+func (t *Term) finish(ctx context.Context, reason error) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), releaseTimeout)
+	defer cancel()
+
+	// Lock must not stay at pooled connection, when connection is broken unlock fails and pool drops it.
+	_ = locker.SessionUnlock(ctx, t.session, t.key)
+	t.session.Release()
+
+	t.err = reason
+	close(t.lost)
+}
@@ -0,0 +1,204 @@
+package election
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/godepo/elephant/internal/locker"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jaswdr/faker/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	queryTryLock = "SELECT pg_try_advisory_lock($1)"
+	queryUnlock  = "SELECT pg_advisory_unlock($1)"
+)
+
+func connectTo(sessions ...locker.Session) locker.Connector {
+	var idx atomic.Int64
+	return func(context.Context) (locker.Session, error) {
+		return sessions[int(idx.Add(1)-1)%len(sessions)], nil
+	}
+}
+
+func tryLock(t *testing.T, session *MockSession, acquired bool) {
+	t.Helper()
+	row := NewMockRow(t)
+	row.EXPECT().Scan(mock.Anything).RunAndReturn(func(dest ...any) error {
+		*dest[0].(*bool) = acquired
+		return nil
+	})
+	session.EXPECT().QueryRow(mock.Anything, queryTryLock, []any{locker.Key("cron")}).Return(row).Once()
+}
+
+func expectStepDown(session *MockSession) {
+	session.EXPECT().Exec(mock.Anything, queryUnlock, []any{locker.Key("cron")}).
+		Return(pgconn.CommandTag{}, nil).Once()
+	session.EXPECT().Release().Return().Once()
+}
+
+func newError() error {
+	return errors.New(faker.New().RandomStringWithLength(10))
+}
+
+func TestElector_Campaign(t *testing.T) {
+	t.Run("should be able to take leadership and resign", func(t *testing.T) {
+		session := NewMockSession(t)
+		tryLock(t, session, true)
+		expectStepDown(session)
+		elector := New(connectTo(session), "cron", WithCheckInterval(time.Hour))
+
+		term, err := elector.Campaign(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "cron", elector.Name())
+		assert.NoError(t, term.Err())
+
+		term.Resign()
+		assert.ErrorIs(t, term.Err(), ErrLeadershipResigned)
+	})
+
+	t.Run("should be able to wait while leadership is held by another candidate", func(t *testing.T) {
+		busy, free := NewMockSession(t), NewMockSession(t)
+		tryLock(t, busy, false)
+		busy.EXPECT().Release().Return().Once()
+		tryLock(t, free, true)
+		expectStepDown(free)
+		elector := New(connectTo(busy, free), "cron", WithRetryInterval(time.Millisecond))
+
+		term, err := elector.Campaign(context.Background())
+		require.NoError(t, err)
+		term.Resign()
+	})
+
+	t.Run("should be able to report connection errors and retry", func(t *testing.T) {
+		expErr := newError()
+		session := NewMockSession(t)
+		tryLock(t, session, true)
+		expectStepDown(session)
+		var reported error
+		calls := 0
+		connect := func(context.Context) (locker.Session, error) {
+			calls++
+			if calls == 1 {
+				return nil, expErr
+			}
+			return session, nil
+		}
+		elector := New(connect, "cron",
+			WithRetryInterval(time.Millisecond),
+			WithErrorHandler(func(err error) { reported = err }),
+		)
+
+		term, err := elector.Campaign(context.Background())
+		require.NoError(t, err)
+		term.Resign()
+		assert.ErrorIs(t, reported, expErr)
+	})
+
+	t.Run("should be able to stop campaign when context is done", func(t *testing.T) {
+		session := NewMockSession(t)
+		tryLock(t, session, false)
+		session.EXPECT().Release().Return().Once()
+		ctx, cancel := context.WithCancel(context.Background())
+		elector := New(connectTo(session), "cron", WithRetryInterval(time.Hour))
+
+		time.AfterFunc(10*time.Millisecond, cancel)
+		term, err := elector.Campaign(ctx)
+		require.ErrorIs(t, err, context.Canceled)
+		assert.Nil(t, term)
+	})
+}
+
+func TestTerm_Lost(t *testing.T) {
+	t.Run("should be able to signal when connection is lost", func(t *testing.T) {
+		expErr := newError()
+		session := NewMockSession(t)
+		tryLock(t, session, true)
+		session.EXPECT().Exec(mock.Anything, queryHeartbeat).Return(pgconn.CommandTag{}, expErr).Once()
+		session.EXPECT().Exec(mock.Anything, queryUnlock, []any{locker.Key("cron")}).
+			Return(pgconn.CommandTag{}, expErr).Once()
+		session.EXPECT().Release().Return().Once()
+		elector := New(connectTo(session), "cron", WithCheckInterval(time.Millisecond))
+
+		term, err := elector.Campaign(context.Background())
+		require.NoError(t, err)
+
+		<-term.Lost()
+		assert.ErrorIs(t, term.Err(), ErrLeadershipLost)
+		assert.ErrorIs(t, term.Err(), expErr)
+	})
+
+	t.Run("should be able to step down when context is done", func(t *testing.T) {
+		session := NewMockSession(t)
+		tryLock(t, session, true)
+		expectStepDown(session)
+		ctx, cancel := context.WithCancel(context.Background())
+		elector := New(connectTo(session), "cron", WithCheckInterval(time.Hour))
+
+		term, err := elector.Campaign(ctx)
+		require.NoError(t, err)
+		cancel()
+
+		<-term.Lost()
+		assert.ErrorIs(t, term.Err(), context.Canceled)
+	})
+}
+
+func TestElector_Run(t *testing.T) {
+	t.Run("should be able to return error of leader function", func(t *testing.T) {
+		expErr := newError()
+		session := NewMockSession(t)
+		tryLock(t, session, true)
+		expectStepDown(session)
+		elector := New(connectTo(session), "cron", WithCheckInterval(time.Hour))
+
+		err := elector.Run(context.Background(), func(context.Context) error {
+			return expErr
+		})
+		require.ErrorIs(t, err, expErr)
+	})
+
+	t.Run("should be able to campaign again when leadership is lost", func(t *testing.T) {
+		lost, held := NewMockSession(t), NewMockSession(t)
+		tryLock(t, lost, true)
+		lost.EXPECT().Exec(mock.Anything, queryHeartbeat).Return(pgconn.CommandTag{}, newError()).Once()
+		expectStepDown(lost)
+		tryLock(t, held, true)
+		expectStepDown(held)
+		elector := New(connectTo(lost, held), "cron", WithCheckInterval(time.Millisecond))
+		held.EXPECT().Exec(mock.Anything, queryHeartbeat).Return(pgconn.CommandTag{}, nil).Maybe()
+
+		terms := 0
+		err := elector.Run(context.Background(), func(ctx context.Context) error {
+			terms++
+			if terms == 1 {
+				<-ctx.Done()
+				return ctx.Err()
+			}
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 2, terms)
+	})
+
+	t.Run("should be able to stop when context is done", func(t *testing.T) {
+		session := NewMockSession(t)
+		tryLock(t, session, true)
+		expectStepDown(session)
+		ctx, cancel := context.WithCancel(context.Background())
+		elector := New(connectTo(session), "cron", WithCheckInterval(time.Hour))
+
+		err := elector.Run(ctx, func(ctx context.Context) error {
+			cancel()
+			<-ctx.Done()
+			return nil
+		})
+		require.ErrorIs(t, err, context.Canceled)
+	})
+}
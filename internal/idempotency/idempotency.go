@@ -0,0 +1,207 @@
+// Package idempotency implements store of request keys and their serialized responses. Request runs inside
+// Transactional and its response is stored by the same transaction as business writes, so repeated request
+// with the same key gets stored response. Concurrent duplicates are serialized by advisory lock bound to
+// transaction: they wait for the first request or fail fast with ErrInProgress.
+//
+//go:generate go tool mockery
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/godepo/elephant/internal/locker"
+	"github.com/godepo/elephant/internal/pkg/pgcontext"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+var (
+	ErrInProgress    = errors.New("idempotency: request with the same key is in progress")
+	ErrNoTransaction = errors.New("idempotency: pool didn't put transaction to context")
+)
+
+const (
+	defaultTable = "idempotency_keys"
+	defaultTTL   = 24 * time.Hour
+)
+
+type DB interface {
+	QueryRow(ctx context.Context, query string, args ...any) pgx.Row
+	Exec(ctx context.Context, query string, args ...any) (pgconn.CommandTag, error)
+	Transactional(ctx context.Context, fn func(ctx context.Context) error) (out error)
+}
+
+// Result is response of request. Replayed is true when response was stored by previous request with the same key.
+type Result struct {
+	Response []byte
+	Replayed bool
+}
+
+type Config struct {
+	table    string
+	ttl      time.Duration
+	failFast bool
+}
+
+type Option func(cfg *Config)
+
+// WithTable sets keys table name, it may be qualified by schema.
+func WithTable(table string) Option {
+	return func(cfg *Config) {
+		cfg.table = table
+	}
+}
+
+// WithTTL sets how long stored response is replayed.
+func WithTTL(ttl time.Duration) Option {
+	return func(cfg *Config) {
+		cfg.ttl = ttl
+	}
+}
+
+// WithFailFast makes concurrent duplicate fail with ErrInProgress instead of waiting for the first request.
+func WithFailFast() Option {
+	return func(cfg *Config) {
+		cfg.failFast = true
+	}
+}
+
+type queries struct {
+	stored  string
+	store   string
+	cleanup string
+}
+
+type Store struct {
+	db      DB
+	cfg     Config
+	queries queries
+}
+
+func New(db DB, opts ...Option) *Store {
+	cfg := Config{
+		table: defaultTable,
+		ttl:   defaultTTL,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	table := tableIdentifier(cfg.table)
+	return &Store{
+		db:  db,
+		cfg: cfg,
+		queries: queries{
+			stored: fmt.Sprintf("SELECT response FROM %s WHERE key = $1 AND expires_at > now()", table),
+			store: fmt.Sprintf(
+				"INSERT INTO %s (key, response, expires_at) VALUES ($1, $2, now() + $3 * interval '1 millisecond') "+
+					"ON CONFLICT (key) DO UPDATE SET response = EXCLUDED.response, created_at = now(), "+
+					"expires_at = EXCLUDED.expires_at",
+				table,
+			),
+			cleanup: fmt.Sprintf("DELETE FROM %s WHERE expires_at <= now()", table),
+		},
+	}
+}
+
+// Schema returns DDL of keys table with given name.
+func Schema(table string) string {
+	index := pgx.Identifier{strings.ReplaceAll(table, ".", "_") + "_expires_at_idx"}.Sanitize()
+	return fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %[1]s (
+	key text PRIMARY KEY,
+	response bytea NOT NULL,
+	created_at timestamptz NOT NULL DEFAULT now(),
+	expires_at timestamptz NOT NULL
+);
+CREATE INDEX IF NOT EXISTS %[2]s ON %[1]s (expires_at);`,
+		tableIdentifier(table), index,
+	)
+}
+
+func tableIdentifier(table string) string {
+	return pgx.Identifier(strings.Split(table, ".")).Sanitize()
+}
+
+// Do returns stored response of request with key, or runs fn inside Transactional and stores its response
+// by the same transaction. When context has no shard ID or sharding key, key is used as sharding key.
+func (s *Store) Do(ctx context.Context, key string, fn func(ctx context.Context) ([]byte, error)) (Result, error) {
+	ctx = pgcontext.With(ctx, pgcontext.WithCanWrite)
+	if !hasShard(ctx) {
+		ctx = pgcontext.With(ctx, pgcontext.WithShardingKey(key))
+	}
+
+	var res Result
+	err := s.db.Transactional(ctx, func(ctx context.Context) error {
+		return s.lock(ctx, key, func(ctx context.Context) error {
+			response, replayed, err := s.stored(ctx, key)
+			if err != nil || replayed {
+				res = Result{Response: response, Replayed: replayed}
+				return err
+			}
+
+			response, err = fn(ctx)
+			if err != nil {
+				return err
+			}
+			if _, err := s.db.Exec(ctx, s.queries.store, key, response, s.cfg.ttl.Milliseconds()); err != nil {
+				return fmt.Errorf("can't store response of %s: %w", key, err)
+			}
+			res = Result{Response: response}
+			return nil
+		})
+	})
+	if err != nil {
+		return Result{}, err
+	}
+	return res, nil
+}
+
+// Cleanup deletes expired keys at pool picked from context and returns count of deleted keys.
+func (s *Store) Cleanup(ctx context.Context) (int64, error) {
+	tag, err := s.db.Exec(pgcontext.With(ctx, pgcontext.WithCanWrite), s.queries.cleanup)
+	if err != nil {
+		return 0, fmt.Errorf("can't clean up expired idempotency keys: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+func (s *Store) lock(ctx context.Context, key string, fn func(ctx context.Context) error) error {
+	lockKey := locker.Key(s.cfg.table + ":" + key)
+	if !s.cfg.failFast {
+		return locker.Lock(ctx, noSession, lockKey, fn)
+	}
+
+	err := locker.TryLock(ctx, noSession, lockKey, fn)
+	if errors.Is(err, locker.ErrLockNotAcquired) {
+		return ErrInProgress
+	}
+	return err
+}
+
+func (s *Store) stored(ctx context.Context, key string) ([]byte, bool, error) {
+	var response []byte
+	err := s.db.QueryRow(ctx, s.queries.stored, key).Scan(&response)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("can't select stored response of %s: %w", key, err)
+	}
+	return response, true, nil
+}
+
+func hasShard(ctx context.Context) bool {
+	if _, ok := pgcontext.ShardIDFrom(ctx); ok {
+		return true
+	}
+	_, ok := pgcontext.ShardingKeyFrom(ctx)
+	return ok
+}
+
+func noSession(context.Context) (locker.Session, error) {
+	return nil, ErrNoTransaction
+}
@@ -0,0 +1,193 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/godepo/elephant/internal/locker"
+	"github.com/godepo/elephant/internal/pkg/pgcontext"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jaswdr/faker/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	queryXactLock    = "SELECT pg_advisory_xact_lock($1)"
+	queryTryXactLock = "SELECT pg_try_advisory_xact_lock($1)"
+)
+
+func newError() error {
+	return errors.New(faker.New().RandomStringWithLength(10))
+}
+
+func runTransactional(db *MockDB, tx *MockTx) {
+	db.EXPECT().Transactional(mock.Anything, mock.Anything).
+		RunAndReturn(func(ctx context.Context, fn func(ctx context.Context) error) error {
+			return fn(pgcontext.With(ctx, pgcontext.WithTransaction(tx)))
+		})
+}
+
+func lockKey(key string) int64 {
+	return locker.Key(defaultTable + ":" + key)
+}
+
+func storedRow(t *testing.T, response []byte, err error) *MockRow {
+	t.Helper()
+	row := NewMockRow(t)
+	row.EXPECT().Scan(mock.Anything).RunAndReturn(func(dest ...any) error {
+		if err != nil {
+			return err
+		}
+		*dest[0].(*[]byte) = response
+		return nil
+	})
+	return row
+}
+
+func lockRow(t *testing.T, value bool) *MockRow {
+	t.Helper()
+	row := NewMockRow(t)
+	row.EXPECT().Scan(mock.Anything).RunAndReturn(func(dest ...any) error {
+		*dest[0].(*bool) = value
+		return nil
+	})
+	return row
+}
+
+func TestSchema(t *testing.T) {
+	t.Run("should be able to make ddl for qualified table", func(t *testing.T) {
+		ddl := Schema("api.keys")
+		assert.Contains(t, ddl, `CREATE TABLE IF NOT EXISTS "api"."keys"`)
+		assert.Contains(t, ddl, `"api_keys_expires_at_idx"`)
+	})
+}
+
+func TestStore_Do(t *testing.T) {
+	t.Run("should be able to run request and store response in transaction", func(t *testing.T) {
+		db, tx := NewMockDB(t), NewMockTx(t)
+		store := New(db, WithTTL(time.Minute))
+		runTransactional(db, tx)
+		tx.EXPECT().Exec(mock.Anything, queryXactLock, []any{lockKey("req-1")}).Return(pgconn.CommandTag{}, nil)
+		db.EXPECT().QueryRow(mock.Anything, store.queries.stored, []any{"req-1"}).Return(storedRow(t, nil, pgx.ErrNoRows))
+		db.EXPECT().Exec(mock.Anything, store.queries.store, []any{"req-1", []byte("ok"), int64(60000)}).
+			Return(pgconn.CommandTag{}, nil)
+
+		res, err := store.Do(context.Background(), "req-1", func(ctx context.Context) ([]byte, error) {
+			key, ok := pgcontext.ShardingKeyFrom(ctx)
+			assert.True(t, ok)
+			assert.Equal(t, "req-1", key)
+			assert.True(t, pgcontext.CanWriteFrom(ctx))
+			return []byte("ok"), nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, Result{Response: []byte("ok")}, res)
+	})
+
+	t.Run("should be able to replay stored response", func(t *testing.T) {
+		db, tx := NewMockDB(t), NewMockTx(t)
+		store := New(db)
+		runTransactional(db, tx)
+		tx.EXPECT().Exec(mock.Anything, queryXactLock, mock.Anything).Return(pgconn.CommandTag{}, nil)
+		db.EXPECT().QueryRow(mock.Anything, store.queries.stored, []any{"req-1"}).
+			Return(storedRow(t, []byte("stored"), nil))
+
+		ctx := pgcontext.With(context.Background(), pgcontext.WithShardID(1))
+		res, err := store.Do(ctx, "req-1", func(context.Context) ([]byte, error) {
+			t.Fatal("request must not run twice")
+			return nil, nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, Result{Response: []byte("stored"), Replayed: true}, res)
+	})
+
+	t.Run("should be able to fail fast when duplicate is in progress", func(t *testing.T) {
+		db, tx := NewMockDB(t), NewMockTx(t)
+		store := New(db, WithFailFast())
+		runTransactional(db, tx)
+		tx.EXPECT().QueryRow(mock.Anything, queryTryXactLock, []any{lockKey("req-1")}).
+			Return(lockRow(t, false))
+
+		_, err := store.Do(context.Background(), "req-1", nil)
+		require.ErrorIs(t, err, ErrInProgress)
+	})
+
+	t.Run("should be able to return error of request without storing response", func(t *testing.T) {
+		expErr := newError()
+		db, tx := NewMockDB(t), NewMockTx(t)
+		store := New(db, WithFailFast())
+		runTransactional(db, tx)
+		tx.EXPECT().QueryRow(mock.Anything, queryTryXactLock, mock.Anything).Return(lockRow(t, true))
+		db.EXPECT().QueryRow(mock.Anything, store.queries.stored, mock.Anything).Return(storedRow(t, nil, pgx.ErrNoRows))
+
+		_, err := store.Do(context.Background(), "req-1", func(context.Context) ([]byte, error) {
+			return nil, expErr
+		})
+		require.ErrorIs(t, err, expErr)
+	})
+
+	t.Run("should be able to fail when can't select stored response", func(t *testing.T) {
+		expErr := newError()
+		db, tx := NewMockDB(t), NewMockTx(t)
+		store := New(db)
+		runTransactional(db, tx)
+		tx.EXPECT().Exec(mock.Anything, queryXactLock, mock.Anything).Return(pgconn.CommandTag{}, nil)
+		db.EXPECT().QueryRow(mock.Anything, store.queries.stored, mock.Anything).Return(storedRow(t, nil, expErr))
+
+		_, err := store.Do(context.Background(), "req-1", nil)
+		require.ErrorIs(t, err, expErr)
+	})
+
+	t.Run("should be able to fail when can't store response", func(t *testing.T) {
+		expErr := newError()
+		db, tx := NewMockDB(t), NewMockTx(t)
+		store := New(db)
+		runTransactional(db, tx)
+		tx.EXPECT().Exec(mock.Anything, queryXactLock, mock.Anything).Return(pgconn.CommandTag{}, nil)
+		db.EXPECT().QueryRow(mock.Anything, store.queries.stored, mock.Anything).Return(storedRow(t, nil, pgx.ErrNoRows))
+		db.EXPECT().Exec(mock.Anything, store.queries.store, mock.Anything).Return(pgconn.CommandTag{}, expErr)
+
+		_, err := store.Do(context.Background(), "req-1", func(context.Context) ([]byte, error) {
+			return []byte("ok"), nil
+		})
+		require.ErrorIs(t, err, expErr)
+	})
+
+	t.Run("should be able to fail when pool didn't put transaction to context", func(t *testing.T) {
+		db := NewMockDB(t)
+		store := New(db, WithTable("keys"))
+		db.EXPECT().Transactional(mock.Anything, mock.Anything).
+			RunAndReturn(func(ctx context.Context, fn func(ctx context.Context) error) error {
+				return fn(ctx)
+			})
+
+		_, err := store.Do(context.Background(), "req-1", nil)
+		require.ErrorIs(t, err, ErrNoTransaction)
+	})
+}
+
+func TestStore_Cleanup(t *testing.T) {
+	t.Run("should be able to delete expired keys", func(t *testing.T) {
+		db := NewMockDB(t)
+		store := New(db)
+		db.EXPECT().Exec(mock.Anything, store.queries.cleanup).Return(pgconn.NewCommandTag("DELETE 3"), nil)
+
+		deleted, err := store.Cleanup(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), deleted)
+	})
+
+	t.Run("should be able to fail when can't delete keys", func(t *testing.T) {
+		expErr := newError()
+		db := NewMockDB(t)
+		store := New(db)
+		db.EXPECT().Exec(mock.Anything, store.queries.cleanup).Return(pgconn.CommandTag{}, expErr)
+
+		_, err := store.Cleanup(context.Background())
+		require.ErrorIs(t, err, expErr)
+	})
+}
@@ -0,0 +1,127 @@
+// Package locker implements PostgreSQL advisory locks bound to transaction from context.
+// When transaction is in context, lock is taken by pg_advisory_xact_lock and released at the end
+// of transaction. Otherwise, lock is taken by session on dedicated connection, which is held until
+// function returns.
+//
+//go:generate go tool mockery
+package locker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+
+	"github.com/godepo/elephant/internal/pkg/pgcontext"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+var ErrLockNotAcquired = errors.New("advisory lock is held by another session")
+
+const (
+	queryXactLock       = "SELECT pg_advisory_xact_lock($1)"
+	queryTryXactLock    = "SELECT pg_try_advisory_xact_lock($1)"
+	querySessionLock    = "SELECT pg_advisory_lock($1)"
+	queryTrySessionLock = "SELECT pg_try_advisory_lock($1)"
+	querySessionUnlock  = "SELECT pg_advisory_unlock($1)"
+)
+
+// Session is dedicated connection which holds session level locks, like pgxpool.Conn.
+type Session interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	Release()
+}
+
+type rowQuerier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// Connector acquires new dedicated connection.
+type Connector func(ctx context.Context) (Session, error)
+
+// Key hashes namespace to advisory lock key.
+func Key(namespace string) int64 {
+	hash := fnv.New64a()
+	_, _ = hash.Write([]byte(namespace))
+	return int64(hash.Sum64()) //nolint:gosec
+}
+
+// Lock waits for advisory lock and runs fn while lock is held.
+func Lock(ctx context.Context, connect Connector, key int64, fn func(ctx context.Context) error) error {
+	if tx, ok := pgcontext.TransactionFrom(ctx); ok {
+		if _, err := tx.Exec(ctx, queryXactLock, key); err != nil {
+			return fmt.Errorf("can't take advisory lock in transaction: %w", err)
+		}
+		return fn(ctx)
+	}
+
+	return withSession(ctx, connect, func(session Session) error {
+		if _, err := session.Exec(ctx, querySessionLock, key); err != nil {
+			return fmt.Errorf("can't take advisory lock: %w", err)
+		}
+		return runAndUnlock(ctx, session, key, fn)
+	})
+}
+
+// TryLock runs fn while lock is held, or returns ErrLockNotAcquired without waiting when lock is busy.
+func TryLock(ctx context.Context, connect Connector, key int64, fn func(ctx context.Context) error) error {
+	if tx, ok := pgcontext.TransactionFrom(ctx); ok {
+		if err := try(ctx, tx, queryTryXactLock, key); err != nil {
+			return err
+		}
+		return fn(ctx)
+	}
+
+	return withSession(ctx, connect, func(session Session) error {
+		if err := TrySessionLock(ctx, session, key); err != nil {
+			return err
+		}
+		return runAndUnlock(ctx, session, key, fn)
+	})
+}
+
+// TrySessionLock takes session level lock at dedicated connection without waiting. Lock is held until
+// SessionUnlock is called or connection is closed.
+func TrySessionLock(ctx context.Context, session Session, key int64) error {
+	return try(ctx, session, queryTrySessionLock, key)
+}
+
+// SessionUnlock releases session level lock taken at dedicated connection.
+func SessionUnlock(ctx context.Context, session Session, key int64) error {
+	if _, err := session.Exec(ctx, querySessionUnlock, key); err != nil {
+		return fmt.Errorf("can't release advisory lock: %w", err)
+	}
+	return nil
+}
+
+func withSession(ctx context.Context, connect Connector, fn func(session Session) error) error {
+	session, err := connect(ctx)
+	if err != nil {
+		return fmt.Errorf("can't acquire connection for advisory lock: %w", err)
+	}
+	defer session.Release()
+
+	return fn(session)
+}
+
+func try(ctx context.Context, db rowQuerier, query string, key int64) error {
+	var acquired bool
+	if err := db.QueryRow(ctx, query, key).Scan(&acquired); err != nil {
+		return fmt.Errorf("can't try advisory lock: %w", err)
+	}
+	if !acquired {
+		return ErrLockNotAcquired
+	}
+	return nil
+}
+
+func runAndUnlock(ctx context.Context, session Session, key int64, fn func(ctx context.Context) error) (out error) {
+	defer func() {
+		if err := SessionUnlock(context.WithoutCancel(ctx), session, key); err != nil {
+			out = errors.Join(out, err)
+		}
+	}()
+	return fn(ctx)
+}
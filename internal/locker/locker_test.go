@@ -0,0 +1,176 @@
+package locker
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/godepo/elephant/internal/pkg/pgcontext"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jaswdr/faker/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func connectTo(session Session) Connector {
+	return func(context.Context) (Session, error) {
+		return session, nil
+	}
+}
+
+func scanBool(t *testing.T, value bool) *MockRow {
+	t.Helper()
+	row := NewMockRow(t)
+	row.EXPECT().Scan(mock.Anything).RunAndReturn(func(dest ...any) error {
+		*dest[0].(*bool) = value
+		return nil
+	})
+	return row
+}
+
+func newError() error {
+	return errors.New(faker.New().RandomStringWithLength(10))
+}
+
+func TestKey(t *testing.T) {
+	t.Run("should be able to hash namespace to stable key", func(t *testing.T) {
+		assert.Equal(t, Key("jobs"), Key("jobs"))
+		assert.NotEqual(t, Key("jobs"), Key("reports"))
+	})
+}
+
+func TestLock(t *testing.T) {
+	key := Key("jobs")
+
+	t.Run("should be able to lock in transaction from context", func(t *testing.T) {
+		tx := NewMockTx(t)
+		ctx := pgcontext.With(context.Background(), pgcontext.WithTransaction(tx))
+		tx.EXPECT().Exec(ctx, queryXactLock, []any{key}).Return(pgconn.CommandTag{}, nil)
+
+		var called bool
+		err := Lock(ctx, nil, key, func(context.Context) error {
+			called = true
+			return nil
+		})
+		require.NoError(t, err)
+		assert.True(t, called)
+	})
+
+	t.Run("should be able to fail when transaction can't take lock", func(t *testing.T) {
+		tx := NewMockTx(t)
+		expErr := newError()
+		ctx := pgcontext.With(context.Background(), pgcontext.WithTransaction(tx))
+		tx.EXPECT().Exec(ctx, queryXactLock, []any{key}).Return(pgconn.CommandTag{}, expErr)
+
+		err := Lock(ctx, nil, key, func(context.Context) error {
+			require.FailNow(t, "must not be called")
+			return nil
+		})
+		require.ErrorIs(t, err, expErr)
+	})
+
+	t.Run("should be able to lock at session and unlock after run", func(t *testing.T) {
+		session := NewMockSession(t)
+		expErr := newError()
+		session.EXPECT().Exec(mock.Anything, querySessionLock, []any{key}).Return(pgconn.CommandTag{}, nil).Once()
+		session.EXPECT().Exec(mock.Anything, querySessionUnlock, []any{key}).Return(pgconn.CommandTag{}, nil).Once()
+		session.EXPECT().Release().Return()
+
+		err := Lock(context.Background(), connectTo(session), key, func(context.Context) error {
+			return expErr
+		})
+		require.ErrorIs(t, err, expErr)
+	})
+
+	t.Run("should be able to return unlock error", func(t *testing.T) {
+		session := NewMockSession(t)
+		expErr := newError()
+		session.EXPECT().Exec(mock.Anything, querySessionLock, []any{key}).Return(pgconn.CommandTag{}, nil).Once()
+		session.EXPECT().Exec(mock.Anything, querySessionUnlock, []any{key}).Return(pgconn.CommandTag{}, expErr).Once()
+		session.EXPECT().Release().Return()
+
+		err := Lock(context.Background(), connectTo(session), key, func(context.Context) error {
+			return nil
+		})
+		require.ErrorIs(t, err, expErr)
+	})
+
+	t.Run("should be able to fail when session can't take lock", func(t *testing.T) {
+		session := NewMockSession(t)
+		expErr := newError()
+		session.EXPECT().Exec(mock.Anything, querySessionLock, []any{key}).Return(pgconn.CommandTag{}, expErr).Once()
+		session.EXPECT().Release().Return()
+
+		err := Lock(context.Background(), connectTo(session), key, func(context.Context) error {
+			return nil
+		})
+		require.ErrorIs(t, err, expErr)
+	})
+
+	t.Run("should be able to fail when can't acquire connection", func(t *testing.T) {
+		expErr := newError()
+
+		err := Lock(context.Background(), func(context.Context) (Session, error) {
+			return nil, expErr
+		}, key, func(context.Context) error {
+			return nil
+		})
+		require.ErrorIs(t, err, expErr)
+	})
+}
+
+func TestTryLock(t *testing.T) {
+	key := Key("jobs")
+
+	t.Run("should be able to lock in transaction from context", func(t *testing.T) {
+		tx := NewMockTx(t)
+		ctx := pgcontext.With(context.Background(), pgcontext.WithTransaction(tx))
+		tx.EXPECT().QueryRow(ctx, queryTryXactLock, []any{key}).Return(scanBool(t, true))
+
+		var called bool
+		err := TryLock(ctx, nil, key, func(context.Context) error {
+			called = true
+			return nil
+		})
+		require.NoError(t, err)
+		assert.True(t, called)
+	})
+
+	t.Run("should be able to return not acquired when transaction lock is busy", func(t *testing.T) {
+		tx := NewMockTx(t)
+		ctx := pgcontext.With(context.Background(), pgcontext.WithTransaction(tx))
+		tx.EXPECT().QueryRow(ctx, queryTryXactLock, []any{key}).Return(scanBool(t, false))
+
+		err := TryLock(ctx, nil, key, func(context.Context) error {
+			return nil
+		})
+		require.ErrorIs(t, err, ErrLockNotAcquired)
+	})
+
+	t.Run("should be able to lock at session and unlock after run", func(t *testing.T) {
+		session := NewMockSession(t)
+		session.EXPECT().QueryRow(mock.Anything, queryTrySessionLock, []any{key}).Return(scanBool(t, true))
+		session.EXPECT().Exec(mock.Anything, querySessionUnlock, []any{key}).Return(pgconn.CommandTag{}, nil).Once()
+		session.EXPECT().Release().Return()
+
+		err := TryLock(context.Background(), connectTo(session), key, func(context.Context) error {
+			return nil
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("should be able to fail when session can't try lock", func(t *testing.T) {
+		session := NewMockSession(t)
+		row := NewMockRow(t)
+		expErr := newError()
+		row.EXPECT().Scan(mock.Anything).Return(expErr)
+		session.EXPECT().QueryRow(mock.Anything, queryTrySessionLock, []any{key}).Return(row)
+		session.EXPECT().Release().Return()
+
+		err := TryLock(context.Background(), connectTo(session), key, func(context.Context) error {
+			return nil
+		})
+		require.ErrorIs(t, err, expErr)
+	})
+}
@@ -0,0 +1,17 @@
+package metrics
+
+import (
+	"context"
+
+	"github.com/godepo/elephant/internal/pkg/passthrough"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Acquire delegates to wrapped pool. Queries at acquired connection are not measured.
+func (m DB) Acquire(ctx context.Context) (*pgxpool.Conn, error) {
+	acq, err := passthrough.AsAcquirer(m.db)
+	if err != nil {
+		return nil, err
+	}
+	return acq.Acquire(ctx)
+}
@@ -0,0 +1,37 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/godepo/elephant/internal/pkg/passthrough"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type acquirePool struct {
+	*MockPool
+	*MockAcquirer
+}
+
+func TestDB_Acquire(t *testing.T) {
+	t.Run("should be able to acquire connection at wrapped pool", func(t *testing.T) {
+		acq := NewMockAcquirer(t)
+		db := New(acquirePool{MockPool: NewMockPool(t), MockAcquirer: acq}, NewMockCollector(t))
+		expErr := errors.New(uuid.NewString())
+		acq.EXPECT().Acquire(context.Background()).Return(nil, expErr)
+
+		conn, err := db.Acquire(context.Background())
+		require.ErrorIs(t, err, expErr)
+		assert.Nil(t, conn)
+	})
+
+	t.Run("should be able to fail when pool can't acquire connection", func(t *testing.T) {
+		db := New(NewMockPool(t), NewMockCollector(t))
+
+		_, err := db.Acquire(context.Background())
+		require.ErrorIs(t, err, passthrough.ErrAcquireNotSupported)
+	})
+}
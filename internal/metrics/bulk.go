@@ -0,0 +1,36 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/godepo/elephant/internal/pkg/failure"
+	"github.com/godepo/elephant/internal/pkg/passthrough"
+	"github.com/jackc/pgx/v5"
+)
+
+// CopyFrom copies rows through wrapped pool and tracks it as single query.
+func (m DB) CopyFrom(
+	ctx context.Context,
+	tableName pgx.Identifier,
+	columnNames []string,
+	rowSrc pgx.CopyFromSource,
+) (int64, error) {
+	bulk, err := passthrough.AsBulk(m.db)
+	if err != nil {
+		return 0, err
+	}
+	begin := time.Now()
+	count, err := bulk.CopyFrom(ctx, tableName, columnNames, rowSrc)
+	m.defaultMetricsCollector.TrackQueryMetrics(ctx, begin, err)
+	return count, err
+}
+
+// SendBatch delegates batch to wrapped pool.
+func (m DB) SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults {
+	bulk, err := passthrough.AsBulk(m.db)
+	if err != nil {
+		return failure.BatchResults(err)
+	}
+	return bulk.SendBatch(ctx, b)
+}
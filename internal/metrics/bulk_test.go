@@ -0,0 +1,80 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/godepo/elephant/internal/pkg/failure"
+	"github.com/godepo/elephant/internal/pkg/passthrough"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type bulkPool struct {
+	*MockPool
+	*MockBulkPool
+}
+
+func TestDB_CopyFrom(t *testing.T) {
+	table := pgx.Identifier{"users"}
+	columns := []string{"id"}
+
+	t.Run("should be able to copy rows and track it", func(t *testing.T) {
+		bulk := NewMockBulkPool(t)
+		col := NewMockCollector(t)
+		db := New(bulkPool{MockPool: NewMockPool(t), MockBulkPool: bulk}, col)
+		src := pgx.CopyFromRows([][]any{{uuid.New()}})
+		ctx := context.Background()
+
+		bulk.EXPECT().CopyFrom(ctx, table, columns, src).Return(1, nil)
+		col.EXPECT().TrackQueryMetrics(ctx, mock.Anything, nil).Return()
+
+		count, err := db.CopyFrom(ctx, table, columns, src)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), count)
+	})
+
+	t.Run("should be able to track failure", func(t *testing.T) {
+		bulk := NewMockBulkPool(t)
+		col := NewMockCollector(t)
+		db := New(bulkPool{MockPool: NewMockPool(t), MockBulkPool: bulk}, col)
+		expErr := errors.New(uuid.NewString())
+		ctx := context.Background()
+
+		bulk.EXPECT().CopyFrom(ctx, table, columns, mock.Anything).Return(0, expErr)
+		col.EXPECT().TrackQueryMetrics(ctx, mock.Anything, expErr).Return()
+
+		_, err := db.CopyFrom(ctx, table, columns, pgx.CopyFromRows(nil))
+		require.ErrorIs(t, err, expErr)
+	})
+
+	t.Run("should be able to fail when pool does not support bulk operations", func(t *testing.T) {
+		db := New(NewMockPool(t), NewMockCollector(t))
+
+		_, err := db.CopyFrom(context.Background(), table, columns, pgx.CopyFromRows(nil))
+		require.ErrorIs(t, err, passthrough.ErrBulkNotSupported)
+	})
+}
+
+func TestDB_SendBatch(t *testing.T) {
+	t.Run("should be able to delegate batch", func(t *testing.T) {
+		bulk := NewMockBulkPool(t)
+		db := New(bulkPool{MockPool: NewMockPool(t), MockBulkPool: bulk}, NewMockCollector(t))
+		batch := &pgx.Batch{}
+		expErr := errors.New(uuid.NewString())
+
+		bulk.EXPECT().SendBatch(mock.Anything, batch).Return(failure.BatchResults(expErr))
+
+		assert.ErrorIs(t, db.SendBatch(context.Background(), batch).Close(), expErr)
+	})
+
+	t.Run("should be able to fail when pool does not support bulk operations", func(t *testing.T) {
+		db := New(NewMockPool(t), NewMockCollector(t))
+
+		assert.ErrorIs(t, db.SendBatch(context.Background(), &pgx.Batch{}).Close(), passthrough.ErrBulkNotSupported)
+	})
+}
@@ -4,6 +4,7 @@ package collector
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/godepo/elephant"
 	"github.com/godepo/elephant/internal/pkg/monads"
@@ -19,6 +20,16 @@ type builder struct {
 	queryLatency       monads.Optional[elephant.HistogramCollector]
 	logInterceptor     monads.Optional[elephant.ErrorsLogInterceptor]
 	resultsInterceptor monads.Optional[elephant.Interceptor]
+	streamRows         monads.Optional[elephant.HistogramCollector]
+	timeToFirstRow     monads.Optional[elephant.HistogramCollector]
+	breakerState       monads.Optional[elephant.GaugeCollector]
+	hedgedReads        monads.Optional[elephant.CounterCollector]
+	lockWait           monads.Optional[elephant.HistogramCollector]
+	poolConns          monads.Optional[elephant.GaugeCollector]
+	poolAcquireWait    monads.Optional[elephant.GaugeCollector]
+	poolAcquireTime    monads.Optional[elephant.GaugeCollector]
+	poolEmptyAcquires  monads.Optional[elephant.GaugeCollector]
+	poolStatsInterval  time.Duration
 }
 
 func (b builder) ResultsInterceptor(interceptor elephant.Interceptor) elephant.MetricsBuilder {
@@ -45,6 +56,84 @@ func (b builder) Latency(collector elephant.HistogramCollector) elephant.Metrics
 	return cln
 }
 
+// StreamRows sets collector which observes count of rows of every elephant.Stream iteration.
+func (b builder) StreamRows(collector elephant.HistogramCollector) elephant.StreamMetricsBuilder {
+	cln := b.clone()
+	cln.streamRows = monads.OptionalOf(collector)
+	return cln
+}
+
+// TimeToFirstRow sets collector which observes milliseconds to first row of elephant.Stream iteration.
+func (b builder) TimeToFirstRow(collector elephant.HistogramCollector) elephant.StreamMetricsBuilder {
+	cln := b.clone()
+	cln.timeToFirstRow = monads.OptionalOf(collector)
+	return cln
+}
+
+// BreakerState sets gauge which is labeled by node name and set to state of its circuit breaker:
+// 0 is closed, 1 is half-open and 2 is open.
+func (b builder) BreakerState(collector elephant.GaugeCollector) elephant.BreakerMetricsBuilder {
+	cln := b.clone()
+	cln.breakerState = monads.OptionalOf(collector)
+	return cln
+}
+
+// HedgedReads sets counter which is labeled by outcome of every hedged read of cluster pools: not_sent,
+// primary_won, hedge_won or failed. Rate of hedges is share of reads which outcome is not not_sent.
+func (b builder) HedgedReads(collector elephant.CounterCollector) elephant.HedgeMetricsBuilder {
+	cln := b.clone()
+	cln.hedgedReads = monads.OptionalOf(collector)
+	return cln
+}
+
+// LockWait sets collector which observes milliseconds spent waiting for advisory lock, failed attempts are
+// observed with failure label.
+func (b builder) LockWait(collector elephant.HistogramCollector) elephant.LockMetricsBuilder {
+	cln := b.clone()
+	cln.lockWait = monads.OptionalOf(collector)
+	return cln
+}
+
+// PoolConnections sets gauge which is labeled by node role, shard and state of connections of pool: acquired,
+// idle, total or max. Shard label is empty for pools which are not sharded.
+func (b builder) PoolConnections(collector elephant.GaugeCollector) elephant.PoolMetricsBuilder {
+	cln := b.clone()
+	cln.poolConns = monads.OptionalOf(collector)
+	return cln
+}
+
+// PoolAcquireWait sets gauge which is labeled by node role and shard and set to cumulative milliseconds spent
+// waiting for connection, because pool was empty. Value only grows, so rate of it is time spent waiting per second.
+func (b builder) PoolAcquireWait(collector elephant.GaugeCollector) elephant.PoolMetricsBuilder {
+	cln := b.clone()
+	cln.poolAcquireWait = monads.OptionalOf(collector)
+	return cln
+}
+
+// PoolAcquireDuration sets gauge which is labeled by node role and shard and set to cumulative milliseconds spent
+// acquiring connections, including acquires which got idle connection at once. Value only grows, so rate of it is
+// time spent acquiring per second.
+func (b builder) PoolAcquireDuration(collector elephant.GaugeCollector) elephant.PoolMetricsBuilder {
+	cln := b.clone()
+	cln.poolAcquireTime = monads.OptionalOf(collector)
+	return cln
+}
+
+// PoolEmptyAcquires sets gauge which is labeled by node role and shard and set to cumulative count of acquires
+// which waited for connection, because pool was empty. Value only grows, like value of PoolAcquireWait.
+func (b builder) PoolEmptyAcquires(collector elephant.GaugeCollector) elephant.PoolMetricsBuilder {
+	cln := b.clone()
+	cln.poolEmptyAcquires = monads.OptionalOf(collector)
+	return cln
+}
+
+// PoolStatsInterval sets interval of export of pool stats, DefaultPoolStatsInterval is used when it is not set.
+func (b builder) PoolStatsInterval(interval time.Duration) elephant.PoolMetricsBuilder {
+	cln := b.clone()
+	cln.poolStatsInterval = interval
+	return cln
+}
+
 func (b builder) Build() (elephant.MetricsCollector, error) {
 	if b.queryPerSeconds.IsEmpty() {
 		return nil, ErrQueryPerSecondIsRequired
@@ -57,6 +146,19 @@ func (b builder) Build() (elephant.MetricsCollector, error) {
 		queryResultsCollector:   b.queryLatency.Value,
 		interceptor:             defaultInterceptor,
 		logInterceptor:          func(err error) {},
+		streamRows:              b.streamRows,
+		timeToFirstRow:          b.timeToFirstRow,
+		breakerState:            b.breakerState,
+		hedgedReads:             b.hedgedReads,
+		lockWait:                b.lockWait,
+		poolConns:               b.poolConns,
+		poolAcquireWait:         b.poolAcquireWait,
+		poolAcquireTime:         b.poolAcquireTime,
+		poolEmptyAcquires:       b.poolEmptyAcquires,
+		poolStatsInterval:       DefaultPoolStatsInterval,
+	}
+	if b.poolStatsInterval > 0 {
+		collector.poolStatsInterval = b.poolStatsInterval
 	}
 	if !b.logInterceptor.IsEmpty() {
 		collector.logInterceptor = b.logInterceptor.Value
@@ -73,6 +175,16 @@ func (b builder) clone() builder {
 		queryPerSeconds:    b.queryPerSeconds,
 		logInterceptor:     b.logInterceptor,
 		resultsInterceptor: b.resultsInterceptor,
+		streamRows:         b.streamRows,
+		timeToFirstRow:     b.timeToFirstRow,
+		breakerState:       b.breakerState,
+		hedgedReads:        b.hedgedReads,
+		lockWait:           b.lockWait,
+		poolConns:          b.poolConns,
+		poolAcquireWait:    b.poolAcquireWait,
+		poolAcquireTime:    b.poolAcquireTime,
+		poolEmptyAcquires:  b.poolEmptyAcquires,
+		poolStatsInterval:  b.poolStatsInterval,
 	}
 
 	return out
@@ -4,8 +4,10 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/godepo/elephant"
+	"github.com/godepo/elephant/internal/pkg/monads"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -181,3 +183,125 @@ func TestBuilder_Build(t *testing.T) {
 	})
 
 }
+
+func TestBuilder_StreamMetrics(t *testing.T) {
+	t.Run("should be able to be able", func(t *testing.T) {
+		rows := NewMockHistogram(t)
+		firstRow := NewMockHistogram(t)
+		bld := New()
+		tmp, ok := bld.(builder)
+		require.True(t, ok)
+
+		bld = bld.(elephant.StreamMetricsBuilder).
+			StreamRows(func(labels ...string) (elephant.Histogram, error) {
+				return rows, nil
+			}).
+			TimeToFirstRow(func(labels ...string) (elephant.Histogram, error) {
+				return firstRow, nil
+			})
+		res, ok := bld.(builder)
+		require.True(t, ok)
+		assert.True(t, tmp.streamRows.IsEmpty())
+		assert.True(t, tmp.timeToFirstRow.IsEmpty())
+
+		col, err := res.streamRows.Value()
+		require.NoError(t, err)
+		assert.Equal(t, rows, col)
+
+		col, err = res.timeToFirstRow.Value()
+		require.NoError(t, err)
+		assert.Equal(t, firstRow, col)
+	})
+}
+
+func TestBuilder_BreakerState(t *testing.T) {
+	t.Run("should be able to be able", func(t *testing.T) {
+		exp := NewMockGauge(t)
+		bld := New()
+		tmp, ok := bld.(builder)
+		require.True(t, ok)
+
+		bld = bld.(elephant.BreakerMetricsBuilder).BreakerState(func(labels ...string) (elephant.Gauge, error) {
+			return exp, nil
+		})
+		res, ok := bld.(builder)
+		require.True(t, ok)
+		assert.True(t, tmp.breakerState.IsEmpty())
+
+		col, err := res.breakerState.Value()
+		require.NoError(t, err)
+		assert.Equal(t, exp, col)
+	})
+}
+
+func TestBuilder_HedgedReads(t *testing.T) {
+	t.Run("should be able to be able", func(t *testing.T) {
+		exp := NewMockCounter(t)
+		bld := New()
+		tmp, ok := bld.(builder)
+		require.True(t, ok)
+
+		bld = bld.(elephant.HedgeMetricsBuilder).HedgedReads(func(labels ...string) (elephant.Counter, error) {
+			return exp, nil
+		})
+		res, ok := bld.(builder)
+		require.True(t, ok)
+		assert.True(t, tmp.hedgedReads.IsEmpty())
+
+		col, err := res.hedgedReads.Value()
+		require.NoError(t, err)
+		assert.Equal(t, exp, col)
+	})
+}
+
+func TestBuilder_LockWait(t *testing.T) {
+	t.Run("should be able to be able", func(t *testing.T) {
+		exp := NewMockHistogram(t)
+		bld := New()
+		tmp, ok := bld.(builder)
+		require.True(t, ok)
+
+		bld = bld.(elephant.LockMetricsBuilder).LockWait(func(labels ...string) (elephant.Histogram, error) {
+			return exp, nil
+		})
+		res, ok := bld.(builder)
+		require.True(t, ok)
+		assert.True(t, tmp.lockWait.IsEmpty())
+
+		col, err := res.lockWait.Value()
+		require.NoError(t, err)
+		assert.Equal(t, exp, col)
+	})
+}
+
+func TestBuilder_PoolStats(t *testing.T) {
+	t.Run("should be able to be able", func(t *testing.T) {
+		conns, wait, acquire, empty := NewMockGauge(t), NewMockGauge(t), NewMockGauge(t), NewMockGauge(t)
+		bld := New()
+		tmp, ok := bld.(builder)
+		require.True(t, ok)
+
+		bld = bld.(elephant.PoolMetricsBuilder).
+			PoolConnections(func(...string) (elephant.Gauge, error) { return conns, nil }).
+			PoolAcquireWait(func(...string) (elephant.Gauge, error) { return wait, nil }).
+			PoolAcquireDuration(func(...string) (elephant.Gauge, error) { return acquire, nil }).
+			PoolEmptyAcquires(func(...string) (elephant.Gauge, error) { return empty, nil }).
+			PoolStatsInterval(time.Second)
+		res, ok := bld.(builder)
+		require.True(t, ok)
+		assert.True(t, tmp.poolConns.IsEmpty())
+		assert.Zero(t, tmp.poolStatsInterval)
+		assert.Equal(t, time.Second, res.poolStatsInterval)
+
+		for exp, col := range map[elephant.Gauge]monads.Optional[elephant.GaugeCollector]{
+			conns:   res.poolConns,
+			wait:    res.poolAcquireWait,
+			acquire: res.poolAcquireTime,
+			empty:   res.poolEmptyAcquires,
+		} {
+			gauge, err := col.Value()
+			require.NoError(t, err)
+			assert.Equal(t, exp, gauge)
+		}
+	})
+}
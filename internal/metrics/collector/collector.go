@@ -8,12 +8,21 @@ import (
 	"time"
 
 	"github.com/godepo/elephant"
+	"github.com/godepo/elephant/internal/breaker"
+	"github.com/godepo/elephant/internal/cluster"
+	"github.com/godepo/elephant/internal/pkg/monads"
 	"github.com/godepo/elephant/internal/pkg/pgcontext"
 )
 
 var (
 	ErrCantGetQueryPerSecondCollector = errors.New("can't get query per second collector")
 	ErrCantQetQueryLatencyCollector   = errors.New("can't query latency collector")
+	ErrCantGetStreamRowsCollector     = errors.New("can't get stream rows collector")
+	ErrCantGetTimeToFirstRowCollector = errors.New("can't get time to first row collector")
+	ErrCantGetBreakerStateCollector   = errors.New("can't get breaker state collector")
+	ErrCantGetHedgedReadsCollector    = errors.New("can't get hedged reads collector")
+	ErrCantGetLockWaitCollector       = errors.New("can't get lock wait collector")
+	ErrCantGetPoolStatsCollector      = errors.New("can't get pool stats collector")
 )
 
 type Collector struct {
@@ -21,6 +30,16 @@ type Collector struct {
 	queryPerSecondCollector elephant.CounterCollector
 	queryResultsCollector   elephant.HistogramCollector
 	logInterceptor          elephant.ErrorsLogInterceptor
+	streamRows              monads.Optional[elephant.HistogramCollector]
+	timeToFirstRow          monads.Optional[elephant.HistogramCollector]
+	breakerState            monads.Optional[elephant.GaugeCollector]
+	hedgedReads             monads.Optional[elephant.CounterCollector]
+	lockWait                monads.Optional[elephant.HistogramCollector]
+	poolConns               monads.Optional[elephant.GaugeCollector]
+	poolAcquireWait         monads.Optional[elephant.GaugeCollector]
+	poolAcquireTime         monads.Optional[elephant.GaugeCollector]
+	poolEmptyAcquires       monads.Optional[elephant.GaugeCollector]
+	poolStatsInterval       time.Duration
 }
 
 func (clt *Collector) TrackQueryMetrics(ctx context.Context, begin time.Time, err error) {
@@ -61,3 +80,76 @@ func (clt *Collector) TrackQueryMetrics(ctx context.Context, begin time.Time, er
 		col.Observe(since)
 	}
 }
+
+// TrackStreamMetrics observes count of streamed rows and time to first row, when collectors for them are set.
+// Time to first row is not observed for empty streams.
+func (clt *Collector) TrackStreamMetrics(ctx context.Context, firstRow time.Duration, rows int, err error) {
+	labels, ok := pgcontext.MetricsLabelsFrom(ctx)
+	if !ok {
+		return
+	}
+	labels = append(labels, clt.interceptor(ctx, err))
+
+	if !clt.streamRows.IsEmpty() {
+		clt.observe(clt.streamRows.Value, ErrCantGetStreamRowsCollector, labels, float64(rows))
+	}
+	if !clt.timeToFirstRow.IsEmpty() && rows > 0 {
+		clt.observe(clt.timeToFirstRow.Value, ErrCantGetTimeToFirstRowCollector, labels,
+			float64(firstRow.Milliseconds()))
+	}
+}
+
+// TrackLockWait observes time spent waiting for advisory lock, when collector for it is set.
+func (clt *Collector) TrackLockWait(ctx context.Context, wait time.Duration, err error) {
+	if clt.lockWait.IsEmpty() {
+		return
+	}
+	labels, ok := pgcontext.MetricsLabelsFrom(ctx)
+	if !ok {
+		return
+	}
+	labels = append(labels, clt.interceptor(ctx, err))
+	clt.observe(clt.lockWait.Value, ErrCantGetLockWaitCollector, labels, float64(wait.Milliseconds()))
+}
+
+func (clt *Collector) observe(collector elephant.HistogramCollector, cause error, labels []string, value float64) {
+	col, err := collector(labels...)
+	if err != nil {
+		clt.logInterceptor(fmt.Errorf("%w: %w: %v", cause, err, labels))
+		return
+	}
+	col.Observe(value)
+}
+
+// TrackBreakerState sets state of circuit breaker of node, when gauge for it is set.
+func (clt *Collector) TrackBreakerState(node string, state breaker.State) {
+	if clt.breakerState.IsEmpty() {
+		return
+	}
+	col, err := clt.breakerState.Value(node)
+	if err != nil {
+		clt.logInterceptor(fmt.Errorf("%w: %w: %s", ErrCantGetBreakerStateCollector, err, node))
+		return
+	}
+	col.Set(float64(state))
+}
+
+// TrackHedgedRead counts outcome of hedged read of cluster pool, when counter for it is set. Outcome is appended
+// to metrics labels from context.
+func (clt *Collector) TrackHedgedRead(ctx context.Context, outcome cluster.HedgeOutcome) {
+	if clt.hedgedReads.IsEmpty() {
+		return
+	}
+	labels, ok := pgcontext.MetricsLabelsFrom(ctx)
+	if !ok {
+		return
+	}
+	labels = append(labels, string(outcome))
+
+	col, err := clt.hedgedReads.Value(labels...)
+	if err != nil {
+		clt.logInterceptor(fmt.Errorf("%w: %w: %v", ErrCantGetHedgedReadsCollector, err, labels))
+		return
+	}
+	col.Inc()
+}
@@ -7,6 +7,8 @@ import (
 	"time"
 
 	"github.com/godepo/elephant"
+	"github.com/godepo/elephant/internal/breaker"
+	"github.com/godepo/elephant/internal/cluster"
 	"github.com/godepo/elephant/internal/pkg/pgcontext"
 	"github.com/godepo/groat"
 	"github.com/google/uuid"
@@ -249,3 +251,218 @@ func ArrangeQueryLabels(t *testing.T, state State) State {
 	state.Given.Labels = []string{uuid.NewString(), uuid.NewString()}
 	return state
 }
+
+func TestCollector_TrackStreamMetrics(t *testing.T) {
+	labels := []string{uuid.NewString()}
+	ctx := pgcontext.With(context.Background(), pgcontext.WithMetricsLabel(labels...))
+	expLabels := []any{labels[0], "ok"}
+
+	newCollector := func(t *testing.T, rows, firstRow *MockHistogramCollector, log *MockErrorsLogInterceptor) *Collector {
+		t.Helper()
+		res, err := New().(elephant.StreamMetricsBuilder).
+			StreamRows(rows.Execute).
+			TimeToFirstRow(firstRow.Execute).
+			ErrorsLogInterceptor(log.Execute).
+			ResultsInterceptor(func(context.Context, error) string { return "ok" }).
+			QueryPerSecond(NewMockCounterCollector(t).Execute).
+			Latency(NewMockHistogramCollector(t).Execute).
+			Build()
+		require.NoError(t, err)
+		return res.(*Collector)
+	}
+
+	t.Run("should be able to observe rows and time to first row", func(t *testing.T) {
+		rows, firstRow := NewMockHistogramCollector(t), NewMockHistogramCollector(t)
+		rowsHist, firstRowHist := NewMockHistogram(t), NewMockHistogram(t)
+		rows.EXPECT().Execute(expLabels...).Return(rowsHist, nil)
+		firstRow.EXPECT().Execute(expLabels...).Return(firstRowHist, nil)
+		rowsHist.EXPECT().Observe(float64(3))
+		firstRowHist.EXPECT().Observe(float64(15))
+
+		newCollector(t, rows, firstRow, NewMockErrorsLogInterceptor(t)).
+			TrackStreamMetrics(ctx, 15*time.Millisecond, 3, nil)
+	})
+
+	t.Run("should be able to skip time to first row of empty stream", func(t *testing.T) {
+		rows, firstRow := NewMockHistogramCollector(t), NewMockHistogramCollector(t)
+		rowsHist := NewMockHistogram(t)
+		rows.EXPECT().Execute(expLabels...).Return(rowsHist, nil)
+		rowsHist.EXPECT().Observe(float64(0))
+
+		newCollector(t, rows, firstRow, NewMockErrorsLogInterceptor(t)).
+			TrackStreamMetrics(ctx, 0, 0, nil)
+	})
+
+	t.Run("should be able to log error of collector", func(t *testing.T) {
+		rows, firstRow := NewMockHistogramCollector(t), NewMockHistogramCollector(t)
+		log := NewMockErrorsLogInterceptor(t)
+		expErr := errors.New(uuid.NewString())
+		rows.EXPECT().Execute(expLabels...).Return(nil, expErr)
+		firstRow.EXPECT().Execute(expLabels...).Return(nil, expErr)
+		log.EXPECT().Execute(mock.MatchedBy(func(err error) bool {
+			return errors.Is(err, expErr) &&
+				(errors.Is(err, ErrCantGetStreamRowsCollector) || errors.Is(err, ErrCantGetTimeToFirstRowCollector))
+		})).Times(2)
+
+		newCollector(t, rows, firstRow, log).TrackStreamMetrics(ctx, time.Millisecond, 1, nil)
+	})
+
+	t.Run("should be able to do nothing, when labels is empty", func(t *testing.T) {
+		rows, firstRow := NewMockHistogramCollector(t), NewMockHistogramCollector(t)
+		newCollector(t, rows, firstRow, NewMockErrorsLogInterceptor(t)).
+			TrackStreamMetrics(context.Background(), time.Millisecond, 1, nil)
+	})
+}
+
+func TestCollector_TrackBreakerState(t *testing.T) {
+	t.Run("should be able to set state of node", func(t *testing.T) {
+		gauge := NewMockGauge(t)
+		gauge.EXPECT().Set(float64(breaker.StateOpen))
+		res, err := New().(elephant.BreakerMetricsBuilder).
+			BreakerState(func(labels ...string) (elephant.Gauge, error) {
+				assert.Equal(t, []string{"follower-1"}, labels)
+				return gauge, nil
+			}).
+			QueryPerSecond(NewMockCounterCollector(t).Execute).
+			Latency(NewMockHistogramCollector(t).Execute).
+			Build()
+		require.NoError(t, err)
+
+		res.(*Collector).TrackBreakerState("follower-1", breaker.StateOpen)
+	})
+
+	t.Run("should be able to log error of collector", func(t *testing.T) {
+		log := NewMockErrorsLogInterceptor(t)
+		expErr := errors.New(uuid.NewString())
+		log.EXPECT().Execute(mock.MatchedBy(func(err error) bool {
+			return errors.Is(err, expErr) && errors.Is(err, ErrCantGetBreakerStateCollector)
+		}))
+		res, err := New().(elephant.BreakerMetricsBuilder).
+			BreakerState(func(...string) (elephant.Gauge, error) { return nil, expErr }).
+			ErrorsLogInterceptor(log.Execute).
+			QueryPerSecond(NewMockCounterCollector(t).Execute).
+			Latency(NewMockHistogramCollector(t).Execute).
+			Build()
+		require.NoError(t, err)
+
+		res.(*Collector).TrackBreakerState("follower-1", breaker.StateOpen)
+	})
+
+	t.Run("should be able to do nothing, when gauge is not set", func(t *testing.T) {
+		res, err := New().
+			QueryPerSecond(NewMockCounterCollector(t).Execute).
+			Latency(NewMockHistogramCollector(t).Execute).
+			Build()
+		require.NoError(t, err)
+
+		res.(*Collector).TrackBreakerState("follower-1", breaker.StateOpen)
+	})
+}
+
+func TestCollector_TrackHedgedRead(t *testing.T) {
+	ctx := pgcontext.With(context.Background(), pgcontext.WithMetricsLabel("users"))
+
+	t.Run("should be able to count outcome of hedged read", func(t *testing.T) {
+		counter := NewMockCounter(t)
+		counter.EXPECT().Inc()
+		res, err := New().(elephant.HedgeMetricsBuilder).
+			HedgedReads(func(labels ...string) (elephant.Counter, error) {
+				assert.Equal(t, []string{"users", "hedge_won"}, labels)
+				return counter, nil
+			}).
+			QueryPerSecond(NewMockCounterCollector(t).Execute).
+			Latency(NewMockHistogramCollector(t).Execute).
+			Build()
+		require.NoError(t, err)
+
+		res.(*Collector).TrackHedgedRead(ctx, cluster.HedgeWon)
+	})
+
+	t.Run("should be able to log error of collector", func(t *testing.T) {
+		log := NewMockErrorsLogInterceptor(t)
+		expErr := errors.New(uuid.NewString())
+		log.EXPECT().Execute(mock.MatchedBy(func(err error) bool {
+			return errors.Is(err, expErr) && errors.Is(err, ErrCantGetHedgedReadsCollector)
+		}))
+		res, err := New().(elephant.HedgeMetricsBuilder).
+			HedgedReads(func(...string) (elephant.Counter, error) { return nil, expErr }).
+			ErrorsLogInterceptor(log.Execute).
+			QueryPerSecond(NewMockCounterCollector(t).Execute).
+			Latency(NewMockHistogramCollector(t).Execute).
+			Build()
+		require.NoError(t, err)
+
+		res.(*Collector).TrackHedgedRead(ctx, cluster.HedgeNotSent)
+	})
+
+	t.Run("should be able to do nothing, when counter or labels are not set", func(t *testing.T) {
+		res, err := New().(elephant.HedgeMetricsBuilder).
+			HedgedReads(NewMockCounterCollector(t).Execute).
+			QueryPerSecond(NewMockCounterCollector(t).Execute).
+			Latency(NewMockHistogramCollector(t).Execute).
+			Build()
+		require.NoError(t, err)
+		res.(*Collector).TrackHedgedRead(context.Background(), cluster.HedgeWon)
+
+		res, err = New().
+			QueryPerSecond(NewMockCounterCollector(t).Execute).
+			Latency(NewMockHistogramCollector(t).Execute).
+			Build()
+		require.NoError(t, err)
+		res.(*Collector).TrackHedgedRead(ctx, cluster.HedgeWon)
+	})
+}
+
+func TestCollector_TrackLockWait(t *testing.T) {
+	ctx := pgcontext.With(context.Background(), pgcontext.WithMetricsLabel("billing"))
+	expErr := errors.New(uuid.NewString())
+
+	t.Run("should be able to observe lock wait", func(t *testing.T) {
+		hist := NewMockHistogram(t)
+		hist.EXPECT().Observe(float64(25))
+		res, err := New().(elephant.LockMetricsBuilder).
+			LockWait(func(labels ...string) (elephant.Histogram, error) {
+				assert.Equal(t, []string{"billing", InterceptAsFailure}, labels)
+				return hist, nil
+			}).
+			QueryPerSecond(NewMockCounterCollector(t).Execute).
+			Latency(NewMockHistogramCollector(t).Execute).
+			Build()
+		require.NoError(t, err)
+
+		res.(*Collector).TrackLockWait(ctx, 25*time.Millisecond, expErr)
+	})
+
+	t.Run("should be able to log error of collector", func(t *testing.T) {
+		log := NewMockErrorsLogInterceptor(t)
+		log.EXPECT().Execute(mock.MatchedBy(func(err error) bool {
+			return errors.Is(err, expErr) && errors.Is(err, ErrCantGetLockWaitCollector)
+		}))
+		res, err := New().(elephant.LockMetricsBuilder).
+			LockWait(func(...string) (elephant.Histogram, error) { return nil, expErr }).
+			ErrorsLogInterceptor(log.Execute).
+			QueryPerSecond(NewMockCounterCollector(t).Execute).
+			Latency(NewMockHistogramCollector(t).Execute).
+			Build()
+		require.NoError(t, err)
+
+		res.(*Collector).TrackLockWait(ctx, time.Millisecond, nil)
+	})
+
+	t.Run("should be able to do nothing, when collector or labels are not set", func(t *testing.T) {
+		res, err := New().(elephant.LockMetricsBuilder).
+			LockWait(NewMockHistogramCollector(t).Execute).
+			QueryPerSecond(NewMockCounterCollector(t).Execute).
+			Latency(NewMockHistogramCollector(t).Execute).
+			Build()
+		require.NoError(t, err)
+		res.(*Collector).TrackLockWait(context.Background(), time.Millisecond, nil)
+
+		res, err = New().
+			QueryPerSecond(NewMockCounterCollector(t).Execute).
+			Latency(NewMockHistogramCollector(t).Execute).
+			Build()
+		require.NoError(t, err)
+		res.(*Collector).TrackLockWait(ctx, time.Millisecond, nil)
+	})
+}
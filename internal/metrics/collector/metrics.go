@@ -1,6 +1,17 @@
 package collector
 
+import "time"
+
 const (
 	InterceptAsFailure = "failure"
 	InterceptAsSuccess = "success"
 )
+
+const (
+	ConnsAcquired = "acquired"
+	ConnsIdle     = "idle"
+	ConnsTotal    = "total"
+	ConnsMax      = "max"
+)
+
+const DefaultPoolStatsInterval = 15 * time.Second
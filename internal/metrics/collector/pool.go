@@ -0,0 +1,70 @@
+package collector
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/godepo/elephant"
+	"github.com/godepo/elephant/internal/pkg/lifecycle"
+	"github.com/godepo/elephant/internal/pkg/monads"
+)
+
+type poolGroup struct {
+	role  string
+	shard string
+}
+
+// PoolStatsInterval returns interval of export of pool stats. It is zero, when no gauge of pool stats is set.
+func (clt *Collector) PoolStatsInterval() time.Duration {
+	if clt.poolConns.IsEmpty() && clt.poolAcquireWait.IsEmpty() && clt.poolAcquireTime.IsEmpty() &&
+		clt.poolEmptyAcquires.IsEmpty() {
+		return 0
+	}
+	return clt.poolStatsInterval
+}
+
+// TrackPoolStats sets gauges of pool stats, when they are set. Stats of nodes are summed up by role and shard, so
+// followers of cluster are one series. Acquire wait, acquire duration and empty acquires are cumulative, so their
+// values only grow until pool is recreated.
+func (clt *Collector) TrackPoolStats(stats lifecycle.Stats) {
+	var groups []poolGroup
+	sums := map[poolGroup]lifecycle.Stat{}
+	for _, stat := range stats {
+		group := poolGroup{role: string(stat.Node.Role)}
+		if !stat.Node.Shard.IsEmpty() {
+			group.shard = strconv.FormatUint(uint64(stat.Node.Shard.Value), 10)
+		}
+		if _, ok := sums[group]; !ok {
+			groups = append(groups, group)
+		}
+		sums[group] = sums[group].Add(stat.Stat)
+	}
+
+	for _, group := range groups {
+		stat := sums[group]
+		for state, value := range map[string]int32{
+			ConnsAcquired: stat.AcquiredConns,
+			ConnsIdle:     stat.IdleConns,
+			ConnsTotal:    stat.TotalConns,
+			ConnsMax:      stat.MaxConns,
+		} {
+			clt.set(clt.poolConns, float64(value), group.role, group.shard, state)
+		}
+		clt.set(clt.poolAcquireWait, float64(stat.EmptyAcquireWaitTime.Milliseconds()), group.role, group.shard)
+		clt.set(clt.poolAcquireTime, float64(stat.AcquireDuration.Milliseconds()), group.role, group.shard)
+		clt.set(clt.poolEmptyAcquires, float64(stat.EmptyAcquireCount), group.role, group.shard)
+	}
+}
+
+func (clt *Collector) set(collector monads.Optional[elephant.GaugeCollector], value float64, labels ...string) {
+	if collector.IsEmpty() {
+		return
+	}
+	col, err := collector.Value(labels...)
+	if err != nil {
+		clt.logInterceptor(fmt.Errorf("%w: %w: %v", ErrCantGetPoolStatsCollector, err, labels))
+		return
+	}
+	col.Set(value)
+}
@@ -0,0 +1,120 @@
+package collector
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/godepo/elephant"
+	"github.com/godepo/elephant/internal/pkg/lifecycle"
+	"github.com/godepo/elephant/internal/pkg/monads"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func gauges(t *testing.T, exp map[string]float64) elephant.GaugeCollector {
+	t.Helper()
+	return func(labels ...string) (elephant.Gauge, error) {
+		key := ""
+		for _, label := range labels {
+			key += "/" + label
+		}
+		value, ok := exp[key]
+		require.True(t, ok, key)
+		gauge := NewMockGauge(t)
+		gauge.EXPECT().Set(value)
+		return gauge, nil
+	}
+}
+
+func TestCollector_TrackPoolStats(t *testing.T) {
+	follower := lifecycle.Node{Role: lifecycle.RoleFollower}
+	stats := lifecycle.Stats{
+		{
+			Node: lifecycle.Node{Role: lifecycle.RoleLeader},
+			Stat: lifecycle.Stat{AcquiredConns: 1, IdleConns: 2, TotalConns: 3, MaxConns: 4,
+				EmptyAcquireCount: 5, EmptyAcquireWaitTime: 6 * time.Millisecond, AcquireDuration: 7 * time.Millisecond},
+		},
+		{
+			Node: follower.InShard(1),
+			Stat: lifecycle.Stat{AcquiredConns: 1, TotalConns: 1, MaxConns: 2, EmptyAcquireCount: 1,
+				AcquireDuration: time.Second},
+		},
+		{
+			Node: lifecycle.Node{Role: lifecycle.RoleFollower, Index: 1, Shard: monads.OptionalOf[uint](1)},
+			Stat: lifecycle.Stat{IdleConns: 1, TotalConns: 1, MaxConns: 2, EmptyAcquireWaitTime: time.Second},
+		},
+	}
+
+	t.Run("should be able to set gauges summed up by role and shard", func(t *testing.T) {
+		res, err := New().(elephant.PoolMetricsBuilder).
+			PoolConnections(gauges(t, map[string]float64{
+				"/leader//acquired": 1, "/leader//idle": 2, "/leader//total": 3, "/leader//max": 4,
+				"/follower/1/acquired": 1, "/follower/1/idle": 1, "/follower/1/total": 2, "/follower/1/max": 4,
+			})).
+			PoolAcquireWait(gauges(t, map[string]float64{"/leader/": 6, "/follower/1": 1000})).
+			PoolAcquireDuration(gauges(t, map[string]float64{"/leader/": 7, "/follower/1": 1000})).
+			PoolEmptyAcquires(gauges(t, map[string]float64{"/leader/": 5, "/follower/1": 1})).
+			QueryPerSecond(NewMockCounterCollector(t).Execute).
+			Latency(NewMockHistogramCollector(t).Execute).
+			Build()
+		require.NoError(t, err)
+
+		res.(*Collector).TrackPoolStats(stats)
+	})
+
+	t.Run("should be able to log error of collector", func(t *testing.T) {
+		log := NewMockErrorsLogInterceptor(t)
+		expErr := errors.New(uuid.NewString())
+		log.EXPECT().Execute(mock.MatchedBy(func(err error) bool {
+			return errors.Is(err, expErr) && errors.Is(err, ErrCantGetPoolStatsCollector)
+		})).Times(2)
+		res, err := New().(elephant.PoolMetricsBuilder).
+			PoolEmptyAcquires(func(...string) (elephant.Gauge, error) { return nil, expErr }).
+			ErrorsLogInterceptor(log.Execute).
+			QueryPerSecond(NewMockCounterCollector(t).Execute).
+			Latency(NewMockHistogramCollector(t).Execute).
+			Build()
+		require.NoError(t, err)
+
+		res.(*Collector).TrackPoolStats(stats)
+	})
+}
+
+func TestCollector_PoolStatsInterval(t *testing.T) {
+	t.Run("should be able to be zero, when gauges are not set", func(t *testing.T) {
+		res, err := New().(elephant.PoolMetricsBuilder).
+			PoolStatsInterval(time.Second).
+			QueryPerSecond(NewMockCounterCollector(t).Execute).
+			Latency(NewMockHistogramCollector(t).Execute).
+			Build()
+		require.NoError(t, err)
+
+		assert.Zero(t, res.(*Collector).PoolStatsInterval())
+	})
+
+	t.Run("should be able to be default, when it is not set", func(t *testing.T) {
+		res, err := New().(elephant.PoolMetricsBuilder).
+			PoolConnections(gauges(t, nil)).
+			QueryPerSecond(NewMockCounterCollector(t).Execute).
+			Latency(NewMockHistogramCollector(t).Execute).
+			Build()
+		require.NoError(t, err)
+
+		assert.Equal(t, DefaultPoolStatsInterval, res.(*Collector).PoolStatsInterval())
+	})
+
+	t.Run("should be able to be set", func(t *testing.T) {
+		res, err := New().(elephant.PoolMetricsBuilder).
+			PoolAcquireDuration(gauges(t, nil)).
+			PoolStatsInterval(time.Second).
+			QueryPerSecond(NewMockCounterCollector(t).Execute).
+			Latency(NewMockHistogramCollector(t).Execute).
+			Build()
+		require.NoError(t, err)
+
+		assert.Equal(t, time.Second, res.(*Collector).PoolStatsInterval())
+	})
+}
@@ -0,0 +1,22 @@
+package metrics
+
+import (
+	"context"
+
+	"github.com/godepo/elephant/internal/pkg/lifecycle"
+)
+
+// Close closes wrapped pool when it can be closed.
+func (m DB) Close() {
+	lifecycle.CloseMember(m.db)
+}
+
+// Ping delegates to wrapped pool, pings are not measured.
+func (m DB) Ping(ctx context.Context) (lifecycle.Pings, error) {
+	pings := lifecycle.PingMember(ctx, m.db)
+	return pings, pings.Err()
+}
+
+func (m DB) Stats() lifecycle.Stats {
+	return lifecycle.MemberStats(m.db)
+}
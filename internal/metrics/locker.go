@@ -0,0 +1,54 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/godepo/elephant/internal/pkg/passthrough"
+)
+
+// LockCollector interface is implemented by collector with histogram of lock waits, waits are not tracked without it.
+type LockCollector interface {
+	TrackLockWait(ctx context.Context, wait time.Duration, err error)
+}
+
+type lockFunc func(ctx context.Context, key string, fn func(ctx context.Context) error) error
+
+// WithAdvisoryLock runs fn under advisory lock of wrapped pool and tracks how long lock was awaited.
+func (m DB) WithAdvisoryLock(ctx context.Context, key string, fn func(ctx context.Context) error) error {
+	lck, err := passthrough.AsLocker(m.db)
+	if err != nil {
+		return err
+	}
+	return m.trackLock(ctx, lck.WithAdvisoryLock, key, fn)
+}
+
+// WithTryAdvisoryLock runs fn under advisory lock of wrapped pool without waiting and tracks attempt duration.
+func (m DB) WithTryAdvisoryLock(ctx context.Context, key string, fn func(ctx context.Context) error) error {
+	lck, err := passthrough.AsLocker(m.db)
+	if err != nil {
+		return err
+	}
+	return m.trackLock(ctx, lck.WithTryAdvisoryLock, key, fn)
+}
+
+// trackLock passes how long lock was awaited to collector, when it supports lock metrics. Lock wait is not query,
+// so it is not tracked as query metrics.
+func (m DB) trackLock(ctx context.Context, lock lockFunc, key string, fn func(ctx context.Context) error) error {
+	col, ok := m.defaultMetricsCollector.(LockCollector)
+	if !ok {
+		return lock(ctx, key, fn)
+	}
+	begin := time.Now()
+	acquired := false
+
+	err := lock(ctx, key, func(ctx context.Context) error {
+		acquired = true
+		col.TrackLockWait(ctx, time.Since(begin), nil)
+		return fn(ctx)
+	})
+	if !acquired {
+		col.TrackLockWait(ctx, time.Since(begin), err)
+	}
+	return err
+}
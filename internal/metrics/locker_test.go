@@ -0,0 +1,120 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/godepo/elephant/internal/pkg/passthrough"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type lockPool struct {
+	*MockPool
+	*MockAdvisoryLocker
+}
+
+type lockCollector struct {
+	*MockCollector
+	*MockLockCollector
+}
+
+func TestDB_WithAdvisoryLock(t *testing.T) {
+	t.Run("should be able to track awaited lock", func(t *testing.T) {
+		lck := NewMockAdvisoryLocker(t)
+		col := NewMockLockCollector(t)
+		db := New(
+			lockPool{MockPool: NewMockPool(t), MockAdvisoryLocker: lck},
+			lockCollector{MockCollector: NewMockCollector(t), MockLockCollector: col},
+		)
+		expErr := errors.New(uuid.NewString())
+
+		lck.EXPECT().WithAdvisoryLock(mock.Anything, "jobs", mock.Anything).
+			RunAndReturn(func(ctx context.Context, _ string, fn func(ctx context.Context) error) error {
+				return fn(ctx)
+			})
+		col.EXPECT().TrackLockWait(mock.Anything, mock.Anything, nil).Return().Once()
+
+		err := db.WithAdvisoryLock(context.Background(), "jobs", func(context.Context) error {
+			return expErr
+		})
+		require.ErrorIs(t, err, expErr)
+	})
+
+	t.Run("should be able to track failed lock", func(t *testing.T) {
+		lck := NewMockAdvisoryLocker(t)
+		col := NewMockLockCollector(t)
+		db := New(
+			lockPool{MockPool: NewMockPool(t), MockAdvisoryLocker: lck},
+			lockCollector{MockCollector: NewMockCollector(t), MockLockCollector: col},
+		)
+		expErr := errors.New(uuid.NewString())
+
+		lck.EXPECT().WithAdvisoryLock(mock.Anything, "jobs", mock.Anything).Return(expErr)
+		col.EXPECT().TrackLockWait(mock.Anything, mock.Anything, expErr).Return().Once()
+
+		err := db.WithAdvisoryLock(context.Background(), "jobs", func(context.Context) error {
+			return nil
+		})
+		require.ErrorIs(t, err, expErr)
+	})
+
+	t.Run("should be able to skip tracking, when collector does not support lock metrics", func(t *testing.T) {
+		lck := NewMockAdvisoryLocker(t)
+		db := New(lockPool{MockPool: NewMockPool(t), MockAdvisoryLocker: lck}, NewMockCollector(t))
+
+		lck.EXPECT().WithAdvisoryLock(mock.Anything, "jobs", mock.Anything).
+			RunAndReturn(func(ctx context.Context, _ string, fn func(ctx context.Context) error) error {
+				return fn(ctx)
+			})
+
+		require.NoError(t, db.WithAdvisoryLock(context.Background(), "jobs", func(context.Context) error {
+			return nil
+		}))
+	})
+
+	t.Run("should be able to fail when pool does not support locks", func(t *testing.T) {
+		db := New(NewMockPool(t), NewMockCollector(t))
+
+		err := db.WithAdvisoryLock(context.Background(), "jobs", func(context.Context) error {
+			return nil
+		})
+		require.ErrorIs(t, err, passthrough.ErrLockNotSupported)
+	})
+}
+
+func TestDB_WithTryAdvisoryLock(t *testing.T) {
+	t.Run("should be able to track lock attempt", func(t *testing.T) {
+		lck := NewMockAdvisoryLocker(t)
+		col := NewMockLockCollector(t)
+		db := New(
+			lockPool{MockPool: NewMockPool(t), MockAdvisoryLocker: lck},
+			lockCollector{MockCollector: NewMockCollector(t), MockLockCollector: col},
+		)
+
+		lck.EXPECT().WithTryAdvisoryLock(mock.Anything, "jobs", mock.Anything).
+			RunAndReturn(func(ctx context.Context, _ string, fn func(ctx context.Context) error) error {
+				return fn(ctx)
+			})
+		col.EXPECT().TrackLockWait(mock.Anything, mock.Anything, nil).Return().Once()
+
+		var called bool
+		require.NoError(t, db.WithTryAdvisoryLock(context.Background(), "jobs", func(context.Context) error {
+			called = true
+			return nil
+		}))
+		assert.True(t, called)
+	})
+
+	t.Run("should be able to fail when pool does not support locks", func(t *testing.T) {
+		db := New(NewMockPool(t), NewMockCollector(t))
+
+		err := db.WithTryAdvisoryLock(context.Background(), "jobs", func(context.Context) error {
+			return nil
+		})
+		require.ErrorIs(t, err, passthrough.ErrLockNotSupported)
+	})
+}
@@ -12,7 +12,9 @@ import (
 	"context"
 	"time"
 
+	"github.com/godepo/elephant/internal/pkg/failure"
 	"github.com/godepo/elephant/internal/pkg/monads"
+	"github.com/godepo/elephant/internal/pkg/named"
 	"github.com/godepo/elephant/internal/pkg/pgcontext"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
@@ -69,6 +71,7 @@ func (m DB) Begin(ctx context.Context) (pgx.Tx, error) {
 //   - Tracks query execution time
 //   - Supports query timeouts via context
 //   - Records errors through the metrics collector
+//   - Binds named parameters from single map or struct argument
 //
 // Example usage:
 //
@@ -76,6 +79,11 @@ func (m DB) Begin(ctx context.Context) (pgx.Tx, error) {
 //	       rows, err := db.Query(ctx, "SELECT * FROM users WHERE age > $1", 18)
 func (m DB) Query(ctx context.Context, query string, args ...interface{}) (pgx.Rows, error) {
 	begin := time.Now()
+	query, args, err := named.Bind(query, args)
+	if err != nil {
+		m.defaultMetricsCollector.TrackQueryMetrics(ctx, begin, err)
+		return nil, err
+	}
 	rows, err := m.db.Query(ctx, query, args...)
 	if err != nil {
 		m.defaultMetricsCollector.TrackQueryMetrics(ctx, begin, err)
@@ -99,9 +107,13 @@ func (m DB) QueryRow(ctx context.Context, query string, args ...interface{}) pgx
 	row := decoratedMetricRow{
 		ctx:       ctx,
 		begin:     time.Now(),
-		row:       m.db.QueryRow(ctx, query, args...),
 		collector: m.defaultMetricsCollector,
 	}
+	if query, args, err := named.Bind(query, args); err != nil {
+		row.row = failure.Row(err)
+	} else {
+		row.row = m.db.QueryRow(ctx, query, args...)
+	}
 	timeout, ok := pgcontext.QueryTimeoutFrom(ctx)
 	if ok {
 		cancelCtx, cancel := context.WithTimeout(ctx, timeout)
@@ -119,6 +131,11 @@ func (m DB) QueryRow(ctx context.Context, query string, args ...interface{}) pgx
 //   - Returns affected row count via CommandTag
 func (m DB) Exec(ctx context.Context, query string, args ...interface{}) (pgconn.CommandTag, error) {
 	begin := time.Now()
+	query, args, err := named.Bind(query, args)
+	if err != nil {
+		m.defaultMetricsCollector.TrackQueryMetrics(ctx, begin, err)
+		return pgconn.CommandTag{}, err
+	}
 	tag, err := m.db.Exec(ctx, query, args...)
 	if err != nil {
 		m.defaultMetricsCollector.TrackQueryMetrics(ctx, begin, err)
@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"context"
+	"testing"
+
+	"github.com/godepo/elephant/internal/pkg/named"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDB_NamedParameters(t *testing.T) {
+	params := map[string]any{"id": 1}
+
+	t.Run("should be able to pass positional query to wrapped pool", func(t *testing.T) {
+		pool, collector := NewMockPool(t), NewMockCollector(t)
+		row := NewMockRow(t)
+		db := New(pool, collector)
+		ctx := context.Background()
+		tag := pgconn.NewCommandTag("DELETE 1")
+
+		pool.EXPECT().QueryRow(ctx, "SELECT name FROM users WHERE id = $1", []any{1}).Return(row)
+		pool.EXPECT().Exec(ctx, "DELETE FROM users WHERE id = $1", []any{1}).Return(tag, nil)
+		row.EXPECT().Scan().Return(nil)
+		collector.EXPECT().TrackQueryMetrics(mock.Anything, mock.Anything, nil).Times(2)
+
+		require.NoError(t, db.QueryRow(ctx, "SELECT name FROM users WHERE id = :id", params).Scan())
+		res, err := db.Exec(ctx, "DELETE FROM users WHERE id = :id", params)
+		require.NoError(t, err)
+		assert.Equal(t, tag, res)
+	})
+
+	t.Run("should be able to track error of binding", func(t *testing.T) {
+		collector := NewMockCollector(t)
+		db := New(NewMockPool(t), collector)
+		ctx := context.Background()
+		isMissing := mock.MatchedBy(func(err error) bool {
+			return assert.ErrorIs(t, err, named.ErrMissingValue)
+		})
+		collector.EXPECT().TrackQueryMetrics(ctx, mock.Anything, isMissing).Times(3)
+
+		_, err := db.Query(ctx, "SELECT :name", params)
+		require.ErrorIs(t, err, named.ErrMissingValue)
+		require.ErrorIs(t, db.QueryRow(ctx, "SELECT :name", params).Scan(), named.ErrMissingValue)
+		_, err = db.Exec(ctx, "SELECT :name", params)
+		require.ErrorIs(t, err, named.ErrMissingValue)
+	})
+}
@@ -0,0 +1,24 @@
+package metrics
+
+import (
+	"context"
+
+	"github.com/godepo/elephant/internal/notify"
+	"github.com/godepo/elephant/internal/pkg/passthrough"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Listen delegates subscription to wrapped pool.
+func (m DB) Listen(ctx context.Context, channel string) (<-chan pgconn.Notification, error) {
+	lst, err := passthrough.AsListener(m.db)
+	if err != nil {
+		return nil, err
+	}
+	return lst.Listen(ctx, channel)
+}
+
+// Notify sends notification with metrics tracking.
+func (m DB) Notify(ctx context.Context, channel, payload string) error {
+	_, err := m.Exec(ctx, notify.Query, channel, payload)
+	return err
+}
@@ -0,0 +1,52 @@
+package metrics
+
+import (
+	"context"
+	"testing"
+
+	"github.com/godepo/elephant/internal/notify"
+	"github.com/godepo/elephant/internal/pkg/passthrough"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type listenPool struct {
+	*MockPool
+	*MockListener
+}
+
+func TestDB_Listen(t *testing.T) {
+	t.Run("should be able to delegate listen", func(t *testing.T) {
+		lst := NewMockListener(t)
+		db := New(listenPool{MockPool: NewMockPool(t), MockListener: lst}, NewMockCollector(t))
+		exp := make(chan pgconn.Notification)
+		lst.EXPECT().Listen(mock.Anything, "events").Return(exp, nil)
+
+		ch, err := db.Listen(context.Background(), "events")
+		require.NoError(t, err)
+		assert.Equal(t, (<-chan pgconn.Notification)(exp), ch)
+	})
+
+	t.Run("should be able to fail when pool does not support listen", func(t *testing.T) {
+		db := New(NewMockPool(t), NewMockCollector(t))
+
+		_, err := db.Listen(context.Background(), "events")
+		require.ErrorIs(t, err, passthrough.ErrListenNotSupported)
+	})
+}
+
+func TestDB_Notify(t *testing.T) {
+	t.Run("should be able to notify with tracking", func(t *testing.T) {
+		pool := NewMockPool(t)
+		col := NewMockCollector(t)
+		db := New(pool, col)
+		ctx := context.Background()
+
+		pool.EXPECT().Exec(ctx, notify.Query, []any{"events", "payload"}).Return(pgconn.CommandTag{}, nil)
+		col.EXPECT().TrackQueryMetrics(ctx, mock.Anything, nil).Return()
+
+		require.NoError(t, db.Notify(ctx, "events", "payload"))
+	})
+}
@@ -0,0 +1,34 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/godepo/elephant/internal/pkg/lifecycle"
+)
+
+// PoolStatsCollector interface is implemented by collector which exports pool stats when any gauge of them is set.
+type PoolStatsCollector interface {
+	TrackPoolStats(stats lifecycle.Stats)
+	PoolStatsInterval() time.Duration
+}
+
+// ExportPoolStats passes stats of pool to collector at once and then every interval of collector, until ctx is
+// done. It returns at once, when collector does not export pool stats.
+func ExportPoolStats(ctx context.Context, pool lifecycle.Stater, collector Collector) {
+	col, ok := collector.(PoolStatsCollector)
+	if !ok || col.PoolStatsInterval() <= 0 {
+		return
+	}
+	ticker := time.NewTicker(col.PoolStatsInterval())
+	defer ticker.Stop()
+
+	for {
+		col.TrackPoolStats(pool.Stats())
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package metrics
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/godepo/elephant/internal/pkg/lifecycle"
+)
+
+type poolStats lifecycle.Stats
+
+func (s poolStats) Stats() lifecycle.Stats {
+	return lifecycle.Stats(s)
+}
+
+type poolStatsCollector struct {
+	*MockCollector
+	*MockPoolStatsCollector
+}
+
+func TestExportPoolStats(t *testing.T) {
+	stats := poolStats{{Node: lifecycle.Node{Role: lifecycle.RoleNode}, Stat: lifecycle.Stat{MaxConns: 4}}}
+
+	t.Run("should be able to export stats until context is done", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		col := NewMockPoolStatsCollector(t)
+		col.EXPECT().PoolStatsInterval().Return(time.Millisecond)
+		calls := 0
+		col.EXPECT().TrackPoolStats(lifecycle.Stats(stats)).Run(func(lifecycle.Stats) {
+			calls++
+			if calls == 3 {
+				cancel()
+			}
+		})
+
+		ExportPoolStats(ctx, stats, poolStatsCollector{NewMockCollector(t), col})
+	})
+
+	t.Run("should be able to return at once, when pool stats are not collected", func(t *testing.T) {
+		col := NewMockPoolStatsCollector(t)
+		col.EXPECT().PoolStatsInterval().Return(0)
+
+		ExportPoolStats(context.Background(), stats, poolStatsCollector{NewMockCollector(t), col})
+		ExportPoolStats(context.Background(), stats, NewMockCollector(t))
+	})
+}
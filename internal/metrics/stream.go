@@ -0,0 +1,21 @@
+package metrics
+
+import (
+	"context"
+	"time"
+)
+
+// StreamCollector interface defines tracking of Stream iterations, it is implemented by metrics.Collector.
+type StreamCollector interface {
+	TrackStreamMetrics(ctx context.Context, firstRow time.Duration, rows int, err error)
+}
+
+// TrackStreamMetrics is called by elephant.Stream, when DB is streamed or is set by elephant.WithStreamTracker, and
+// passes count of rows and time to first row to collector, when it supports stream metrics.
+func (m DB) TrackStreamMetrics(ctx context.Context, firstRow time.Duration, rows int, err error) {
+	col, ok := m.defaultMetricsCollector.(StreamCollector)
+	if !ok {
+		return
+	}
+	col.TrackStreamMetrics(ctx, firstRow, rows, err)
+}
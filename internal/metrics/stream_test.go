@@ -0,0 +1,31 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type streamCollector struct {
+	*MockCollector
+	*MockStreamCollector
+}
+
+func TestDB_TrackStreamMetrics(t *testing.T) {
+	t.Run("should be able to pass stream metrics to collector", func(t *testing.T) {
+		col := NewMockStreamCollector(t)
+		db := New(NewMockPool(t), streamCollector{MockCollector: NewMockCollector(t), MockStreamCollector: col})
+		expErr := errors.New(uuid.NewString())
+		col.EXPECT().TrackStreamMetrics(context.Background(), time.Second, 42, expErr).Return()
+
+		db.TrackStreamMetrics(context.Background(), time.Second, 42, expErr)
+	})
+
+	t.Run("should be able to skip stream metrics when collector does not support them", func(t *testing.T) {
+		db := New(NewMockPool(t), NewMockCollector(t))
+		db.TrackStreamMetrics(context.Background(), time.Second, 42, nil)
+	})
+}
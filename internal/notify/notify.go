@@ -0,0 +1,192 @@
+// Package notify implements LISTEN/NOTIFY subscriptions on dedicated connections.
+// Listener holds connection while subscription is active, reconnects with exponential backoff
+// when connection is lost and re-issues LISTEN at the new connection. Notifications sent while
+// listener is reconnecting are lost, as PostgreSQL does not store them for absent listeners.
+//
+//go:generate go tool mockery
+package notify
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Query sends notification to channel with payload. It's regular statement, so notification is
+// delivered only after commit when it runs inside transaction.
+const Query = "SELECT pg_notify($1, $2)"
+
+const (
+	defaultMinBackoff = 100 * time.Millisecond
+	defaultMaxBackoff = 30 * time.Second
+	defaultBufferSize = 16
+	releaseTimeout    = time.Second
+)
+
+// Conn is dedicated connection which receives notifications.
+type Conn interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+	WaitForNotification(ctx context.Context) (*pgconn.Notification, error)
+	Release()
+}
+
+// Connector acquires new dedicated connection.
+type Connector func(ctx context.Context) (Conn, error)
+
+type Config struct {
+	minBackoff   time.Duration
+	maxBackoff   time.Duration
+	bufferSize   int
+	errorHandler func(err error)
+}
+
+type Option func(cfg *Config)
+
+func WithBackoff(minBackoff, maxBackoff time.Duration) Option {
+	return func(cfg *Config) {
+		cfg.minBackoff = minBackoff
+		cfg.maxBackoff = maxBackoff
+	}
+}
+
+func WithBufferSize(size int) Option {
+	return func(cfg *Config) {
+		cfg.bufferSize = size
+	}
+}
+
+// WithErrorHandler sets function which receives connection errors and failed reconnection attempts.
+func WithErrorHandler(fn func(err error)) Option {
+	return func(cfg *Config) {
+		cfg.errorHandler = fn
+	}
+}
+
+type poolConn struct {
+	*pgxpool.Conn
+}
+
+// PoolConn adapts connection acquired from pgxpool.
+func PoolConn(conn *pgxpool.Conn) Conn {
+	return poolConn{Conn: conn}
+}
+
+func (conn poolConn) WaitForNotification(ctx context.Context) (*pgconn.Notification, error) {
+	return conn.Conn.Conn().WaitForNotification(ctx)
+}
+
+type listener struct {
+	connect Connector
+	channel string
+	cfg     Config
+	out     chan pgconn.Notification
+}
+
+// Listen subscribes to channel and returns notifications until ctx is done. First subscription is made
+// synchronously, so connection and LISTEN errors are returned to caller. Returned channel is closed
+// when ctx is done.
+func Listen(
+	ctx context.Context,
+	connect Connector,
+	channel string,
+	opts ...Option,
+) (<-chan pgconn.Notification, error) {
+	cfg := Config{
+		minBackoff:   defaultMinBackoff,
+		maxBackoff:   defaultMaxBackoff,
+		bufferSize:   defaultBufferSize,
+		errorHandler: func(error) {},
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	conn, err := subscribe(ctx, connect, channel)
+	if err != nil {
+		return nil, err
+	}
+
+	lst := &listener{
+		connect: connect,
+		channel: channel,
+		cfg:     cfg,
+		out:     make(chan pgconn.Notification, cfg.bufferSize),
+	}
+	go lst.run(ctx, conn)
+
+	return lst.out, nil
+}
+
+func subscribe(ctx context.Context, connect Connector, channel string) (Conn, error) {
+	conn, err := connect(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("can't acquire connection to listen: %w", err)
+	}
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{channel}.Sanitize()); err != nil {
+		conn.Release()
+		return nil, fmt.Errorf("can't listen channel %q: %w", channel, err)
+	}
+	return conn, nil
+}
+
+// release unsubscribes connection before return it to pool, so next borrower doesn't receive notifications.
+// Broken connection fails UNLISTEN and pool drops it at release.
+func release(ctx context.Context, conn Conn) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), releaseTimeout)
+	defer cancel()
+
+	_, _ = conn.Exec(ctx, "UNLISTEN *")
+	conn.Release()
+}
+
+func (lst *listener) run(ctx context.Context, conn Conn) {
+	defer close(lst.out)
+
+	for conn != nil {
+		err := lst.receive(ctx, conn)
+		release(ctx, conn)
+		if ctx.Err() != nil {
+			return
+		}
+		lst.cfg.errorHandler(fmt.Errorf("listen channel %q: %w", lst.channel, err))
+		conn = lst.reconnect(ctx)
+	}
+}
+
+func (lst *listener) receive(ctx context.Context, conn Conn) error {
+	for {
+		msg, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		select {
+		case lst.out <- *msg:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (lst *listener) reconnect(ctx context.Context) Conn {
+	delay := lst.cfg.minBackoff
+	for {
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+
+		conn, err := subscribe(ctx, lst.connect, lst.channel)
+		if err == nil {
+			return conn
+		}
+		lst.cfg.errorHandler(err)
+		delay = min(delay*2, lst.cfg.maxBackoff)
+	}
+}
@@ -0,0 +1,202 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jaswdr/faker/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const testChannel = "events"
+
+type connector struct {
+	mu    sync.Mutex
+	conns []Conn
+	errs  []error
+}
+
+func (c *connector) connect(_ context.Context) (Conn, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.errs) > 0 {
+		err := c.errs[0]
+		c.errs = c.errs[1:]
+		if err != nil {
+			return nil, err
+		}
+	}
+	conn := c.conns[0]
+	c.conns = c.conns[1:]
+	return conn, nil
+}
+
+func expectSubscribe(conn *MockConn) {
+	conn.EXPECT().Exec(mock.Anything, `LISTEN "events"`).Return(pgconn.NewCommandTag("LISTEN"), nil).Once()
+}
+
+func expectRelease(conn *MockConn) {
+	conn.EXPECT().Exec(mock.Anything, "UNLISTEN *").Return(pgconn.NewCommandTag("UNLISTEN"), nil).Once()
+	conn.EXPECT().Release().Return().Once()
+}
+
+func expectWaitUntilDone(conn *MockConn) {
+	conn.EXPECT().WaitForNotification(mock.Anything).
+		RunAndReturn(func(ctx context.Context) (*pgconn.Notification, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}).Once()
+}
+
+func receive(t *testing.T, ch <-chan pgconn.Notification) pgconn.Notification {
+	t.Helper()
+	select {
+	case msg, ok := <-ch:
+		require.True(t, ok)
+		return msg
+	case <-time.After(time.Second):
+		require.FailNow(t, "notification is not received")
+	}
+	return pgconn.Notification{}
+}
+
+func assertClosed(t *testing.T, ch <-chan pgconn.Notification) {
+	t.Helper()
+	select {
+	case _, ok := <-ch:
+		require.False(t, ok)
+	case <-time.After(time.Second):
+		require.FailNow(t, "channel is not closed")
+	}
+}
+
+func TestListen(t *testing.T) {
+	t.Run("should be able to receive notifications", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		conn := NewMockConn(t)
+		msg := &pgconn.Notification{PID: 1, Channel: testChannel, Payload: faker.New().Lorem().Word()}
+
+		expectSubscribe(conn)
+		conn.EXPECT().WaitForNotification(mock.Anything).Return(msg, nil).Once()
+		expectWaitUntilDone(conn)
+		expectRelease(conn)
+
+		src := &connector{conns: []Conn{conn}}
+		ch, err := Listen(ctx, src.connect, testChannel)
+		require.NoError(t, err)
+
+		assert.Equal(t, *msg, receive(t, ch))
+		cancel()
+		assertClosed(t, ch)
+	})
+
+	t.Run("should be able to reconnect and listen again after connection lost", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		broken, restored := NewMockConn(t), NewMockConn(t)
+		lostErr := errors.New(faker.New().RandomStringWithLength(10))
+		connectErr := errors.New(faker.New().RandomStringWithLength(10))
+		msg := &pgconn.Notification{PID: 2, Channel: testChannel}
+
+		expectSubscribe(broken)
+		broken.EXPECT().WaitForNotification(mock.Anything).Return(nil, lostErr).Once()
+		expectRelease(broken)
+		expectSubscribe(restored)
+		restored.EXPECT().WaitForNotification(mock.Anything).Return(msg, nil).Once()
+		expectWaitUntilDone(restored)
+		expectRelease(restored)
+
+		var (
+			mu   sync.Mutex
+			errs []error
+		)
+		src := &connector{conns: []Conn{broken, restored}, errs: []error{nil, connectErr}}
+		ch, err := Listen(ctx, src.connect, testChannel,
+			WithBackoff(time.Millisecond, 2*time.Millisecond),
+			WithBufferSize(0),
+			WithErrorHandler(func(err error) {
+				mu.Lock()
+				defer mu.Unlock()
+				errs = append(errs, err)
+			}),
+		)
+		require.NoError(t, err)
+
+		assert.Equal(t, *msg, receive(t, ch))
+		cancel()
+		assertClosed(t, ch)
+
+		mu.Lock()
+		defer mu.Unlock()
+		require.Len(t, errs, 2)
+		assert.ErrorIs(t, errs[0], lostErr)
+		assert.ErrorIs(t, errs[1], connectErr)
+	})
+
+	t.Run("should be able to stop reconnecting when context is done", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		conn := NewMockConn(t)
+		lostErr := errors.New(faker.New().RandomStringWithLength(10))
+
+		expectSubscribe(conn)
+		conn.EXPECT().WaitForNotification(mock.Anything).Return(nil, lostErr).Once()
+		expectRelease(conn)
+
+		src := &connector{conns: []Conn{conn}}
+		ch, err := Listen(ctx, src.connect, testChannel,
+			WithBackoff(time.Hour, time.Hour),
+			WithErrorHandler(func(error) {
+				cancel()
+			}),
+		)
+		require.NoError(t, err)
+		assertClosed(t, ch)
+	})
+
+	t.Run("should be able to stop delivery when context is done", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		conn := NewMockConn(t)
+
+		expectSubscribe(conn)
+		conn.EXPECT().WaitForNotification(mock.Anything).
+			RunAndReturn(func(context.Context) (*pgconn.Notification, error) {
+				cancel()
+				return &pgconn.Notification{}, nil
+			}).Once()
+		conn.EXPECT().WaitForNotification(mock.Anything).Return(nil, context.Canceled).Maybe()
+		expectRelease(conn)
+
+		src := &connector{conns: []Conn{conn}}
+		ch, err := Listen(ctx, src.connect, testChannel, WithBufferSize(0))
+		require.NoError(t, err)
+		for range ch {
+			continue
+		}
+	})
+
+	t.Run("should be able to return error when can't acquire connection", func(t *testing.T) {
+		expErr := errors.New(faker.New().RandomStringWithLength(10))
+		src := &connector{errs: []error{expErr}}
+
+		ch, err := Listen(context.Background(), src.connect, testChannel)
+		require.ErrorIs(t, err, expErr)
+		assert.Nil(t, ch)
+	})
+
+	t.Run("should be able to return error when can't listen channel", func(t *testing.T) {
+		conn := NewMockConn(t)
+		expErr := errors.New(faker.New().RandomStringWithLength(10))
+		conn.EXPECT().Exec(mock.Anything, `LISTEN "events"`).Return(pgconn.CommandTag{}, expErr)
+		conn.EXPECT().Release().Return()
+
+		src := &connector{conns: []Conn{conn}}
+		ch, err := Listen(context.Background(), src.connect, testChannel)
+		require.ErrorIs(t, err, expErr)
+		assert.Nil(t, ch)
+	})
+}
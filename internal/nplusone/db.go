@@ -0,0 +1,69 @@
+package nplusone
+
+import (
+	"context"
+
+	"github.com/godepo/elephant/internal/notify"
+	"github.com/godepo/elephant/internal/pkg/passthrough"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Pool interface defines database operations of wrapped pool.
+type Pool interface {
+	BeginTx(ctx context.Context, opts pgx.TxOptions) (pgx.Tx, error)
+	Begin(ctx context.Context) (pgx.Tx, error)
+	Query(ctx context.Context, query string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, query string, args ...interface{}) pgx.Row
+	Exec(ctx context.Context, query string, args ...interface{}) (pgconn.CommandTag, error)
+	Transactional(ctx context.Context, fn func(ctx context.Context) error) (out error)
+}
+
+// DB is pool decorated by detector. Statements of dedicated connections and batches are not counted.
+type DB struct {
+	passthrough.Delegate
+	det *Detector
+	db  Pool
+}
+
+// Wrap decorates db by detector. Pool which is used by application code should be wrapped, like whole cluster
+// or sharded pool, because statements of transaction from context don't reach members of topology.
+func (det *Detector) Wrap(db Pool) *DB {
+	return &DB{Delegate: passthrough.New(db), det: det, db: db}
+}
+
+func (d *DB) Begin(ctx context.Context) (pgx.Tx, error) {
+	return d.db.Begin(ctx)
+}
+
+func (d *DB) BeginTx(ctx context.Context, opts pgx.TxOptions) (pgx.Tx, error) {
+	return d.db.BeginTx(ctx, opts)
+}
+
+func (d *DB) Query(ctx context.Context, query string, args ...interface{}) (pgx.Rows, error) {
+	d.det.track(ctx, query)
+	return d.db.Query(ctx, query, args...)
+}
+
+func (d *DB) QueryRow(ctx context.Context, query string, args ...interface{}) pgx.Row {
+	d.det.track(ctx, query)
+	return d.db.QueryRow(ctx, query, args...)
+}
+
+func (d *DB) Exec(ctx context.Context, query string, args ...interface{}) (pgconn.CommandTag, error) {
+	d.det.track(ctx, query)
+	return d.db.Exec(ctx, query, args...)
+}
+
+// Transactional starts scope for fn when context has no scope yet, scope is finished after transaction.
+func (d *DB) Transactional(ctx context.Context, fn func(ctx context.Context) error) (out error) {
+	ctx, finish := d.det.Scope(ctx)
+	defer finish()
+	return d.db.Transactional(ctx, fn)
+}
+
+// Notify sends notification through Exec, so it is counted.
+func (d *DB) Notify(ctx context.Context, channel, payload string) error {
+	_, err := d.Exec(ctx, notify.Query, channel, payload)
+	return err
+}
@@ -0,0 +1,75 @@
+package nplusone
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDB(t *testing.T) {
+	t.Run("should be able to count statements of transactional scope", func(t *testing.T) {
+		var reports []Report
+		pool := NewMockPool(t)
+		row := NewMockRow(t)
+		db := New(func(report Report) { reports = append(reports, report) }, WithThreshold(2)).Wrap(pool)
+
+		pool.EXPECT().Transactional(mock.Anything, mock.Anything).
+			RunAndReturn(func(ctx context.Context, fn func(ctx context.Context) error) error {
+				return fn(ctx)
+			})
+		pool.EXPECT().QueryRow(mock.Anything, "SELECT name FROM items WHERE id = $1", mock.Anything).Return(row)
+		row.EXPECT().Scan(mock.Anything).Return(nil)
+		pool.EXPECT().Query(mock.Anything, "SELECT id FROM items").Return(nil, nil)
+		pool.EXPECT().Exec(mock.Anything, "UPDATE items SET n = n + 1").Return(pgconn.CommandTag{}, nil)
+
+		err := db.Transactional(context.Background(), func(ctx context.Context) error {
+			if _, err := db.Query(ctx, "SELECT id FROM items"); err != nil {
+				return err
+			}
+			for id := range 3 {
+				var name string
+				if err := db.QueryRow(ctx, "SELECT name FROM items WHERE id = $1", id).Scan(&name); err != nil {
+					return err
+				}
+			}
+			_, err := db.Exec(ctx, "UPDATE items SET n = n + 1")
+			return err
+		})
+		require.NoError(t, err)
+		require.Len(t, reports, 1)
+		assert.Equal(t, "select name from items where id = ?", reports[0].SQL)
+		assert.Contains(t, reports[0].Stacks[0], "db_test.go")
+	})
+
+	t.Run("should be able to begin transaction at wrapped pool", func(t *testing.T) {
+		pool := NewMockPool(t)
+		db := New(func(Report) {}).Wrap(pool)
+		pool.EXPECT().Begin(mock.Anything).Return(nil, nil)
+		pool.EXPECT().BeginTx(mock.Anything, pgx.TxOptions{}).Return(nil, nil)
+
+		_, err := db.Begin(context.Background())
+		require.NoError(t, err)
+		_, err = db.BeginTx(context.Background(), pgx.TxOptions{})
+		require.NoError(t, err)
+	})
+
+	t.Run("should be able to count notify", func(t *testing.T) {
+		var reports []Report
+		pool := NewMockPool(t)
+		db := New(func(report Report) { reports = append(reports, report) }, WithThreshold(2)).Wrap(pool)
+		pool.EXPECT().Exec(mock.Anything, mock.Anything, []any{"events", "payload"}).Return(pgconn.CommandTag{}, nil)
+
+		ctx, finish := db.det.Scope(context.Background())
+		for range 3 {
+			require.NoError(t, db.Notify(ctx, "events", "payload"))
+		}
+		finish()
+		require.Len(t, reports, 1)
+		assert.Equal(t, "select pg_notify(?)", reports[0].SQL)
+	})
+}
@@ -0,0 +1,192 @@
+// Package nplusone implements development-mode decorator for elephant pools which detects N+1 queries. Decorator
+// counts statements with the same fingerprint within scope, which is request context or Transactional call,
+// and reports statements which ran more times than threshold together with stacks of their call sites.
+//
+//go:generate go tool mockery
+package nplusone
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/godepo/elephant/internal/pkg/fingerprint"
+)
+
+const (
+	defaultThreshold = 5
+	maxFrames        = 32
+	modulePrefix     = "github.com/godepo/elephant/"
+)
+
+// Report describes statement which ran more times than threshold within scope.
+type Report struct {
+	Fingerprint string
+	SQL         string
+	Count       int
+	Threshold   int
+	// Stacks are distinct call sites of statement, frames of elephant pools are skipped.
+	Stacks []string
+}
+
+func (r Report) String() string {
+	var out strings.Builder
+	_, _ = fmt.Fprintf(&out, "statement ran %d times in one scope, threshold is %d: %s", r.Count, r.Threshold, r.SQL)
+	for i, stack := range r.Stacks {
+		_, _ = fmt.Fprintf(&out, "\ncall site #%d:\n%s", i+1, stack)
+	}
+	return out.String()
+}
+
+// Reporter receives reports when scope is finished.
+type Reporter func(report Report)
+
+// TestingT is subset of testing.TB used by FailTest.
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...any)
+}
+
+// FailTest makes reporter which fails test with report.
+func FailTest(t TestingT) Reporter {
+	return func(report Report) {
+		t.Helper()
+		t.Errorf("nplusone: %s", report)
+	}
+}
+
+type Config struct {
+	threshold int
+}
+
+type Option func(cfg *Config)
+
+// WithThreshold sets how many times statement can run within scope before it is reported.
+func WithThreshold(n int) Option {
+	return func(cfg *Config) {
+		cfg.threshold = n
+	}
+}
+
+// Detector counts statements of pools it wraps within scopes.
+type Detector struct {
+	reporter  Reporter
+	threshold int
+}
+
+func New(reporter Reporter, opts ...Option) *Detector {
+	cfg := Config{
+		threshold: defaultThreshold,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return &Detector{
+		reporter:  reporter,
+		threshold: cfg.threshold,
+	}
+}
+
+type scopeKey struct{}
+
+type statement struct {
+	sql    string
+	count  int
+	stacks []string
+}
+
+type scope struct {
+	mu         sync.Mutex
+	statements map[string]*statement
+	order      []string
+}
+
+// Scope starts scope in context, like request scope in middleware. Returned function finishes scope and reports
+// statements which ran more times than threshold. Scope is not started when context already has one.
+func (det *Detector) Scope(ctx context.Context) (context.Context, func()) {
+	if _, ok := ctx.Value(scopeKey{}).(*scope); ok {
+		return ctx, func() {}
+	}
+	sc := &scope{statements: make(map[string]*statement)}
+	return context.WithValue(ctx, scopeKey{}, sc), func() {
+		det.finish(sc)
+	}
+}
+
+// track counts statement in scope from context, statements outside of scope are not counted.
+func (det *Detector) track(ctx context.Context, query string) {
+	sc, ok := ctx.Value(scopeKey{}).(*scope)
+	if !ok {
+		return
+	}
+	key := fingerprint.Fingerprint(query)
+
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	stmt, ok := sc.statements[key]
+	if !ok {
+		stmt = &statement{sql: fingerprint.Normalize(query)}
+		sc.statements[key] = stmt
+		sc.order = append(sc.order, key)
+	}
+	stmt.count++
+	// stacks are kept only until statement is known to be reported
+	if stmt.count <= det.threshold+1 {
+		if stack := callSite(); !slices.Contains(stmt.stacks, stack) {
+			stmt.stacks = append(stmt.stacks, stack)
+		}
+	}
+}
+
+func (det *Detector) finish(sc *scope) {
+	sc.mu.Lock()
+	var reports []Report
+	for _, key := range sc.order {
+		stmt := sc.statements[key]
+		if stmt.count <= det.threshold {
+			continue
+		}
+		reports = append(reports, Report{
+			Fingerprint: key,
+			SQL:         stmt.sql,
+			Count:       stmt.count,
+			Threshold:   det.threshold,
+			Stacks:      slices.Clone(stmt.stacks),
+		})
+	}
+	sc.mu.Unlock()
+
+	for _, report := range reports {
+		det.reporter(report)
+	}
+}
+
+// callSite formats stack of caller, frames of elephant itself are skipped except its tests.
+func callSite() string {
+	pcs := make([]uintptr, maxFrames)
+	n := runtime.Callers(1, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	var out strings.Builder
+	for {
+		frame, more := frames.Next()
+		if !internalFrame(frame) {
+			_, _ = fmt.Fprintf(&out, "%s\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
+		}
+		if !more {
+			break
+		}
+	}
+	return strings.TrimRight(out.String(), "\n")
+}
+
+func internalFrame(frame runtime.Frame) bool {
+	if strings.HasPrefix(frame.Function, "runtime.") || strings.HasPrefix(frame.Function, "testing.") {
+		return true
+	}
+	return strings.HasPrefix(frame.Function, modulePrefix) && !strings.HasSuffix(frame.File, "_test.go")
+}
@@ -0,0 +1,95 @@
+package nplusone
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/godepo/elephant/internal/pkg/fingerprint"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type reporter struct {
+	errors []string
+}
+
+func (r *reporter) Helper() {}
+
+func (r *reporter) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestDetector(t *testing.T) {
+	t.Run("should be able to report statements over threshold when scope is finished", func(t *testing.T) {
+		var reports []Report
+		det := New(func(report Report) { reports = append(reports, report) }, WithThreshold(2))
+
+		ctx, finish := det.Scope(context.Background())
+		for i := range 3 {
+			det.track(ctx, fmt.Sprintf("SELECT * FROM items WHERE id = %d", i))
+			det.track(ctx, "SELECT * FROM orders WHERE id = $1")
+		}
+		det.track(ctx, "SELECT * FROM users")
+		det.track(ctx, "SELECT * FROM users")
+		assert.Empty(t, reports)
+
+		finish()
+		require.Len(t, reports, 2)
+		assert.Equal(t, fingerprint.Fingerprint("SELECT * FROM items WHERE id = 1"), reports[0].Fingerprint)
+		assert.Equal(t, "select * from items where id = ?", reports[0].SQL)
+		assert.Equal(t, 3, reports[0].Count)
+		assert.Equal(t, 2, reports[0].Threshold)
+		assert.Equal(t, "select * from orders where id = ?", reports[1].SQL)
+	})
+
+	t.Run("should be able to keep distinct call sites", func(t *testing.T) {
+		var reports []Report
+		det := New(func(report Report) { reports = append(reports, report) }, WithThreshold(1))
+
+		ctx, finish := det.Scope(context.Background())
+		det.track(ctx, "SELECT 1")
+		for range 3 {
+			det.track(ctx, "SELECT 1")
+		}
+		finish()
+
+		require.Len(t, reports, 1)
+		assert.Equal(t, 4, reports[0].Count)
+		require.Len(t, reports[0].Stacks, 2)
+		assert.Contains(t, reports[0].Stacks[0], "nplusone_test.go")
+		assert.NotContains(t, reports[0].Stacks[0], "nplusone.go")
+		assert.Contains(t, reports[0].String(), "call site #2")
+	})
+
+	t.Run("should be able to keep outer scope", func(t *testing.T) {
+		var reports []Report
+		det := New(func(report Report) { reports = append(reports, report) }, WithThreshold(1))
+
+		ctx, finish := det.Scope(context.Background())
+		for range 2 {
+			inner, innerFinish := det.Scope(ctx)
+			det.track(inner, "SELECT 1")
+			innerFinish()
+		}
+		assert.Empty(t, reports)
+		finish()
+		assert.Len(t, reports, 1)
+	})
+
+	t.Run("should be able to ignore statements outside of scope", func(t *testing.T) {
+		det := New(func(Report) { t.Fatal("must not be reported") }, WithThreshold(0))
+		det.track(context.Background(), "SELECT 1")
+	})
+
+	t.Run("should be able to fail test", func(t *testing.T) {
+		r := &reporter{}
+		det := New(FailTest(r), WithThreshold(0))
+		ctx, finish := det.Scope(context.Background())
+		det.track(ctx, "SELECT 1")
+		finish()
+
+		require.Len(t, r.errors, 1)
+		assert.Contains(t, r.errors[0], "statement ran 1 times in one scope, threshold is 0: select ?")
+	})
+}
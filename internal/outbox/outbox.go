@@ -0,0 +1,358 @@
+// Package outbox implements transactional outbox on elephant pools. Messages are written to outbox table by
+// transaction from context, so they are committed atomically with domain writes. Relay locks pending messages
+// with FOR UPDATE SKIP LOCKED, hands them to publisher and marks them dispatched, failed messages are retried
+// with backoff and moved to dead state after max attempts.
+//
+//go:generate go tool mockery
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/godepo/elephant/internal/notify"
+	"github.com/godepo/elephant/internal/pkg/backoff"
+	"github.com/godepo/elephant/internal/pkg/passthrough"
+	"github.com/godepo/elephant/internal/pkg/pgcontext"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+var ErrNoTransaction = errors.New("outbox: message must be written by transaction from context")
+
+const (
+	StatusPending    = "pending"
+	StatusDispatched = "dispatched"
+	StatusDead       = "dead"
+
+	defaultTable        = "outbox"
+	defaultBatchSize    = 100
+	defaultPollInterval = time.Second
+	defaultMaxAttempts  = 10
+	defaultMinBackoff   = time.Second
+	defaultMaxBackoff   = 10 * time.Minute
+)
+
+type DB interface {
+	Query(ctx context.Context, query string, args ...any) (pgx.Rows, error)
+	Exec(ctx context.Context, query string, args ...any) (pgconn.CommandTag, error)
+	Transactional(ctx context.Context, fn func(ctx context.Context) error) (out error)
+}
+
+// Publisher delivers messages to broker. Message is marked dispatched when Publish returns nil.
+type Publisher interface {
+	Publish(ctx context.Context, msg Message) error
+}
+
+type Message struct {
+	ID        int64
+	Topic     string
+	Key       string
+	Payload   []byte
+	Headers   map[string]string
+	Attempts  int
+	CreatedAt time.Time
+}
+
+type Config struct {
+	table        string
+	batchSize    int
+	pollInterval time.Duration
+	maxAttempts  int
+	minBackoff   time.Duration
+	maxBackoff   time.Duration
+	shards       uint
+	channel      string
+	errorHandler func(err error)
+}
+
+type Option func(cfg *Config)
+
+// WithTable sets outbox table name, it may be qualified by schema.
+func WithTable(table string) Option {
+	return func(cfg *Config) {
+		cfg.table = table
+	}
+}
+
+// WithBatchSize sets how many messages relay locks by one transaction.
+func WithBatchSize(size int) Option {
+	return func(cfg *Config) {
+		cfg.batchSize = size
+	}
+}
+
+// WithPollInterval sets how often relay polls outbox table when there are no pending messages.
+func WithPollInterval(interval time.Duration) Option {
+	return func(cfg *Config) {
+		cfg.pollInterval = interval
+	}
+}
+
+// WithMaxAttempts sets how many times message is published before it is moved to dead state.
+func WithMaxAttempts(attempts int) Option {
+	return func(cfg *Config) {
+		cfg.maxAttempts = attempts
+	}
+}
+
+// WithBackoff sets bounds of exponential delay between publish attempts.
+func WithBackoff(minDelay, maxDelay time.Duration) Option {
+	return func(cfg *Config) {
+		cfg.minBackoff = minDelay
+		cfg.maxBackoff = maxDelay
+	}
+}
+
+// WithShards makes relay dispatch messages from each of n shards of sharded pool.
+func WithShards(n uint) Option {
+	return func(cfg *Config) {
+		cfg.shards = n
+	}
+}
+
+// WithNotifyChannel makes writer notify channel on commit and relay wake up by notifications instead
+// of waiting for next poll.
+func WithNotifyChannel(channel string) Option {
+	return func(cfg *Config) {
+		cfg.channel = channel
+	}
+}
+
+// WithErrorHandler sets handler for relay errors.
+func WithErrorHandler(handler func(err error)) Option {
+	return func(cfg *Config) {
+		cfg.errorHandler = handler
+	}
+}
+
+type queries struct {
+	insert     string
+	pending    string
+	dispatched string
+	failed     string
+}
+
+type Outbox struct {
+	db      DB
+	cfg     Config
+	queries queries
+}
+
+func New(db DB, opts ...Option) *Outbox {
+	cfg := Config{
+		table:        defaultTable,
+		batchSize:    defaultBatchSize,
+		pollInterval: defaultPollInterval,
+		maxAttempts:  defaultMaxAttempts,
+		minBackoff:   defaultMinBackoff,
+		maxBackoff:   defaultMaxBackoff,
+		errorHandler: func(error) {},
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	table := tableIdentifier(cfg.table)
+	return &Outbox{
+		db:  db,
+		cfg: cfg,
+		queries: queries{
+			insert: fmt.Sprintf("INSERT INTO %s (topic, key, payload, headers) VALUES ($1, $2, $3, $4)", table),
+			pending: fmt.Sprintf(
+				"SELECT id, topic, key, payload, headers, attempts, created_at FROM %s "+
+					"WHERE status = '%s' AND next_attempt_at <= now() ORDER BY id LIMIT $1 FOR UPDATE SKIP LOCKED",
+				table, StatusPending,
+			),
+			dispatched: fmt.Sprintf(
+				"UPDATE %s SET status = '%s', dispatched_at = now() WHERE id = ANY($1)",
+				table, StatusDispatched,
+			),
+			failed: fmt.Sprintf(
+				"UPDATE %s SET attempts = attempts + 1, last_error = $2, "+
+					"next_attempt_at = now() + $3 * interval '1 millisecond', "+
+					"status = CASE WHEN attempts + 1 >= $4 THEN '%s' ELSE '%s' END WHERE id = $1",
+				table, StatusDead, StatusPending,
+			),
+		},
+	}
+}
+
+// Schema returns DDL of outbox table with given name.
+func Schema(table string) string {
+	index := pgx.Identifier{strings.ReplaceAll(table, ".", "_") + "_pending_idx"}.Sanitize()
+	return fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %[1]s (
+	id bigserial PRIMARY KEY,
+	topic text NOT NULL,
+	key text NOT NULL DEFAULT '',
+	payload bytea NOT NULL,
+	headers jsonb NOT NULL DEFAULT '{}',
+	status text NOT NULL DEFAULT '%[2]s',
+	attempts integer NOT NULL DEFAULT 0,
+	last_error text,
+	created_at timestamptz NOT NULL DEFAULT now(),
+	next_attempt_at timestamptz NOT NULL DEFAULT now(),
+	dispatched_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS %[3]s ON %[1]s (next_attempt_at, id) WHERE status = '%[2]s';`,
+		tableIdentifier(table), StatusPending, index,
+	)
+}
+
+func tableIdentifier(table string) string {
+	return pgx.Identifier(strings.Split(table, ".")).Sanitize()
+}
+
+// Write stores messages by transaction from context, they become visible to relay when transaction commits.
+func (o *Outbox) Write(ctx context.Context, msgs ...Message) error {
+	tx, ok := pgcontext.TransactionFrom(ctx)
+	if !ok {
+		return ErrNoTransaction
+	}
+
+	for _, msg := range msgs {
+		headers := msg.Headers
+		if headers == nil {
+			headers = map[string]string{}
+		}
+		if _, err := tx.Exec(ctx, o.queries.insert, msg.Topic, msg.Key, msg.Payload, headers); err != nil {
+			return fmt.Errorf("can't write message to outbox: %w", err)
+		}
+	}
+
+	if o.cfg.channel != "" && len(msgs) > 0 {
+		if _, err := tx.Exec(ctx, notify.Query, o.cfg.channel, ""); err != nil {
+			return fmt.Errorf("can't notify outbox relay: %w", err)
+		}
+	}
+	return nil
+}
+
+// Dispatch publishes one batch of pending messages at node picked from context and returns count of
+// processed messages.
+func (o *Outbox) Dispatch(ctx context.Context, pub Publisher) (int, error) {
+	var processed int
+	err := o.db.Transactional(pgcontext.With(ctx, pgcontext.WithCanWrite), func(ctx context.Context) error {
+		msgs, err := o.pending(ctx)
+		if err != nil {
+			return err
+		}
+		processed = len(msgs)
+
+		dispatched := make([]int64, 0, len(msgs))
+		for _, msg := range msgs {
+			if err := pub.Publish(ctx, msg); err != nil {
+				if err := o.fail(ctx, msg, err); err != nil {
+					return err
+				}
+				continue
+			}
+			dispatched = append(dispatched, msg.ID)
+		}
+
+		if len(dispatched) == 0 {
+			return nil
+		}
+		if _, err := o.db.Exec(ctx, o.queries.dispatched, dispatched); err != nil {
+			return fmt.Errorf("can't mark outbox messages dispatched: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return processed, nil
+}
+
+// Run relays messages until ctx is done. With WithShards relay runs for every shard concurrently.
+func (o *Outbox) Run(ctx context.Context, pub Publisher) error {
+	if o.cfg.shards == 0 {
+		o.relay(ctx, pub)
+		return ctx.Err()
+	}
+
+	var wg sync.WaitGroup
+	for id := range o.cfg.shards {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			o.relay(pgcontext.With(ctx, pgcontext.WithShardID(id)), pub)
+		}()
+	}
+	wg.Wait()
+	return ctx.Err()
+}
+
+func (o *Outbox) relay(ctx context.Context, pub Publisher) {
+	wakeup := o.listen(ctx)
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		case <-wakeup:
+		}
+
+		processed, err := o.Dispatch(ctx, pub)
+		if err != nil && ctx.Err() == nil {
+			o.cfg.errorHandler(err)
+		}
+
+		delay := o.cfg.pollInterval
+		if err == nil && processed == o.cfg.batchSize {
+			delay = 0
+		}
+		timer.Reset(delay)
+	}
+}
+
+func (o *Outbox) listen(ctx context.Context) <-chan pgconn.Notification {
+	lst, ok := o.db.(passthrough.Listener)
+	if o.cfg.channel == "" || !ok {
+		return nil
+	}
+	ch, err := lst.Listen(ctx, o.cfg.channel)
+	if err != nil {
+		o.cfg.errorHandler(fmt.Errorf("can't listen outbox channel, fall back to polling: %w", err))
+		return nil
+	}
+	return ch
+}
+
+func (o *Outbox) pending(ctx context.Context) (msgs []Message, out error) {
+	rows, err := o.db.Query(ctx, o.queries.pending, o.cfg.batchSize)
+	if err != nil {
+		return nil, fmt.Errorf("can't select pending outbox messages: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var msg Message
+		if err := rows.Scan(
+			&msg.ID, &msg.Topic, &msg.Key, &msg.Payload, &msg.Headers, &msg.Attempts, &msg.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("can't scan outbox message: %w", err)
+		}
+		msgs = append(msgs, msg)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("can't select pending outbox messages: %w", err)
+	}
+	return msgs, nil
+}
+
+func (o *Outbox) fail(ctx context.Context, msg Message, reason error) error {
+	delay := backoff.Exponential(o.cfg.minBackoff, o.cfg.maxBackoff, msg.Attempts)
+	_, err := o.db.Exec(ctx, o.queries.failed, msg.ID, reason.Error(), delay.Milliseconds(), o.cfg.maxAttempts)
+	if err != nil {
+		return fmt.Errorf("can't mark outbox message %d failed: %w", msg.ID, err)
+	}
+	return nil
+}
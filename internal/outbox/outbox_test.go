@@ -0,0 +1,241 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/godepo/elephant/internal/notify"
+	"github.com/godepo/elephant/internal/pkg/pgcontext"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jaswdr/faker/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newError() error {
+	return errors.New(faker.New().RandomStringWithLength(10))
+}
+
+func runTransactional(db *MockDB) {
+	db.EXPECT().Transactional(mock.Anything, mock.Anything).
+		RunAndReturn(func(ctx context.Context, fn func(ctx context.Context) error) error {
+			return fn(ctx)
+		})
+}
+
+func pendingRows(t *testing.T, msgs ...Message) *MockRows {
+	t.Helper()
+	rows := NewMockRows(t)
+	for _, msg := range msgs {
+		rows.EXPECT().Next().Return(true).Once()
+		rows.EXPECT().Scan(mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything,
+			mock.Anything, mock.Anything).
+			RunAndReturn(func(dest ...any) error {
+				*dest[0].(*int64) = msg.ID
+				*dest[1].(*string) = msg.Topic
+				*dest[5].(*int) = msg.Attempts
+				return nil
+			}).Once()
+	}
+	rows.EXPECT().Next().Return(false).Once()
+	rows.EXPECT().Err().Return(nil)
+	rows.EXPECT().Close().Return()
+	return rows
+}
+
+func TestSchema(t *testing.T) {
+	t.Run("should be able to make ddl for qualified table", func(t *testing.T) {
+		ddl := Schema("events.outbox")
+		assert.Contains(t, ddl, `CREATE TABLE IF NOT EXISTS "events"."outbox"`)
+		assert.Contains(t, ddl, `"events_outbox_pending_idx"`)
+	})
+}
+
+func TestOutbox_Write(t *testing.T) {
+	msg := Message{Topic: "orders", Key: "42", Payload: []byte(`{}`)}
+
+	t.Run("should be able to write message by transaction from context", func(t *testing.T) {
+		tx := NewMockTx(t)
+		ob := New(NewMockDB(t))
+		ctx := pgcontext.With(context.Background(), pgcontext.WithTransaction(tx))
+		tx.EXPECT().Exec(ctx, ob.queries.insert, []any{msg.Topic, msg.Key, msg.Payload, map[string]string{}}).
+			Return(pgconn.CommandTag{}, nil)
+
+		require.NoError(t, ob.Write(ctx, msg))
+	})
+
+	t.Run("should be able to notify relay after write", func(t *testing.T) {
+		tx := NewMockTx(t)
+		ob := New(NewMockDB(t), WithNotifyChannel("outbox"))
+		ctx := pgcontext.With(context.Background(), pgcontext.WithTransaction(tx))
+		tx.EXPECT().Exec(ctx, ob.queries.insert, mock.Anything).Return(pgconn.CommandTag{}, nil)
+		tx.EXPECT().Exec(ctx, notify.Query, []any{"outbox", ""}).Return(pgconn.CommandTag{}, nil)
+
+		require.NoError(t, ob.Write(ctx, msg))
+	})
+
+	t.Run("should be able to fail without transaction in context", func(t *testing.T) {
+		ob := New(NewMockDB(t))
+
+		require.ErrorIs(t, ob.Write(context.Background(), msg), ErrNoTransaction)
+	})
+
+	t.Run("should be able to fail when transaction can't insert", func(t *testing.T) {
+		expErr := newError()
+		tx := NewMockTx(t)
+		ob := New(NewMockDB(t), WithNotifyChannel("outbox"))
+		ctx := pgcontext.With(context.Background(), pgcontext.WithTransaction(tx))
+		tx.EXPECT().Exec(ctx, ob.queries.insert, mock.Anything).Return(pgconn.CommandTag{}, expErr)
+
+		require.ErrorIs(t, ob.Write(ctx, msg), expErr)
+	})
+}
+
+func TestOutbox_Dispatch(t *testing.T) {
+	t.Run("should be able to publish pending messages and mark them dispatched", func(t *testing.T) {
+		db := NewMockDB(t)
+		pub := NewMockPublisher(t)
+		ob := New(db, WithBatchSize(10))
+		runTransactional(db)
+		db.EXPECT().Query(mock.Anything, ob.queries.pending, []any{10}).
+			Return(pendingRows(t, Message{ID: 1, Topic: "orders"}, Message{ID: 2, Topic: "orders"}), nil)
+		pub.EXPECT().Publish(mock.Anything, mock.Anything).Return(nil).Twice()
+		db.EXPECT().Exec(mock.Anything, ob.queries.dispatched, []any{[]int64{1, 2}}).
+			Return(pgconn.CommandTag{}, nil)
+
+		processed, err := ob.Dispatch(context.Background(), pub)
+		require.NoError(t, err)
+		assert.Equal(t, 2, processed)
+	})
+
+	t.Run("should be able to schedule retry of failed message", func(t *testing.T) {
+		expErr := newError()
+		db := NewMockDB(t)
+		pub := NewMockPublisher(t)
+		ob := New(db, WithBackoff(time.Second, time.Minute), WithMaxAttempts(3))
+		runTransactional(db)
+		db.EXPECT().Query(mock.Anything, ob.queries.pending, mock.Anything).
+			Return(pendingRows(t, Message{ID: 7, Attempts: 2}), nil)
+		pub.EXPECT().Publish(mock.Anything, mock.Anything).Return(expErr)
+		db.EXPECT().Exec(mock.Anything, ob.queries.failed, []any{int64(7), expErr.Error(), int64(4000), 3}).
+			Return(pgconn.CommandTag{}, nil)
+
+		processed, err := ob.Dispatch(context.Background(), pub)
+		require.NoError(t, err)
+		assert.Equal(t, 1, processed)
+	})
+
+	t.Run("should be able to fail when can't select pending messages", func(t *testing.T) {
+		expErr := newError()
+		db := NewMockDB(t)
+		ob := New(db)
+		runTransactional(db)
+		db.EXPECT().Query(mock.Anything, ob.queries.pending, mock.Anything).Return(nil, expErr)
+
+		_, err := ob.Dispatch(context.Background(), NewMockPublisher(t))
+		require.ErrorIs(t, err, expErr)
+	})
+
+	t.Run("should be able to fail when can't mark message failed", func(t *testing.T) {
+		expErr := newError()
+		db := NewMockDB(t)
+		pub := NewMockPublisher(t)
+		ob := New(db)
+		runTransactional(db)
+		db.EXPECT().Query(mock.Anything, ob.queries.pending, mock.Anything).
+			Return(pendingRows(t, Message{ID: 7}), nil)
+		pub.EXPECT().Publish(mock.Anything, mock.Anything).Return(newError())
+		db.EXPECT().Exec(mock.Anything, ob.queries.failed, mock.Anything).Return(pgconn.CommandTag{}, expErr)
+
+		_, err := ob.Dispatch(context.Background(), pub)
+		require.ErrorIs(t, err, expErr)
+	})
+
+	t.Run("should be able to dispatch at leader", func(t *testing.T) {
+		db := NewMockDB(t)
+		ob := New(db)
+		db.EXPECT().Transactional(mock.Anything, mock.Anything).
+			RunAndReturn(func(ctx context.Context, _ func(ctx context.Context) error) error {
+				assert.True(t, pgcontext.CanWriteFrom(ctx))
+				return nil
+			})
+
+		_, err := ob.Dispatch(context.Background(), NewMockPublisher(t))
+		require.NoError(t, err)
+	})
+}
+
+type listenDB struct {
+	*MockDB
+	*MockListener
+}
+
+func TestOutbox_Run(t *testing.T) {
+	t.Run("should be able to relay every shard", func(t *testing.T) {
+		db := NewMockDB(t)
+		ob := New(db, WithShards(3), WithPollInterval(time.Hour))
+		ctx, cancel := context.WithCancel(context.Background())
+
+		var (
+			mu     sync.Mutex
+			shards = map[uint]bool{}
+		)
+		db.EXPECT().Transactional(mock.Anything, mock.Anything).
+			RunAndReturn(func(ctx context.Context, _ func(ctx context.Context) error) error {
+				id, ok := pgcontext.ShardIDFrom(ctx)
+				require.True(t, ok)
+				mu.Lock()
+				defer mu.Unlock()
+				shards[id] = true
+				if len(shards) == 3 {
+					cancel()
+				}
+				return nil
+			})
+
+		require.ErrorIs(t, ob.Run(ctx, NewMockPublisher(t)), context.Canceled)
+		assert.Len(t, shards, 3)
+	})
+
+	t.Run("should be able to wake up by notification", func(t *testing.T) {
+		db := listenDB{MockDB: NewMockDB(t), MockListener: NewMockListener(t)}
+		ob := New(db, WithNotifyChannel("outbox"), WithPollInterval(time.Hour))
+		ctx, cancel := context.WithCancel(context.Background())
+		notifications := make(chan pgconn.Notification, 1)
+		db.MockListener.EXPECT().Listen(mock.Anything, "outbox").Return(notifications, nil)
+
+		calls := 0
+		db.MockDB.EXPECT().Transactional(mock.Anything, mock.Anything).
+			RunAndReturn(func(context.Context, func(ctx context.Context) error) error {
+				calls++
+				if calls == 1 {
+					notifications <- pgconn.Notification{Channel: "outbox"}
+				} else {
+					cancel()
+				}
+				return nil
+			})
+
+		require.ErrorIs(t, ob.Run(ctx, NewMockPublisher(t)), context.Canceled)
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("should be able to report relay errors", func(t *testing.T) {
+		expErr := newError()
+		db := NewMockDB(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		var reported error
+		ob := New(db, WithTable("outbox"), WithErrorHandler(func(err error) {
+			reported = err
+			cancel()
+		}))
+		db.EXPECT().Transactional(mock.Anything, mock.Anything).Return(expErr)
+
+		require.ErrorIs(t, ob.Run(ctx, NewMockPublisher(t)), context.Canceled)
+		assert.ErrorIs(t, reported, expErr)
+	})
+}
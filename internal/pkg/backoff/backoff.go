@@ -0,0 +1,16 @@
+// Package backoff calculates exponential delays between retry attempts.
+package backoff
+
+import "time"
+
+// Exponential returns minDelay doubled attempts times, but not more than maxDelay.
+func Exponential(minDelay, maxDelay time.Duration, attempts int) time.Duration {
+	delay := minDelay
+	for range attempts {
+		delay *= 2
+		if delay >= maxDelay {
+			return maxDelay
+		}
+	}
+	return min(delay, maxDelay)
+}
@@ -0,0 +1,32 @@
+package backoff
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExponential(t *testing.T) {
+	cases := []struct {
+		name     string
+		minDelay time.Duration
+		maxDelay time.Duration
+		attempts int
+		exp      time.Duration
+	}{
+		{name: "min delay of first attempt", minDelay: time.Second, maxDelay: 5 * time.Second, exp: time.Second},
+		{name: "doubled delay per attempt", minDelay: time.Second, maxDelay: 5 * time.Second, attempts: 2,
+			exp: 4 * time.Second},
+		{name: "max delay when doubled delay is greater", minDelay: time.Second, maxDelay: 5 * time.Second,
+			attempts: 3, exp: 5 * time.Second},
+		{name: "max delay when it is less than min delay", minDelay: time.Minute, maxDelay: time.Second,
+			exp: time.Second},
+	}
+
+	for _, tc := range cases {
+		t.Run("should be able to return "+tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.exp, Exponential(tc.minDelay, tc.maxDelay, tc.attempts))
+		})
+	}
+}
@@ -0,0 +1,46 @@
+// Package failure contains pgx results stubs which are returned instead of real results
+// when operation could not be started and error must be reported through result methods.
+package failure
+
+import (
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type batchResults struct {
+	err error
+}
+
+// BatchResults returns pgx.BatchResults which fails every read with err.
+func BatchResults(err error) pgx.BatchResults {
+	return batchResults{err: err}
+}
+
+func (res batchResults) Exec() (pgconn.CommandTag, error) {
+	return pgconn.CommandTag{}, res.err
+}
+
+func (res batchResults) Query() (pgx.Rows, error) {
+	return nil, res.err
+}
+
+func (res batchResults) QueryRow() pgx.Row {
+	return row(res)
+}
+
+func (res batchResults) Close() error {
+	return res.err
+}
+
+// Row returns pgx.Row which fails scan with err.
+func Row(err error) pgx.Row {
+	return row{err: err}
+}
+
+type row struct {
+	err error
+}
+
+func (r row) Scan(_ ...any) error {
+	return r.err
+}
@@ -0,0 +1,34 @@
+package failure
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jaswdr/faker/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBatchResults(t *testing.T) {
+	t.Run("should be able to return error from every method", func(t *testing.T) {
+		expErr := errors.New(faker.New().RandomStringWithLength(10))
+		res := BatchResults(expErr)
+
+		tag, err := res.Exec()
+		assert.ErrorIs(t, err, expErr)
+		assert.Zero(t, tag.RowsAffected())
+
+		rows, err := res.Query()
+		assert.ErrorIs(t, err, expErr)
+		assert.Nil(t, rows)
+
+		assert.ErrorIs(t, res.QueryRow().Scan(), expErr)
+		assert.ErrorIs(t, res.Close(), expErr)
+	})
+}
+
+func TestRow(t *testing.T) {
+	t.Run("should be able to fail scan", func(t *testing.T) {
+		expErr := errors.New(faker.New().RandomStringWithLength(10))
+		assert.ErrorIs(t, Row(expErr).Scan(), expErr)
+	})
+}
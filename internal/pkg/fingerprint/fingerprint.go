@@ -0,0 +1,138 @@
+// Package fingerprint normalizes SQL statements, so statements which differ only by literals, placeholders,
+// comments, whitespace or keyword case have the same fingerprint.
+package fingerprint
+
+import (
+	"fmt"
+	"hash/fnv"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var (
+	listOfValues   = regexp.MustCompile(`\(\s*\?(?:\s*,\s*\?)*\s*\)`)
+	spaceBefore    = regexp.MustCompile(`\s+([,)])`)
+	spaceAfterOpen = regexp.MustCompile(`\(\s+`)
+)
+
+// Normalize lowercases statement, replaces literals and placeholders by ?, collapses lists of values to (?)
+// and removes comments and extra whitespace. Quoted identifiers are kept as is.
+func Normalize(sql string) string {
+	var out strings.Builder
+	out.Grow(len(sql))
+
+	src := []rune(sql)
+	for i := 0; i < len(src); i++ {
+		r := src[i]
+		switch {
+		case r == '-' && peek(src, i+1) == '-':
+			i = skipUntil(src, i, "\n")
+			out.WriteRune(' ')
+		case r == '/' && peek(src, i+1) == '*':
+			i = skipUntil(src, i+2, "*/") + 1
+			out.WriteRune(' ')
+		case r == '\'':
+			i = skipQuoted(src, i, '\'')
+			out.WriteRune('?')
+		case r == '"':
+			end := skipQuoted(src, i, '"')
+			out.WriteString(string(src[i:min(end+1, len(src))]))
+			i = end
+		case r == '$' && unicode.IsDigit(peek(src, i+1)):
+			i = skipWhile(src, i+1, unicode.IsDigit)
+			out.WriteRune('?')
+		case r == '$' && isDollarTag(src, i):
+			i = skipDollarQuoted(src, i)
+			out.WriteRune('?')
+		case unicode.IsDigit(r) && !isIdentifier(peek(src, i-1)):
+			i = skipWhile(src, i, isNumber)
+			out.WriteRune('?')
+		case unicode.IsSpace(r):
+			i = skipWhile(src, i, unicode.IsSpace)
+			out.WriteRune(' ')
+		default:
+			out.WriteRune(unicode.ToLower(r))
+		}
+	}
+
+	norm := strings.Join(strings.Fields(out.String()), " ")
+	norm = spaceBefore.ReplaceAllString(norm, "$1")
+	norm = spaceAfterOpen.ReplaceAllString(norm, "(")
+	return listOfValues.ReplaceAllString(norm, "(?)")
+}
+
+// Fingerprint returns short hash of normalized statement.
+func Fingerprint(sql string) string {
+	hash := fnv.New64a()
+	_, _ = hash.Write([]byte(Normalize(sql)))
+	return fmt.Sprintf("%016x", hash.Sum64())
+}
+
+func peek(src []rune, i int) rune {
+	if i < 0 || i >= len(src) {
+		return 0
+	}
+	return src[i]
+}
+
+// skipWhile returns index of last rune from i which matches fn.
+func skipWhile(src []rune, i int, fn func(r rune) bool) int {
+	for i+1 < len(src) && fn(src[i+1]) {
+		i++
+	}
+	return i
+}
+
+// skipUntil returns index of last rune of terminator found after i, or last index of src.
+func skipUntil(src []rune, i int, terminator string) int {
+	idx := strings.Index(string(src[i:]), terminator)
+	if idx < 0 {
+		return len(src) - 1
+	}
+	return i + len([]rune(string(src[i:])[:idx])) + len([]rune(terminator)) - 1
+}
+
+// skipQuoted returns index of closing quote, doubled quotes are escaped quotes.
+func skipQuoted(src []rune, i int, quote rune) int {
+	for j := i + 1; j < len(src); j++ {
+		if src[j] != quote {
+			continue
+		}
+		if peek(src, j+1) == quote {
+			j++
+			continue
+		}
+		return j
+	}
+	return len(src) - 1
+}
+
+func isDollarTag(src []rune, i int) bool {
+	for j := i + 1; j < len(src); j++ {
+		if src[j] == '$' {
+			return true
+		}
+		if !isIdentifier(src[j]) {
+			return false
+		}
+	}
+	return false
+}
+
+func skipDollarQuoted(src []rune, i int) int {
+	end := i + 1
+	for src[end] != '$' {
+		end++
+	}
+	tag := string(src[i : end+1])
+	return skipUntil(src, end+1, tag)
+}
+
+func isIdentifier(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func isNumber(r rune) bool {
+	return unicode.IsDigit(r) || r == '.' || r == 'e' || r == 'E'
+}
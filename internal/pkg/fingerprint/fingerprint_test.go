@@ -0,0 +1,62 @@
+package fingerprint
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	cases := []struct {
+		name string
+		sql  string
+		exp  string
+	}{
+		{
+			name: "placeholders and whitespace",
+			sql:  "SELECT id,  name\n\tFROM users WHERE id = $1",
+			exp:  "select id, name from users where id = ?",
+		},
+		{
+			name: "literals",
+			sql:  "SELECT * FROM users WHERE name = 'O''Brien' AND age > 42.5 AND t2.id = 1",
+			exp:  "select * from users where name = ? and age > ? and t2.id = ?",
+		},
+		{
+			name: "comments",
+			sql:  "SELECT 1 -- probe\n/* multi\nline */ FROM dual",
+			exp:  "select ? from dual",
+		},
+		{
+			name: "lists of values",
+			sql:  "SELECT * FROM users WHERE id IN ( $1, $2,$3 )",
+			exp:  "select * from users where id in (?)",
+		},
+		{
+			name: "quoted identifiers",
+			sql:  `SELECT "UserID" FROM "Users"`,
+			exp:  `select "UserID" from "Users"`,
+		},
+		{
+			name: "dollar quoted strings",
+			sql:  "SELECT $tag$it's $1$tag$",
+			exp:  "select ?",
+		},
+	}
+	for _, tc := range cases {
+		t.Run("should be able to normalize "+tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.exp, Normalize(tc.sql))
+		})
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	t.Run("should be able to match statements which differ only by values", func(t *testing.T) {
+		assert.Equal(t,
+			Fingerprint("select * from users where id = 1"),
+			Fingerprint("SELECT *\nFROM users\nWHERE id = $1"),
+		)
+		assert.NotEqual(t, Fingerprint("SELECT * FROM users"), Fingerprint("SELECT * FROM orders"))
+		assert.Len(t, Fingerprint("SELECT 1"), 16)
+	})
+}
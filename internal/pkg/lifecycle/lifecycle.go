@@ -0,0 +1,267 @@
+// Package lifecycle contains results of Ping and Stats of pools. Every result identifies node of topology it
+// came from, so results of clusters and shards are flat lists of their nodes.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/godepo/elephant/internal/pkg/monads"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var ErrPingNotSupported = errors.New("pool does not support ping")
+
+// Pinger is implemented by pools which can check their nodes.
+type Pinger interface {
+	Ping(ctx context.Context) (Pings, error)
+}
+
+// Stater is implemented by pools which give stats of their nodes.
+type Stater interface {
+	Stats() Stats
+}
+
+// Closer is implemented by pools which can be closed.
+type Closer interface {
+	Close()
+}
+
+type Role string
+
+const (
+	RoleNode     Role = "node"
+	RoleLeader   Role = "leader"
+	RoleFollower Role = "follower"
+)
+
+// Node identifies member of topology. Index is index of follower, shard is set for nodes of sharded pools, tenant
+// is set for nodes of dedicated databases of tenants.
+type Node struct {
+	Role   Role
+	Index  int
+	Shard  monads.Optional[uint]
+	Tenant string
+}
+
+func (n Node) String() string {
+	res := string(n.Role)
+	if n.Role == RoleFollower {
+		res = fmt.Sprintf("%s [%d]", res, n.Index)
+	}
+	if !n.Shard.IsEmpty() {
+		res = fmt.Sprintf("shard [%d] %s", n.Shard.Value, res)
+	}
+	if n.Tenant != "" {
+		res = fmt.Sprintf("tenant [%s] %s", n.Tenant, res)
+	}
+	return res
+}
+
+// Member makes node of single pool member of cluster with role and index.
+func (n Node) Member(role Role, index int) Node {
+	if n.Role != RoleNode {
+		return n
+	}
+	n.Role = role
+	n.Index = index
+	return n
+}
+
+// InShard makes node member of shard.
+func (n Node) InShard(shard uint) Node {
+	n.Shard = monads.OptionalOf(shard)
+	return n
+}
+
+// OfTenant makes node member of dedicated database of tenant.
+func (n Node) OfTenant(tenant string) Node {
+	n.Tenant = tenant
+	return n
+}
+
+type Ping struct {
+	Node    Node
+	Latency time.Duration
+	Err     error
+}
+
+type Pings []Ping
+
+// Err joins errors of failed nodes, it is nil when every node is alive.
+func (p Pings) Err() error {
+	var errs []error
+	for _, ping := range p {
+		if ping.Err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", ping.Node, ping.Err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Map returns pings with nodes changed by fn.
+func (p Pings) Map(fn func(node Node) Node) Pings {
+	res := make(Pings, len(p))
+	for i, ping := range p {
+		ping.Node = fn(ping.Node)
+		res[i] = ping
+	}
+	return res
+}
+
+// Stat is snapshot of pgxpool.Stat, which can be summed up.
+type Stat struct {
+	AcquireCount            int64
+	AcquireDuration         time.Duration
+	AcquiredConns           int32
+	CanceledAcquireCount    int64
+	ConstructingConns       int32
+	EmptyAcquireCount       int64
+	EmptyAcquireWaitTime    time.Duration
+	IdleConns               int32
+	MaxConns                int32
+	MaxIdleDestroyCount     int64
+	MaxLifetimeDestroyCount int64
+	NewConnsCount           int64
+	TotalConns              int32
+}
+
+func StatOf(stat *pgxpool.Stat) Stat {
+	return Stat{
+		AcquireCount:            stat.AcquireCount(),
+		AcquireDuration:         stat.AcquireDuration(),
+		AcquiredConns:           stat.AcquiredConns(),
+		CanceledAcquireCount:    stat.CanceledAcquireCount(),
+		ConstructingConns:       stat.ConstructingConns(),
+		EmptyAcquireCount:       stat.EmptyAcquireCount(),
+		EmptyAcquireWaitTime:    stat.EmptyAcquireWaitTime(),
+		IdleConns:               stat.IdleConns(),
+		MaxConns:                stat.MaxConns(),
+		MaxIdleDestroyCount:     stat.MaxIdleDestroyCount(),
+		MaxLifetimeDestroyCount: stat.MaxLifetimeDestroyCount(),
+		NewConnsCount:           stat.NewConnsCount(),
+		TotalConns:              stat.TotalConns(),
+	}
+}
+
+func (s Stat) Add(other Stat) Stat {
+	return Stat{
+		AcquireCount:            s.AcquireCount + other.AcquireCount,
+		AcquireDuration:         s.AcquireDuration + other.AcquireDuration,
+		AcquiredConns:           s.AcquiredConns + other.AcquiredConns,
+		CanceledAcquireCount:    s.CanceledAcquireCount + other.CanceledAcquireCount,
+		ConstructingConns:       s.ConstructingConns + other.ConstructingConns,
+		EmptyAcquireCount:       s.EmptyAcquireCount + other.EmptyAcquireCount,
+		EmptyAcquireWaitTime:    s.EmptyAcquireWaitTime + other.EmptyAcquireWaitTime,
+		IdleConns:               s.IdleConns + other.IdleConns,
+		MaxConns:                s.MaxConns + other.MaxConns,
+		MaxIdleDestroyCount:     s.MaxIdleDestroyCount + other.MaxIdleDestroyCount,
+		MaxLifetimeDestroyCount: s.MaxLifetimeDestroyCount + other.MaxLifetimeDestroyCount,
+		NewConnsCount:           s.NewConnsCount + other.NewConnsCount,
+		TotalConns:              s.TotalConns + other.TotalConns,
+	}
+}
+
+type NodeStat struct {
+	Node Node
+	Stat Stat
+}
+
+// Stats are stats of every node of topology, nodes which pools don't give stats are skipped.
+type Stats []NodeStat
+
+// Total sums up stats of every node.
+func (s Stats) Total() Stat {
+	var res Stat
+	for _, stat := range s {
+		res = res.Add(stat.Stat)
+	}
+	return res
+}
+
+// ByShard sums up stats of nodes of every shard.
+func (s Stats) ByShard() map[uint]Stat {
+	res := map[uint]Stat{}
+	for _, stat := range s {
+		if stat.Node.Shard.IsEmpty() {
+			continue
+		}
+		res[stat.Node.Shard.Value] = res[stat.Node.Shard.Value].Add(stat.Stat)
+	}
+	return res
+}
+
+// Map returns stats with nodes changed by fn.
+func (s Stats) Map(fn func(node Node) Node) Stats {
+	res := make(Stats, len(s))
+	for i, stat := range s {
+		stat.Node = fn(stat.Node)
+		res[i] = stat
+	}
+	return res
+}
+
+// PingMember pings member, member which can't be pinged is reported as single node failed with
+// ErrPingNotSupported.
+func PingMember(ctx context.Context, member any) Pings {
+	pinger, ok := member.(Pinger)
+	if !ok {
+		return Pings{{Node: Node{Role: RoleNode}, Err: ErrPingNotSupported}}
+	}
+	pings, _ := pinger.Ping(ctx)
+	return pings
+}
+
+// PingMembers pings members concurrently and changes their nodes by fn with index of member.
+func PingMembers[T any](ctx context.Context, members []T, fn func(ix int, node Node) Node) Pings {
+	results := make([]Pings, len(members))
+	var wg sync.WaitGroup
+	for i, member := range members {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = PingMember(ctx, member).Map(func(node Node) Node {
+				return fn(i, node)
+			})
+		}()
+	}
+	wg.Wait()
+	return slices.Concat(results...)
+}
+
+// MemberStats returns stats of member, they are empty when member does not give stats.
+func MemberStats(member any) Stats {
+	stater, ok := member.(Stater)
+	if !ok {
+		return nil
+	}
+	return stater.Stats()
+}
+
+// MembersStats collects stats of members and changes their nodes by fn with index of member.
+func MembersStats[T any](members []T, fn func(ix int, node Node) Node) Stats {
+	var res Stats
+	for i, member := range members {
+		res = append(res, MemberStats(member).Map(func(node Node) Node {
+			return fn(i, node)
+		})...)
+	}
+	return res
+}
+
+// CloseMember closes member when it can be closed.
+func CloseMember(member any) {
+	if closer, ok := member.(Closer); ok {
+		closer.Close()
+	}
+}
+
+func CloseMembers[T any](members []T) {
+	for _, member := range members {
+		CloseMember(member)
+	}
+}
@@ -0,0 +1,98 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/godepo/elephant/internal/pkg/monads"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type member struct {
+	err    error
+	stat   Stat
+	closed bool
+}
+
+func (m *member) Ping(context.Context) (Pings, error) {
+	pings := Pings{{Node: Node{Role: RoleNode}, Err: m.err}}
+	return pings, pings.Err()
+}
+
+func (m *member) Stats() Stats {
+	return Stats{{Node: Node{Role: RoleNode}, Stat: m.stat}}
+}
+
+func (m *member) Close() {
+	m.closed = true
+}
+
+func TestNode(t *testing.T) {
+	t.Run("should be able to name node of topology", func(t *testing.T) {
+		node := Node{Role: RoleNode}
+		assert.Equal(t, "node", node.String())
+		assert.Equal(t, "leader", node.Member(RoleLeader, 0).String())
+		assert.Equal(t, "shard [2] follower [1]", node.Member(RoleFollower, 1).InShard(2).String())
+		assert.Equal(t, "tenant [acme] leader", node.Member(RoleLeader, 0).OfTenant("acme").String())
+	})
+
+	t.Run("should be able to keep role of cluster member", func(t *testing.T) {
+		node := Node{Role: RoleFollower, Index: 1}
+		assert.Equal(t, node, node.Member(RoleLeader, 0))
+	})
+}
+
+func TestPingMembers(t *testing.T) {
+	t.Run("should be able to ping every member and join errors of failed nodes", func(t *testing.T) {
+		expErr := errors.New(uuid.NewString())
+		members := []any{&member{}, &member{err: expErr}, struct{}{}}
+
+		pings := PingMembers(context.Background(), members, func(ix int, node Node) Node {
+			return node.InShard(uint(ix))
+		})
+		require.Len(t, pings, 3)
+		assert.NoError(t, pings[0].Err)
+		assert.Equal(t, monads.OptionalOf(uint(1)), pings[1].Node.Shard)
+		assert.ErrorIs(t, pings[1].Err, expErr)
+		assert.ErrorIs(t, pings[2].Err, ErrPingNotSupported)
+
+		err := pings.Err()
+		require.ErrorIs(t, err, expErr)
+		require.ErrorIs(t, err, ErrPingNotSupported)
+		assert.ErrorContains(t, err, "shard [1] node")
+		assert.NoError(t, pings[:1].Err())
+	})
+}
+
+func TestMembersStats(t *testing.T) {
+	t.Run("should be able to sum up stats of members per shard", func(t *testing.T) {
+		members := []any{
+			&member{stat: Stat{TotalConns: 2, MaxConns: 10}},
+			&member{stat: Stat{TotalConns: 3, MaxConns: 10}},
+			struct{}{},
+		}
+
+		stats := MembersStats(members, func(ix int, node Node) Node {
+			return node.InShard(uint(ix % 2))
+		})
+		require.Len(t, stats, 2)
+		assert.Equal(t, Stat{TotalConns: 5, MaxConns: 20}, stats.Total())
+		assert.Equal(t, map[uint]Stat{
+			0: {TotalConns: 2, MaxConns: 10},
+			1: {TotalConns: 3, MaxConns: 10},
+		}, stats.ByShard())
+	})
+}
+
+func TestCloseMembers(t *testing.T) {
+	t.Run("should be able to close members which can be closed", func(t *testing.T) {
+		first, second := &member{}, &member{}
+
+		CloseMembers([]any{first, struct{}{}, second})
+		assert.True(t, first.closed)
+		assert.True(t, second.closed)
+	})
+}
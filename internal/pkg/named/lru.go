@@ -0,0 +1,61 @@
+package named
+
+import (
+	"container/list"
+	"sync"
+)
+
+// lru keeps parsed queries and evicts least recently used query, when count of queries exceeds capacity.
+type lru struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List
+	entries  map[string]*list.Element
+}
+
+type entry struct {
+	query string
+	p     parsed
+}
+
+func newLRU(capacity int) *lru {
+	return &lru{
+		capacity: capacity,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+func (c *lru) get(query string) (parsed, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[query]
+	if !ok {
+		return parsed{}, false
+	}
+	c.order.MoveToFront(el)
+	return el.Value.(*entry).p, true //nolint:forcetypeassert
+}
+
+func (c *lru) set(query string, p parsed) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.entries[query]; ok {
+		c.order.MoveToFront(el)
+		return
+	}
+	c.entries[query] = c.order.PushFront(&entry{query: query, p: p})
+	if c.order.Len() > c.capacity {
+		el := c.order.Back()
+		c.order.Remove(el)
+		delete(c.entries, el.Value.(*entry).query) //nolint:forcetypeassert
+	}
+}
+
+func (c *lru) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
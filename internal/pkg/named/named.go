@@ -0,0 +1,291 @@
+// Package named rewrites queries with :name and @name placeholders to positional $n form and binds values of
+// placeholders from single map or struct argument. Recently used queries are cached, so they are not parsed again.
+package named
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// maxCached limits count of cached queries, least recently used query is evicted when cache is full.
+const maxCached = 4096
+
+var (
+	ErrMissingValue      = errors.New("value of named parameter is not found")
+	ErrMixedPlaceholders = errors.New("named and positional placeholders can't be mixed in one query")
+)
+
+var cache = newLRU(maxCached) //nolint:gochecknoglobals
+
+type parsed struct {
+	sql        string
+	names      []string
+	positional bool
+}
+
+// Bind rewrites query when args is single map with string keys or struct, and query has named placeholders.
+// Same name is bound to same positional placeholder. Map keys are matched exactly, struct fields are matched by
+// db tag or field name ignoring case, like pgx maps rows to structs. Other queries are returned as is.
+func Bind(query string, args []any) (string, []any, error) {
+	if len(args) != 1 {
+		return query, args, nil
+	}
+	src, ok := source(args[0])
+	if !ok {
+		return query, args, nil
+	}
+
+	p := parse(query)
+	if len(p.names) == 0 {
+		return query, args, nil
+	}
+	if p.positional {
+		return "", nil, ErrMixedPlaceholders
+	}
+
+	out := make([]any, 0, len(p.names))
+	for _, name := range p.names {
+		value, err := src(name)
+		if err != nil {
+			return "", nil, err
+		}
+		out = append(out, value)
+	}
+	return p.sql, out, nil
+}
+
+func parse(query string) parsed {
+	if p, ok := cache.get(query); ok {
+		return p
+	}
+	p := rewrite(query)
+	cache.set(query, p)
+	return p
+}
+
+func rewrite(query string) parsed {
+	var (
+		out       strings.Builder
+		p         parsed
+		positions = map[string]int{}
+	)
+	out.Grow(len(query))
+
+	src := []rune(query)
+	for i := 0; i < len(src); i++ {
+		r := src[i]
+		switch {
+		case r == '-' && peek(src, i+1) == '-':
+			end := skipUntil(src, i, "\n")
+			out.WriteString(string(src[i : end+1]))
+			i = end
+		case r == '/' && peek(src, i+1) == '*':
+			end := skipUntil(src, i+2, "*/")
+			out.WriteString(string(src[i : end+1]))
+			i = end
+		case r == '\'' || r == '"':
+			end := skipQuoted(src, i, r, r == '\'' && isEscapeString(src, i))
+			out.WriteString(string(src[i : end+1]))
+			i = end
+		case r == '$' && unicode.IsDigit(peek(src, i+1)):
+			p.positional = true
+			out.WriteRune(r)
+		case r == '$' && isDollarTag(src, i):
+			end := skipDollarQuoted(src, i)
+			out.WriteString(string(src[i : end+1]))
+			i = end
+		case r == ':' && peek(src, i+1) == ':':
+			out.WriteString("::")
+			i++
+		case (r == ':' || r == '@') && isNameStart(peek(src, i+1)) && !isIdentifier(peek(src, i-1)):
+			end := skipWhile(src, i+1, isIdentifier)
+			name := string(src[i+1 : end+1])
+			pos, ok := positions[name]
+			if !ok {
+				p.names = append(p.names, name)
+				pos = len(p.names)
+				positions[name] = pos
+			}
+			out.WriteString("$" + strconv.Itoa(pos))
+			i = end
+		default:
+			out.WriteRune(r)
+		}
+	}
+	p.sql = out.String()
+	return p
+}
+
+// source returns lookup of values by name for map or struct argument.
+func source(arg any) (func(name string) (any, error), bool) {
+	if arg == nil {
+		return nil, false
+	}
+	if _, ok := arg.(driver.Valuer); ok {
+		return nil, false
+	}
+
+	val := reflect.ValueOf(arg)
+	for val.Kind() == reflect.Pointer {
+		if val.IsNil() {
+			return nil, false
+		}
+		val = val.Elem()
+	}
+
+	switch val.Kind() {
+	case reflect.Map:
+		if val.Type().Key().Kind() != reflect.String {
+			return nil, false
+		}
+		return mapSource(val), true
+	case reflect.Struct:
+		if val.Type().PkgPath() == "time" {
+			return nil, false
+		}
+		return structSource(val), true
+	default:
+		return nil, false
+	}
+}
+
+func mapSource(val reflect.Value) func(name string) (any, error) {
+	return func(name string) (any, error) {
+		value := val.MapIndex(reflect.ValueOf(name).Convert(val.Type().Key()))
+		if !value.IsValid() {
+			return nil, fmt.Errorf("%w: %s", ErrMissingValue, name)
+		}
+		return value.Interface(), nil
+	}
+}
+
+func structSource(val reflect.Value) func(name string) (any, error) {
+	fields := map[string]reflect.Value{}
+	collectFields(val, fields)
+	return func(name string) (any, error) {
+		value, ok := fields[strings.ToLower(name)]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrMissingValue, name)
+		}
+		return value.Interface(), nil
+	}
+}
+
+// collectFields maps exported fields by lowercased db tag or field name, fields of embedded structs are
+// promoted unless they are shadowed by outer fields.
+func collectFields(val reflect.Value, fields map[string]reflect.Value) {
+	var embedded []reflect.Value
+	for i := range val.NumField() {
+		field := val.Type().Field(i)
+		tag, hasTag := field.Tag.Lookup("db")
+		if tag == "-" {
+			continue
+		}
+		if field.Anonymous && !hasTag && field.Type.Kind() == reflect.Struct {
+			embedded = append(embedded, val.Field(i))
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		name := field.Name
+		if hasTag && tag != "" {
+			name = tag
+		}
+		fields[strings.ToLower(name)] = val.Field(i)
+	}
+
+	for _, emb := range embedded {
+		inner := map[string]reflect.Value{}
+		collectFields(emb, inner)
+		for name, value := range inner {
+			if _, ok := fields[name]; !ok {
+				fields[name] = value
+			}
+		}
+	}
+}
+
+func peek(src []rune, i int) rune {
+	if i < 0 || i >= len(src) {
+		return 0
+	}
+	return src[i]
+}
+
+// skipWhile returns index of last rune from i which matches fn.
+func skipWhile(src []rune, i int, fn func(r rune) bool) int {
+	for i+1 < len(src) && fn(src[i+1]) {
+		i++
+	}
+	return i
+}
+
+// skipUntil returns index of last rune of terminator found after i, or last index of src.
+func skipUntil(src []rune, i int, terminator string) int {
+	idx := strings.Index(string(src[i:]), terminator)
+	if idx < 0 {
+		return len(src) - 1
+	}
+	return i + len([]rune(string(src[i:])[:idx])) + len([]rune(terminator)) - 1
+}
+
+// skipQuoted returns index of closing quote, doubled quotes are escaped quotes. Backslash escapes next rune when
+// escapes are enabled.
+func skipQuoted(src []rune, i int, quote rune, escapes bool) int {
+	for j := i + 1; j < len(src); j++ {
+		if escapes && src[j] == '\\' {
+			j++
+			continue
+		}
+		if src[j] != quote {
+			continue
+		}
+		if peek(src, j+1) == quote {
+			j++
+			continue
+		}
+		return j
+	}
+	return len(src) - 1
+}
+
+// isEscapeString reports string constant with C-style escapes, which quote at i opens after E or e prefix.
+func isEscapeString(src []rune, i int) bool {
+	prefix := peek(src, i-1)
+	return (prefix == 'E' || prefix == 'e') && !isIdentifier(peek(src, i-2))
+}
+
+func isDollarTag(src []rune, i int) bool {
+	for j := i + 1; j < len(src); j++ {
+		if src[j] == '$' {
+			return true
+		}
+		if !isIdentifier(src[j]) {
+			return false
+		}
+	}
+	return false
+}
+
+func skipDollarQuoted(src []rune, i int) int {
+	end := i + 1
+	for src[end] != '$' {
+		end++
+	}
+	tag := string(src[i : end+1])
+	return skipUntil(src, end+1, tag)
+}
+
+func isNameStart(r rune) bool {
+	return r == '_' || unicode.IsLetter(r)
+}
+
+func isIdentifier(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
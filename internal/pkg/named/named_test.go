@@ -0,0 +1,148 @@
+package named
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBind(t *testing.T) {
+	t.Run("should be able to bind values from map", func(t *testing.T) {
+		query, args, err := Bind(
+			"UPDATE users SET name = :name, updated_at = @now WHERE id = :id AND name <> :name",
+			[]any{map[string]any{"id": 1, "name": "bob", "now": "today"}},
+		)
+		require.NoError(t, err)
+		assert.Equal(t, "UPDATE users SET name = $1, updated_at = $2 WHERE id = $3 AND name <> $1", query)
+		assert.Equal(t, []any{"bob", "today", 1}, args)
+	})
+
+	t.Run("should be able to bind values from struct", func(t *testing.T) {
+		type base struct {
+			ID   int64
+			Name string `db:"base_name"`
+		}
+		type user struct {
+			base
+			Name    string `db:"name"`
+			Email   string
+			Skipped string `db:"-"`
+			hidden  string
+		}
+
+		query, args, err := Bind(
+			"INSERT INTO users (id, name, email) VALUES (:id, :name, :EMAIL)",
+			[]any{&user{base: base{ID: 7}, Name: "bob", Email: "bob@example.com", hidden: "x"}},
+		)
+		require.NoError(t, err)
+		assert.Equal(t, "INSERT INTO users (id, name, email) VALUES ($1, $2, $3)", query)
+		assert.Equal(t, []any{int64(7), "bob", "bob@example.com"}, args)
+
+		_, _, err = Bind("SELECT :skipped", []any{user{}})
+		require.ErrorIs(t, err, ErrMissingValue)
+		_, _, err = Bind("SELECT :hidden", []any{user{}})
+		require.ErrorIs(t, err, ErrMissingValue)
+	})
+
+	t.Run("should be able to skip literals, comments and casts", func(t *testing.T) {
+		query, args, err := Bind(
+			`SELECT ':a', ":b", $$ :c $$, $tag$ @d $tag$, arr[lo:hi], x::int, :e -- :f
+/* @g */ FROM t WHERE e @> :e`,
+			[]any{map[string]any{"e": 1}},
+		)
+		require.NoError(t, err)
+		assert.Equal(t, `SELECT ':a', ":b", $$ :c $$, $tag$ @d $tag$, arr[lo:hi], x::int, $1 -- :f
+/* @g */ FROM t WHERE e @> $1`, query)
+		assert.Equal(t, []any{1}, args)
+	})
+
+	t.Run("should be able to pass positional queries as is", func(t *testing.T) {
+		id := uuid.New()
+		now := time.Now()
+		text := pgtype.Text{String: "bob", Valid: true}
+		cases := [][]any{
+			nil,
+			{1, 2},
+			{id},
+			{now},
+			{text},
+			{map[int]any{1: 1}},
+			{(*struct{ ID int })(nil)},
+		}
+		for _, args := range cases {
+			query, out, err := Bind("SELECT :id WHERE $1", args)
+			require.NoError(t, err)
+			assert.Equal(t, "SELECT :id WHERE $1", query)
+			assert.Equal(t, args, out)
+		}
+
+		args := []any{map[string]any{"id": 1}}
+		query, out, err := Bind("SELECT id FROM users", args)
+		require.NoError(t, err)
+		assert.Equal(t, "SELECT id FROM users", query)
+		assert.Equal(t, args, out)
+	})
+
+	t.Run("should be able to skip escaped quotes of escape string constants", func(t *testing.T) {
+		query, args, err := Bind(
+			`SELECT E'it\'s :a', e'\\', :b, 'c:\', :c, name'd'`,
+			[]any{map[string]any{"b": 1, "c": 2}},
+		)
+		require.NoError(t, err)
+		assert.Equal(t, `SELECT E'it\'s :a', e'\\', $1, 'c:\', $2, name'd'`, query)
+		assert.Equal(t, []any{1, 2}, args)
+	})
+
+	t.Run("should be able to fail", func(t *testing.T) {
+		_, _, err := Bind("SELECT :id, $1", []any{map[string]any{"id": 1}})
+		require.ErrorIs(t, err, ErrMixedPlaceholders)
+
+		_, _, err = Bind("SELECT :id", []any{map[string]any{}})
+		require.ErrorIs(t, err, ErrMissingValue)
+	})
+}
+
+func TestParse(t *testing.T) {
+	t.Run("should be able to cache parsed query", func(t *testing.T) {
+		query := "SELECT :" + "id_" + uuid.NewString()[:8]
+		first := parse(query)
+		cached, ok := cache.get(query)
+		require.True(t, ok)
+		assert.Equal(t, first, cached)
+		assert.Equal(t, first, parse(query))
+	})
+}
+
+func TestLRU(t *testing.T) {
+	t.Run("should be able to evict least recently used query", func(t *testing.T) {
+		c := newLRU(2)
+		c.set("a", parsed{sql: "a"})
+		c.set("b", parsed{sql: "b"})
+		_, ok := c.get("a")
+		require.True(t, ok)
+
+		c.set("c", parsed{sql: "c"})
+		assert.Equal(t, 2, c.len())
+		_, ok = c.get("b")
+		assert.False(t, ok)
+		p, ok := c.get("a")
+		require.True(t, ok)
+		assert.Equal(t, "a", p.sql)
+		_, ok = c.get("c")
+		assert.True(t, ok)
+	})
+
+	t.Run("should be able to keep first parsed query", func(t *testing.T) {
+		c := newLRU(2)
+		c.set("a", parsed{sql: "a"})
+		c.set("a", parsed{sql: "b"})
+		p, ok := c.get("a")
+		require.True(t, ok)
+		assert.Equal(t, "a", p.sql)
+		assert.Equal(t, 1, c.len())
+	})
+}
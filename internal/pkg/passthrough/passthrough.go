@@ -0,0 +1,165 @@
+// Package passthrough contains optional capabilities of pools and delegates them to wrapped pool, so decorators
+// implement only capabilities which they change.
+//
+//go:generate go tool mockery
+package passthrough
+
+import (
+	"context"
+	"errors"
+
+	"github.com/godepo/elephant/internal/pkg/failure"
+	"github.com/godepo/elephant/internal/pkg/lifecycle"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrAcquireNotSupported = errors.New("pool can't acquire dedicated connection")
+	ErrBulkNotSupported    = errors.New("pool does not support bulk operations")
+	ErrListenNotSupported  = errors.New("pool does not support listen")
+	ErrLockNotSupported    = errors.New("pool does not support advisory locks")
+)
+
+// Acquirer is implemented by pools which can give dedicated connection, like pgxpool.Pool.
+type Acquirer interface {
+	Acquire(ctx context.Context) (*pgxpool.Conn, error)
+}
+
+// BulkPool is implemented by pools which can load data in bulk.
+type BulkPool interface {
+	CopyFrom(
+		ctx context.Context,
+		tableName pgx.Identifier,
+		columnNames []string,
+		rowSrc pgx.CopyFromSource,
+	) (int64, error)
+	SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults
+}
+
+// Listener is implemented by pools which can subscribe to channels of LISTEN/NOTIFY.
+type Listener interface {
+	Listen(ctx context.Context, channel string) (<-chan pgconn.Notification, error)
+}
+
+// AdvisoryLocker is implemented by pools which can run functions under advisory locks.
+type AdvisoryLocker interface {
+	WithAdvisoryLock(ctx context.Context, key string, fn func(ctx context.Context) error) error
+	WithTryAdvisoryLock(ctx context.Context, key string, fn func(ctx context.Context) error) error
+}
+
+// AsAcquirer gives dedicated connections of db, it fails with ErrAcquireNotSupported when db can't give them.
+func AsAcquirer(db any) (Acquirer, error) {
+	acq, ok := db.(Acquirer)
+	if !ok {
+		return nil, ErrAcquireNotSupported
+	}
+	return acq, nil
+}
+
+// AsBulk gives bulk operations of db, it fails with ErrBulkNotSupported when db does not support them.
+func AsBulk(db any) (BulkPool, error) {
+	bulk, ok := db.(BulkPool)
+	if !ok {
+		return nil, ErrBulkNotSupported
+	}
+	return bulk, nil
+}
+
+// AsListener gives subscription of db, it fails with ErrListenNotSupported when db does not support it.
+func AsListener(db any) (Listener, error) {
+	lst, ok := db.(Listener)
+	if !ok {
+		return nil, ErrListenNotSupported
+	}
+	return lst, nil
+}
+
+// AsLocker gives advisory locks of db, it fails with ErrLockNotSupported when db does not support them.
+func AsLocker(db any) (AdvisoryLocker, error) {
+	lck, ok := db.(AdvisoryLocker)
+	if !ok {
+		return nil, ErrLockNotSupported
+	}
+	return lck, nil
+}
+
+// Delegate passes optional capabilities and lifecycle to wrapped pool as is. Decorators embed Delegate and override
+// methods of capabilities which they change.
+type Delegate struct {
+	db any
+}
+
+// New delegates optional capabilities to db.
+func New(db any) Delegate {
+	return Delegate{db: db}
+}
+
+func (d Delegate) Acquire(ctx context.Context) (*pgxpool.Conn, error) {
+	acq, err := AsAcquirer(d.db)
+	if err != nil {
+		return nil, err
+	}
+	return acq.Acquire(ctx)
+}
+
+func (d Delegate) CopyFrom(
+	ctx context.Context,
+	tableName pgx.Identifier,
+	columnNames []string,
+	rowSrc pgx.CopyFromSource,
+) (int64, error) {
+	bulk, err := AsBulk(d.db)
+	if err != nil {
+		return 0, err
+	}
+	return bulk.CopyFrom(ctx, tableName, columnNames, rowSrc)
+}
+
+func (d Delegate) SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults {
+	bulk, err := AsBulk(d.db)
+	if err != nil {
+		return failure.BatchResults(err)
+	}
+	return bulk.SendBatch(ctx, b)
+}
+
+func (d Delegate) Listen(ctx context.Context, channel string) (<-chan pgconn.Notification, error) {
+	lst, err := AsListener(d.db)
+	if err != nil {
+		return nil, err
+	}
+	return lst.Listen(ctx, channel)
+}
+
+func (d Delegate) WithAdvisoryLock(ctx context.Context, key string, fn func(ctx context.Context) error) error {
+	lck, err := AsLocker(d.db)
+	if err != nil {
+		return err
+	}
+	return lck.WithAdvisoryLock(ctx, key, fn)
+}
+
+func (d Delegate) WithTryAdvisoryLock(ctx context.Context, key string, fn func(ctx context.Context) error) error {
+	lck, err := AsLocker(d.db)
+	if err != nil {
+		return err
+	}
+	return lck.WithTryAdvisoryLock(ctx, key, fn)
+}
+
+// Close closes wrapped pool when it can be closed, so topologies close pgxpools of decorated members.
+func (d Delegate) Close() {
+	lifecycle.CloseMember(d.db)
+}
+
+// Ping pings wrapped pool, pool which can't be pinged is reported as failed with lifecycle.ErrPingNotSupported.
+func (d Delegate) Ping(ctx context.Context) (lifecycle.Pings, error) {
+	pings := lifecycle.PingMember(ctx, d.db)
+	return pings, pings.Err()
+}
+
+func (d Delegate) Stats() lifecycle.Stats {
+	return lifecycle.MemberStats(d.db)
+}
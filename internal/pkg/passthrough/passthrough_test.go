@@ -0,0 +1,159 @@
+package passthrough
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/godepo/elephant/internal/pkg/lifecycle"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type capablePool struct {
+	*MockAcquirer
+	*MockBulkPool
+	*MockListener
+	*MockAdvisoryLocker
+}
+
+func TestDelegate(t *testing.T) {
+	ctx := context.Background()
+	expErr := errors.New(uuid.NewString())
+	fn := func(context.Context) error { return nil }
+	batch := &pgx.Batch{}
+
+	cases := []struct {
+		name    string
+		missing error
+		expect  func(t *testing.T, pool capablePool)
+		call    func(d Delegate) error
+	}{
+		{
+			name:    "acquire",
+			missing: ErrAcquireNotSupported,
+			expect: func(_ *testing.T, pool capablePool) {
+				pool.MockAcquirer.EXPECT().Acquire(ctx).Return(nil, expErr)
+			},
+			call: func(d Delegate) error {
+				_, err := d.Acquire(ctx)
+				return err
+			},
+		},
+		{
+			name:    "copy",
+			missing: ErrBulkNotSupported,
+			expect: func(_ *testing.T, pool capablePool) {
+				pool.MockBulkPool.EXPECT().CopyFrom(ctx, pgx.Identifier{"t"}, []string{"id"}, mock.Anything).
+					Return(0, expErr)
+			},
+			call: func(d Delegate) error {
+				_, err := d.CopyFrom(ctx, pgx.Identifier{"t"}, []string{"id"}, pgx.CopyFromRows(nil))
+				return err
+			},
+		},
+		{
+			name:    "batch",
+			missing: ErrBulkNotSupported,
+			expect: func(t *testing.T, pool capablePool) {
+				results := NewMockBatchResults(t)
+				pool.MockBulkPool.EXPECT().SendBatch(ctx, batch).Return(results)
+				results.EXPECT().Close().Return(expErr)
+			},
+			call: func(d Delegate) error {
+				return d.SendBatch(ctx, batch).Close()
+			},
+		},
+		{
+			name:    "listen",
+			missing: ErrListenNotSupported,
+			expect: func(_ *testing.T, pool capablePool) {
+				pool.MockListener.EXPECT().Listen(ctx, "events").Return(nil, expErr)
+			},
+			call: func(d Delegate) error {
+				_, err := d.Listen(ctx, "events")
+				return err
+			},
+		},
+		{
+			name:    "advisory lock",
+			missing: ErrLockNotSupported,
+			expect: func(_ *testing.T, pool capablePool) {
+				pool.MockAdvisoryLocker.EXPECT().WithAdvisoryLock(ctx, "key", mock.Anything).Return(expErr)
+			},
+			call: func(d Delegate) error {
+				return d.WithAdvisoryLock(ctx, "key", fn)
+			},
+		},
+		{
+			name:    "try advisory lock",
+			missing: ErrLockNotSupported,
+			expect: func(_ *testing.T, pool capablePool) {
+				pool.MockAdvisoryLocker.EXPECT().WithTryAdvisoryLock(ctx, "key", mock.Anything).Return(expErr)
+			},
+			call: func(d Delegate) error {
+				return d.WithTryAdvisoryLock(ctx, "key", fn)
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run("should be able to fail "+tc.name+" when wrapped pool has no capability", func(t *testing.T) {
+			require.ErrorIs(t, tc.call(New(struct{}{})), tc.missing)
+		})
+
+		t.Run("should be able to delegate "+tc.name+" to wrapped pool", func(t *testing.T) {
+			pool := capablePool{
+				MockAcquirer:       NewMockAcquirer(t),
+				MockBulkPool:       NewMockBulkPool(t),
+				MockListener:       NewMockListener(t),
+				MockAdvisoryLocker: NewMockAdvisoryLocker(t),
+			}
+			tc.expect(t, pool)
+
+			require.ErrorIs(t, tc.call(New(pool)), expErr)
+		})
+	}
+}
+
+type lifecyclePool struct {
+	closed bool
+}
+
+func (p *lifecyclePool) Ping(context.Context) (lifecycle.Pings, error) {
+	return lifecycle.Pings{{Node: lifecycle.Node{Role: lifecycle.RoleNode}}}, nil
+}
+
+func (p *lifecyclePool) Stats() lifecycle.Stats {
+	return lifecycle.Stats{{Node: lifecycle.Node{Role: lifecycle.RoleNode}, Stat: lifecycle.Stat{MaxConns: 10}}}
+}
+
+func (p *lifecyclePool) Close() {
+	p.closed = true
+}
+
+func TestDelegate_Lifecycle(t *testing.T) {
+	t.Run("should be able to delegate lifecycle to wrapped pool", func(t *testing.T) {
+		pool := &lifecyclePool{}
+		d := New(pool)
+
+		pings, err := d.Ping(context.Background())
+		require.NoError(t, err)
+		assert.Len(t, pings, 1)
+		assert.Equal(t, int32(10), d.Stats().Total().MaxConns)
+		d.Close()
+		assert.True(t, pool.closed)
+	})
+
+	t.Run("should be able to fail ping when wrapped pool can't be pinged", func(t *testing.T) {
+		d := New(struct{}{})
+
+		_, err := d.Ping(context.Background())
+		require.ErrorIs(t, err, lifecycle.ErrPingNotSupported)
+		assert.Empty(t, d.Stats())
+		d.Close()
+	})
+}
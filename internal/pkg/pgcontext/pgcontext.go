@@ -3,6 +3,8 @@ package pgcontext
 
 import (
 	"context"
+	"maps"
+	"slices"
 	"time"
 
 	"github.com/jackc/pgx/v5"
@@ -20,11 +22,33 @@ const (
 	optShardID
 	optShardingKey
 	optQueryTimeout
+	optFetchSize
+	optHedgedRead
+	optPriority
+	optTenant
+	optSessionSettings
+	optCacheTTL
+	optCacheTags
+	optStreamTracker
+)
+
+// Priority of statement is used by bulkhead pools, statements without priority are of PriorityNormal.
+type Priority int8
+
+const (
+	PriorityLow Priority = iota - 1
+	PriorityNormal
+	PriorityCritical
 )
 
 type OptionContext func(ctx context.Context) context.Context
 type TxPassMatcher func(context.Context, error) bool
 
+// StreamTracker receives count of streamed rows and time to first row when iteration of stream is finished.
+type StreamTracker interface {
+	TrackStreamMetrics(ctx context.Context, firstRow time.Duration, rows int, err error)
+}
+
 func With(ctx context.Context, opts ...OptionContext) context.Context {
 	for _, opt := range opts {
 		ctx = opt(ctx) //nolint:fatcontext
@@ -123,3 +147,104 @@ func WithTimeout(timeout time.Duration) OptionContext {
 		return context.WithValue(ctx, optQueryTimeout, timeout)
 	}
 }
+
+func WithFetchSize(size int) OptionContext {
+	return func(ctx context.Context) context.Context {
+		return context.WithValue(ctx, optFetchSize, size)
+	}
+}
+
+func FetchSizeFrom(ctx context.Context) (int, bool) {
+	res, ok := ctx.Value(optFetchSize).(int)
+	return res, ok
+}
+
+// WithHedgedRead enables hedged reads of cluster pools: read is sent to second follower when first one has not
+// returned first row by delay. Delay is p95 of recent reads when it is not positive.
+func WithHedgedRead(delay time.Duration) OptionContext {
+	return func(ctx context.Context) context.Context {
+		return context.WithValue(ctx, optHedgedRead, delay)
+	}
+}
+
+func HedgedReadFrom(ctx context.Context) (time.Duration, bool) {
+	res, ok := ctx.Value(optHedgedRead).(time.Duration)
+	return res, ok
+}
+
+func WithPriority(priority Priority) OptionContext {
+	return func(ctx context.Context) context.Context {
+		return context.WithValue(ctx, optPriority, priority)
+	}
+}
+
+// PriorityFrom returns priority of statement, PriorityNormal is returned when priority is not set.
+func PriorityFrom(ctx context.Context) Priority {
+	res, ok := ctx.Value(optPriority).(Priority)
+	if !ok {
+		return PriorityNormal
+	}
+	return res
+}
+
+func WithTenant(id string) OptionContext {
+	return func(ctx context.Context) context.Context {
+		return context.WithValue(ctx, optTenant, id)
+	}
+}
+
+func TenantFrom(ctx context.Context) (string, bool) {
+	res, ok := ctx.Value(optTenant).(string)
+	return res, ok
+}
+
+// WithSessionSettings sets settings which are applied by set_config at start of transaction, local to it.
+func WithSessionSettings(settings map[string]string) OptionContext {
+	settings = maps.Clone(settings)
+	return func(ctx context.Context) context.Context {
+		return context.WithValue(ctx, optSessionSettings, settings)
+	}
+}
+
+func SessionSettingsFrom(ctx context.Context) (map[string]string, bool) {
+	res, ok := ctx.Value(optSessionSettings).(map[string]string)
+	return res, ok && len(res) > 0
+}
+
+// WithCacheTTL sets time which result of read is cached by cache pools, read is not cached when ttl is not positive.
+func WithCacheTTL(ttl time.Duration) OptionContext {
+	return func(ctx context.Context) context.Context {
+		return context.WithValue(ctx, optCacheTTL, ttl)
+	}
+}
+
+func CacheTTLFrom(ctx context.Context) (time.Duration, bool) {
+	res, ok := ctx.Value(optCacheTTL).(time.Duration)
+	return res, ok
+}
+
+// WithCacheTags sets tags of tables which statement touches: cached results of reads are tagged by them, and
+// writes invalidate results tagged by them.
+func WithCacheTags(tags ...string) OptionContext {
+	tags = slices.Clone(tags)
+	return func(ctx context.Context) context.Context {
+		return context.WithValue(ctx, optCacheTags, tags)
+	}
+}
+
+func CacheTagsFrom(ctx context.Context) ([]string, bool) {
+	res, ok := ctx.Value(optCacheTags).([]string)
+	return res, ok && len(res) > 0
+}
+
+// WithStreamTracker sets tracker which receives metrics of streams, like metrics pool.
+func WithStreamTracker(tracker StreamTracker) OptionContext {
+	return func(ctx context.Context) context.Context {
+		return context.WithValue(ctx, optStreamTracker, tracker)
+	}
+}
+
+func StreamTrackerFrom(ctx context.Context) (StreamTracker, bool) {
+	res, ok := ctx.Value(optStreamTracker).(StreamTracker)
+	return res, ok
+}
@@ -142,3 +142,128 @@ func TestWithTimeout(t *testing.T) {
 		assert.Equal(t, time.Hour, out)
 	})
 }
+
+func TestWithFetchSize(t *testing.T) {
+	t.Run("should be able return false, at empty context", func(t *testing.T) {
+		size, ok := FetchSizeFrom(context.Background())
+		assert.False(t, ok)
+		assert.Zero(t, size)
+	})
+
+	t.Run("should be able to return fetch size and true when its in context", func(t *testing.T) {
+		ctx := With(context.Background(), WithFetchSize(100))
+		out, ok := FetchSizeFrom(ctx)
+		assert.True(t, ok)
+		assert.Equal(t, 100, out)
+	})
+}
+
+func TestWithHedgedRead(t *testing.T) {
+	t.Run("should be able return false, at empty context", func(t *testing.T) {
+		delay, ok := HedgedReadFrom(context.Background())
+		assert.False(t, ok)
+		assert.Zero(t, delay)
+	})
+
+	t.Run("should be able to return hedge delay and true when its in context", func(t *testing.T) {
+		ctx := With(context.Background(), WithHedgedRead(time.Millisecond))
+		out, ok := HedgedReadFrom(ctx)
+		assert.True(t, ok)
+		assert.Equal(t, time.Millisecond, out)
+	})
+}
+
+func TestWithPriority(t *testing.T) {
+	t.Run("should be able return normal priority, at empty context", func(t *testing.T) {
+		assert.Equal(t, PriorityNormal, PriorityFrom(context.Background()))
+	})
+
+	t.Run("should be able to return priority when its in context", func(t *testing.T) {
+		ctx := With(context.Background(), WithPriority(PriorityCritical))
+		assert.Equal(t, PriorityCritical, PriorityFrom(ctx))
+	})
+}
+
+func TestWithTenant(t *testing.T) {
+	t.Run("should be able return false, at empty context", func(t *testing.T) {
+		id, ok := TenantFrom(context.Background())
+		assert.False(t, ok)
+		assert.Empty(t, id)
+	})
+
+	t.Run("should be able to return tenant and true when its in context", func(t *testing.T) {
+		ctx := With(context.Background(), WithTenant("acme"))
+		out, ok := TenantFrom(ctx)
+		assert.True(t, ok)
+		assert.Equal(t, "acme", out)
+	})
+}
+
+func TestWithSessionSettings(t *testing.T) {
+	t.Run("should be able return false, at empty context or settings", func(t *testing.T) {
+		_, ok := SessionSettingsFrom(context.Background())
+		assert.False(t, ok)
+		_, ok = SessionSettingsFrom(With(context.Background(), WithSessionSettings(nil)))
+		assert.False(t, ok)
+	})
+
+	t.Run("should be able to return copy of settings and true when its in context", func(t *testing.T) {
+		settings := map[string]string{"app.user_id": "42"}
+		ctx := With(context.Background(), WithSessionSettings(settings))
+		settings["app.user_id"] = "13"
+
+		out, ok := SessionSettingsFrom(ctx)
+		assert.True(t, ok)
+		assert.Equal(t, map[string]string{"app.user_id": "42"}, out)
+	})
+}
+
+func TestWithCacheTTL(t *testing.T) {
+	t.Run("should be able return false, at empty context", func(t *testing.T) {
+		_, ok := CacheTTLFrom(context.Background())
+		assert.False(t, ok)
+	})
+
+	t.Run("should be able to return ttl and true when its in context", func(t *testing.T) {
+		out, ok := CacheTTLFrom(With(context.Background(), WithCacheTTL(time.Minute)))
+		assert.True(t, ok)
+		assert.Equal(t, time.Minute, out)
+	})
+}
+
+func TestWithCacheTags(t *testing.T) {
+	t.Run("should be able return false, at empty context or tags", func(t *testing.T) {
+		_, ok := CacheTagsFrom(context.Background())
+		assert.False(t, ok)
+		_, ok = CacheTagsFrom(With(context.Background(), WithCacheTags()))
+		assert.False(t, ok)
+	})
+
+	t.Run("should be able to return copy of tags and true when its in context", func(t *testing.T) {
+		tags := []string{"users"}
+		ctx := With(context.Background(), WithCacheTags(tags...))
+		tags[0] = "orders"
+
+		out, ok := CacheTagsFrom(ctx)
+		assert.True(t, ok)
+		assert.Equal(t, []string{"users"}, out)
+	})
+}
+
+type streamTracker struct{}
+
+func (streamTracker) TrackStreamMetrics(context.Context, time.Duration, int, error) {}
+
+func TestWithStreamTracker(t *testing.T) {
+	t.Run("should be able return false, at empty context", func(t *testing.T) {
+		_, ok := StreamTrackerFrom(context.Background())
+		assert.False(t, ok)
+	})
+
+	t.Run("should be able to return tracker and true when its in context", func(t *testing.T) {
+		ctx := With(context.Background(), WithStreamTracker(streamTracker{}))
+		out, ok := StreamTrackerFrom(ctx)
+		assert.True(t, ok)
+		assert.Equal(t, streamTracker{}, out)
+	})
+}
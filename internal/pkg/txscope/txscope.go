@@ -0,0 +1,72 @@
+// Package txscope contains pgx results which finish transaction of single statement when they are read,
+// so statement which must run in transaction can be run outside of it.
+//
+//go:generate go tool mockery
+package txscope
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Finish commits transaction of single statement, or rolls it back when statement failed.
+func Finish(ctx context.Context, tx pgx.Tx, err error) error {
+	if err != nil {
+		_ = tx.Rollback(ctx)
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// Rows returns rows which finish tx when they are read or closed.
+func Rows(ctx context.Context, tx pgx.Tx, rows pgx.Rows) pgx.Rows {
+	return &scopedRows{Rows: rows, ctx: ctx, tx: tx}
+}
+
+type scopedRows struct {
+	pgx.Rows
+	ctx    context.Context
+	tx     pgx.Tx
+	err    error
+	closed bool
+}
+
+func (r *scopedRows) Next() bool {
+	if r.Rows.Next() {
+		return true
+	}
+	r.Close()
+	return false
+}
+
+func (r *scopedRows) Close() {
+	if r.closed {
+		return
+	}
+	r.closed = true
+	r.Rows.Close()
+	r.err = Finish(r.ctx, r.tx, r.Rows.Err())
+}
+
+func (r *scopedRows) Err() error {
+	if r.err != nil {
+		return r.err
+	}
+	return r.Rows.Err()
+}
+
+// BatchResults returns results which finish tx when they are closed.
+func BatchResults(ctx context.Context, tx pgx.Tx, results pgx.BatchResults) pgx.BatchResults {
+	return scopedBatch{BatchResults: results, ctx: ctx, tx: tx}
+}
+
+type scopedBatch struct {
+	pgx.BatchResults
+	ctx context.Context
+	tx  pgx.Tx
+}
+
+func (b scopedBatch) Close() error {
+	return Finish(b.ctx, b.tx, b.BatchResults.Close())
+}
@@ -0,0 +1,74 @@
+package txscope
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFinish(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should be able to commit transaction of succeeded statement", func(t *testing.T) {
+		tx := NewMockTx(t)
+		tx.EXPECT().Commit(ctx).Return(nil)
+
+		require.NoError(t, Finish(ctx, tx, nil))
+	})
+
+	t.Run("should be able to roll back transaction of failed statement", func(t *testing.T) {
+		tx := NewMockTx(t)
+		expErr := errors.New(uuid.NewString())
+		tx.EXPECT().Rollback(ctx).Return(nil)
+
+		require.ErrorIs(t, Finish(ctx, tx, expErr), expErr)
+	})
+}
+
+func TestRows(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should be able to finish transaction once when rows are read and closed", func(t *testing.T) {
+		tx, rows := NewMockTx(t), NewMockRows(t)
+		rows.EXPECT().Next().Return(true).Once()
+		rows.EXPECT().Next().Return(false).Once()
+		rows.EXPECT().Close().Return().Once()
+		rows.EXPECT().Err().Return(nil)
+		tx.EXPECT().Commit(ctx).Return(nil).Once()
+
+		out := Rows(ctx, tx, rows)
+		assert.True(t, out.Next())
+		assert.False(t, out.Next())
+		out.Close()
+		require.NoError(t, out.Err())
+	})
+
+	t.Run("should be able to return error of commit", func(t *testing.T) {
+		tx, rows := NewMockTx(t), NewMockRows(t)
+		expErr := errors.New(uuid.NewString())
+		rows.EXPECT().Close().Return()
+		rows.EXPECT().Err().Return(nil)
+		tx.EXPECT().Commit(ctx).Return(expErr)
+
+		out := Rows(ctx, tx, rows)
+		out.Close()
+		require.ErrorIs(t, out.Err(), expErr)
+	})
+}
+
+func TestBatchResults(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should be able to roll back transaction when batch failed", func(t *testing.T) {
+		tx, results := NewMockTx(t), NewMockBatchResults(t)
+		expErr := errors.New(uuid.NewString())
+		results.EXPECT().Close().Return(expErr)
+		tx.EXPECT().Rollback(ctx).Return(nil)
+
+		require.ErrorIs(t, BatchResults(ctx, tx, results).Close(), expErr)
+	})
+}
@@ -0,0 +1,378 @@
+// Package queue implements durable job queue on elephant pools. Jobs are enqueued by transaction from context,
+// workers lease them with FOR UPDATE SKIP LOCKED for visibility timeout and run handler inside Transactional,
+// so side effects of job and its completion are committed together. Failed jobs are retried with backoff and
+// moved to dead state after max attempts.
+//
+//go:generate go tool mockery
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/godepo/elephant/internal/pkg/backoff"
+	"github.com/godepo/elephant/internal/pkg/pgcontext"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+var (
+	ErrLeaseExpired = errors.New("queue: job lease expired")
+	ErrLeaseLost    = errors.New("queue: job lease was taken by another worker")
+)
+
+const (
+	StatusPending = "pending"
+	StatusDone    = "done"
+	StatusDead    = "dead"
+
+	defaultTable             = "jobs"
+	defaultWorkers           = 1
+	defaultPollInterval      = time.Second
+	defaultVisibilityTimeout = 5 * time.Minute
+	defaultMaxAttempts       = 10
+	defaultMinBackoff        = time.Second
+	defaultMaxBackoff        = time.Hour
+)
+
+type DB interface {
+	QueryRow(ctx context.Context, query string, args ...any) pgx.Row
+	Exec(ctx context.Context, query string, args ...any) (pgconn.CommandTag, error)
+	Transactional(ctx context.Context, fn func(ctx context.Context) error) (out error)
+}
+
+// Handler runs job in transaction from context. Job is completed when handler returns nil and retried otherwise.
+type Handler func(ctx context.Context, job Job) error
+
+type Job struct {
+	ID          int64
+	Queue       string
+	Payload     []byte
+	Priority    int
+	Attempt     int
+	MaxAttempts int
+	CreatedAt   time.Time
+}
+
+type Config struct {
+	table             string
+	workers           int
+	pollInterval      time.Duration
+	visibilityTimeout time.Duration
+	minBackoff        time.Duration
+	maxBackoff        time.Duration
+	errorHandler      func(err error)
+}
+
+type Option func(cfg *Config)
+
+// WithTable sets jobs table name, it may be qualified by schema.
+func WithTable(table string) Option {
+	return func(cfg *Config) {
+		cfg.table = table
+	}
+}
+
+// WithWorkers sets how many jobs Run processes concurrently.
+func WithWorkers(n int) Option {
+	return func(cfg *Config) {
+		cfg.workers = n
+	}
+}
+
+// WithPollInterval sets how often idle worker polls jobs table.
+func WithPollInterval(interval time.Duration) Option {
+	return func(cfg *Config) {
+		cfg.pollInterval = interval
+	}
+}
+
+// WithVisibilityTimeout sets how long leased job is hidden from other workers. Handler context is canceled
+// when timeout expires, and job becomes visible again when worker died.
+func WithVisibilityTimeout(timeout time.Duration) Option {
+	return func(cfg *Config) {
+		cfg.visibilityTimeout = timeout
+	}
+}
+
+// WithBackoff sets bounds of exponential delay between job attempts.
+func WithBackoff(minDelay, maxDelay time.Duration) Option {
+	return func(cfg *Config) {
+		cfg.minBackoff = minDelay
+		cfg.maxBackoff = maxDelay
+	}
+}
+
+// WithErrorHandler sets handler for job and worker errors.
+func WithErrorHandler(handler func(err error)) Option {
+	return func(cfg *Config) {
+		cfg.errorHandler = handler
+	}
+}
+
+type jobConfig struct {
+	priority    int
+	runAt       time.Time
+	maxAttempts int
+}
+
+type JobOption func(cfg *jobConfig)
+
+// WithPriority sets job priority, jobs with higher priority are leased first.
+func WithPriority(priority int) JobOption {
+	return func(cfg *jobConfig) {
+		cfg.priority = priority
+	}
+}
+
+// WithRunAt schedules job to run not earlier than at.
+func WithRunAt(at time.Time) JobOption {
+	return func(cfg *jobConfig) {
+		cfg.runAt = at
+	}
+}
+
+// WithDelay schedules job to run not earlier than after delay.
+func WithDelay(delay time.Duration) JobOption {
+	return func(cfg *jobConfig) {
+		cfg.runAt = time.Now().Add(delay)
+	}
+}
+
+// WithMaxAttempts sets how many times job runs before it is moved to dead state.
+func WithMaxAttempts(attempts int) JobOption {
+	return func(cfg *jobConfig) {
+		cfg.maxAttempts = attempts
+	}
+}
+
+type queries struct {
+	enqueue  string
+	lease    string
+	complete string
+	fail     string
+	purge    string
+}
+
+type Queue struct {
+	db      DB
+	cfg     Config
+	queries queries
+}
+
+func New(db DB, opts ...Option) *Queue {
+	cfg := Config{
+		table:             defaultTable,
+		workers:           defaultWorkers,
+		pollInterval:      defaultPollInterval,
+		visibilityTimeout: defaultVisibilityTimeout,
+		minBackoff:        defaultMinBackoff,
+		maxBackoff:        defaultMaxBackoff,
+		errorHandler:      func(error) {},
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	table := tableIdentifier(cfg.table)
+	return &Queue{
+		db:  db,
+		cfg: cfg,
+		queries: queries{
+			enqueue: fmt.Sprintf(
+				"INSERT INTO %s (queue, payload, priority, run_at, max_attempts) "+
+					"VALUES ($1, $2, $3, COALESCE($4, now()), $5) RETURNING id",
+				table,
+			),
+			lease: fmt.Sprintf(
+				"UPDATE %[1]s SET attempts = attempts + 1, run_at = now() + $2 * interval '1 millisecond' "+
+					"WHERE id = (SELECT id FROM %[1]s WHERE queue = $1 AND status = '%[2]s' AND run_at <= now() "+
+					"ORDER BY priority DESC, run_at, id LIMIT 1 FOR UPDATE SKIP LOCKED) "+
+					"RETURNING id, queue, payload, priority, attempts, max_attempts, created_at",
+				table, StatusPending,
+			),
+			complete: fmt.Sprintf(
+				"UPDATE %s SET status = '%s', finished_at = now() WHERE id = $1 AND attempts = $2",
+				table, StatusDone,
+			),
+			fail: fmt.Sprintf(
+				"UPDATE %s SET last_error = $3, run_at = now() + $4 * interval '1 millisecond', "+
+					"status = CASE WHEN attempts >= max_attempts THEN '%s' ELSE '%s' END "+
+					"WHERE id = $1 AND attempts = $2",
+				table, StatusDead, StatusPending,
+			),
+			purge: fmt.Sprintf(
+				"DELETE FROM %s WHERE status = '%s' AND finished_at < now() - $1 * interval '1 millisecond'",
+				table, StatusDone,
+			),
+		},
+	}
+}
+
+// Schema returns DDL of jobs table with given name.
+func Schema(table string) string {
+	index := pgx.Identifier{strings.ReplaceAll(table, ".", "_") + "_pending_idx"}.Sanitize()
+	return fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %[1]s (
+	id bigserial PRIMARY KEY,
+	queue text NOT NULL,
+	payload bytea NOT NULL,
+	priority integer NOT NULL DEFAULT 0,
+	status text NOT NULL DEFAULT '%[2]s',
+	attempts integer NOT NULL DEFAULT 0,
+	max_attempts integer NOT NULL DEFAULT %[4]d,
+	last_error text,
+	created_at timestamptz NOT NULL DEFAULT now(),
+	run_at timestamptz NOT NULL DEFAULT now(),
+	finished_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS %[3]s ON %[1]s (queue, priority DESC, run_at, id) WHERE status = '%[2]s';`,
+		tableIdentifier(table), StatusPending, index, defaultMaxAttempts,
+	)
+}
+
+func tableIdentifier(table string) string {
+	return pgx.Identifier(strings.Split(table, ".")).Sanitize()
+}
+
+// Enqueue adds job to queue and returns its ID. When transaction is in context, job becomes visible to workers
+// when transaction commits.
+func (q *Queue) Enqueue(ctx context.Context, queue string, payload []byte, opts ...JobOption) (int64, error) {
+	cfg := jobConfig{maxAttempts: defaultMaxAttempts}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	var runAt *time.Time
+	if !cfg.runAt.IsZero() {
+		runAt = &cfg.runAt
+	}
+
+	var id int64
+	err := q.db.QueryRow(
+		pgcontext.With(ctx, pgcontext.WithCanWrite),
+		q.queries.enqueue, queue, payload, cfg.priority, runAt, cfg.maxAttempts,
+	).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("can't enqueue job to %s: %w", queue, err)
+	}
+	return id, nil
+}
+
+// Process leases one job from queue and runs handler inside Transactional. It returns false when there is no
+// job ready to run.
+func (q *Queue) Process(ctx context.Context, queue string, handler Handler) (bool, error) {
+	ctx = pgcontext.With(ctx, pgcontext.WithCanWrite)
+
+	job, err := q.lease(ctx, queue)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if job.Attempt > job.MaxAttempts {
+		return true, q.fail(ctx, job, ErrLeaseExpired)
+	}
+
+	err = q.run(ctx, job, handler)
+	if err == nil {
+		return true, nil
+	}
+	if errors.Is(err, ErrLeaseLost) {
+		return true, err
+	}
+	return true, errors.Join(err, q.fail(ctx, job, err))
+}
+
+// Run processes jobs from queue by configured count of workers until ctx is done.
+func (q *Queue) Run(ctx context.Context, queue string, handler Handler) error {
+	var wg sync.WaitGroup
+	for range q.cfg.workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			q.work(ctx, queue, handler)
+		}()
+	}
+	wg.Wait()
+	return ctx.Err()
+}
+
+// Purge deletes jobs which were completed earlier than olderThan ago and returns count of deleted jobs.
+func (q *Queue) Purge(ctx context.Context, olderThan time.Duration) (int64, error) {
+	tag, err := q.db.Exec(pgcontext.With(ctx, pgcontext.WithCanWrite), q.queries.purge, olderThan.Milliseconds())
+	if err != nil {
+		return 0, fmt.Errorf("can't purge completed jobs: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+func (q *Queue) work(ctx context.Context, queue string, handler Handler) {
+	for {
+		processed, err := q.Process(ctx, queue, handler)
+		if err != nil && ctx.Err() == nil {
+			q.cfg.errorHandler(err)
+		}
+		if processed {
+			continue
+		}
+
+		timer := time.NewTimer(q.cfg.pollInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+func (q *Queue) lease(ctx context.Context, queue string) (Job, error) {
+	var job Job
+	err := q.db.QueryRow(ctx, q.queries.lease, queue, q.cfg.visibilityTimeout.Milliseconds()).Scan(
+		&job.ID, &job.Queue, &job.Payload, &job.Priority, &job.Attempt, &job.MaxAttempts, &job.CreatedAt,
+	)
+	if err != nil {
+		if ctx.Err() != nil || errors.Is(err, pgx.ErrNoRows) {
+			return job, err
+		}
+		return job, fmt.Errorf("can't lease job from %s: %w", queue, err)
+	}
+	return job, nil
+}
+
+func (q *Queue) run(ctx context.Context, job Job, handler Handler) error {
+	ctx, cancel := context.WithTimeout(ctx, q.cfg.visibilityTimeout)
+	defer cancel()
+
+	return q.db.Transactional(ctx, func(ctx context.Context) error {
+		if err := handler(ctx, job); err != nil {
+			return fmt.Errorf("job %d from %s failed: %w", job.ID, job.Queue, err)
+		}
+
+		tag, err := q.db.Exec(ctx, q.queries.complete, job.ID, job.Attempt)
+		if err != nil {
+			return fmt.Errorf("can't complete job %d: %w", job.ID, err)
+		}
+		if tag.RowsAffected() == 0 {
+			return ErrLeaseLost
+		}
+		return nil
+	})
+}
+
+func (q *Queue) fail(ctx context.Context, job Job, reason error) error {
+	delay := backoff.Exponential(q.cfg.minBackoff, q.cfg.maxBackoff, job.Attempt-1)
+	_, err := q.db.Exec(
+		context.WithoutCancel(ctx), q.queries.fail, job.ID, job.Attempt, reason.Error(), delay.Milliseconds(),
+	)
+	if err != nil {
+		return fmt.Errorf("can't schedule retry of job %d: %w", job.ID, err)
+	}
+	return nil
+}
@@ -0,0 +1,230 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/godepo/elephant/internal/pkg/pgcontext"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jaswdr/faker/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newError() error {
+	return errors.New(faker.New().RandomStringWithLength(10))
+}
+
+func runTransactional(db *MockDB) {
+	db.EXPECT().Transactional(mock.Anything, mock.Anything).
+		RunAndReturn(func(ctx context.Context, fn func(ctx context.Context) error) error {
+			return fn(ctx)
+		})
+}
+
+func leasedRow(t *testing.T, job Job) *MockRow {
+	t.Helper()
+	row := NewMockRow(t)
+	row.EXPECT().Scan(mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything,
+		mock.Anything, mock.Anything).
+		RunAndReturn(func(dest ...any) error {
+			*dest[0].(*int64) = job.ID
+			*dest[1].(*string) = job.Queue
+			*dest[4].(*int) = job.Attempt
+			*dest[5].(*int) = job.MaxAttempts
+			return nil
+		})
+	return row
+}
+
+func noRows(t *testing.T) *MockRow {
+	t.Helper()
+	row := NewMockRow(t)
+	row.EXPECT().Scan(mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything,
+		mock.Anything, mock.Anything).Return(pgx.ErrNoRows)
+	return row
+}
+
+func TestSchema(t *testing.T) {
+	t.Run("should be able to make ddl for qualified table", func(t *testing.T) {
+		ddl := Schema("work.jobs")
+		assert.Contains(t, ddl, `CREATE TABLE IF NOT EXISTS "work"."jobs"`)
+		assert.Contains(t, ddl, `"work_jobs_pending_idx"`)
+	})
+}
+
+func TestQueue_Enqueue(t *testing.T) {
+	t.Run("should be able to enqueue scheduled job with priority", func(t *testing.T) {
+		db := NewMockDB(t)
+		q := New(db)
+		runAt := time.Now().Add(time.Hour)
+		row := NewMockRow(t)
+		row.EXPECT().Scan(mock.Anything).RunAndReturn(func(dest ...any) error {
+			*dest[0].(*int64) = 42
+			return nil
+		})
+		db.EXPECT().QueryRow(mock.Anything, q.queries.enqueue, []any{"mail", []byte("hi"), 5, &runAt, 3}).
+			RunAndReturn(func(ctx context.Context, _ string, _ ...any) pgx.Row {
+				assert.True(t, pgcontext.CanWriteFrom(ctx))
+				return row
+			})
+
+		id, err := q.Enqueue(context.Background(), "mail", []byte("hi"),
+			WithPriority(5), WithRunAt(runAt), WithMaxAttempts(3))
+		require.NoError(t, err)
+		assert.Equal(t, int64(42), id)
+	})
+
+	t.Run("should be able to fail when can't insert job", func(t *testing.T) {
+		expErr := newError()
+		db := NewMockDB(t)
+		q := New(db)
+		row := NewMockRow(t)
+		row.EXPECT().Scan(mock.Anything).Return(expErr)
+		db.EXPECT().QueryRow(mock.Anything, q.queries.enqueue, mock.Anything).Return(row)
+
+		_, err := q.Enqueue(context.Background(), "mail", nil, WithDelay(time.Minute))
+		require.ErrorIs(t, err, expErr)
+	})
+}
+
+func TestQueue_Process(t *testing.T) {
+	job := Job{ID: 7, Queue: "mail", Attempt: 1, MaxAttempts: 3}
+
+	t.Run("should be able to run job and complete it in one transaction", func(t *testing.T) {
+		db := NewMockDB(t)
+		q := New(db, WithVisibilityTimeout(time.Minute))
+		db.EXPECT().QueryRow(mock.Anything, q.queries.lease, []any{"mail", int64(60000)}).Return(leasedRow(t, job))
+		runTransactional(db)
+		db.EXPECT().Exec(mock.Anything, q.queries.complete, []any{job.ID, job.Attempt}).
+			Return(pgconn.NewCommandTag("UPDATE 1"), nil)
+
+		var handled Job
+		processed, err := q.Process(context.Background(), "mail", func(ctx context.Context, job Job) error {
+			_, hasDeadline := ctx.Deadline()
+			assert.True(t, hasDeadline)
+			handled = job
+			return nil
+		})
+		require.NoError(t, err)
+		assert.True(t, processed)
+		assert.Equal(t, job.ID, handled.ID)
+	})
+
+	t.Run("should be able to report empty queue", func(t *testing.T) {
+		db := NewMockDB(t)
+		q := New(db)
+		db.EXPECT().QueryRow(mock.Anything, q.queries.lease, mock.Anything).Return(noRows(t))
+
+		processed, err := q.Process(context.Background(), "mail", nil)
+		require.NoError(t, err)
+		assert.False(t, processed)
+	})
+
+	t.Run("should be able to schedule retry when handler failed", func(t *testing.T) {
+		expErr := newError()
+		db := NewMockDB(t)
+		q := New(db, WithBackoff(time.Second, time.Minute))
+		db.EXPECT().QueryRow(mock.Anything, q.queries.lease, mock.Anything).Return(leasedRow(t, job))
+		runTransactional(db)
+		db.EXPECT().Exec(mock.Anything, q.queries.fail, mock.MatchedBy(func(args []any) bool {
+			return args[0] == job.ID && args[1] == job.Attempt &&
+				strings.Contains(args[2].(string), expErr.Error()) && args[3] == int64(1000)
+		})).Return(pgconn.CommandTag{}, nil)
+
+		processed, err := q.Process(context.Background(), "mail", func(context.Context, Job) error {
+			return expErr
+		})
+		require.ErrorIs(t, err, expErr)
+		assert.True(t, processed)
+	})
+
+	t.Run("should be able to roll back job when lease was lost", func(t *testing.T) {
+		db := NewMockDB(t)
+		q := New(db)
+		db.EXPECT().QueryRow(mock.Anything, q.queries.lease, mock.Anything).Return(leasedRow(t, job))
+		runTransactional(db)
+		db.EXPECT().Exec(mock.Anything, q.queries.complete, mock.Anything).
+			Return(pgconn.NewCommandTag("UPDATE 0"), nil)
+
+		_, err := q.Process(context.Background(), "mail", func(context.Context, Job) error {
+			return nil
+		})
+		require.ErrorIs(t, err, ErrLeaseLost)
+	})
+
+	t.Run("should be able to bury job which expired lease too many times", func(t *testing.T) {
+		db := NewMockDB(t)
+		q := New(db)
+		db.EXPECT().QueryRow(mock.Anything, q.queries.lease, mock.Anything).
+			Return(leasedRow(t, Job{ID: 7, Attempt: 4, MaxAttempts: 3}))
+		db.EXPECT().Exec(mock.Anything, q.queries.fail, mock.Anything).Return(pgconn.CommandTag{}, nil)
+
+		processed, err := q.Process(context.Background(), "mail", nil)
+		require.NoError(t, err)
+		assert.True(t, processed)
+	})
+
+	t.Run("should be able to fail when can't lease job", func(t *testing.T) {
+		expErr := newError()
+		db := NewMockDB(t)
+		q := New(db)
+		row := NewMockRow(t)
+		row.EXPECT().Scan(mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything,
+			mock.Anything, mock.Anything).Return(expErr)
+		db.EXPECT().QueryRow(mock.Anything, q.queries.lease, mock.Anything).Return(row)
+
+		_, err := q.Process(context.Background(), "mail", nil)
+		require.ErrorIs(t, err, expErr)
+	})
+}
+
+func TestQueue_Run(t *testing.T) {
+	t.Run("should be able to process jobs by workers until context is done", func(t *testing.T) {
+		expErr := newError()
+		db := NewMockDB(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		var reported atomic.Int64
+		q := New(db, WithWorkers(2), WithPollInterval(time.Millisecond), WithErrorHandler(func(err error) {
+			assert.ErrorIs(t, err, expErr)
+			if reported.Add(1) == 2 {
+				cancel()
+			}
+		}))
+		row := NewMockRow(t)
+		row.EXPECT().Scan(mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything,
+			mock.Anything, mock.Anything).Return(expErr)
+		db.EXPECT().QueryRow(mock.Anything, q.queries.lease, mock.Anything).Return(row)
+
+		require.ErrorIs(t, q.Run(ctx, "mail", nil), context.Canceled)
+	})
+}
+
+func TestQueue_Purge(t *testing.T) {
+	t.Run("should be able to delete completed jobs", func(t *testing.T) {
+		db := NewMockDB(t)
+		q := New(db, WithTable("jobs"))
+		db.EXPECT().Exec(mock.Anything, q.queries.purge, []any{int64(3600000)}).
+			Return(pgconn.NewCommandTag("DELETE 5"), nil)
+
+		deleted, err := q.Purge(context.Background(), time.Hour)
+		require.NoError(t, err)
+		assert.Equal(t, int64(5), deleted)
+	})
+
+	t.Run("should be able to fail when can't delete jobs", func(t *testing.T) {
+		expErr := newError()
+		db := NewMockDB(t)
+		q := New(db)
+		db.EXPECT().Exec(mock.Anything, q.queries.purge, mock.Anything).Return(pgconn.CommandTag{}, expErr)
+
+		_, err := q.Purge(context.Background(), time.Hour)
+		require.ErrorIs(t, err, expErr)
+	})
+}
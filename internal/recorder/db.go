@@ -0,0 +1,296 @@
+package recorder
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/godepo/elephant/internal/notify"
+	"github.com/godepo/elephant/internal/pkg/passthrough"
+	"github.com/godepo/elephant/internal/pkg/pgcontext"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Pool interface defines database operations of wrapped pool.
+type Pool interface {
+	BeginTx(ctx context.Context, opts pgx.TxOptions) (pgx.Tx, error)
+	Begin(ctx context.Context) (pgx.Tx, error)
+	Query(ctx context.Context, query string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, query string, args ...interface{}) pgx.Row
+	Exec(ctx context.Context, query string, args ...interface{}) (pgconn.CommandTag, error)
+	Transactional(ctx context.Context, fn func(ctx context.Context) error) (out error)
+}
+
+// DB is pool decorated by recorder. Dedicated connections, batches, subscriptions and advisory locks are passed
+// to wrapped pool without recording.
+type DB struct {
+	passthrough.Delegate
+	rec  *Recorder
+	db   Pool
+	node node
+}
+
+// Wrap decorates db by recorder. Every member of cluster or sharded pool should be wrapped with its route before
+// it is added to topology, transactions begun by member are recorded with its route.
+func (rec *Recorder) Wrap(db Pool, opts ...NodeOption) *DB {
+	out := &DB{
+		Delegate: passthrough.New(db),
+		rec:      rec,
+		db:       db,
+	}
+	for _, opt := range opts {
+		opt(&out.node)
+	}
+	return out
+}
+
+// depth returns depth of transaction from context. Statements of transaction begun by recorded pool are
+// recorded by transaction itself, transactions which are begun elsewhere are recorded with depth 1.
+func (d *DB) depth(ctx context.Context) (int, bool) {
+	tx, ok := pgcontext.TransactionFrom(ctx)
+	if !ok {
+		return 0, false
+	}
+	if _, ok := tx.(*recordedTx); ok {
+		return 0, true
+	}
+	return 1, false
+}
+
+func (d *DB) begin(begin func() (pgx.Tx, error)) (pgx.Tx, error) {
+	tx, err := begin()
+	d.rec.record(d.node.entry(OpBegin, "", nil, 1), err)
+	if err != nil {
+		return nil, err
+	}
+	return d.wrapTx(tx, 1), nil
+}
+
+func (d *DB) wrapTx(tx pgx.Tx, depth int) *recordedTx {
+	return &recordedTx{Tx: tx, rec: d.rec, node: d.node, depth: depth}
+}
+
+func (d *DB) Begin(ctx context.Context) (pgx.Tx, error) {
+	return d.begin(func() (pgx.Tx, error) {
+		return d.db.Begin(ctx)
+	})
+}
+
+func (d *DB) BeginTx(ctx context.Context, opts pgx.TxOptions) (pgx.Tx, error) {
+	return d.begin(func() (pgx.Tx, error) {
+		return d.db.BeginTx(ctx, opts)
+	})
+}
+
+func (d *DB) Query(ctx context.Context, query string, args ...interface{}) (pgx.Rows, error) {
+	depth, recorded := d.depth(ctx)
+	if recorded {
+		return d.db.Query(ctx, query, args...)
+	}
+	return recordQuery(d.rec, d.node.entry(OpQuery, query, args, depth), func() (pgx.Rows, error) {
+		return d.db.Query(ctx, query, args...)
+	})
+}
+
+func (d *DB) QueryRow(ctx context.Context, query string, args ...interface{}) pgx.Row {
+	depth, recorded := d.depth(ctx)
+	if recorded {
+		return d.db.QueryRow(ctx, query, args...)
+	}
+	e := d.rec.start(d.node.entry(OpQueryRow, query, args, depth))
+	return recordedRow{row: d.db.QueryRow(ctx, query, args...), rec: d.rec, entry: e}
+}
+
+func (d *DB) Exec(ctx context.Context, query string, args ...interface{}) (pgconn.CommandTag, error) {
+	depth, recorded := d.depth(ctx)
+	if recorded {
+		return d.db.Exec(ctx, query, args...)
+	}
+	e := d.rec.start(d.node.entry(OpExec, query, args, depth))
+	tag, err := d.db.Exec(ctx, query, args...)
+	d.rec.finish(e, err)
+	return tag, err
+}
+
+// Transactional records begin when fn is called and commit or rollback when wrapped pool finished transaction.
+// Transaction from context is wrapped, so statements of fn are recorded with route of this pool.
+func (d *DB) Transactional(ctx context.Context, fn func(ctx context.Context) error) (out error) {
+	if _, ok := pgcontext.TransactionFrom(ctx); ok {
+		return d.db.Transactional(ctx, fn)
+	}
+
+	var (
+		called bool
+		fnErr  error
+	)
+	err := d.db.Transactional(ctx, func(ctx context.Context) error {
+		called = true
+		d.rec.record(d.node.entry(OpBegin, "", nil, 1), nil)
+		if tx, ok := pgcontext.TransactionFrom(ctx); ok {
+			ctx = pgcontext.With(ctx, pgcontext.WithTransaction(d.wrapTx(tx, 1)))
+		}
+		fnErr = fn(ctx)
+		return fnErr
+	})
+
+	switch {
+	case !called:
+		d.rec.record(d.node.entry(OpBegin, "", nil, 1), err)
+	case fnErr != nil && !passed(ctx, fnErr):
+		d.rec.record(d.node.entry(OpRollback, "", nil, 1), nil)
+	default:
+		commitErr := err
+		if fnErr != nil && errors.Is(err, fnErr) {
+			commitErr = nil
+		}
+		d.rec.record(d.node.entry(OpCommit, "", nil, 1), commitErr)
+	}
+	return err
+}
+
+// CopyFrom records copy with sanitized table name as SQL.
+func (d *DB) CopyFrom(
+	ctx context.Context,
+	tableName pgx.Identifier,
+	columnNames []string,
+	rowSrc pgx.CopyFromSource,
+) (int64, error) {
+	bulk, err := passthrough.AsBulk(d.db)
+	if err != nil {
+		return 0, err
+	}
+	depth, recorded := d.depth(ctx)
+	if recorded {
+		return bulk.CopyFrom(ctx, tableName, columnNames, rowSrc)
+	}
+	entry := d.node.entry(OpCopy, "", nil, depth)
+	entry.SQL = tableName.Sanitize()
+	e := d.rec.start(entry)
+	count, err := bulk.CopyFrom(ctx, tableName, columnNames, rowSrc)
+	d.rec.finish(e, err)
+	return count, err
+}
+
+// Notify sends notification through Exec, so it is recorded.
+func (d *DB) Notify(ctx context.Context, channel, payload string) error {
+	_, err := d.Exec(ctx, notify.Query, channel, payload)
+	return err
+}
+
+func passed(ctx context.Context, err error) bool {
+	matcher, ok := pgcontext.TxPassMatcherFrom(ctx)
+	return ok && matcher(ctx, err)
+}
+
+func recordQuery(rec *Recorder, e Entry, query func() (pgx.Rows, error)) (pgx.Rows, error) {
+	entry := rec.start(e)
+	rows, err := query()
+	if err != nil {
+		rec.finish(entry, err)
+		return nil, err
+	}
+	return &recordedRows{Rows: rows, once: &sync.Once{}, rec: rec, entry: entry}, nil
+}
+
+type recordedTx struct {
+	pgx.Tx
+	rec   *Recorder
+	node  node
+	depth int
+}
+
+func (tx *recordedTx) Begin(ctx context.Context) (pgx.Tx, error) {
+	nested, err := tx.Tx.Begin(ctx)
+	tx.rec.record(tx.node.entry(OpBegin, "", nil, tx.depth+1), err)
+	if err != nil {
+		return nil, err
+	}
+	return &recordedTx{Tx: nested, rec: tx.rec, node: tx.node, depth: tx.depth + 1}, nil
+}
+
+func (tx *recordedTx) Commit(ctx context.Context) error {
+	err := tx.Tx.Commit(ctx)
+	tx.rec.record(tx.node.entry(OpCommit, "", nil, tx.depth), err)
+	return err
+}
+
+// Rollback is not recorded when transaction is already closed, like at deferred rollback after commit.
+func (tx *recordedTx) Rollback(ctx context.Context) error {
+	err := tx.Tx.Rollback(ctx)
+	if errors.Is(err, pgx.ErrTxClosed) {
+		return err
+	}
+	tx.rec.record(tx.node.entry(OpRollback, "", nil, tx.depth), err)
+	return err
+}
+
+func (tx *recordedTx) Query(ctx context.Context, query string, args ...any) (pgx.Rows, error) {
+	return recordQuery(tx.rec, tx.node.entry(OpQuery, query, args, tx.depth), func() (pgx.Rows, error) {
+		return tx.Tx.Query(ctx, query, args...)
+	})
+}
+
+func (tx *recordedTx) QueryRow(ctx context.Context, query string, args ...any) pgx.Row {
+	e := tx.rec.start(tx.node.entry(OpQueryRow, query, args, tx.depth))
+	return recordedRow{row: tx.Tx.QueryRow(ctx, query, args...), rec: tx.rec, entry: e}
+}
+
+func (tx *recordedTx) Exec(ctx context.Context, query string, args ...any) (pgconn.CommandTag, error) {
+	e := tx.rec.start(tx.node.entry(OpExec, query, args, tx.depth))
+	tag, err := tx.Tx.Exec(ctx, query, args...)
+	tx.rec.finish(e, err)
+	return tag, err
+}
+
+func (tx *recordedTx) CopyFrom(
+	ctx context.Context,
+	tableName pgx.Identifier,
+	columnNames []string,
+	rowSrc pgx.CopyFromSource,
+) (int64, error) {
+	entry := tx.node.entry(OpCopy, "", nil, tx.depth)
+	entry.SQL = tableName.Sanitize()
+	e := tx.rec.start(entry)
+	count, err := tx.Tx.CopyFrom(ctx, tableName, columnNames, rowSrc)
+	tx.rec.finish(e, err)
+	return count, err
+}
+
+type recordedRows struct {
+	pgx.Rows
+	once  *sync.Once
+	rec   *Recorder
+	entry *Entry
+}
+
+func (rows *recordedRows) finish() {
+	rows.once.Do(func() {
+		rows.rec.finish(rows.entry, rows.Rows.Err())
+	})
+}
+
+func (rows *recordedRows) Next() bool {
+	if rows.Rows.Next() {
+		return true
+	}
+	rows.finish()
+	return false
+}
+
+func (rows *recordedRows) Close() {
+	rows.Rows.Close()
+	rows.finish()
+}
+
+type recordedRow struct {
+	row   pgx.Row
+	rec   *Recorder
+	entry *Entry
+}
+
+func (row recordedRow) Scan(dest ...any) error {
+	err := row.row.Scan(dest...)
+	row.rec.finish(row.entry, err)
+	return err
+}
@@ -0,0 +1,271 @@
+package recorder
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/godepo/elephant/internal/pkg/passthrough"
+	"github.com/godepo/elephant/internal/pkg/pgcontext"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDB_Statements(t *testing.T) {
+	t.Run("should be able to record statements outside transaction", func(t *testing.T) {
+		pool := NewMockPool(t)
+		rows := NewMockRows(t)
+		row := NewMockRow(t)
+		rec := New()
+		db := rec.Wrap(pool, WithRoute(RouteFollower))
+
+		pool.EXPECT().Query(mock.Anything, "SELECT id FROM t WHERE n > $1", []any{1}).Return(rows, nil)
+		rows.EXPECT().Next().Return(false)
+		rows.EXPECT().Err().Return(nil)
+		rows.EXPECT().Close().Return()
+		pool.EXPECT().QueryRow(mock.Anything, "SELECT n FROM t WHERE id = $1", []any{"a"}).Return(row)
+		row.EXPECT().Scan(mock.Anything).Return(pgx.ErrNoRows)
+		pool.EXPECT().Exec(mock.Anything, "DELETE FROM t").Return(pgconn.CommandTag{}, &pgconn.PgError{Code: "23503"})
+
+		out, err := db.Query(context.Background(), "SELECT id FROM t WHERE n > $1", 1)
+		require.NoError(t, err)
+		assert.False(t, out.Next())
+		out.Close()
+
+		var n int
+		require.ErrorIs(t, db.QueryRow(context.Background(), "SELECT n FROM t WHERE id = $1", "a").Scan(&n), pgx.ErrNoRows)
+
+		_, err = db.Exec(context.Background(), "DELETE FROM t")
+		require.Error(t, err)
+
+		assert.Equal(t, []Entry{
+			{Op: OpQuery, SQL: "select id from t where n > ?", ArgTypes: []string{"int"}, Route: RouteFollower,
+				Outcome: OutcomeOK},
+			{Op: OpQueryRow, SQL: "select n from t where id = ?", ArgTypes: []string{"string"}, Route: RouteFollower,
+				Outcome: OutcomeNoRows},
+			{Op: OpExec, SQL: "delete from t", Route: RouteFollower, Outcome: "error 23503"},
+		}, rec.Entries())
+	})
+
+	t.Run("should be able to record failed query", func(t *testing.T) {
+		pool := NewMockPool(t)
+		rec := New()
+		expErr := errors.New(uuid.NewString())
+		pool.EXPECT().Query(mock.Anything, "SELECT 1").Return(nil, expErr)
+
+		_, err := rec.Wrap(pool).Query(context.Background(), "SELECT 1")
+		require.ErrorIs(t, err, expErr)
+		assert.Equal(t, []Entry{{Op: OpQuery, SQL: "select ?", Outcome: OutcomeError}}, rec.Entries())
+	})
+
+	t.Run("should be able to pass statements of recorded transaction to wrapped pool", func(t *testing.T) {
+		pool := NewMockPool(t)
+		rec := New()
+		db := rec.Wrap(pool)
+		ctx := pgcontext.With(context.Background(), pgcontext.WithTransaction(db.wrapTx(NewMockTx(t), 1)))
+		pool.EXPECT().Exec(ctx, "DELETE FROM t").Return(pgconn.CommandTag{}, nil)
+
+		_, err := db.Exec(ctx, "DELETE FROM t")
+		require.NoError(t, err)
+		assert.Empty(t, rec.Entries())
+	})
+
+	t.Run("should be able to record statements of foreign transaction with depth 1", func(t *testing.T) {
+		pool := NewMockPool(t)
+		rec := New()
+		ctx := pgcontext.With(context.Background(), pgcontext.WithTransaction(NewMockTx(t)))
+		pool.EXPECT().Exec(mock.Anything, "DELETE FROM t").Return(pgconn.CommandTag{}, nil)
+
+		_, err := rec.Wrap(pool).Exec(ctx, "DELETE FROM t")
+		require.NoError(t, err)
+		assert.Equal(t, []Entry{{Op: OpExec, SQL: "delete from t", TxDepth: 1, Outcome: OutcomeOK}}, rec.Entries())
+	})
+}
+
+func TestDB_Begin(t *testing.T) {
+	t.Run("should be able to record transaction with savepoint", func(t *testing.T) {
+		pool := NewMockPool(t)
+		tx := NewMockTx(t)
+		nested := NewMockTx(t)
+		rec := New()
+		db := rec.Wrap(pool, WithShard(1))
+
+		pool.EXPECT().BeginTx(mock.Anything, pgx.TxOptions{}).Return(tx, nil)
+		tx.EXPECT().Exec(mock.Anything, "UPDATE t SET n = $1", []any{1}).Return(pgconn.CommandTag{}, nil)
+		tx.EXPECT().Begin(mock.Anything).Return(nested, nil)
+		nested.EXPECT().Rollback(mock.Anything).Return(nil).Once()
+		nested.EXPECT().Rollback(mock.Anything).Return(pgx.ErrTxClosed).Once()
+		tx.EXPECT().Commit(mock.Anything).Return(nil)
+
+		ctx := context.Background()
+		out, err := db.BeginTx(ctx, pgx.TxOptions{})
+		require.NoError(t, err)
+		_, err = out.Exec(ctx, "UPDATE t SET n = $1", 1)
+		require.NoError(t, err)
+		sp, err := out.Begin(ctx)
+		require.NoError(t, err)
+		require.NoError(t, sp.Rollback(ctx))
+		require.ErrorIs(t, sp.Rollback(ctx), pgx.ErrTxClosed)
+		require.NoError(t, out.Commit(ctx))
+
+		assert.Equal(t, []Entry{
+			{Op: OpBegin, Route: "shard 1", TxDepth: 1, Outcome: OutcomeOK},
+			{Op: OpExec, SQL: "update t set n = ?", ArgTypes: []string{"int"}, Route: "shard 1", TxDepth: 1,
+				Outcome: OutcomeOK},
+			{Op: OpBegin, Route: "shard 1", TxDepth: 2, Outcome: OutcomeOK},
+			{Op: OpRollback, Route: "shard 1", TxDepth: 2, Outcome: OutcomeOK},
+			{Op: OpCommit, Route: "shard 1", TxDepth: 1, Outcome: OutcomeOK},
+		}, rec.Entries())
+	})
+
+	t.Run("should be able to record failed begin", func(t *testing.T) {
+		pool := NewMockPool(t)
+		rec := New()
+		expErr := errors.New(uuid.NewString())
+		pool.EXPECT().Begin(mock.Anything).Return(nil, expErr)
+
+		_, err := rec.Wrap(pool).Begin(context.Background())
+		require.ErrorIs(t, err, expErr)
+		assert.Equal(t, []Entry{{Op: OpBegin, TxDepth: 1, Outcome: OutcomeError}}, rec.Entries())
+	})
+}
+
+func TestDB_Transactional(t *testing.T) {
+	run := func(tx pgx.Tx, err error) func(ctx context.Context, fn func(ctx context.Context) error) error {
+		return func(ctx context.Context, fn func(ctx context.Context) error) error {
+			if fnErr := fn(pgcontext.With(ctx, pgcontext.WithTransaction(tx))); fnErr != nil {
+				return fnErr
+			}
+			return err
+		}
+	}
+
+	t.Run("should be able to record statements of fn with route of pool", func(t *testing.T) {
+		pool := NewMockPool(t)
+		tx := NewMockTx(t)
+		rec := New()
+		db := rec.Wrap(pool, WithRoute(RouteLeader))
+		pool.EXPECT().Transactional(mock.Anything, mock.Anything).RunAndReturn(run(tx, nil))
+		tx.EXPECT().Exec(mock.Anything, "DELETE FROM t").Return(pgconn.CommandTag{}, nil)
+
+		err := db.Transactional(context.Background(), func(ctx context.Context) error {
+			// elephant pools run statements by transaction from context
+			tx, ok := pgcontext.TransactionFrom(ctx)
+			require.True(t, ok)
+			_, err := tx.Exec(ctx, "DELETE FROM t")
+			return err
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []Entry{
+			{Op: OpBegin, Route: RouteLeader, TxDepth: 1, Outcome: OutcomeOK},
+			{Op: OpExec, SQL: "delete from t", Route: RouteLeader, TxDepth: 1, Outcome: OutcomeOK},
+			{Op: OpCommit, Route: RouteLeader, TxDepth: 1, Outcome: OutcomeOK},
+		}, rec.Entries())
+	})
+
+	t.Run("should be able to record rollback when fn fails", func(t *testing.T) {
+		pool := NewMockPool(t)
+		rec := New()
+		expErr := errors.New(uuid.NewString())
+		pool.EXPECT().Transactional(mock.Anything, mock.Anything).RunAndReturn(run(NewMockTx(t), nil))
+
+		err := rec.Wrap(pool).Transactional(context.Background(), func(context.Context) error {
+			return expErr
+		})
+		require.ErrorIs(t, err, expErr)
+		assert.Equal(t, []Entry{
+			{Op: OpBegin, TxDepth: 1, Outcome: OutcomeOK},
+			{Op: OpRollback, TxDepth: 1, Outcome: OutcomeOK},
+		}, rec.Entries())
+	})
+
+	t.Run("should be able to record commit of passed error", func(t *testing.T) {
+		pool := NewMockPool(t)
+		rec := New()
+		expErr := errors.New(uuid.NewString())
+		ctx := pgcontext.With(context.Background(), pgcontext.WithFnTxPassMatcher(func(context.Context, error) bool {
+			return true
+		}))
+		pool.EXPECT().Transactional(mock.Anything, mock.Anything).RunAndReturn(run(NewMockTx(t), nil))
+
+		err := rec.Wrap(pool).Transactional(ctx, func(context.Context) error {
+			return expErr
+		})
+		require.ErrorIs(t, err, expErr)
+		assert.Equal(t, OpCommit, rec.Entries()[1].Op)
+		assert.Equal(t, OutcomeOK, rec.Entries()[1].Outcome)
+	})
+
+	t.Run("should be able to record failed commit", func(t *testing.T) {
+		pool := NewMockPool(t)
+		rec := New()
+		pool.EXPECT().Transactional(mock.Anything, mock.Anything).
+			RunAndReturn(run(NewMockTx(t), &pgconn.PgError{Code: "40001"}))
+
+		err := rec.Wrap(pool).Transactional(context.Background(), func(context.Context) error { return nil })
+		require.Error(t, err)
+		assert.Equal(t, Entry{Op: OpCommit, TxDepth: 1, Outcome: "error 40001"}, rec.Entries()[1])
+	})
+
+	t.Run("should be able to record failed begin", func(t *testing.T) {
+		pool := NewMockPool(t)
+		rec := New()
+		expErr := errors.New(uuid.NewString())
+		pool.EXPECT().Transactional(mock.Anything, mock.Anything).Return(expErr)
+
+		err := rec.Wrap(pool).Transactional(context.Background(), func(context.Context) error { return nil })
+		require.ErrorIs(t, err, expErr)
+		assert.Equal(t, []Entry{{Op: OpBegin, TxDepth: 1, Outcome: OutcomeError}}, rec.Entries())
+	})
+
+	t.Run("should be able to pass nested transactional to wrapped pool", func(t *testing.T) {
+		pool := NewMockPool(t)
+		rec := New()
+		ctx := pgcontext.With(context.Background(), pgcontext.WithTransaction(NewMockTx(t)))
+		pool.EXPECT().Transactional(ctx, mock.Anything).Return(nil)
+
+		require.NoError(t, rec.Wrap(pool).Transactional(ctx, func(context.Context) error { return nil }))
+		assert.Empty(t, rec.Entries())
+	})
+}
+
+type bulkPool struct {
+	*MockPool
+	*MockBulkPool
+}
+
+func TestDB_CopyFrom(t *testing.T) {
+	t.Run("should be able to record copy and notify", func(t *testing.T) {
+		pool := bulkPool{MockPool: NewMockPool(t), MockBulkPool: NewMockBulkPool(t)}
+		rec := New()
+		db := rec.Wrap(pool, WithRoute(RouteLeader))
+		ctx := context.Background()
+		pool.MockBulkPool.EXPECT().CopyFrom(ctx, pgx.Identifier{"t"}, []string{"id"}, mock.Anything).Return(3, nil)
+		pool.MockPool.EXPECT().Exec(ctx, mock.Anything, []any{"events", "payload"}).
+			Return(pgconn.NewCommandTag("SELECT 1"), nil)
+
+		count, err := db.CopyFrom(ctx, pgx.Identifier{"t"}, []string{"id"}, pgx.CopyFromRows(nil))
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), count)
+		require.NoError(t, db.Notify(ctx, "events", "payload"))
+
+		assert.Equal(t, []Entry{
+			{Op: OpCopy, SQL: `"t"`, Route: RouteLeader, Outcome: OutcomeOK},
+			{Op: OpExec, SQL: "select pg_notify(?)", ArgTypes: []string{"string", "string"}, Route: RouteLeader,
+				Outcome: OutcomeOK},
+		}, rec.Entries())
+	})
+
+	t.Run("should be able to fail when wrapped pool has no bulk operations", func(t *testing.T) {
+		rec := New()
+		db := rec.Wrap(NewMockPool(t))
+
+		_, err := db.CopyFrom(context.Background(), pgx.Identifier{"t"}, []string{"id"}, pgx.CopyFromRows(nil))
+		require.ErrorIs(t, err, passthrough.ErrBulkNotSupported)
+		assert.Empty(t, rec.Entries())
+	})
+}
@@ -0,0 +1,98 @@
+package recorder
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// UpdateGoldenEnv is environment variable which makes AssertGolden rewrite golden files instead of comparing.
+const UpdateGoldenEnv = "ELEPHANT_UPDATE_GOLDEN"
+
+const (
+	goldenDirPerm  = 0o755
+	goldenFilePerm = 0o644
+)
+
+// TestingT is subset of testing.TB used by AssertGolden.
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...any)
+}
+
+// AssertGolden compares entries of recorder, written as JSON lines, with golden file at path. When environment
+// variable UpdateGoldenEnv is not empty, golden file is written instead.
+func AssertGolden(t TestingT, path string, rec *Recorder) bool {
+	t.Helper()
+
+	var got bytes.Buffer
+	if _, err := rec.WriteTo(&got); err != nil {
+		t.Errorf("recorder: %v", err)
+		return false
+	}
+
+	if os.Getenv(UpdateGoldenEnv) != "" {
+		if err := writeGolden(path, got.Bytes()); err != nil {
+			t.Errorf("recorder: %v", err)
+			return false
+		}
+		return true
+	}
+
+	want, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("recorder: golden file %s does not exist, run tests with %s=1 to create it", path, UpdateGoldenEnv)
+		return false
+	}
+	if err != nil {
+		t.Errorf("recorder: can't read golden file: %v", err)
+		return false
+	}
+
+	if line, ok := diff(want, got.Bytes()); !ok {
+		t.Errorf("recorder: statements differ from golden file %s at line %d:\nwant: %s\n got: %s",
+			path, line.number, line.want, line.got)
+		return false
+	}
+	return true
+}
+
+func writeGolden(path string, content []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), goldenDirPerm); err != nil {
+		return fmt.Errorf("can't create golden file dir: %w", err)
+	}
+	if err := os.WriteFile(path, content, goldenFilePerm); err != nil {
+		return fmt.Errorf("can't write golden file: %w", err)
+	}
+	return nil
+}
+
+type mismatch struct {
+	number int
+	want   string
+	got    string
+}
+
+// diff returns first line which differs.
+func diff(want, got []byte) (mismatch, bool) {
+	wantLines := bytes.Split(bytes.TrimRight(want, "\n"), []byte("\n"))
+	gotLines := bytes.Split(bytes.TrimRight(got, "\n"), []byte("\n"))
+
+	for i := range max(len(wantLines), len(gotLines)) {
+		w, g := line(wantLines, i), line(gotLines, i)
+		if w != g {
+			return mismatch{number: i + 1, want: w, got: g}, false
+		}
+	}
+	return mismatch{}, true
+}
+
+func line(lines [][]byte, i int) string {
+	if i >= len(lines) || (len(lines) == 1 && len(lines[0]) == 0) {
+		return "<none>"
+	}
+	return string(lines[i])
+}
@@ -0,0 +1,60 @@
+package recorder
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type reporter struct {
+	errors []string
+}
+
+func (r *reporter) Helper() {}
+
+func (r *reporter) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestAssertGolden(t *testing.T) {
+	rec := New()
+	rec.record(Entry{Op: OpExec, SQL: "delete from t"}, nil)
+	path := filepath.Join(t.TempDir(), "testdata", "statements.golden")
+
+	t.Run("should be able to fail when golden file does not exist", func(t *testing.T) {
+		r := &reporter{}
+		assert.False(t, AssertGolden(r, path, rec))
+		require.Len(t, r.errors, 1)
+		assert.Contains(t, r.errors[0], UpdateGoldenEnv)
+	})
+
+	t.Run("should be able to write golden file when update is requested", func(t *testing.T) {
+		t.Setenv(UpdateGoldenEnv, "1")
+		r := &reporter{}
+		assert.True(t, AssertGolden(r, path, rec))
+		assert.Empty(t, r.errors)
+
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, `{"op":"exec","sql":"delete from t","tx_depth":0,"outcome":"ok"}`+"\n", string(content))
+	})
+
+	t.Run("should be able to pass when entries match golden file", func(t *testing.T) {
+		r := &reporter{}
+		assert.True(t, AssertGolden(r, path, rec))
+		assert.Empty(t, r.errors)
+	})
+
+	t.Run("should be able to report first differing line", func(t *testing.T) {
+		rec.record(Entry{Op: OpQuery, SQL: "select ?"}, nil)
+		r := &reporter{}
+		assert.False(t, AssertGolden(r, path, rec))
+		require.Len(t, r.errors, 1)
+		assert.Contains(t, r.errors[0], "line 2")
+		assert.Contains(t, r.errors[0], "want: <none>")
+	})
+}
@@ -0,0 +1,198 @@
+// Package recorder implements decorator for elephant pools which records every statement with its normalized SQL,
+// argument types, routing decision, transaction depth and outcome. Log of recorded entries is compared with golden
+// file by AssertGolden, so changes of statements, routing and transaction boundaries are visible in review.
+//
+//go:generate go tool mockery
+package recorder
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/godepo/elephant/internal/pkg/fingerprint"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const (
+	OpQuery    = "query"
+	OpQueryRow = "query_row"
+	OpExec     = "exec"
+	OpCopy     = "copy"
+	OpBegin    = "begin"
+	OpCommit   = "commit"
+	OpRollback = "rollback"
+
+	OutcomeOK     = "ok"
+	OutcomeNoRows = "no rows"
+	OutcomeError  = "error"
+
+	RouteLeader   = "leader"
+	RouteFollower = "follower"
+)
+
+// Entry is single recorded statement or transaction control operation.
+type Entry struct {
+	Op       string   `json:"op"`
+	SQL      string   `json:"sql,omitempty"`
+	ArgTypes []string `json:"arg_types,omitempty"`
+	Route    string   `json:"route,omitempty"`
+	TxDepth  int      `json:"tx_depth"`
+	Outcome  string   `json:"outcome"`
+}
+
+type Config struct {
+	sink func(Entry)
+}
+
+type Option func(cfg *Config)
+
+// WithSink sets function which receives every entry when its outcome is known, for example to write it to
+// structured logger.
+func WithSink(sink func(Entry)) Option {
+	return func(cfg *Config) {
+		cfg.sink = sink
+	}
+}
+
+// Recorder keeps entries of every pool it wraps in order statements were called.
+type Recorder struct {
+	mu      sync.Mutex
+	entries []*Entry
+	sink    func(Entry)
+}
+
+func New(opts ...Option) *Recorder {
+	cfg := Config{
+		sink: func(Entry) {},
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return &Recorder{sink: cfg.sink}
+}
+
+// Entries returns copy of recorded entries. Entries of statements which are not finished yet, like rows which are
+// not closed, have empty outcome.
+func (rec *Recorder) Entries() []Entry {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	out := make([]Entry, 0, len(rec.entries))
+	for _, e := range rec.entries {
+		out = append(out, *e)
+	}
+	return out
+}
+
+// Reset drops recorded entries.
+func (rec *Recorder) Reset() {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	rec.entries = nil
+}
+
+// WriteTo writes entries as JSON lines.
+func (rec *Recorder) WriteTo(w io.Writer) (int64, error) {
+	var total int64
+	for _, e := range rec.Entries() {
+		line, err := json.Marshal(e)
+		if err != nil {
+			return total, fmt.Errorf("can't marshal recorded entry: %w", err)
+		}
+		n, err := w.Write(append(line, '\n'))
+		total += int64(n)
+		if err != nil {
+			return total, fmt.Errorf("can't write recorded entry: %w", err)
+		}
+	}
+	return total, nil
+}
+
+// start appends entry without outcome, so entries are kept in order of calls.
+func (rec *Recorder) start(e Entry) *Entry {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	entry := &e
+	rec.entries = append(rec.entries, entry)
+	return entry
+}
+
+func (rec *Recorder) finish(e *Entry, err error) {
+	rec.mu.Lock()
+	e.Outcome = outcome(err)
+	out := *e
+	rec.mu.Unlock()
+
+	rec.sink(out)
+}
+
+func (rec *Recorder) record(e Entry, err error) {
+	rec.finish(rec.start(e), err)
+}
+
+func outcome(err error) string {
+	var pgErr *pgconn.PgError
+	switch {
+	case err == nil:
+		return OutcomeOK
+	case errors.Is(err, pgx.ErrNoRows):
+		return OutcomeNoRows
+	case errors.As(err, &pgErr):
+		return OutcomeError + " " + pgErr.Code
+	default:
+		return OutcomeError
+	}
+}
+
+func argTypes(args []any) []string {
+	if len(args) == 0 {
+		return nil
+	}
+	out := make([]string, 0, len(args))
+	for _, arg := range args {
+		if arg == nil {
+			out = append(out, "nil")
+			continue
+		}
+		out = append(out, fmt.Sprintf("%T", arg))
+	}
+	return out
+}
+
+type node struct {
+	route string
+}
+
+type NodeOption func(n *node)
+
+// WithRoute sets routing decision which is recorded for statements of node, like RouteLeader or RouteFollower.
+func WithRoute(route string) NodeOption {
+	return func(n *node) {
+		n.route = route
+	}
+}
+
+// WithShard records statements of node as routed to shard with given ID.
+func WithShard(id uint) NodeOption {
+	return func(n *node) {
+		n.route = fmt.Sprintf("shard %d", id)
+	}
+}
+
+func (n node) entry(op, query string, args []any, depth int) Entry {
+	e := Entry{
+		Op:       op,
+		ArgTypes: argTypes(args),
+		Route:    n.route,
+		TxDepth:  depth,
+	}
+	if query != "" {
+		e.SQL = fingerprint.Normalize(query)
+	}
+	return e
+}
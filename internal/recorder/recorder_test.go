@@ -0,0 +1,87 @@
+package recorder
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutcome(t *testing.T) {
+	t.Run("should be able to describe outcome of statement", func(t *testing.T) {
+		assert.Equal(t, OutcomeOK, outcome(nil))
+		assert.Equal(t, OutcomeNoRows, outcome(pgx.ErrNoRows))
+		assert.Equal(t, "error 40001", outcome(&pgconn.PgError{Code: "40001"}))
+		assert.Equal(t, OutcomeError, outcome(errors.New(uuid.NewString())))
+	})
+}
+
+func TestArgTypes(t *testing.T) {
+	t.Run("should be able to describe types of arguments", func(t *testing.T) {
+		assert.Nil(t, argTypes(nil))
+		assert.Equal(t, []string{"int", "string", "nil", "time.Time"}, argTypes([]any{1, "a", nil, time.Time{}}))
+	})
+}
+
+func TestNodeOption(t *testing.T) {
+	t.Run("should be able to record route of node", func(t *testing.T) {
+		var n node
+		WithShard(3)(&n)
+		assert.Equal(t, "shard 3", n.route)
+
+		WithRoute(RouteFollower)(&n)
+		assert.Equal(t, Entry{
+			Op:       OpQuery,
+			SQL:      "select * from users where id = ?",
+			ArgTypes: []string{"int"},
+			Route:    RouteFollower,
+			TxDepth:  2,
+		}, n.entry(OpQuery, "SELECT * FROM users WHERE id = $1", []any{1}, 2))
+	})
+}
+
+func TestRecorder(t *testing.T) {
+	t.Run("should be able to keep entries in order of calls and send finished ones to sink", func(t *testing.T) {
+		var sunk []Entry
+		rec := New(WithSink(func(e Entry) { sunk = append(sunk, e) }))
+
+		first := rec.start(Entry{Op: OpQuery, SQL: "select ?"})
+		rec.record(Entry{Op: OpExec, SQL: "delete from t"}, nil)
+		assert.Equal(t, []Entry{
+			{Op: OpQuery, SQL: "select ?"},
+			{Op: OpExec, SQL: "delete from t", Outcome: OutcomeOK},
+		}, rec.Entries())
+
+		rec.finish(first, pgx.ErrNoRows)
+		assert.Equal(t, OutcomeNoRows, rec.Entries()[0].Outcome)
+		assert.Equal(t, []Entry{
+			{Op: OpExec, SQL: "delete from t", Outcome: OutcomeOK},
+			{Op: OpQuery, SQL: "select ?", Outcome: OutcomeNoRows},
+		}, sunk)
+
+		rec.Reset()
+		assert.Empty(t, rec.Entries())
+	})
+
+	t.Run("should be able to write entries as json lines", func(t *testing.T) {
+		rec := New()
+		rec.record(Entry{Op: OpBegin, TxDepth: 1, Route: RouteLeader}, nil)
+		rec.record(Entry{Op: OpExec, SQL: "update t set n = ?", ArgTypes: []string{"int"}, TxDepth: 1}, nil)
+
+		var buf bytes.Buffer
+		n, err := rec.WriteTo(&buf)
+		require.NoError(t, err)
+		assert.Equal(t, int64(buf.Len()), n)
+		assert.Equal(t,
+			`{"op":"begin","route":"leader","tx_depth":1,"outcome":"ok"}`+"\n"+
+				`{"op":"exec","sql":"update t set n = ?","arg_types":["int"],"tx_depth":1,"outcome":"ok"}`+"\n",
+			buf.String(),
+		)
+	})
+}
@@ -66,6 +66,24 @@ func ArrangeTxErrPassMatcher(fn pgcontext.TxPassMatcher) groat.Given[State] {
 	}
 }
 
+func ArrangeSessionSettings(t *testing.T, state State) State {
+	t.Helper()
+	state.ctx = pgcontext.With(state.ctx, pgcontext.WithSessionSettings(map[string]string{
+		"app.user_id": state.Record.ID.String(),
+	}))
+	return state
+}
+
+func AssertSessionSetting(sut *Instance, ctx context.Context, expect string) groat.Then[State] {
+	return func(t *testing.T, state State) {
+		t.Helper()
+		var res string
+		err := sut.QueryRow(ctx, "SELECT coalesce(current_setting('app.user_id', true), '')").Scan(&res)
+		require.NoError(t, err)
+		assert.Equal(t, expect, res)
+	}
+}
+
 func InjectPoolMock(sut *Instance) groat.When[Deps, State] {
 	return func(t *testing.T, deps Deps, state State) State {
 		t.Helper()
@@ -205,3 +223,50 @@ func AssertRecordQueried(sut *Instance) groat.Then[State] {
 		assert.Contains(t, items, state.Record)
 	}
 }
+
+type bulkPool struct {
+	*MockPool
+	*MockBulkPool
+}
+
+func InjectBulkPoolMock(sut *Instance) groat.When[Deps, State] {
+	return func(t *testing.T, deps Deps, state State) State {
+		t.Helper()
+		sut.db = bulkPool{MockPool: deps.MockPool, MockBulkPool: deps.MockBulkPool}
+		return state
+	}
+}
+
+func ExpectCopyFromError(t *testing.T, deps Deps, state State) State {
+	t.Helper()
+	deps.MockBulkPool.EXPECT().
+		CopyFrom(mock.Anything, pgx.Identifier{"regular", "instance"}, []string{"id", "value"}, mock.Anything).
+		Return(0, state.ExpectError)
+	return state
+}
+
+func ActCopyRecord(sut *Instance) groat.When[Deps, State] {
+	return func(t *testing.T, deps Deps, state State) State {
+		count, err := sut.CopyFrom(
+			state.ctx,
+			pgx.Identifier{"regular", "instance"},
+			[]string{"id", "value"},
+			pgx.CopyFromRows([][]any{{state.Record.ID, state.Record.Value}}),
+		)
+		require.NoError(t, err)
+		require.Equal(t, int64(1), count)
+		return state
+	}
+}
+
+func ActSendRecordBatch(sut *Instance) groat.When[Deps, State] {
+	return func(t *testing.T, deps Deps, state State) State {
+		batch := &pgx.Batch{}
+		batch.Queue(
+			"INSERT INTO regular.instance (id, value) VALUES ($1, $2)",
+			state.Record.ID, state.Record.Value,
+		)
+		require.NoError(t, sut.SendBatch(state.ctx, batch).Close())
+		return state
+	}
+}
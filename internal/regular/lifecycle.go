@@ -0,0 +1,48 @@
+package regular
+
+import (
+	"context"
+	"time"
+
+	"github.com/godepo/elephant/internal/pkg/lifecycle"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Pinger is implemented by pools which can check connection, like pgxpool.Pool.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// Stater is implemented by pools which give stats, like pgxpool.Pool.
+type Stater interface {
+	Stat() *pgxpool.Stat
+}
+
+// Close closes wrapped pool, pools which can't be closed are left as is.
+func (ins *Instance) Close() {
+	lifecycle.CloseMember(ins.db)
+}
+
+// Ping checks connection of wrapped pool, it fails with lifecycle.ErrPingNotSupported when pool can't be checked.
+func (ins *Instance) Ping(ctx context.Context) (lifecycle.Pings, error) {
+	ping := lifecycle.Ping{Node: lifecycle.Node{Role: lifecycle.RoleNode}, Err: lifecycle.ErrPingNotSupported}
+	if pinger, ok := ins.db.(Pinger); ok {
+		begin := time.Now()
+		ping.Err = pinger.Ping(ctx)
+		ping.Latency = time.Since(begin)
+	}
+	pings := lifecycle.Pings{ping}
+	return pings, pings.Err()
+}
+
+// Stats returns stats of wrapped pool, they are empty when pool does not give stats.
+func (ins *Instance) Stats() lifecycle.Stats {
+	stater, ok := ins.db.(Stater)
+	if !ok {
+		return nil
+	}
+	return lifecycle.Stats{{
+		Node: lifecycle.Node{Role: lifecycle.RoleNode},
+		Stat: lifecycle.StatOf(stater.Stat()),
+	}}
+}
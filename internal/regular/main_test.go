@@ -19,6 +19,7 @@ func mainProvider(t *testing.T) *groat.Case[Deps, State, *Instance] {
 		deps.MockDB = NewMockDB(t)
 		deps.MockPool = NewMockPool(t)
 		deps.MockRows = NewMockRows(t)
+		deps.MockBulkPool = NewMockBulkPool(t)
 		return deps
 	})
 	tcs.Given(func(t *testing.T, state State) State {
@@ -6,9 +6,16 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/godepo/elephant/internal/locker"
+	"github.com/godepo/elephant/internal/notify"
+	"github.com/godepo/elephant/internal/pkg/failure"
+	"github.com/godepo/elephant/internal/pkg/named"
+	"github.com/godepo/elephant/internal/pkg/passthrough"
 	"github.com/godepo/elephant/internal/pkg/pgcontext"
+	"github.com/godepo/elephant/internal/pkg/txscope"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type Pool interface {
@@ -58,7 +65,7 @@ func (ins *Instance) Begin(ctx context.Context) (pgx.Tx, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to begin transaction: %w", err)
 	}
-	return tx, nil
+	return applySettings(ctx, tx)
 }
 
 func (ins *Instance) BeginTx(ctx context.Context, opts pgx.TxOptions) (pgx.Tx, error) {
@@ -66,29 +73,170 @@ func (ins *Instance) BeginTx(ctx context.Context, opts pgx.TxOptions) (pgx.Tx, e
 	if err != nil {
 		return nil, fmt.Errorf("can't begin tx at regular instance: %w", err)
 	}
-	return tx, nil
+	return applySettings(ctx, tx)
 }
 
 func (ins *Instance) Query(ctx context.Context, query string, args ...interface{}) (pgx.Rows, error) {
-	rows, err := ins.selector(ctx).Query(ctx, query, args...)
+	query, args, err := named.Bind(query, args)
 	if err != nil {
+		return nil, fmt.Errorf("can't bind named parameters: %w", err)
+	}
+	tx, ok, err := ins.implicit(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		rows, err := ins.selector(ctx).Query(ctx, query, args...)
+		if err != nil {
+			return nil, fmt.Errorf("can't query regular instance: %w", err)
+		}
+		return rows, nil
+	}
+
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		_ = tx.Rollback(ctx)
 		return nil, fmt.Errorf("can't query regular instance: %w", err)
 	}
-	return rows, nil
+	return txscope.Rows(ctx, tx, rows), nil
 }
 
 func (ins *Instance) QueryRow(ctx context.Context, query string, args ...interface{}) pgx.Row {
+	query, args, err := named.Bind(query, args)
+	if err != nil {
+		return failure.Row(fmt.Errorf("can't bind named parameters: %w", err))
+	}
+	_, inTx := pgcontext.TransactionFrom(ctx)
+	if _, ok := pgcontext.SessionSettingsFrom(ctx); ok && !inTx {
+		return implicitRow{ctx: ctx, ins: ins, query: query, args: args}
+	}
 	return ins.selector(ctx).QueryRow(ctx, query, args...)
 }
 
 func (ins *Instance) Exec(ctx context.Context, query string, args ...interface{}) (pgconn.CommandTag, error) {
-	tag, err := ins.selector(ctx).Exec(ctx, query, args...)
+	query, args, err := named.Bind(query, args)
+	if err != nil {
+		return pgconn.CommandTag{}, fmt.Errorf("can't bind named parameters: %w", err)
+	}
+	tx, ok, err := ins.implicit(ctx)
+	if err != nil {
+		return pgconn.CommandTag{}, err
+	}
+	var tag pgconn.CommandTag
+	if ok {
+		tag, err = tx.Exec(ctx, query, args...)
+		err = txscope.Finish(ctx, tx, err)
+	} else {
+		tag, err = ins.selector(ctx).Exec(ctx, query, args...)
+	}
 	if err != nil {
 		return pgconn.CommandTag{}, fmt.Errorf("can't query regular instance: %w", err)
 	}
 	return tag, nil
 }
 
+func (ins *Instance) bulkSelector(ctx context.Context) (passthrough.BulkPool, error) {
+	if tx, ok := pgcontext.TransactionFrom(ctx); ok {
+		return tx, nil
+	}
+	return passthrough.AsBulk(ins.db)
+}
+
+func (ins *Instance) CopyFrom(
+	ctx context.Context,
+	tableName pgx.Identifier,
+	columnNames []string,
+	rowSrc pgx.CopyFromSource,
+) (int64, error) {
+	tx, ok, err := ins.implicit(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if ok {
+		count, err := tx.CopyFrom(ctx, tableName, columnNames, rowSrc)
+		if err = txscope.Finish(ctx, tx, err); err != nil {
+			return count, fmt.Errorf("can't copy rows to regular instance: %w", err)
+		}
+		return count, nil
+	}
+
+	db, err := ins.bulkSelector(ctx)
+	if err != nil {
+		return 0, err
+	}
+	count, err := db.CopyFrom(ctx, tableName, columnNames, rowSrc)
+	if err != nil {
+		return count, fmt.Errorf("can't copy rows to regular instance: %w", err)
+	}
+	return count, nil
+}
+
+func (ins *Instance) SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults {
+	tx, ok, err := ins.implicit(ctx)
+	if err != nil {
+		return failure.BatchResults(err)
+	}
+	if ok {
+		return txscope.BatchResults(ctx, tx, tx.SendBatch(ctx, b))
+	}
+
+	db, err := ins.bulkSelector(ctx)
+	if err != nil {
+		return failure.BatchResults(err)
+	}
+	return db.SendBatch(ctx, b)
+}
+
+// Acquire takes dedicated connection from pool. Caller must release it.
+func (ins *Instance) Acquire(ctx context.Context) (*pgxpool.Conn, error) {
+	acq, err := passthrough.AsAcquirer(ins.db)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := acq.Acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("can't acquire connection at regular instance: %w", err)
+	}
+	return conn, nil
+}
+
+// Listen subscribes to channel at dedicated connection until ctx is done.
+func (ins *Instance) Listen(ctx context.Context, channel string) (<-chan pgconn.Notification, error) {
+	return notify.Listen(ctx, func(ctx context.Context) (notify.Conn, error) {
+		conn, err := ins.Acquire(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return notify.PoolConn(conn), nil
+	}, channel)
+}
+
+// Notify sends notification to channel. When transaction is in context, notification is delivered after commit.
+func (ins *Instance) Notify(ctx context.Context, channel, payload string) error {
+	_, err := ins.Exec(ctx, notify.Query, channel, payload)
+	return err
+}
+
+func (ins *Instance) session(ctx context.Context) (locker.Session, error) {
+	conn, err := ins.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return conn, nil
+}
+
+// WithAdvisoryLock runs fn while advisory lock hashed from key is held. Lock is bound to transaction
+// from context, or held by session on dedicated connection when there is no transaction.
+func (ins *Instance) WithAdvisoryLock(ctx context.Context, key string, fn func(ctx context.Context) error) error {
+	return locker.Lock(ctx, ins.session, locker.Key(key), fn)
+}
+
+// WithTryAdvisoryLock works like WithAdvisoryLock, but returns locker.ErrLockNotAcquired
+// without waiting when lock is held by another session.
+func (ins *Instance) WithTryAdvisoryLock(ctx context.Context, key string, fn func(ctx context.Context) error) error {
+	return locker.TryLock(ctx, ins.session, locker.Key(key), fn)
+}
+
 func (ins *Instance) nestedTx(ctx context.Context, tx pgx.Tx, fn func(ctx context.Context) error) (out error) {
 	nested, err := tx.Begin(ctx)
 	if err != nil {
@@ -5,20 +5,27 @@ import (
 	"errors"
 	"testing"
 
+	"github.com/godepo/elephant/internal/locker"
+	"github.com/godepo/elephant/internal/pkg/lifecycle"
+	"github.com/godepo/elephant/internal/pkg/named"
+	"github.com/godepo/elephant/internal/pkg/passthrough"
 	"github.com/godepo/groat/integration"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/jaswdr/faker/v2"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
 
 type Deps struct {
-	DB       *pgxpool.Pool `groat:"pgxpool"`
-	Faker    faker.Faker
-	MockDB   *MockDB
-	MockRows *MockRows
-	MockPool *MockPool
+	DB           *pgxpool.Pool `groat:"pgxpool"`
+	Faker        faker.Faker
+	MockDB       *MockDB
+	MockRows     *MockRows
+	MockPool     *MockPool
+	MockBulkPool *MockBulkPool
 }
 
 type Record struct {
@@ -28,6 +35,7 @@ type Record struct {
 
 type Result struct {
 	Error error
+	Count int64
 }
 
 type State struct {
@@ -124,6 +132,21 @@ func TestInstance_Exec(t *testing.T) {
 			).Then(AssertExpectError)
 		_, tcs.State.Result.Error = tcs.SUT.Exec(tcs.State.ctx, "SELECT 1")
 	})
+
+	t.Run("should be able to bind named parameters", func(t *testing.T) {
+		tcs := suite.Case(t)
+		tcs.Given(ArrangeContext, ArrangeExpectedError).
+			When(
+				InjectPoolMock(tcs.SUT),
+				ExpectExecError("SELECT $1", 1),
+			).Then(AssertExpectError)
+		_, tcs.State.Result.Error = tcs.SUT.Exec(tcs.State.ctx, "SELECT :id", map[string]any{"id": 1})
+	})
+
+	t.Run("should be able to fail when named parameter is missing", func(t *testing.T) {
+		_, err := New(NewMockPool(t)).Exec(context.Background(), "SELECT :id", map[string]any{})
+		require.ErrorIs(t, err, named.ErrMissingValue)
+	})
 }
 
 func TestInstance_Query(t *testing.T) {
@@ -307,3 +330,280 @@ func TestInstance_Transactional(t *testing.T) {
 		})
 	})
 }
+
+func TestInstance_CopyFrom(t *testing.T) {
+	t.Run("should be able to copy rows", func(t *testing.T) {
+		tcs := suite.Case(t)
+
+		tcs.Given(ArrangeContext, ArrangeRecord).
+			When(ActCopyRecord(tcs.SUT)).
+			Then(AssertHasRecord(tcs.SUT))
+	})
+
+	t.Run("should be able to copy rows in transaction", func(t *testing.T) {
+		tcs := suite.Case(t)
+
+		tcs.Given(ArrangeContext, ArrangeRecord).
+			When(
+				ActBeginTransaction(tcs.SUT),
+				ActCopyRecord(tcs.SUT),
+			).
+			Then(
+				AssertCommitTransaction,
+				AssertHasRecord(tcs.SUT),
+			)
+	})
+
+	t.Run("should be able to fail when pool does not support bulk operations", func(t *testing.T) {
+		tcs := suite.Case(t)
+
+		tcs.Given(ArrangeContext, ArrangeAsExpectError(passthrough.ErrBulkNotSupported)).
+			When(InjectPoolMock(tcs.SUT)).
+			Then(AssertExpectError)
+
+		tcs.State.Result.Count, tcs.State.Result.Error = tcs.SUT.CopyFrom(
+			tcs.State.ctx,
+			pgx.Identifier{"regular", "instance"},
+			[]string{"id", "value"},
+			pgx.CopyFromRows(nil),
+		)
+	})
+
+	t.Run("should be able return error when fail at driver side", func(t *testing.T) {
+		tcs := suite.Case(t)
+
+		tcs.Given(ArrangeContext, ArrangeExpectedError).
+			When(InjectBulkPoolMock(tcs.SUT), ExpectCopyFromError).
+			Then(AssertExpectError)
+
+		tcs.State.Result.Count, tcs.State.Result.Error = tcs.SUT.CopyFrom(
+			tcs.State.ctx,
+			pgx.Identifier{"regular", "instance"},
+			[]string{"id", "value"},
+			pgx.CopyFromRows(nil),
+		)
+	})
+}
+
+func TestInstance_SendBatch(t *testing.T) {
+	t.Run("should be able to send batch", func(t *testing.T) {
+		tcs := suite.Case(t)
+
+		tcs.Given(ArrangeContext, ArrangeRecord).
+			When(ActSendRecordBatch(tcs.SUT)).
+			Then(AssertHasRecord(tcs.SUT))
+	})
+
+	t.Run("should be able to send batch in transaction", func(t *testing.T) {
+		tcs := suite.Case(t)
+
+		tcs.Given(ArrangeContext, ArrangeRecord).
+			When(
+				ActBeginTransaction(tcs.SUT),
+				ActSendRecordBatch(tcs.SUT),
+			).
+			Then(
+				AssertCommitTransaction,
+				AssertHasRecord(tcs.SUT),
+			)
+	})
+
+	t.Run("should be able to fail when pool does not support bulk operations", func(t *testing.T) {
+		tcs := suite.Case(t)
+
+		tcs.Given(ArrangeContext, ArrangeAsExpectError(passthrough.ErrBulkNotSupported)).
+			When(InjectPoolMock(tcs.SUT)).
+			Then(AssertExpectError)
+
+		tcs.State.Result.Error = tcs.SUT.SendBatch(tcs.State.ctx, &pgx.Batch{}).Close()
+	})
+}
+
+func TestInstance_Acquire(t *testing.T) {
+	t.Run("should be able to acquire dedicated connection", func(t *testing.T) {
+		tcs := suite.Case(t)
+		tcs.Given(ArrangeContext).Then(AssertNoError)
+
+		conn, err := tcs.SUT.Acquire(tcs.State.ctx)
+		require.NoError(t, err)
+		conn.Release()
+	})
+
+	t.Run("should be able to fail when pool can't acquire connection", func(t *testing.T) {
+		tcs := suite.Case(t)
+		tcs.Given(ArrangeContext, ArrangeAsExpectError(passthrough.ErrAcquireNotSupported)).
+			When(InjectPoolMock(tcs.SUT)).
+			Then(AssertExpectError)
+
+		_, tcs.State.Result.Error = tcs.SUT.Acquire(tcs.State.ctx)
+	})
+}
+
+func TestInstance_Listen(t *testing.T) {
+	t.Run("should be able to receive notification", func(t *testing.T) {
+		tcs := suite.Case(t)
+		tcs.Given(ArrangeContext, ArrangeRecord).Then(AssertNoError)
+
+		ctx, cancel := context.WithCancel(tcs.State.ctx)
+		defer cancel()
+
+		ch, err := tcs.SUT.Listen(ctx, tcs.State.Record.ID.String())
+		require.NoError(t, err)
+		require.NoError(t, tcs.SUT.Notify(ctx, tcs.State.Record.ID.String(), tcs.State.Record.Value))
+
+		msg := <-ch
+		require.Equal(t, tcs.State.Record.Value, msg.Payload)
+	})
+
+	t.Run("should be able to deliver notification after commit", func(t *testing.T) {
+		tcs := suite.Case(t)
+		tcs.Given(ArrangeContext, ArrangeRecord).Then(AssertNoError)
+
+		ctx, cancel := context.WithCancel(tcs.State.ctx)
+		defer cancel()
+
+		ch, err := tcs.SUT.Listen(ctx, tcs.State.Record.ID.String())
+		require.NoError(t, err)
+
+		tcs.State.Result.Error = tcs.SUT.Transactional(ctx, func(ctx context.Context) error {
+			return tcs.SUT.Notify(ctx, tcs.State.Record.ID.String(), tcs.State.Record.Value)
+		})
+
+		msg := <-ch
+		require.Equal(t, tcs.State.Record.Value, msg.Payload)
+	})
+
+	t.Run("should be able to fail when pool can't acquire connection", func(t *testing.T) {
+		tcs := suite.Case(t)
+		tcs.Given(ArrangeContext, ArrangeAsExpectError(passthrough.ErrAcquireNotSupported)).
+			When(InjectPoolMock(tcs.SUT)).
+			Then(AssertExpectError)
+
+		_, tcs.State.Result.Error = tcs.SUT.Listen(tcs.State.ctx, "events")
+	})
+}
+
+func TestInstance_WithAdvisoryLock(t *testing.T) {
+	t.Run("should be able to run under session lock", func(t *testing.T) {
+		tcs := suite.Case(t)
+		tcs.Given(ArrangeContext, ArrangeRecord).Then(AssertNoError)
+
+		tcs.State.Result.Error = tcs.SUT.WithAdvisoryLock(tcs.State.ctx, tcs.State.Record.Value,
+			func(ctx context.Context) error {
+				err := tcs.SUT.WithTryAdvisoryLock(ctx, tcs.State.Record.Value, func(ctx context.Context) error {
+					return nil
+				})
+				require.ErrorIs(t, err, locker.ErrLockNotAcquired)
+				return nil
+			})
+	})
+
+	t.Run("should be able to run under transaction lock", func(t *testing.T) {
+		tcs := suite.Case(t)
+		tcs.Given(ArrangeContext, ArrangeRecord).Then(AssertNoError)
+
+		tcs.State.Result.Error = tcs.SUT.Transactional(tcs.State.ctx, func(ctx context.Context) error {
+			return tcs.SUT.WithTryAdvisoryLock(ctx, tcs.State.Record.Value, func(ctx context.Context) error {
+				return tcs.SUT.WithAdvisoryLock(ctx, tcs.State.Record.Value, func(ctx context.Context) error {
+					_, err := tcs.SUT.Exec(ctx, "SELECT 1")
+					return err
+				})
+			})
+		})
+	})
+
+	t.Run("should be able to fail when pool can't acquire connection", func(t *testing.T) {
+		tcs := suite.Case(t)
+		tcs.Given(ArrangeContext, ArrangeAsExpectError(passthrough.ErrAcquireNotSupported)).
+			When(InjectPoolMock(tcs.SUT)).
+			Then(AssertExpectError)
+
+		tcs.State.Result.Error = tcs.SUT.WithAdvisoryLock(tcs.State.ctx, "jobs", func(ctx context.Context) error {
+			return nil
+		})
+	})
+}
+
+func TestInstance_SessionSettings(t *testing.T) {
+	t.Run("should be able to apply settings to standalone query without leak to pool", func(t *testing.T) {
+		tcs := suite.Case(t)
+		tcs.Given(ArrangeContext, ArrangeRecord, ArrangeSessionSettings).
+			Then(AssertNoError, AssertSessionSetting(tcs.SUT, context.Background(), ""))
+
+		var userID string
+		rows, err := tcs.SUT.Query(tcs.State.ctx, "SELECT current_setting('app.user_id')")
+		require.NoError(t, err)
+		for rows.Next() {
+			require.NoError(t, rows.Scan(&userID))
+		}
+		tcs.State.Result.Error = rows.Err()
+		require.Equal(t, tcs.State.Record.ID.String(), userID)
+	})
+
+	t.Run("should be able to apply settings to transactional", func(t *testing.T) {
+		tcs := suite.Case(t)
+		tcs.Given(ArrangeContext, ArrangeRecord, ArrangeSessionSettings).
+			Then(AssertNoError, AssertSessionSetting(tcs.SUT, context.Background(), ""))
+
+		tcs.State.Result.Error = tcs.SUT.Transactional(tcs.State.ctx, func(ctx context.Context) error {
+			AssertSessionSetting(tcs.SUT, ctx, tcs.State.Record.ID.String())(t, tcs.State)
+			_, err := tcs.SUT.Exec(ctx, "INSERT INTO regular.instance (id, value) VALUES ($1, $2)",
+				tcs.State.Record.ID, tcs.State.Record.Value)
+			return err
+		})
+	})
+
+	t.Run("should be able to apply settings to standalone row and exec", func(t *testing.T) {
+		tcs := suite.Case(t)
+		tcs.Given(ArrangeContext, ArrangeRecord, ArrangeSessionSettings).
+			Then(AssertNoError, AssertSessionSetting(tcs.SUT, context.Background(), ""))
+
+		AssertSessionSetting(tcs.SUT, tcs.State.ctx, tcs.State.Record.ID.String())(t, tcs.State)
+		_, tcs.State.Result.Error = tcs.SUT.Exec(tcs.State.ctx,
+			"INSERT INTO regular.instance (id, value) VALUES ($1, current_setting('app.user_id'))",
+			tcs.State.Record.ID,
+		)
+	})
+
+	t.Run("should be able to roll back transaction when settings can't be applied", func(t *testing.T) {
+		tcs := suite.Case(t)
+		tcs.Given(ArrangeContext, ArrangeRecord, ArrangeSessionSettings, ArrangeExpectedError).
+			When(InjectPoolMock(tcs.SUT)).
+			Then(AssertExpectError)
+
+		tx := NewMockTx(t)
+		tcs.Deps.MockPool.EXPECT().Begin(tcs.State.ctx).Return(tx, nil)
+		tx.EXPECT().Exec(tcs.State.ctx, settingsQuery, mock.Anything, mock.Anything).
+			Return(pgconn.CommandTag{}, tcs.State.ExpectError)
+		tx.EXPECT().Rollback(tcs.State.ctx).Return(nil)
+
+		_, tcs.State.Result.Error = tcs.SUT.Exec(tcs.State.ctx, "SELECT 1")
+	})
+}
+
+func TestInstance_Lifecycle(t *testing.T) {
+	t.Run("should be able to ping pool and return its stats", func(t *testing.T) {
+		tcs := suite.Case(t)
+		tcs.Given(ArrangeContext).Then(AssertNoError)
+
+		var pings lifecycle.Pings
+		pings, tcs.State.Result.Error = tcs.SUT.Ping(tcs.State.ctx)
+		require.Len(t, pings, 1)
+		require.Equal(t, lifecycle.RoleNode, pings[0].Node.Role)
+
+		stats := tcs.SUT.Stats()
+		require.Len(t, stats, 1)
+		require.Positive(t, stats.Total().MaxConns)
+	})
+
+	t.Run("should be able to fail ping when pool can't be pinged", func(t *testing.T) {
+		tcs := suite.Case(t)
+		tcs.Given(ArrangeContext, ArrangeAsExpectError(lifecycle.ErrPingNotSupported)).
+			When(InjectPoolMock(tcs.SUT)).
+			Then(AssertExpectError)
+
+		_, tcs.State.Result.Error = tcs.SUT.Ping(tcs.State.ctx)
+		require.Empty(t, tcs.SUT.Stats())
+		tcs.SUT.Close()
+	})
+}
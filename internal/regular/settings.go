@@ -0,0 +1,62 @@
+package regular
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/godepo/elephant/internal/pkg/pgcontext"
+	"github.com/godepo/elephant/internal/pkg/txscope"
+	"github.com/jackc/pgx/v5"
+)
+
+// settingsQuery applies every session setting local to transaction in one round trip.
+const settingsQuery = "SELECT set_config(name, value, true) FROM unnest($1::text[], $2::text[]) AS setting(name, value)"
+
+// applySettings applies session settings from context to tx, tx is rolled back when they can't be applied.
+func applySettings(ctx context.Context, tx pgx.Tx) (pgx.Tx, error) {
+	settings, ok := pgcontext.SessionSettingsFrom(ctx)
+	if !ok {
+		return tx, nil
+	}
+	names := slices.Sorted(maps.Keys(settings))
+	values := make([]string, len(names))
+	for i, name := range names {
+		values[i] = settings[name]
+	}
+	if _, err := tx.Exec(ctx, settingsQuery, names, values); err != nil {
+		_ = tx.Rollback(ctx)
+		return nil, fmt.Errorf("can't apply session settings: %w", err)
+	}
+	return tx, nil
+}
+
+// implicit begins transaction of statement outside transaction when session settings are in context, so
+// settings never outlive statement on pooled connection. It returns false when statement must run as is.
+func (ins *Instance) implicit(ctx context.Context) (pgx.Tx, bool, error) {
+	if _, ok := pgcontext.TransactionFrom(ctx); ok {
+		return nil, false, nil
+	}
+	if _, ok := pgcontext.SessionSettingsFrom(ctx); !ok {
+		return nil, false, nil
+	}
+	tx, err := ins.Begin(ctx)
+	return tx, true, err
+}
+
+// implicitRow runs query in implicit transaction when row is scanned.
+type implicitRow struct {
+	ctx   context.Context
+	ins   *Instance
+	query string
+	args  []any
+}
+
+func (r implicitRow) Scan(dest ...any) error {
+	tx, err := r.ins.Begin(r.ctx)
+	if err != nil {
+		return err
+	}
+	return txscope.Finish(r.ctx, tx, tx.QueryRow(r.ctx, r.query, r.args...).Scan(dest...))
+}
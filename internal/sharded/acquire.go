@@ -0,0 +1,21 @@
+package sharded
+
+import (
+	"context"
+
+	"github.com/godepo/elephant/internal/pkg/passthrough"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Acquire takes dedicated connection at shard picked from context.
+func (s *Hive) Acquire(ctx context.Context) (*pgxpool.Conn, error) {
+	shard, err := s.getShard(ctx)
+	if err != nil {
+		return nil, err
+	}
+	acq, err := passthrough.AsAcquirer(shard)
+	if err != nil {
+		return nil, err
+	}
+	return acq.Acquire(ctx)
+}
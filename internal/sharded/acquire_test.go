@@ -0,0 +1,49 @@
+package sharded
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/godepo/elephant/internal/pkg/passthrough"
+	"github.com/godepo/elephant/internal/pkg/pgcontext"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type acquireShard struct {
+	*MockPool
+	*MockAcquirer
+}
+
+func TestHive_Acquire(t *testing.T) {
+	picker := func(context.Context, string) uint { return 1 }
+
+	t.Run("should be able to acquire connection at picked shard", func(t *testing.T) {
+		acq := NewMockAcquirer(t)
+		hive := New([]Pool{NewMockPool(t), acquireShard{MockPool: NewMockPool(t), MockAcquirer: acq}}, picker)
+		ctx := pgcontext.With(context.Background(), pgcontext.WithShardingKey("user"))
+		expErr := errors.New(uuid.NewString())
+		acq.EXPECT().Acquire(ctx).Return(nil, expErr)
+
+		conn, err := hive.Acquire(ctx)
+		require.ErrorIs(t, err, expErr)
+		assert.Nil(t, conn)
+	})
+
+	t.Run("should be able to fail when shard can't acquire connection", func(t *testing.T) {
+		hive := New([]Pool{NewMockPool(t), NewMockPool(t)}, picker)
+		ctx := pgcontext.With(context.Background(), pgcontext.WithShardID(0))
+
+		_, err := hive.Acquire(ctx)
+		require.ErrorIs(t, err, passthrough.ErrAcquireNotSupported)
+	})
+
+	t.Run("should be able to fail when could not pick shard", func(t *testing.T) {
+		hive := New([]Pool{NewMockPool(t)}, picker)
+
+		_, err := hive.Acquire(context.Background())
+		require.ErrorIs(t, err, ErrCouldNotPickShard)
+	})
+}
@@ -0,0 +1,57 @@
+package sharded
+
+import (
+	"context"
+	"testing"
+
+	"github.com/godepo/elephant/internal/pkg/pgcontext"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/require"
+)
+
+type guardedPool struct {
+	*MockPool
+	available bool
+}
+
+func (p guardedPool) Available() bool {
+	return p.available
+}
+
+type guardedBulkPool struct {
+	guardedPool
+	*MockBulkPool
+}
+
+func TestSharded_Availability(t *testing.T) {
+	t.Run("should be able to fail fast when shard is unavailable", func(t *testing.T) {
+		up := guardedPool{MockPool: NewMockPool(t), available: true}
+		hive := New([]Pool{up, guardedPool{MockPool: NewMockPool(t)}}, func(context.Context, string) uint { return 1 })
+		ctx := pgcontext.With(context.Background(), pgcontext.WithShardingKey("key"))
+		up.EXPECT().Exec(pgcontext.With(context.Background(), pgcontext.WithShardID(0)), "SELECT 1").
+			Return(pgconn.CommandTag{}, nil)
+
+		_, err := hive.Query(ctx, "SELECT 1")
+		require.ErrorIs(t, err, ErrShardUnavailable)
+		require.ErrorIs(t, hive.QueryRow(ctx, "SELECT 1").Scan(), ErrShardUnavailable)
+		_, err = hive.Exec(ctx, "SELECT 1")
+		require.ErrorIs(t, err, ErrShardUnavailable)
+		_, err = hive.Begin(ctx)
+		require.ErrorIs(t, err, ErrShardUnavailable)
+		require.ErrorIs(t, hive.Transactional(ctx, func(context.Context) error { return nil }), ErrShardUnavailable)
+
+		_, err = hive.Exec(pgcontext.With(context.Background(), pgcontext.WithShardID(0)), "SELECT 1")
+		require.NoError(t, err)
+	})
+
+	t.Run("should be able to fail bulk part of unavailable shard", func(t *testing.T) {
+		shard := guardedBulkPool{guardedPool: guardedPool{MockPool: NewMockPool(t)}, MockBulkPool: NewMockBulkPool(t)}
+		hive := New([]Pool{shard}, func(context.Context, string) uint { return 0 })
+
+		res, err := hive.CopyFromSharded(context.Background(), pgx.Identifier{"t"}, []string{"id"}, [][]any{{1}},
+			func([]any) string { return "key" })
+		require.ErrorIs(t, err, ErrShardUnavailable)
+		require.ErrorIs(t, res[0].Error, ErrShardUnavailable)
+	})
+}
@@ -0,0 +1,163 @@
+package sharded
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/godepo/elephant/internal/pkg/passthrough"
+	"github.com/godepo/elephant/internal/pkg/pgcontext"
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrBulkInTransaction = errors.New("sharded bulk operation can't run inside context transaction")
+	ErrUnknownShard      = errors.New("picked shard is out of hive")
+)
+
+// RowKey returns sharding key for row passed to CopyFromSharded.
+type RowKey func(row []any) string
+
+// QueryKey returns sharding key for query queued at batch passed to SendBatchSharded.
+type QueryKey func(query *pgx.QueuedQuery) string
+
+// ShardResult describes outcome of bulk operation at single shard. Rows contains count of copied rows
+// for CopyFromSharded and count of affected rows by queries without callbacks for SendBatchSharded.
+type ShardResult struct {
+	Rows  int64
+	Error error
+}
+
+// BulkResults contains results of bulk operation for every shard which received part of input.
+type BulkResults map[uint]ShardResult
+
+// Rows returns total count of rows processed by all shards.
+func (res BulkResults) Rows() int64 {
+	var total int64
+	for _, shard := range res {
+		total += shard.Rows
+	}
+	return total
+}
+
+// Err joins errors of all failed shards.
+func (res BulkResults) Err() error {
+	errs := make([]error, 0, len(res))
+	for id, shard := range res {
+		if shard.Error != nil {
+			errs = append(errs, fmt.Errorf("shard [%d]: %w", id, shard.Error))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// CopyFromSharded splits rows by sharding key and copies every part to own shard concurrently.
+func (s *Hive) CopyFromSharded(
+	ctx context.Context,
+	tableName pgx.Identifier,
+	columnNames []string,
+	rows [][]any,
+	keyFn RowKey,
+) (BulkResults, error) {
+	parts, err := partition(ctx, s, rows, keyFn)
+	if err != nil {
+		return nil, err
+	}
+
+	res := runBulk(ctx, s, parts, func(ctx context.Context, bulk passthrough.BulkPool, rows [][]any) (int64, error) {
+		return bulk.CopyFrom(ctx, tableName, columnNames, pgx.CopyFromRows(rows))
+	})
+	return res, res.Err()
+}
+
+// SendBatchSharded splits queued queries by sharding key and sends every part as own batch to
+// shard concurrently. Callbacks registered at queued queries are called as usual.
+func (s *Hive) SendBatchSharded(ctx context.Context, batch *pgx.Batch, keyFn QueryKey) (BulkResults, error) {
+	parts, err := partition(ctx, s, batch.QueuedQueries, keyFn)
+	if err != nil {
+		return nil, err
+	}
+
+	res := runBulk(ctx, s, parts, func(ctx context.Context, bulk passthrough.BulkPool, queries []*pgx.QueuedQuery) (int64, error) {
+		return sendBatch(ctx, bulk, queries)
+	})
+	return res, res.Err()
+}
+
+func sendBatch(ctx context.Context, bulk passthrough.BulkPool, queries []*pgx.QueuedQuery) (affected int64, out error) {
+	results := bulk.SendBatch(ctx, &pgx.Batch{QueuedQueries: queries})
+	defer func() {
+		if err := results.Close(); err != nil && out == nil {
+			out = err
+		}
+	}()
+
+	for _, query := range queries {
+		if query.Fn != nil {
+			if err := query.Fn(results); err != nil {
+				return affected, err
+			}
+			continue
+		}
+		tag, err := results.Exec()
+		if err != nil {
+			return affected, err
+		}
+		affected += tag.RowsAffected()
+	}
+	return affected, nil
+}
+
+func partition[T any](ctx context.Context, s *Hive, items []T, keyFn func(T) string) (map[uint][]T, error) {
+	if _, ok := pgcontext.TransactionFrom(ctx); ok {
+		return nil, ErrBulkInTransaction
+	}
+
+	parts := make(map[uint][]T)
+	for _, item := range items {
+		shardID := s.shardPicker(ctx, keyFn(item))
+		if shardID >= uint(len(s.shards)) {
+			return nil, fmt.Errorf("%w: %d", ErrUnknownShard, shardID)
+		}
+		parts[shardID] = append(parts[shardID], item)
+	}
+	return parts, nil
+}
+
+func runBulk[T any](
+	ctx context.Context,
+	s *Hive,
+	parts map[uint][]T,
+	fn func(ctx context.Context, bulk passthrough.BulkPool, items []T) (int64, error),
+) BulkResults {
+	var (
+		wg  sync.WaitGroup
+		mu  sync.Mutex
+		res = make(BulkResults, len(parts))
+	)
+
+	for shardID, items := range parts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			var out ShardResult
+			shard := s.shards[shardID]
+			if av, ok := shard.(Availability); ok && !av.Available() {
+				out.Error = fmt.Errorf("%w: %d", ErrShardUnavailable, shardID)
+			} else if bulk, err := passthrough.AsBulk(shard); err != nil {
+				out.Error = err
+			} else {
+				shardCtx := pgcontext.With(ctx, pgcontext.WithShardID(shardID))
+				out.Rows, out.Error = fn(shardCtx, bulk, items)
+			}
+
+			mu.Lock()
+			res[shardID] = out
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	return res
+}
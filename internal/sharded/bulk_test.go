@@ -0,0 +1,204 @@
+package sharded
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/godepo/elephant/internal/pkg/passthrough"
+	"github.com/godepo/elephant/internal/pkg/pgcontext"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jaswdr/faker/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type bulkShard struct {
+	*MockPool
+	*MockBulkPool
+}
+
+func newBulkHive(t *testing.T, size int) (*Hive, []*MockBulkPool) {
+	t.Helper()
+	shards := make([]Pool, 0, size)
+	bulks := make([]*MockBulkPool, 0, size)
+	for range size {
+		bulk := NewMockBulkPool(t)
+		bulks = append(bulks, bulk)
+		shards = append(shards, bulkShard{MockPool: NewMockPool(t), MockBulkPool: bulk})
+	}
+	return New(shards, func(_ context.Context, key string) uint {
+		id, err := strconv.Atoi(key)
+		require.NoError(t, err)
+		return uint(id)
+	}), bulks
+}
+
+func rowKey(row []any) string {
+	return strconv.Itoa(row[0].(int))
+}
+
+func queryKey(query *pgx.QueuedQuery) string {
+	return strconv.Itoa(query.Arguments[0].(int))
+}
+
+func TestHive_CopyFromSharded(t *testing.T) {
+	table := pgx.Identifier{"users"}
+	columns := []string{"shard", "name"}
+
+	t.Run("should be able to split rows by shards", func(t *testing.T) {
+		hive, bulks := newBulkHive(t, 3)
+		rows := [][]any{{0, "first"}, {2, "second"}, {0, "third"}}
+
+		bulks[0].EXPECT().
+			CopyFrom(mock.Anything, table, columns, pgx.CopyFromRows([][]any{rows[0], rows[2]})).
+			RunAndReturn(func(
+				ctx context.Context,
+				_ pgx.Identifier,
+				_ []string,
+				_ pgx.CopyFromSource,
+			) (int64, error) {
+				id, ok := pgcontext.ShardIDFrom(ctx)
+				assert.True(t, ok)
+				assert.Equal(t, uint(0), id)
+				return 2, nil
+			})
+		bulks[2].EXPECT().
+			CopyFrom(mock.Anything, table, columns, pgx.CopyFromRows([][]any{rows[1]})).
+			Return(1, nil)
+
+		res, err := hive.CopyFromSharded(context.Background(), table, columns, rows, rowKey)
+		require.NoError(t, err)
+		assert.Equal(t, BulkResults{0: {Rows: 2}, 2: {Rows: 1}}, res)
+		assert.Equal(t, int64(3), res.Rows())
+	})
+
+	t.Run("should be able to return per shard errors", func(t *testing.T) {
+		hive, bulks := newBulkHive(t, 2)
+		expErr := errors.New(faker.New().RandomStringWithLength(10))
+		rows := [][]any{{0, "first"}, {1, "second"}}
+
+		bulks[0].EXPECT().CopyFrom(mock.Anything, table, columns, mock.Anything).Return(1, nil)
+		bulks[1].EXPECT().CopyFrom(mock.Anything, table, columns, mock.Anything).Return(0, expErr)
+
+		res, err := hive.CopyFromSharded(context.Background(), table, columns, rows, rowKey)
+		require.ErrorIs(t, err, expErr)
+		assert.Equal(t, ShardResult{Rows: 1}, res[0])
+		assert.ErrorIs(t, res[1].Error, expErr)
+	})
+
+	t.Run("should be able to fail when shard does not support bulk operations", func(t *testing.T) {
+		hive := New([]Pool{NewMockPool(t)}, func(context.Context, string) uint { return 0 })
+
+		res, err := hive.CopyFromSharded(context.Background(), table, columns, [][]any{{0, "first"}}, rowKey)
+		require.ErrorIs(t, err, passthrough.ErrBulkNotSupported)
+		assert.ErrorIs(t, res[0].Error, passthrough.ErrBulkNotSupported)
+	})
+
+	t.Run("should be able to fail when picked unknown shard", func(t *testing.T) {
+		hive, _ := newBulkHive(t, 1)
+
+		res, err := hive.CopyFromSharded(context.Background(), table, columns, [][]any{{5, "first"}}, rowKey)
+		require.ErrorIs(t, err, ErrUnknownShard)
+		assert.Nil(t, res)
+	})
+
+	t.Run("should be able to fail when transaction in context", func(t *testing.T) {
+		hive, _ := newBulkHive(t, 1)
+		ctx := pgcontext.With(context.Background(), pgcontext.WithTransaction(NewMockTx(t)))
+
+		res, err := hive.CopyFromSharded(ctx, table, columns, [][]any{{0, "first"}}, rowKey)
+		require.ErrorIs(t, err, ErrBulkInTransaction)
+		assert.Nil(t, res)
+	})
+}
+
+func TestHive_SendBatchSharded(t *testing.T) {
+	const query = "INSERT INTO users (shard) VALUES ($1)"
+
+	t.Run("should be able to split batch by shards", func(t *testing.T) {
+		hive, bulks := newBulkHive(t, 2)
+		first, second := NewMockBatchResults(t), NewMockBatchResults(t)
+		batch := &pgx.Batch{}
+		batch.Queue(query, 0)
+		batch.Queue(query, 1)
+		var called bool
+		batch.Queue(query, 1).Exec(func(ct pgconn.CommandTag) error {
+			called = true
+			return nil
+		})
+
+		bulks[0].EXPECT().SendBatch(mock.Anything, &pgx.Batch{QueuedQueries: batch.QueuedQueries[:1]}).Return(first)
+		bulks[1].EXPECT().SendBatch(mock.Anything, mock.Anything).Return(second)
+		first.EXPECT().Exec().Return(pgconn.NewCommandTag("INSERT 0 1"), nil).Once()
+		first.EXPECT().Close().Return(nil)
+		second.EXPECT().Exec().Return(pgconn.NewCommandTag("INSERT 0 1"), nil).Twice()
+		second.EXPECT().Close().Return(nil)
+
+		res, err := hive.SendBatchSharded(context.Background(), batch, queryKey)
+		require.NoError(t, err)
+		assert.Equal(t, BulkResults{0: {Rows: 1}, 1: {Rows: 1}}, res)
+		assert.True(t, called)
+	})
+
+	t.Run("should be able to return error of query", func(t *testing.T) {
+		hive, bulks := newBulkHive(t, 1)
+		results := NewMockBatchResults(t)
+		expErr := errors.New(faker.New().RandomStringWithLength(10))
+		batch := &pgx.Batch{}
+		batch.Queue(query, 0)
+
+		bulks[0].EXPECT().SendBatch(mock.Anything, mock.Anything).Return(results)
+		results.EXPECT().Exec().Return(pgconn.CommandTag{}, expErr)
+		results.EXPECT().Close().Return(expErr)
+
+		res, err := hive.SendBatchSharded(context.Background(), batch, queryKey)
+		require.ErrorIs(t, err, expErr)
+		assert.ErrorIs(t, res[0].Error, expErr)
+	})
+
+	t.Run("should be able to return error of callback", func(t *testing.T) {
+		hive, bulks := newBulkHive(t, 1)
+		results := NewMockBatchResults(t)
+		expErr := errors.New(faker.New().RandomStringWithLength(10))
+		batch := &pgx.Batch{}
+		batch.Queue(query, 0).Exec(func(pgconn.CommandTag) error {
+			return expErr
+		})
+
+		bulks[0].EXPECT().SendBatch(mock.Anything, mock.Anything).Return(results)
+		results.EXPECT().Exec().Return(pgconn.NewCommandTag("INSERT 0 1"), nil)
+		results.EXPECT().Close().Return(nil)
+
+		_, err := hive.SendBatchSharded(context.Background(), batch, queryKey)
+		require.ErrorIs(t, err, expErr)
+	})
+
+	t.Run("should be able to return error at close", func(t *testing.T) {
+		hive, bulks := newBulkHive(t, 1)
+		results := NewMockBatchResults(t)
+		expErr := errors.New(faker.New().RandomStringWithLength(10))
+		batch := &pgx.Batch{}
+		batch.Queue(query, 0)
+
+		bulks[0].EXPECT().SendBatch(mock.Anything, mock.Anything).Return(results)
+		results.EXPECT().Exec().Return(pgconn.NewCommandTag("INSERT 0 1"), nil)
+		results.EXPECT().Close().Return(expErr)
+
+		res, err := hive.SendBatchSharded(context.Background(), batch, queryKey)
+		require.ErrorIs(t, err, expErr)
+		assert.Equal(t, int64(1), res[0].Rows)
+	})
+
+	t.Run("should be able to fail when transaction in context", func(t *testing.T) {
+		hive, _ := newBulkHive(t, 1)
+		ctx := pgcontext.With(context.Background(), pgcontext.WithTransaction(NewMockTx(t)))
+
+		res, err := hive.SendBatchSharded(ctx, &pgx.Batch{}, queryKey)
+		require.ErrorIs(t, err, ErrBulkInTransaction)
+		assert.Nil(t, res)
+	})
+}
@@ -0,0 +1,27 @@
+package sharded
+
+import (
+	"context"
+
+	"github.com/godepo/elephant/internal/pkg/lifecycle"
+)
+
+func inShard(ix int, node lifecycle.Node) lifecycle.Node {
+	return node.InShard(uint(ix))
+}
+
+// Close closes every shard.
+func (s *Hive) Close() {
+	lifecycle.CloseMembers(s.shards)
+}
+
+// Ping checks nodes of every shard concurrently, error joins errors of failed nodes.
+func (s *Hive) Ping(ctx context.Context) (lifecycle.Pings, error) {
+	pings := lifecycle.PingMembers(ctx, s.shards, inShard)
+	return pings, pings.Err()
+}
+
+// Stats returns stats of nodes of every shard, they are summed up per shard by ByShard.
+func (s *Hive) Stats() lifecycle.Stats {
+	return lifecycle.MembersStats(s.shards, inShard)
+}
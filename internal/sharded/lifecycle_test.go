@@ -0,0 +1,75 @@
+package sharded
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/godepo/elephant/internal/pkg/lifecycle"
+	"github.com/godepo/elephant/internal/pkg/monads"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type shard struct {
+	*MockPool
+	nodes  []lifecycle.Node
+	err    error
+	closed bool
+}
+
+func (s *shard) Ping(context.Context) (lifecycle.Pings, error) {
+	var pings lifecycle.Pings
+	for _, node := range s.nodes {
+		pings = append(pings, lifecycle.Ping{Node: node, Err: s.err})
+	}
+	return pings, pings.Err()
+}
+
+func (s *shard) Stats() lifecycle.Stats {
+	var stats lifecycle.Stats
+	for _, node := range s.nodes {
+		stats = append(stats, lifecycle.NodeStat{Node: node, Stat: lifecycle.Stat{MaxConns: 10}})
+	}
+	return stats
+}
+
+func (s *shard) Close() {
+	s.closed = true
+}
+
+func TestHive_Lifecycle(t *testing.T) {
+	expErr := errors.New(uuid.NewString())
+	single := &shard{MockPool: NewMockPool(t), nodes: []lifecycle.Node{{Role: lifecycle.RoleNode}}}
+	cluster := &shard{
+		MockPool: NewMockPool(t),
+		nodes:    []lifecycle.Node{{Role: lifecycle.RoleLeader}, {Role: lifecycle.RoleFollower}},
+		err:      expErr,
+	}
+	hive := New([]Pool{single, cluster}, HashPicker(2))
+
+	t.Run("should be able to ping nodes of every shard", func(t *testing.T) {
+		pings, err := hive.Ping(context.Background())
+		require.ErrorIs(t, err, expErr)
+		assert.ErrorContains(t, err, "shard [1] follower [0]")
+
+		require.Len(t, pings, 3)
+		assert.NoError(t, pings[0].Err)
+		assert.Equal(t, monads.OptionalOf(uint(0)), pings[0].Node.Shard)
+		assert.Equal(t, lifecycle.RoleLeader, pings[1].Node.Role)
+		assert.Equal(t, monads.OptionalOf(uint(1)), pings[1].Node.Shard)
+	})
+
+	t.Run("should be able to sum up stats per shard", func(t *testing.T) {
+		stats := hive.Stats()
+		require.Len(t, stats, 3)
+		assert.Equal(t, map[uint]lifecycle.Stat{0: {MaxConns: 10}, 1: {MaxConns: 20}}, stats.ByShard())
+	})
+
+	t.Run("should be able to close every shard", func(t *testing.T) {
+		hive.Close()
+		assert.True(t, single.closed)
+		assert.True(t, cluster.closed)
+	})
+}
@@ -0,0 +1,33 @@
+package sharded
+
+import (
+	"context"
+
+	"github.com/godepo/elephant/internal/pkg/passthrough"
+)
+
+func (s *Hive) advisoryLocker(ctx context.Context) (passthrough.AdvisoryLocker, error) {
+	shard, err := s.getShard(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return passthrough.AsLocker(shard)
+}
+
+// WithAdvisoryLock runs fn under advisory lock taken at shard picked from context.
+func (s *Hive) WithAdvisoryLock(ctx context.Context, key string, fn func(ctx context.Context) error) error {
+	lck, err := s.advisoryLocker(ctx)
+	if err != nil {
+		return err
+	}
+	return lck.WithAdvisoryLock(ctx, key, fn)
+}
+
+// WithTryAdvisoryLock runs fn under advisory lock taken at shard picked from context without waiting.
+func (s *Hive) WithTryAdvisoryLock(ctx context.Context, key string, fn func(ctx context.Context) error) error {
+	lck, err := s.advisoryLocker(ctx)
+	if err != nil {
+		return err
+	}
+	return lck.WithTryAdvisoryLock(ctx, key, fn)
+}
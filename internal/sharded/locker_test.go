@@ -0,0 +1,82 @@
+package sharded
+
+import (
+	"context"
+	"testing"
+
+	"github.com/godepo/elephant/internal/pkg/passthrough"
+	"github.com/godepo/elephant/internal/pkg/pgcontext"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type lockShard struct {
+	*MockPool
+	*MockAdvisoryLocker
+}
+
+func runFn(ctx context.Context, _ string, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func TestHive_WithAdvisoryLock(t *testing.T) {
+	picker := func(context.Context, string) uint { return 1 }
+
+	t.Run("should be able to lock at shard picked by sharding key", func(t *testing.T) {
+		lck := NewMockAdvisoryLocker(t)
+		hive := New([]Pool{NewMockPool(t), lockShard{MockPool: NewMockPool(t), MockAdvisoryLocker: lck}}, picker)
+		ctx := pgcontext.With(context.Background(), pgcontext.WithShardingKey("user"))
+		lck.EXPECT().WithAdvisoryLock(ctx, "jobs", mock.Anything).RunAndReturn(runFn)
+
+		var called bool
+		require.NoError(t, hive.WithAdvisoryLock(ctx, "jobs", func(context.Context) error {
+			called = true
+			return nil
+		}))
+		assert.True(t, called)
+	})
+
+	t.Run("should be able to fail when shard does not support locks", func(t *testing.T) {
+		hive := New([]Pool{NewMockPool(t), NewMockPool(t)}, picker)
+		ctx := pgcontext.With(context.Background(), pgcontext.WithShardingKey("user"))
+
+		err := hive.WithAdvisoryLock(ctx, "jobs", func(context.Context) error {
+			return nil
+		})
+		require.ErrorIs(t, err, passthrough.ErrLockNotSupported)
+	})
+
+	t.Run("should be able to fail when could not pick shard", func(t *testing.T) {
+		hive := New([]Pool{NewMockPool(t)}, picker)
+
+		err := hive.WithAdvisoryLock(context.Background(), "jobs", func(context.Context) error {
+			return nil
+		})
+		require.ErrorIs(t, err, ErrCouldNotPickShard)
+	})
+}
+
+func TestHive_WithTryAdvisoryLock(t *testing.T) {
+	picker := func(context.Context, string) uint { return 0 }
+
+	t.Run("should be able to try lock at picked shard", func(t *testing.T) {
+		lck := NewMockAdvisoryLocker(t)
+		hive := New([]Pool{lockShard{MockPool: NewMockPool(t), MockAdvisoryLocker: lck}}, picker)
+		ctx := pgcontext.With(context.Background(), pgcontext.WithShardID(0))
+		lck.EXPECT().WithTryAdvisoryLock(ctx, "jobs", mock.Anything).RunAndReturn(runFn)
+
+		require.NoError(t, hive.WithTryAdvisoryLock(ctx, "jobs", func(context.Context) error {
+			return nil
+		}))
+	})
+
+	t.Run("should be able to fail when could not pick shard", func(t *testing.T) {
+		hive := New([]Pool{NewMockPool(t)}, picker)
+
+		err := hive.WithTryAdvisoryLock(context.Background(), "jobs", func(context.Context) error {
+			return nil
+		})
+		require.ErrorIs(t, err, ErrCouldNotPickShard)
+	})
+}
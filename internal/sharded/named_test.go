@@ -0,0 +1,52 @@
+package sharded
+
+import (
+	"context"
+	"testing"
+
+	"github.com/godepo/elephant/internal/pkg/named"
+	"github.com/godepo/elephant/internal/pkg/pgcontext"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSharded_NamedParameters(t *testing.T) {
+	type user struct {
+		ID   int64 `db:"user_id"`
+		Name string
+	}
+	params := user{ID: 3, Name: "bob"}
+	pick := func(context.Context, string) uint { return 0 }
+
+	t.Run("should be able to pass positional query to shard", func(t *testing.T) {
+		shard := NewMockPool(t)
+		rows, row := NewMockRows(t), NewMockRow(t)
+		hive := New([]Pool{NewMockPool(t), shard}, pick)
+		ctx := pgcontext.With(context.Background(), pgcontext.WithShardID(1))
+		tag := pgconn.NewCommandTag("DELETE 1")
+
+		shard.EXPECT().Query(ctx, "SELECT name FROM users WHERE id = $1", []any{int64(3)}).Return(rows, nil)
+		shard.EXPECT().QueryRow(ctx, "SELECT $1", []any{"bob"}).Return(row)
+		shard.EXPECT().Exec(ctx, "DELETE FROM users WHERE id = $1", []any{int64(3)}).Return(tag, nil)
+
+		out, err := hive.Query(ctx, "SELECT name FROM users WHERE id = :user_id", params)
+		require.NoError(t, err)
+		assert.Equal(t, rows, out)
+		assert.Equal(t, row, hive.QueryRow(ctx, "SELECT :name", params))
+		res, err := hive.Exec(ctx, "DELETE FROM users WHERE id = @user_id", params)
+		require.NoError(t, err)
+		assert.Equal(t, tag, res)
+	})
+
+	t.Run("should be able to fail before shard is picked", func(t *testing.T) {
+		hive := New([]Pool{NewMockPool(t)}, pick)
+		ctx := context.Background()
+
+		_, err := hive.Query(ctx, "SELECT :id", params)
+		require.ErrorIs(t, err, named.ErrMissingValue)
+		require.ErrorIs(t, hive.QueryRow(ctx, "SELECT :id", params).Scan(), named.ErrMissingValue)
+		_, err = hive.Exec(ctx, "SELECT :id", params)
+		require.ErrorIs(t, err, named.ErrMissingValue)
+	})
+}
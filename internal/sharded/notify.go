@@ -0,0 +1,28 @@
+package sharded
+
+import (
+	"context"
+
+	"github.com/godepo/elephant/internal/notify"
+	"github.com/godepo/elephant/internal/pkg/passthrough"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Listen subscribes to channel at shard picked from context.
+func (s *Hive) Listen(ctx context.Context, channel string) (<-chan pgconn.Notification, error) {
+	shard, err := s.getShard(ctx)
+	if err != nil {
+		return nil, err
+	}
+	lst, err := passthrough.AsListener(shard)
+	if err != nil {
+		return nil, err
+	}
+	return lst.Listen(ctx, channel)
+}
+
+// Notify sends notification to channel at shard picked from context.
+func (s *Hive) Notify(ctx context.Context, channel, payload string) error {
+	_, err := s.Exec(ctx, notify.Query, channel, payload)
+	return err
+}
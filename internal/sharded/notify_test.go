@@ -0,0 +1,60 @@
+package sharded
+
+import (
+	"context"
+	"testing"
+
+	"github.com/godepo/elephant/internal/notify"
+	"github.com/godepo/elephant/internal/pkg/passthrough"
+	"github.com/godepo/elephant/internal/pkg/pgcontext"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type listenShard struct {
+	*MockPool
+	*MockListener
+}
+
+func TestHive_Listen(t *testing.T) {
+	picker := func(context.Context, string) uint { return 0 }
+
+	t.Run("should be able to listen at picked shard", func(t *testing.T) {
+		lst := NewMockListener(t)
+		hive := New([]Pool{NewMockPool(t), listenShard{MockPool: NewMockPool(t), MockListener: lst}}, picker)
+		ctx := pgcontext.With(context.Background(), pgcontext.WithShardID(1))
+		exp := make(chan pgconn.Notification)
+		lst.EXPECT().Listen(ctx, "events").Return(exp, nil)
+
+		ch, err := hive.Listen(ctx, "events")
+		require.NoError(t, err)
+		assert.Equal(t, (<-chan pgconn.Notification)(exp), ch)
+	})
+
+	t.Run("should be able to fail when shard does not support listen", func(t *testing.T) {
+		hive := New([]Pool{NewMockPool(t)}, picker)
+		ctx := pgcontext.With(context.Background(), pgcontext.WithShardingKey("key"))
+
+		_, err := hive.Listen(ctx, "events")
+		require.ErrorIs(t, err, passthrough.ErrListenNotSupported)
+	})
+
+	t.Run("should be able to fail when could not pick shard", func(t *testing.T) {
+		hive := New([]Pool{NewMockPool(t)}, picker)
+
+		_, err := hive.Listen(context.Background(), "events")
+		require.ErrorIs(t, err, ErrCouldNotPickShard)
+	})
+}
+
+func TestHive_Notify(t *testing.T) {
+	t.Run("should be able to notify at picked shard", func(t *testing.T) {
+		shard := NewMockPool(t)
+		hive := New([]Pool{shard}, func(context.Context, string) uint { return 0 })
+		ctx := pgcontext.With(context.Background(), pgcontext.WithShardID(0))
+		shard.EXPECT().Exec(ctx, notify.Query, []any{"events", "payload"}).Return(pgconn.CommandTag{}, nil)
+
+		require.NoError(t, hive.Notify(ctx, "events", "payload"))
+	})
+}
@@ -0,0 +1,36 @@
+package sharded
+
+import (
+	"context"
+	"hash/fnv"
+)
+
+// HashPicker picks shard by FNV-1a hash of sharding key modulo count of shards.
+func HashPicker(shards uint) Picker {
+	return func(_ context.Context, key string) uint {
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(key))
+		return uint(h.Sum64() % uint64(shards))
+	}
+}
+
+// JumpPicker picks shard by jump consistent hash of sharding key, so only 1/n of keys move to new shard when
+// count of shards grows to n.
+func JumpPicker(shards uint) Picker {
+	return func(_ context.Context, key string) uint {
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(key))
+		return uint(jump(h.Sum64(), int64(shards)))
+	}
+}
+
+// jump is jump consistent hash of Lamping and Veach.
+func jump(key uint64, buckets int64) int64 {
+	var b, j int64 = -1, 0
+	for j < buckets {
+		b = j
+		key = key*2862933555777941757 + 1
+		j = int64(float64(b+1) * (float64(int64(1)<<31) / float64((key>>33)+1)))
+	}
+	return b
+}
@@ -0,0 +1,45 @@
+package sharded
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPickers(t *testing.T) {
+	pickers := map[string]func(shards uint) Picker{
+		"hash": HashPicker,
+		"jump": JumpPicker,
+	}
+	for name, picker := range pickers {
+		t.Run("should be able to pick same shard of key by "+name, func(t *testing.T) {
+			pick := picker(4)
+			ctx := context.Background()
+			seen := map[uint]bool{}
+			for i := range 100 {
+				key := strconv.Itoa(i)
+				id := pick(ctx, key)
+				assert.Less(t, id, uint(4))
+				assert.Equal(t, id, pick(ctx, key))
+				seen[id] = true
+			}
+			assert.Len(t, seen, 4)
+		})
+	}
+
+	t.Run("should be able to keep most keys at their shards when shard is added by jump", func(t *testing.T) {
+		before, after := JumpPicker(4), JumpPicker(5)
+		ctx := context.Background()
+		moved := 0
+		for i := range 1000 {
+			key := strconv.Itoa(i)
+			if b, a := before(ctx, key), after(ctx, key); b != a {
+				assert.Equal(t, uint(4), a)
+				moved++
+			}
+		}
+		assert.Less(t, moved, 300)
+	})
+}
@@ -4,20 +4,24 @@ package sharded
 import (
 	"context"
 	"errors"
+	"fmt"
 
+	"github.com/godepo/elephant/internal/pkg/failure"
+	"github.com/godepo/elephant/internal/pkg/named"
 	"github.com/godepo/elephant/internal/pkg/pgcontext"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 )
 
-var ErrCouldNotPickShard = errors.New("could not get shardID or shardingKey from context")
-
-type failedRow struct {
-	err error
-}
+var (
+	ErrCouldNotPickShard = errors.New("could not get shardID or shardingKey from context")
+	ErrShardUnavailable  = errors.New("shard is unavailable")
+)
 
-func (r failedRow) Scan(_ ...any) error {
-	return r.err
+// Availability is implemented by shards which can be temporarily unavailable, like pools guarded by circuit
+// breaker. Calls to unavailable shard fail fast with ErrShardUnavailable.
+type Availability interface {
+	Available() bool
 }
 
 type Pool interface {
@@ -59,7 +63,11 @@ func (s *Hive) getShard(ctx context.Context) (Pool, error) {
 		return nil, err
 	}
 
-	return s.shards[shardID], nil
+	shard := s.shards[shardID]
+	if av, ok := shard.(Availability); ok && !av.Available() {
+		return nil, fmt.Errorf("%w: %d", ErrShardUnavailable, shardID)
+	}
+	return shard, nil
 }
 
 func (s *Hive) BeginTx(ctx context.Context, opts pgx.TxOptions) (pgx.Tx, error) {
@@ -78,7 +86,12 @@ func (s *Hive) Begin(ctx context.Context) (pgx.Tx, error) {
 	return shard.Begin(ctx)
 }
 
+// Query binds named parameters before shard is picked, so every shard receives positional query.
 func (s *Hive) Query(ctx context.Context, query string, args ...interface{}) (pgx.Rows, error) {
+	query, args, err := named.Bind(query, args)
+	if err != nil {
+		return nil, err
+	}
 	shard, err := s.getShard(ctx)
 	if err != nil {
 		return nil, err
@@ -87,14 +100,22 @@ func (s *Hive) Query(ctx context.Context, query string, args ...interface{}) (pg
 }
 
 func (s *Hive) QueryRow(ctx context.Context, query string, args ...interface{}) pgx.Row {
+	query, args, err := named.Bind(query, args)
+	if err != nil {
+		return failure.Row(err)
+	}
 	shard, err := s.getShard(ctx)
 	if err != nil {
-		return failedRow{err: err}
+		return failure.Row(err)
 	}
 	return shard.QueryRow(ctx, query, args...)
 }
 
 func (s *Hive) Exec(ctx context.Context, query string, args ...interface{}) (pgconn.CommandTag, error) {
+	query, args, err := named.Bind(query, args)
+	if err != nil {
+		return pgconn.CommandTag{}, err
+	}
 	shard, err := s.getShard(ctx)
 	if err != nil {
 		return pgconn.CommandTag{}, err
@@ -0,0 +1,136 @@
+package tenant
+
+import (
+	"context"
+
+	"github.com/godepo/elephant/internal/notify"
+	"github.com/godepo/elephant/internal/pkg/failure"
+	"github.com/godepo/elephant/internal/pkg/passthrough"
+	"github.com/godepo/elephant/internal/pkg/txscope"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Acquire gives dedicated connection of tenant with dedicated database only, search_path of schema tenant can't
+// be set for connection which is returned to shared pool.
+func (r *Router) Acquire(ctx context.Context) (*pgxpool.Conn, error) {
+	t, err := r.target(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if t.schema != "" {
+		return nil, passthrough.ErrAcquireNotSupported
+	}
+	acq, err := passthrough.AsAcquirer(t.pool)
+	if err != nil {
+		return nil, err
+	}
+	return acq.Acquire(ctx)
+}
+
+func (r *Router) bulk(ctx context.Context) (target, passthrough.BulkPool, error) {
+	t, err := r.target(ctx)
+	if err != nil {
+		return target{}, nil, err
+	}
+	bulk, err := passthrough.AsBulk(t.pool)
+	if err != nil {
+		return target{}, nil, err
+	}
+	return t, bulk, nil
+}
+
+func (r *Router) CopyFrom(
+	ctx context.Context,
+	tableName pgx.Identifier,
+	columnNames []string,
+	rowSrc pgx.CopyFromSource,
+) (int64, error) {
+	t, bulk, err := r.bulk(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if t.direct(ctx) {
+		return bulk.CopyFrom(ctx, tableName, columnNames, rowSrc)
+	}
+
+	tx, err := t.begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	count, err := tx.CopyFrom(ctx, tableName, columnNames, rowSrc)
+	return count, txscope.Finish(ctx, tx, err)
+}
+
+// SendBatch sends batch of schema tenant outside transaction in transaction, which is finished when results are
+// closed.
+func (r *Router) SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults {
+	t, bulk, err := r.bulk(ctx)
+	if err != nil {
+		return failure.BatchResults(err)
+	}
+	if t.direct(ctx) {
+		return bulk.SendBatch(ctx, b)
+	}
+
+	tx, err := t.begin(ctx)
+	if err != nil {
+		return failure.BatchResults(err)
+	}
+	return txscope.BatchResults(ctx, tx, tx.SendBatch(ctx, b))
+}
+
+// Listen subscribes to channel of database of tenant, tenants in schemas of shared database share channels.
+func (r *Router) Listen(ctx context.Context, channel string) (<-chan pgconn.Notification, error) {
+	t, err := r.target(ctx)
+	if err != nil {
+		return nil, err
+	}
+	lst, err := passthrough.AsListener(t.pool)
+	if err != nil {
+		return nil, err
+	}
+	return lst.Listen(ctx, channel)
+}
+
+// Notify sends notification through Exec, so it is routed like other statements.
+func (r *Router) Notify(ctx context.Context, channel, payload string) error {
+	_, err := r.Exec(ctx, notify.Query, channel, payload)
+	return err
+}
+
+func (r *Router) advisoryLocker(ctx context.Context) (passthrough.AdvisoryLocker, string, error) {
+	t, err := r.target(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	lck, err := passthrough.AsLocker(t.pool)
+	if err != nil {
+		return nil, "", err
+	}
+	if t.schema == "" {
+		return lck, "", nil
+	}
+	return lck, t.tenant + "/", nil
+}
+
+// WithAdvisoryLock runs fn under advisory lock of tenant. Keys of tenants in schemas of shared database are
+// prefixed by tenant, so same key of different tenants is different lock.
+func (r *Router) WithAdvisoryLock(ctx context.Context, key string, fn func(ctx context.Context) error) error {
+	lck, prefix, err := r.advisoryLocker(ctx)
+	if err != nil {
+		return err
+	}
+	return lck.WithAdvisoryLock(ctx, prefix+key, fn)
+}
+
+// WithTryAdvisoryLock runs fn under advisory lock of tenant without waiting, keys are prefixed like keys of
+// WithAdvisoryLock.
+func (r *Router) WithTryAdvisoryLock(ctx context.Context, key string, fn func(ctx context.Context) error) error {
+	lck, prefix, err := r.advisoryLocker(ctx)
+	if err != nil {
+		return err
+	}
+	return lck.WithTryAdvisoryLock(ctx, prefix+key, fn)
+}
@@ -0,0 +1,116 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/godepo/elephant/internal/pkg/passthrough"
+	"github.com/godepo/elephant/internal/pkg/pgcontext"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type capablePool struct {
+	*MockPool
+	*MockAcquirer
+	*MockBulkPool
+	*MockListener
+	*MockAdvisoryLocker
+}
+
+func newCapablePool(t *testing.T) capablePool {
+	t.Helper()
+	return capablePool{
+		MockPool:           NewMockPool(t),
+		MockAcquirer:       NewMockAcquirer(t),
+		MockBulkPool:       NewMockBulkPool(t),
+		MockListener:       NewMockListener(t),
+		MockAdvisoryLocker: NewMockAdvisoryLocker(t),
+	}
+}
+
+func TestRouter_Capabilities(t *testing.T) {
+	acme := pgcontext.With(context.Background(), pgcontext.WithTenant("acme"))
+	globex := pgcontext.With(context.Background(), pgcontext.WithTenant("globex"))
+	fn := func(context.Context) error { return nil }
+
+	t.Run("should be able to fail without tenant", func(t *testing.T) {
+		_, _, router := newRouter(t)
+		ctx := context.Background()
+
+		_, err := router.Acquire(ctx)
+		require.ErrorIs(t, err, ErrNoTenant)
+		_, err = router.CopyFrom(ctx, pgx.Identifier{"t"}, []string{"id"}, pgx.CopyFromRows(nil))
+		require.ErrorIs(t, err, ErrNoTenant)
+		require.ErrorIs(t, router.SendBatch(ctx, &pgx.Batch{}).Close(), ErrNoTenant)
+		_, err = router.Listen(ctx, "events")
+		require.ErrorIs(t, err, ErrNoTenant)
+		require.ErrorIs(t, router.Notify(ctx, "events", "payload"), ErrNoTenant)
+		require.ErrorIs(t, router.WithAdvisoryLock(ctx, "key", fn), ErrNoTenant)
+		require.ErrorIs(t, router.WithTryAdvisoryLock(ctx, "key", fn), ErrNoTenant)
+	})
+
+	t.Run("should be able to delegate to pool of tenant with dedicated database", func(t *testing.T) {
+		pool := newCapablePool(t)
+		router := New(nil, schemaOf, map[string]Pool{"acme": pool})
+		expErr := errors.New(uuid.NewString())
+		batch := &pgx.Batch{}
+		results := NewMockBatchResults(t)
+
+		pool.MockAcquirer.EXPECT().Acquire(acme).Return(nil, expErr)
+		pool.MockBulkPool.EXPECT().CopyFrom(acme, pgx.Identifier{"t"}, []string{"id"}, mock.Anything).Return(3, nil)
+		pool.MockBulkPool.EXPECT().SendBatch(acme, batch).Return(results)
+		pool.MockListener.EXPECT().Listen(acme, "events").Return(nil, expErr)
+		pool.MockAdvisoryLocker.EXPECT().WithAdvisoryLock(acme, "key", mock.Anything).Return(expErr)
+		pool.MockAdvisoryLocker.EXPECT().WithTryAdvisoryLock(acme, "key", mock.Anything).Return(expErr)
+		pool.MockPool.EXPECT().Exec(acme, mock.Anything, []any{"events", "payload"}).
+			Return(pgconn.NewCommandTag("SELECT 1"), nil)
+
+		_, err := router.Acquire(acme)
+		require.ErrorIs(t, err, expErr)
+		count, err := router.CopyFrom(acme, pgx.Identifier{"t"}, []string{"id"}, pgx.CopyFromRows(nil))
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), count)
+		assert.Equal(t, results, router.SendBatch(acme, batch))
+		_, err = router.Listen(acme, "events")
+		require.ErrorIs(t, err, expErr)
+		require.ErrorIs(t, router.WithAdvisoryLock(acme, "key", fn), expErr)
+		require.ErrorIs(t, router.WithTryAdvisoryLock(acme, "key", fn), expErr)
+		require.NoError(t, router.Notify(acme, "events", "payload"))
+	})
+
+	t.Run("should be able to scope bulk operations and locks of schema tenant", func(t *testing.T) {
+		pool := newCapablePool(t)
+		router := New(pool, schemaOf, nil)
+		first, second := NewMockTx(t), NewMockTx(t)
+		batch := &pgx.Batch{}
+		results := NewMockBatchResults(t)
+
+		pool.MockPool.EXPECT().Begin(globex).Return(first, nil).Once()
+		pool.MockPool.EXPECT().Begin(globex).Return(second, nil).Once()
+		first.EXPECT().Exec(globex, searchPath).Return(pgconn.CommandTag{}, nil)
+		first.EXPECT().CopyFrom(globex, pgx.Identifier{"t"}, []string{"id"}, mock.Anything).Return(2, nil)
+		first.EXPECT().Commit(globex).Return(nil)
+		second.EXPECT().Exec(globex, searchPath).Return(pgconn.CommandTag{}, nil)
+		second.EXPECT().SendBatch(globex, batch).Return(results)
+		results.EXPECT().Close().Return(nil)
+		second.EXPECT().Commit(globex).Return(nil)
+		pool.MockAdvisoryLocker.EXPECT().WithAdvisoryLock(globex, "globex/key", mock.Anything).Return(nil)
+		pool.MockAdvisoryLocker.EXPECT().WithTryAdvisoryLock(globex, "globex/key", mock.Anything).Return(nil)
+
+		count, err := router.CopyFrom(globex, pgx.Identifier{"t"}, []string{"id"}, pgx.CopyFromRows(nil))
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), count)
+		require.NoError(t, router.SendBatch(globex, batch).Close())
+		require.NoError(t, router.WithAdvisoryLock(globex, "key", fn))
+		require.NoError(t, router.WithTryAdvisoryLock(globex, "key", fn))
+
+		_, err = router.Acquire(globex)
+		require.ErrorIs(t, err, passthrough.ErrAcquireNotSupported)
+	})
+}
@@ -0,0 +1,49 @@
+package tenant
+
+import (
+	"context"
+	"maps"
+	"slices"
+
+	"github.com/godepo/elephant/internal/pkg/lifecycle"
+)
+
+// members returns shared pool and pools of dedicated databases ordered by tenant, tenant of shared pool is empty.
+func (r *Router) members() ([]Pool, []string) {
+	var (
+		pools   []Pool
+		tenants []string
+	)
+	if r.shared != nil {
+		pools = append(pools, r.shared)
+		tenants = append(tenants, "")
+	}
+	for _, tenant := range slices.Sorted(maps.Keys(r.databases)) {
+		pools = append(pools, r.databases[tenant])
+		tenants = append(tenants, tenant)
+	}
+	return pools, tenants
+}
+
+// Close closes shared pool and pools of dedicated databases when they can be closed.
+func (r *Router) Close() {
+	pools, _ := r.members()
+	lifecycle.CloseMembers(pools)
+}
+
+// Ping checks shared pool and pools of dedicated databases concurrently, nodes of dedicated databases are marked by
+// tenant.
+func (r *Router) Ping(ctx context.Context) (lifecycle.Pings, error) {
+	pools, tenants := r.members()
+	pings := lifecycle.PingMembers(ctx, pools, func(ix int, node lifecycle.Node) lifecycle.Node {
+		return node.OfTenant(tenants[ix])
+	})
+	return pings, pings.Err()
+}
+
+func (r *Router) Stats() lifecycle.Stats {
+	pools, tenants := r.members()
+	return lifecycle.MembersStats(pools, func(ix int, node lifecycle.Node) lifecycle.Node {
+		return node.OfTenant(tenants[ix])
+	})
+}
@@ -0,0 +1,65 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/godepo/elephant/internal/pkg/lifecycle"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type lifecyclePool struct {
+	*MockPool
+	err    error
+	closed bool
+}
+
+func (p *lifecyclePool) Ping(context.Context) (lifecycle.Pings, error) {
+	pings := lifecycle.Pings{{Node: lifecycle.Node{Role: lifecycle.RoleNode}, Err: p.err}}
+	return pings, pings.Err()
+}
+
+func (p *lifecyclePool) Stats() lifecycle.Stats {
+	return lifecycle.Stats{{Node: lifecycle.Node{Role: lifecycle.RoleNode}, Stat: lifecycle.Stat{MaxConns: 4}}}
+}
+
+func (p *lifecyclePool) Close() {
+	p.closed = true
+}
+
+func TestRouter_Lifecycle(t *testing.T) {
+	t.Run("should be able to ping and close shared pool and every tenant database", func(t *testing.T) {
+		expErr := errors.New(uuid.NewString())
+		shared := &lifecyclePool{MockPool: NewMockPool(t)}
+		acme := &lifecyclePool{MockPool: NewMockPool(t), err: expErr}
+		globex := &lifecyclePool{MockPool: NewMockPool(t)}
+		r := New(shared, schemaOf, map[string]Pool{"globex": globex, "acme": acme})
+
+		pings, err := r.Ping(context.Background())
+		require.ErrorIs(t, err, expErr)
+		require.Len(t, pings, 3)
+		assert.Equal(t, "node", pings[0].Node.String())
+		assert.Equal(t, "tenant [acme] node", pings[1].Node.String())
+		assert.Equal(t, "tenant [globex] node", pings[2].Node.String())
+		assert.Equal(t, int32(12), r.Stats().Total().MaxConns)
+
+		r.Close()
+		assert.True(t, shared.closed)
+		assert.True(t, acme.closed)
+		assert.True(t, globex.closed)
+	})
+
+	t.Run("should be able to report tenant database which can't be pinged", func(t *testing.T) {
+		r := New(nil, nil, map[string]Pool{"acme": NewMockPool(t)})
+
+		pings, err := r.Ping(context.Background())
+		require.ErrorIs(t, err, lifecycle.ErrPingNotSupported)
+		require.Len(t, pings, 1)
+		assert.Equal(t, "acme", pings[0].Node.Tenant)
+		assert.Empty(t, r.Stats())
+		r.Close()
+	})
+}
@@ -0,0 +1,241 @@
+// Package tenant implements router which picks pool of tenant from context. Tenant lives in dedicated database,
+// which pool is used as is, or in schema of shared database. Statements of schema tenants run in transaction
+// which search_path is set to schema of tenant by SET LOCAL, so connections of shared pool never keep it.
+// Transactions are begun by shared pool, so reads of schema tenants of clusterpg shared pool run at leader.
+//
+//go:generate go tool mockery
+package tenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/godepo/elephant/internal/pkg/failure"
+	"github.com/godepo/elephant/internal/pkg/named"
+	"github.com/godepo/elephant/internal/pkg/pgcontext"
+	"github.com/godepo/elephant/internal/pkg/txscope"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+var (
+	ErrNoTenant       = errors.New("tenant is not set in context")
+	ErrUnknownTenant  = errors.New("tenant is unknown")
+	ErrTenantMismatch = errors.New("transaction in context is opened for another tenant")
+)
+
+type Pool interface {
+	BeginTx(ctx context.Context, opts pgx.TxOptions) (pgx.Tx, error)
+	Begin(ctx context.Context) (pgx.Tx, error)
+	Query(ctx context.Context, query string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, query string, args ...interface{}) pgx.Row
+	Exec(ctx context.Context, query string, args ...interface{}) (pgconn.CommandTag, error)
+	Transactional(ctx context.Context, fn func(ctx context.Context) error) (out error)
+}
+
+// SchemaNamer returns schema of tenant in shared database, empty schema means that tenant is unknown.
+type SchemaNamer func(tenant string) string
+
+// Router routes statements to pool of tenant from context.
+type Router struct {
+	shared    Pool
+	schema    SchemaNamer
+	databases map[string]Pool
+}
+
+// New makes router of tenants with dedicated databases and tenants in schemas of shared pool. Tenants with
+// dedicated database are looked up first, shared pool may be nil when every tenant has dedicated database.
+func New(shared Pool, schema SchemaNamer, databases map[string]Pool) *Router {
+	return &Router{
+		shared:    shared,
+		schema:    schema,
+		databases: databases,
+	}
+}
+
+// target is pool of tenant, schema is empty for tenants with dedicated database.
+type target struct {
+	tenant string
+	pool   Pool
+	schema string
+}
+
+// txTenantKey tags context of Transactional by tenant, which transaction from context is opened for.
+type txTenantKey struct{}
+
+func (r *Router) target(ctx context.Context) (target, error) {
+	tenant, ok := pgcontext.TenantFrom(ctx)
+	if !ok {
+		return target{}, ErrNoTenant
+	}
+	if owner, ok := ctx.Value(txTenantKey{}).(string); ok && owner != tenant {
+		return target{}, fmt.Errorf("%w: %s is not %s", ErrTenantMismatch, tenant, owner)
+	}
+	if pool, ok := r.databases[tenant]; ok {
+		return target{tenant: tenant, pool: pool}, nil
+	}
+	if r.shared == nil {
+		return target{}, fmt.Errorf("%w: %s", ErrUnknownTenant, tenant)
+	}
+	schema := r.schema(tenant)
+	if schema == "" {
+		return target{}, fmt.Errorf("%w: %s", ErrUnknownTenant, tenant)
+	}
+	return target{tenant: tenant, pool: r.shared, schema: schema}, nil
+}
+
+// direct reports statements which are passed to pool as is: statements of tenants with dedicated database and
+// statements of transaction from context, which search_path is already set.
+func (t target) direct(ctx context.Context) bool {
+	if t.schema == "" {
+		return true
+	}
+	_, ok := pgcontext.TransactionFrom(ctx)
+	return ok
+}
+
+func (t target) searchPath() string {
+	return "SET LOCAL search_path TO " + pgx.Identifier{t.schema}.Sanitize()
+}
+
+// scope sets search_path of tenant in transaction begun by pool.
+func (t target) scope(ctx context.Context, tx pgx.Tx, err error) (pgx.Tx, error) {
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(ctx, t.searchPath()); err != nil {
+		_ = tx.Rollback(ctx)
+		return nil, err
+	}
+	return tx, nil
+}
+
+func (t target) begin(ctx context.Context) (pgx.Tx, error) {
+	tx, err := t.pool.Begin(ctx)
+	return t.scope(ctx, tx, err)
+}
+
+func (r *Router) BeginTx(ctx context.Context, opts pgx.TxOptions) (pgx.Tx, error) {
+	t, err := r.target(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := t.pool.BeginTx(ctx, opts)
+	if t.schema == "" {
+		return tx, err
+	}
+	return t.scope(ctx, tx, err)
+}
+
+func (r *Router) Begin(ctx context.Context) (pgx.Tx, error) {
+	t, err := r.target(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if t.schema == "" {
+		return t.pool.Begin(ctx)
+	}
+	return t.begin(ctx)
+}
+
+// Query runs query of schema tenant outside transaction in transaction, which is finished when rows are closed.
+// Transaction is begun by shared pool, so query of cluster shared pool runs at leader instead of follower.
+func (r *Router) Query(ctx context.Context, query string, args ...interface{}) (pgx.Rows, error) {
+	query, args, err := named.Bind(query, args)
+	if err != nil {
+		return nil, err
+	}
+	t, err := r.target(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if t.direct(ctx) {
+		return t.pool.Query(ctx, query, args...)
+	}
+
+	tx, err := t.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		_ = tx.Rollback(ctx)
+		return nil, err
+	}
+	return txscope.Rows(ctx, tx, rows), nil
+}
+
+// QueryRow runs query of schema tenant outside transaction in transaction, when row is scanned.
+func (r *Router) QueryRow(ctx context.Context, query string, args ...interface{}) pgx.Row {
+	query, args, err := named.Bind(query, args)
+	if err != nil {
+		return failure.Row(err)
+	}
+	t, err := r.target(ctx)
+	if err != nil {
+		return failure.Row(err)
+	}
+	if t.direct(ctx) {
+		return t.pool.QueryRow(ctx, query, args...)
+	}
+	return scopedRow{ctx: ctx, target: t, query: query, args: args}
+}
+
+func (r *Router) Exec(ctx context.Context, query string, args ...interface{}) (pgconn.CommandTag, error) {
+	query, args, err := named.Bind(query, args)
+	if err != nil {
+		return pgconn.CommandTag{}, err
+	}
+	t, err := r.target(ctx)
+	if err != nil {
+		return pgconn.CommandTag{}, err
+	}
+	if t.direct(ctx) {
+		return t.pool.Exec(ctx, query, args...)
+	}
+
+	tx, err := t.begin(ctx)
+	if err != nil {
+		return pgconn.CommandTag{}, err
+	}
+	tag, err := tx.Exec(ctx, query, args...)
+	return tag, txscope.Finish(ctx, tx, err)
+}
+
+// Transactional sets search_path of schema tenant before fn is run, statements of fn are passed to pool as is.
+// Context of fn is tagged by tenant, so statements of another tenant in it fail with ErrTenantMismatch instead of
+// running in transaction of this one.
+func (r *Router) Transactional(ctx context.Context, fn func(ctx context.Context) error) (out error) {
+	t, err := r.target(ctx)
+	if err != nil {
+		return err
+	}
+	tagged := func(ctx context.Context) error {
+		return fn(context.WithValue(ctx, txTenantKey{}, t.tenant))
+	}
+	if t.direct(ctx) {
+		return t.pool.Transactional(ctx, tagged)
+	}
+	return t.pool.Transactional(ctx, func(ctx context.Context) error {
+		if _, err := t.pool.Exec(ctx, t.searchPath()); err != nil {
+			return err
+		}
+		return tagged(ctx)
+	})
+}
+
+type scopedRow struct {
+	ctx    context.Context
+	target target
+	query  string
+	args   []any
+}
+
+func (r scopedRow) Scan(dest ...any) error {
+	tx, err := r.target.begin(r.ctx)
+	if err != nil {
+		return err
+	}
+	return txscope.Finish(r.ctx, tx, tx.QueryRow(r.ctx, r.query, r.args...).Scan(dest...))
+}
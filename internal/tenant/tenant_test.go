@@ -0,0 +1,234 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/godepo/elephant/internal/pkg/pgcontext"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const searchPath = `SET LOCAL search_path TO "tenant_globex"`
+
+func schemaOf(tenant string) string {
+	if tenant == "ghost" {
+		return ""
+	}
+	return "tenant_" + tenant
+}
+
+func newRouter(t *testing.T) (*MockPool, *MockPool, *Router) {
+	t.Helper()
+	shared, acme := NewMockPool(t), NewMockPool(t)
+	return shared, acme, New(shared, schemaOf, map[string]Pool{"acme": acme})
+}
+
+func TestRouter(t *testing.T) {
+	globex := pgcontext.With(context.Background(), pgcontext.WithTenant("globex"))
+	tag := pgconn.NewCommandTag("UPDATE 1")
+
+	t.Run("should be able to reject statements without known tenant", func(t *testing.T) {
+		_, _, router := newRouter(t)
+		fn := func(context.Context) error { return nil }
+		cases := []struct {
+			ctx context.Context
+			err error
+		}{
+			{ctx: context.Background(), err: ErrNoTenant},
+			{ctx: pgcontext.With(context.Background(), pgcontext.WithTenant("ghost")), err: ErrUnknownTenant},
+		}
+		for _, tc := range cases {
+			_, err := router.Begin(tc.ctx)
+			require.ErrorIs(t, err, tc.err)
+			_, err = router.BeginTx(tc.ctx, pgx.TxOptions{})
+			require.ErrorIs(t, err, tc.err)
+			_, err = router.Query(tc.ctx, "SELECT 1")
+			require.ErrorIs(t, err, tc.err)
+			require.ErrorIs(t, router.QueryRow(tc.ctx, "SELECT 1").Scan(), tc.err)
+			_, err = router.Exec(tc.ctx, "SELECT 1")
+			require.ErrorIs(t, err, tc.err)
+			require.ErrorIs(t, router.Transactional(tc.ctx, fn), tc.err)
+		}
+
+		_, err := New(nil, schemaOf, nil).Exec(globex, "SELECT 1")
+		require.ErrorIs(t, err, ErrUnknownTenant)
+	})
+
+	t.Run("should be able to pass statements of tenant with dedicated database", func(t *testing.T) {
+		_, acme, router := newRouter(t)
+		ctx := pgcontext.With(context.Background(), pgcontext.WithTenant("acme"))
+		rows, row, tx := NewMockRows(t), NewMockRow(t), NewMockTx(t)
+		acme.EXPECT().Begin(ctx).Return(tx, nil)
+		acme.EXPECT().BeginTx(ctx, pgx.TxOptions{}).Return(tx, nil)
+		acme.EXPECT().Query(ctx, "SELECT $1", []any{1}).Return(rows, nil)
+		acme.EXPECT().QueryRow(ctx, "SELECT 1").Return(row)
+		acme.EXPECT().Exec(ctx, "UPDATE t").Return(tag, nil)
+		acme.EXPECT().Transactional(ctx, mock.Anything).Return(nil)
+
+		out, err := router.Begin(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, tx, out)
+		out, err = router.BeginTx(ctx, pgx.TxOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, tx, out)
+		res, err := router.Query(ctx, "SELECT :id", map[string]any{"id": 1})
+		require.NoError(t, err)
+		assert.Equal(t, rows, res)
+		assert.Equal(t, row, router.QueryRow(ctx, "SELECT 1"))
+		resTag, err := router.Exec(ctx, "UPDATE t")
+		require.NoError(t, err)
+		assert.Equal(t, tag, resTag)
+		require.NoError(t, router.Transactional(ctx, func(context.Context) error { return nil }))
+	})
+
+	t.Run("should be able to set search path of schema tenant in transaction", func(t *testing.T) {
+		shared, _, router := newRouter(t)
+		first, second := NewMockTx(t), NewMockTx(t)
+		shared.EXPECT().Begin(globex).Return(first, nil)
+		shared.EXPECT().BeginTx(globex, pgx.TxOptions{}).Return(second, nil)
+		first.EXPECT().Exec(globex, searchPath).Return(pgconn.CommandTag{}, nil)
+		second.EXPECT().Exec(globex, searchPath).Return(pgconn.CommandTag{}, nil)
+
+		out, err := router.Begin(globex)
+		require.NoError(t, err)
+		assert.Equal(t, first, out)
+		out, err = router.BeginTx(globex, pgx.TxOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, second, out)
+	})
+
+	t.Run("should be able to roll back transaction when search path was not set", func(t *testing.T) {
+		shared, _, router := newRouter(t)
+		tx := NewMockTx(t)
+		expErr := errors.New(uuid.NewString())
+		shared.EXPECT().Begin(globex).Return(tx, nil)
+		tx.EXPECT().Exec(globex, searchPath).Return(pgconn.CommandTag{}, expErr)
+		tx.EXPECT().Rollback(globex).Return(nil)
+
+		_, err := router.Exec(globex, "UPDATE t")
+		require.ErrorIs(t, err, expErr)
+	})
+
+	t.Run("should be able to exec statement of schema tenant in transaction", func(t *testing.T) {
+		shared, _, router := newRouter(t)
+		tx := NewMockTx(t)
+		shared.EXPECT().Begin(globex).Return(tx, nil)
+		tx.EXPECT().Exec(globex, searchPath).Return(pgconn.CommandTag{}, nil)
+		tx.EXPECT().Exec(globex, "UPDATE t SET n = $1", []any{1}).Return(tag, nil)
+		tx.EXPECT().Commit(globex).Return(nil)
+
+		res, err := router.Exec(globex, "UPDATE t SET n = :n", map[string]any{"n": 1})
+		require.NoError(t, err)
+		assert.Equal(t, tag, res)
+	})
+
+	t.Run("should be able to finish transaction of query when rows are read", func(t *testing.T) {
+		shared, _, router := newRouter(t)
+		tx := NewMockTx(t)
+		rows := NewMockRows(t)
+		shared.EXPECT().Begin(globex).Return(tx, nil)
+		tx.EXPECT().Exec(globex, searchPath).Return(pgconn.CommandTag{}, nil)
+		tx.EXPECT().Query(globex, "SELECT 1").Return(rows, nil)
+		rows.EXPECT().Next().Return(false)
+		rows.EXPECT().Close().Return()
+		rows.EXPECT().Err().Return(nil)
+		tx.EXPECT().Commit(globex).Return(nil).Once()
+
+		out, err := router.Query(globex, "SELECT 1")
+		require.NoError(t, err)
+		assert.False(t, out.Next())
+		out.Close()
+		require.NoError(t, out.Err())
+	})
+
+	t.Run("should be able to roll back transaction of failed query", func(t *testing.T) {
+		shared, _, router := newRouter(t)
+		tx := NewMockTx(t)
+		expErr := errors.New(uuid.NewString())
+		shared.EXPECT().Begin(globex).Return(tx, nil)
+		tx.EXPECT().Exec(globex, searchPath).Return(pgconn.CommandTag{}, nil)
+		tx.EXPECT().Query(globex, "SELECT 1").Return(nil, expErr)
+		tx.EXPECT().Rollback(globex).Return(nil)
+
+		_, err := router.Query(globex, "SELECT 1")
+		require.ErrorIs(t, err, expErr)
+	})
+
+	t.Run("should be able to scan row of schema tenant in transaction", func(t *testing.T) {
+		shared, _, router := newRouter(t)
+		tx := NewMockTx(t)
+		row := NewMockRow(t)
+		shared.EXPECT().Begin(globex).Return(tx, nil)
+		tx.EXPECT().Exec(globex, searchPath).Return(pgconn.CommandTag{}, nil)
+		tx.EXPECT().QueryRow(globex, "SELECT 1").Return(row)
+		row.EXPECT().Scan().Return(pgx.ErrNoRows)
+		tx.EXPECT().Rollback(globex).Return(nil)
+
+		require.ErrorIs(t, router.QueryRow(globex, "SELECT 1").Scan(), pgx.ErrNoRows)
+	})
+
+	t.Run("should be able to set search path before transactional fn", func(t *testing.T) {
+		shared, _, router := newRouter(t)
+		shared.EXPECT().Transactional(globex, mock.Anything).RunAndReturn(
+			func(ctx context.Context, fn func(ctx context.Context) error) error {
+				return fn(ctx)
+			},
+		)
+		shared.EXPECT().Exec(globex, searchPath).Return(pgconn.CommandTag{}, nil)
+
+		called := false
+		require.NoError(t, router.Transactional(globex, func(context.Context) error {
+			called = true
+			return nil
+		}))
+		assert.True(t, called)
+	})
+
+	t.Run("should be able to pass statements of transaction from context", func(t *testing.T) {
+		shared, _, router := newRouter(t)
+		ctx := pgcontext.With(globex, pgcontext.WithTransaction(NewMockTx(t)))
+		row := NewMockRow(t)
+		shared.EXPECT().Query(ctx, "SELECT 1").Return(nil, nil)
+		shared.EXPECT().QueryRow(ctx, "SELECT 1").Return(row)
+		shared.EXPECT().Exec(ctx, "SELECT 1").Return(pgconn.CommandTag{}, nil)
+		shared.EXPECT().Transactional(ctx, mock.Anything).Return(nil)
+
+		_, err := router.Query(ctx, "SELECT 1")
+		require.NoError(t, err)
+		assert.Equal(t, row, router.QueryRow(ctx, "SELECT 1"))
+		_, err = router.Exec(ctx, "SELECT 1")
+		require.NoError(t, err)
+		require.NoError(t, router.Transactional(ctx, func(context.Context) error { return nil }))
+	})
+
+	t.Run("should be able to reject statements of another tenant in transaction of tenant", func(t *testing.T) {
+		shared, acme, router := newRouter(t)
+		tx := NewMockTx(t)
+		acme.EXPECT().Transactional(mock.Anything, mock.Anything).RunAndReturn(
+			func(ctx context.Context, fn func(ctx context.Context) error) error {
+				return fn(pgcontext.With(ctx, pgcontext.WithTransaction(tx)))
+			},
+		)
+		shared.EXPECT().Exec(mock.Anything, "SELECT 1").Return(pgconn.CommandTag{}, nil).Once()
+		ctx := pgcontext.With(context.Background(), pgcontext.WithTenant("acme"))
+
+		err := router.Transactional(ctx, func(ctx context.Context) error {
+			other := pgcontext.With(ctx, pgcontext.WithTenant("globex"))
+			_, err := router.Exec(other, "SELECT 1")
+			require.ErrorIs(t, err, ErrTenantMismatch)
+			require.ErrorIs(t, router.QueryRow(other, "SELECT 1").Scan(), ErrTenantMismatch)
+			require.ErrorIs(t, router.Transactional(other, func(context.Context) error { return nil }), ErrTenantMismatch)
+			return nil
+		})
+		require.NoError(t, err)
+
+		_, err = router.Exec(pgcontext.With(globex, pgcontext.WithTransaction(tx)), "SELECT 1")
+		require.NoError(t, err)
+	})
+}
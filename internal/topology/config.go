@@ -0,0 +1,291 @@
+package topology
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+var ErrInvalidConfig = errors.New("invalid topology config")
+
+type Kind string
+
+const (
+	KindSingle  Kind = "single"
+	KindCluster Kind = "cluster"
+	KindSharded Kind = "sharded"
+)
+
+const (
+	BalancerRoundRobin = "round_robin"
+	BalancerRandom     = "random"
+
+	PickerHash = "hash"
+	PickerJump = "jump"
+)
+
+// Config describes topology which is opened by Open. Topology is inferred from nodes when kind is empty:
+// sharded when shards are set, cluster when leader is set, single otherwise.
+type Config struct {
+	Topology Kind          `yaml:"topology"`
+	DSN      string        `yaml:"dsn"`
+	Pool     PoolConfig    `yaml:"pool"`
+	Cluster  ClusterConfig `yaml:"cluster"`
+	Sharded  ShardedConfig `yaml:"sharded"`
+	Metrics  MetricsConfig `yaml:"metrics"`
+}
+
+// PoolConfig sets pgxpool settings, zero values keep settings of DSN or pgxpool defaults.
+type PoolConfig struct {
+	MaxConns        int32         `yaml:"max_conns"`
+	MinConns        int32         `yaml:"min_conns"`
+	MaxConnLifetime time.Duration `yaml:"max_conn_lifetime"`
+	MaxConnIdleTime time.Duration `yaml:"max_conn_idle_time"`
+}
+
+// Node is single postgres, its pool settings override pool settings of topology.
+type Node struct {
+	DSN  string     `yaml:"dsn"`
+	Pool PoolConfig `yaml:"pool"`
+}
+
+type ClusterConfig struct {
+	Leader    Node   `yaml:"leader"`
+	Followers []Node `yaml:"followers"`
+	// Balancer is round_robin or random, round_robin by default.
+	Balancer string `yaml:"balancer"`
+	// ReadRetries keeps cluster default, which is one retry, when it is nil, zero disables retries.
+	ReadRetries *int `yaml:"read_retries"`
+}
+
+// Shard is single postgres, or cluster when followers are set, which balancer and read retries are taken from
+// cluster config.
+type Shard struct {
+	Node      `yaml:",inline"`
+	Followers []Node `yaml:"followers"`
+}
+
+type ShardedConfig struct {
+	// Picker is hash or jump, hash by default.
+	Picker string  `yaml:"picker"`
+	Shards []Shard `yaml:"shards"`
+}
+
+// MetricsConfig enables metrics wrapper, its collector is given to Open by WithMetrics.
+type MetricsConfig struct {
+	Enabled bool `yaml:"enabled"`
+}
+
+// FromYAML parses config from YAML document.
+func FromYAML(data []byte) (Config, error) {
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return Config{}, fmt.Errorf("%w: %w", ErrInvalidConfig, err)
+	}
+	return cfg, nil
+}
+
+// FromFile parses config from YAML file.
+func FromFile(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("can't read topology config: %w", err)
+	}
+	return FromYAML(data)
+}
+
+// FromEnv reads config from environment variables with prefix, like PG_DSN for prefix PG. Variables are
+// TOPOLOGY, DSN, MAX_CONNS, MIN_CONNS, MAX_CONN_LIFETIME, MAX_CONN_IDLE_TIME, LEADER_DSN, FOLLOWER_DSNS,
+// BALANCER, READ_RETRIES, SHARD_DSNS, PICKER and METRICS_ENABLED. Lists are separated by comma.
+func FromEnv(prefix string) (Config, error) {
+	env := environment{prefix: prefix}
+	cfg := Config{
+		Topology: Kind(env.get("TOPOLOGY")),
+		DSN:      env.get("DSN"),
+		Pool: PoolConfig{
+			MaxConns:        env.int32("MAX_CONNS"),
+			MinConns:        env.int32("MIN_CONNS"),
+			MaxConnLifetime: env.duration("MAX_CONN_LIFETIME"),
+			MaxConnIdleTime: env.duration("MAX_CONN_IDLE_TIME"),
+		},
+		Cluster: ClusterConfig{
+			Leader:   Node{DSN: env.get("LEADER_DSN")},
+			Balancer: env.get("BALANCER"),
+		},
+		Sharded: ShardedConfig{
+			Picker: env.get("PICKER"),
+		},
+		Metrics: MetricsConfig{
+			Enabled: env.bool("METRICS_ENABLED"),
+		},
+	}
+	for _, dsn := range env.list("FOLLOWER_DSNS") {
+		cfg.Cluster.Followers = append(cfg.Cluster.Followers, Node{DSN: dsn})
+	}
+	for _, dsn := range env.list("SHARD_DSNS") {
+		cfg.Sharded.Shards = append(cfg.Sharded.Shards, Shard{Node: Node{DSN: dsn}})
+	}
+	if _, ok := env.lookup("READ_RETRIES"); ok {
+		retries := int(env.int32("READ_RETRIES"))
+		cfg.Cluster.ReadRetries = &retries
+	}
+	if env.err != nil {
+		return Config{}, env.err
+	}
+	return cfg, nil
+}
+
+// environment reads variables with prefix and keeps first parse error.
+type environment struct {
+	prefix string
+	err    error
+}
+
+func (env *environment) name(name string) string {
+	if env.prefix == "" {
+		return name
+	}
+	return env.prefix + "_" + name
+}
+
+func (env *environment) lookup(name string) (string, bool) {
+	val, ok := os.LookupEnv(env.name(name))
+	return strings.TrimSpace(val), ok && strings.TrimSpace(val) != ""
+}
+
+func (env *environment) get(name string) string {
+	val, _ := env.lookup(name)
+	return val
+}
+
+func (env *environment) fail(name string, err error) {
+	if env.err == nil {
+		env.err = fmt.Errorf("%w: %s: %w", ErrInvalidConfig, env.name(name), err)
+	}
+}
+
+func (env *environment) int32(name string) int32 {
+	val, ok := env.lookup(name)
+	if !ok {
+		return 0
+	}
+	res, err := strconv.ParseInt(val, 10, 32)
+	if err != nil {
+		env.fail(name, err)
+	}
+	return int32(res)
+}
+
+func (env *environment) duration(name string) time.Duration {
+	val, ok := env.lookup(name)
+	if !ok {
+		return 0
+	}
+	res, err := time.ParseDuration(val)
+	if err != nil {
+		env.fail(name, err)
+	}
+	return res
+}
+
+func (env *environment) bool(name string) bool {
+	val, ok := env.lookup(name)
+	if !ok {
+		return false
+	}
+	res, err := strconv.ParseBool(val)
+	if err != nil {
+		env.fail(name, err)
+	}
+	return res
+}
+
+func (env *environment) list(name string) []string {
+	val, ok := env.lookup(name)
+	if !ok {
+		return nil
+	}
+	var res []string
+	for _, item := range strings.Split(val, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			res = append(res, item)
+		}
+	}
+	return res
+}
+
+func (cfg Config) kind() Kind {
+	switch {
+	case cfg.Topology != "":
+		return cfg.Topology
+	case len(cfg.Sharded.Shards) > 0:
+		return KindSharded
+	case cfg.Cluster.Leader.DSN != "":
+		return KindCluster
+	default:
+		return KindSingle
+	}
+}
+
+// Validate checks that config describes topology which can be opened.
+func (cfg Config) Validate() error {
+	switch cfg.Cluster.Balancer {
+	case "", BalancerRoundRobin, BalancerRandom:
+	default:
+		return fmt.Errorf("%w: unknown balancer %q", ErrInvalidConfig, cfg.Cluster.Balancer)
+	}
+
+	switch cfg.kind() {
+	case KindSingle:
+		if cfg.DSN == "" {
+			return fmt.Errorf("%w: dsn is required for single topology", ErrInvalidConfig)
+		}
+	case KindCluster:
+		if cfg.Cluster.Leader.DSN == "" {
+			return fmt.Errorf("%w: dsn of leader is required for cluster topology", ErrInvalidConfig)
+		}
+		if len(cfg.Cluster.Followers) == 0 {
+			return fmt.Errorf("%w: at least one follower is required for cluster topology", ErrInvalidConfig)
+		}
+		return validateFollowers("cluster", cfg.Cluster.Followers)
+	case KindSharded:
+		return cfg.Sharded.validate()
+	default:
+		return fmt.Errorf("%w: unknown topology %q", ErrInvalidConfig, cfg.Topology)
+	}
+	return nil
+}
+
+func (cfg ShardedConfig) validate() error {
+	switch cfg.Picker {
+	case "", PickerHash, PickerJump:
+	default:
+		return fmt.Errorf("%w: unknown picker %q", ErrInvalidConfig, cfg.Picker)
+	}
+	if len(cfg.Shards) == 0 {
+		return fmt.Errorf("%w: at least one shard is required for sharded topology", ErrInvalidConfig)
+	}
+	for i, shard := range cfg.Shards {
+		if shard.DSN == "" {
+			return fmt.Errorf("%w: dsn of shard [%d] is required", ErrInvalidConfig, i)
+		}
+		if err := validateFollowers(fmt.Sprintf("shard [%d]", i), shard.Followers); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateFollowers(owner string, followers []Node) error {
+	for i, follower := range followers {
+		if follower.DSN == "" {
+			return fmt.Errorf("%w: dsn of %s follower [%d] is required", ErrInvalidConfig, owner, i)
+		}
+	}
+	return nil
+}
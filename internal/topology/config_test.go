@@ -0,0 +1,137 @@
+package topology
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const clusterYAML = `
+pool:
+  max_conns: 20
+  max_conn_idle_time: 30s
+cluster:
+  leader:
+    dsn: postgres://leader/db
+    pool:
+      max_conns: 40
+  followers:
+    - dsn: postgres://replica-1/db
+    - dsn: postgres://replica-2/db
+  balancer: random
+  read_retries: 0
+metrics:
+  enabled: true
+`
+
+func TestFromYAML(t *testing.T) {
+	t.Run("should be able to parse cluster topology", func(t *testing.T) {
+		cfg, err := FromYAML([]byte(clusterYAML))
+		require.NoError(t, err)
+
+		require.NoError(t, cfg.Validate())
+		assert.Equal(t, KindCluster, cfg.kind())
+		assert.Equal(t, PoolConfig{MaxConns: 20, MaxConnIdleTime: 30 * time.Second}, cfg.Pool)
+		assert.Equal(t, Node{DSN: "postgres://leader/db", Pool: PoolConfig{MaxConns: 40}}, cfg.Cluster.Leader)
+		assert.Equal(t, []Node{{DSN: "postgres://replica-1/db"}, {DSN: "postgres://replica-2/db"}}, cfg.Cluster.Followers)
+		assert.Equal(t, BalancerRandom, cfg.Cluster.Balancer)
+		require.NotNil(t, cfg.Cluster.ReadRetries)
+		assert.Zero(t, *cfg.Cluster.ReadRetries)
+		assert.True(t, cfg.Metrics.Enabled)
+	})
+
+	t.Run("should be able to parse shards with followers from file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "db.yaml")
+		require.NoError(t, os.WriteFile(path, []byte(`
+sharded:
+  picker: jump
+  shards:
+    - dsn: postgres://shard-0/db
+    - dsn: postgres://shard-1/db
+      followers:
+        - dsn: postgres://shard-1-replica/db
+`), 0o600))
+
+		cfg, err := FromFile(path)
+		require.NoError(t, err)
+		require.NoError(t, cfg.Validate())
+		assert.Equal(t, KindSharded, cfg.kind())
+		assert.Equal(t, PickerJump, cfg.Sharded.Picker)
+		require.Len(t, cfg.Sharded.Shards, 2)
+		assert.Equal(t, "postgres://shard-1/db", cfg.Sharded.Shards[1].DSN)
+		assert.Equal(t, []Node{{DSN: "postgres://shard-1-replica/db"}}, cfg.Sharded.Shards[1].Followers)
+	})
+
+	t.Run("should be able to fail at malformed document or missing file", func(t *testing.T) {
+		_, err := FromYAML([]byte("pool: ["))
+		require.ErrorIs(t, err, ErrInvalidConfig)
+		_, err = FromFile(filepath.Join(t.TempDir(), "missing.yaml"))
+		require.ErrorIs(t, err, os.ErrNotExist)
+	})
+}
+
+func TestFromEnv(t *testing.T) {
+	t.Run("should be able to read cluster topology", func(t *testing.T) {
+		t.Setenv("PG_LEADER_DSN", "postgres://leader/db")
+		t.Setenv("PG_FOLLOWER_DSNS", "postgres://replica-1/db, postgres://replica-2/db")
+		t.Setenv("PG_MAX_CONNS", "10")
+		t.Setenv("PG_MAX_CONN_LIFETIME", "1h")
+		t.Setenv("PG_READ_RETRIES", "1")
+		t.Setenv("PG_METRICS_ENABLED", "true")
+
+		cfg, err := FromEnv("PG")
+		require.NoError(t, err)
+		require.NoError(t, cfg.Validate())
+		assert.Equal(t, KindCluster, cfg.kind())
+		assert.Equal(t, PoolConfig{MaxConns: 10, MaxConnLifetime: time.Hour}, cfg.Pool)
+		assert.Equal(t, []Node{{DSN: "postgres://replica-1/db"}, {DSN: "postgres://replica-2/db"}}, cfg.Cluster.Followers)
+		require.NotNil(t, cfg.Cluster.ReadRetries)
+		assert.Equal(t, 1, *cfg.Cluster.ReadRetries)
+		assert.True(t, cfg.Metrics.Enabled)
+	})
+
+	t.Run("should be able to read sharded topology", func(t *testing.T) {
+		t.Setenv("PG_SHARD_DSNS", "postgres://shard-0/db,postgres://shard-1/db")
+		t.Setenv("PG_PICKER", "hash")
+
+		cfg, err := FromEnv("PG")
+		require.NoError(t, err)
+		assert.Equal(t, KindSharded, cfg.kind())
+		assert.Len(t, cfg.Sharded.Shards, 2)
+		assert.Nil(t, cfg.Cluster.ReadRetries)
+	})
+
+	t.Run("should be able to fail at malformed value", func(t *testing.T) {
+		t.Setenv("PG_MAX_CONNS", "many")
+
+		_, err := FromEnv("PG")
+		require.ErrorIs(t, err, ErrInvalidConfig)
+		assert.ErrorContains(t, err, "PG_MAX_CONNS")
+	})
+}
+
+func TestConfig_Validate(t *testing.T) {
+	cases := map[string]Config{
+		"single without dsn":       {},
+		"unknown topology":         {Topology: "ring"},
+		"cluster without leader":   {Topology: KindCluster, Cluster: ClusterConfig{Followers: []Node{{DSN: "f"}}}},
+		"cluster without follower": {Cluster: ClusterConfig{Leader: Node{DSN: "l"}}},
+		"follower without dsn":     {Cluster: ClusterConfig{Leader: Node{DSN: "l"}, Followers: []Node{{}}}},
+		"unknown balancer":         {DSN: "n", Cluster: ClusterConfig{Balancer: "least_conn"}},
+		"sharded without shards":   {Topology: KindSharded},
+		"shard without dsn":        {Sharded: ShardedConfig{Shards: []Shard{{}}}},
+		"unknown picker":           {Sharded: ShardedConfig{Picker: "range", Shards: []Shard{{Node: Node{DSN: "s"}}}}},
+		"shard follower without dsn": {
+			Sharded: ShardedConfig{Shards: []Shard{{Node: Node{DSN: "s"}, Followers: []Node{{}}}}},
+		},
+	}
+	for name, cfg := range cases {
+		t.Run("should be able to reject "+name, func(t *testing.T) {
+			require.ErrorIs(t, cfg.Validate(), ErrInvalidConfig)
+		})
+	}
+}
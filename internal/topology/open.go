@@ -0,0 +1,223 @@
+// Package topology opens single, cluster or sharded pools described by config, so pgxpool pools, nodes and
+// builders are not wired by hand.
+package topology
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/godepo/elephant/internal/cluster"
+	"github.com/godepo/elephant/internal/metrics"
+	"github.com/godepo/elephant/internal/pkg/lifecycle"
+	"github.com/godepo/elephant/internal/regular"
+	"github.com/godepo/elephant/internal/sharded"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var ErrNoMetricsCollector = errors.New("metrics are enabled, but metrics collector is not provided")
+
+// Pool is implemented by pools of every topology.
+type Pool interface {
+	BeginTx(ctx context.Context, opts pgx.TxOptions) (pgx.Tx, error)
+	Begin(ctx context.Context) (pgx.Tx, error)
+	Query(ctx context.Context, query string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, query string, args ...interface{}) pgx.Row
+	Exec(ctx context.Context, query string, args ...interface{}) (pgconn.CommandTag, error)
+	Transactional(ctx context.Context, fn func(ctx context.Context) error) (out error)
+	Acquire(ctx context.Context) (*pgxpool.Conn, error)
+	Listen(ctx context.Context, channel string) (<-chan pgconn.Notification, error)
+	Notify(ctx context.Context, channel, payload string) error
+	WithAdvisoryLock(ctx context.Context, key string, fn func(ctx context.Context) error) error
+	WithTryAdvisoryLock(ctx context.Context, key string, fn func(ctx context.Context) error) error
+	Ping(ctx context.Context) (lifecycle.Pings, error)
+	Stats() lifecycle.Stats
+}
+
+type options struct {
+	collector metrics.Collector
+	configure func(cfg *pgxpool.Config)
+}
+
+type Option func(opts *options)
+
+// WithMetrics sets collector of metrics wrapper, which is used when metrics are enabled by config. Collector
+// which tracks hedged reads is given to clusters too, collector which exports pool stats gets them until pool
+// is closed.
+func WithMetrics(collector metrics.Collector) Option {
+	return func(opts *options) {
+		opts.collector = collector
+	}
+}
+
+// WithPoolConfig sets fn which adjusts config of every pgxpool after settings of topology are applied, like
+// tracer or AfterConnect hook.
+func WithPoolConfig(fn func(cfg *pgxpool.Config)) Option {
+	return func(opts *options) {
+		opts.configure = fn
+	}
+}
+
+// Open opens pool of topology described by cfg and returns function which closes every pgxpool of it.
+// Connections are established lazily, when pool is used.
+func Open(ctx context.Context, cfg Config, opts ...Option) (Pool, func(), error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, nil, err
+	}
+	op := &opener{ctx: ctx, cfg: cfg}
+	for _, opt := range opts {
+		opt(&op.opts)
+	}
+	if cfg.Metrics.Enabled && op.opts.collector == nil {
+		return nil, nil, ErrNoMetricsCollector
+	}
+
+	pool, err := op.open()
+	if err != nil {
+		op.close()
+		return nil, nil, err
+	}
+	if cfg.Metrics.Enabled {
+		pool = metrics.New(pool, op.opts.collector)
+		op.export(pool)
+	}
+	return pool, sync.OnceFunc(op.close), nil
+}
+
+type opener struct {
+	ctx      context.Context
+	cfg      Config
+	opts     options
+	pools    []*pgxpool.Pool
+	exporter func()
+}
+
+func (op *opener) open() (Pool, error) {
+	switch op.cfg.kind() {
+	case KindCluster:
+		return op.cluster("cluster", op.cfg.Cluster.Leader, op.cfg.Cluster.Followers)
+	case KindSharded:
+		return op.sharded()
+	default:
+		return op.node("node", Node{DSN: op.cfg.DSN})
+	}
+}
+
+// export runs export of pool stats, when collector exports them. Export is stopped before pools are closed.
+func (op *opener) export(pool Pool) {
+	col, ok := op.opts.collector.(metrics.PoolStatsCollector)
+	if !ok || col.PoolStatsInterval() <= 0 {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.WithoutCancel(op.ctx))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		metrics.ExportPoolStats(ctx, pool, op.opts.collector)
+	}()
+	op.exporter = func() {
+		cancel()
+		<-done
+	}
+}
+
+func (op *opener) close() {
+	if op.exporter != nil {
+		op.exporter()
+	}
+	for _, pool := range slices.Backward(op.pools) {
+		pool.Close()
+	}
+}
+
+func (op *opener) node(name string, node Node) (*regular.Instance, error) {
+	pcfg, err := pgxpool.ParseConfig(node.DSN)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrInvalidConfig, name, err)
+	}
+	for _, settings := range []PoolConfig{op.cfg.Pool, node.Pool} {
+		settings.apply(pcfg)
+	}
+	if op.opts.configure != nil {
+		op.opts.configure(pcfg)
+	}
+
+	pool, err := pgxpool.NewWithConfig(op.ctx, pcfg)
+	if err != nil {
+		return nil, fmt.Errorf("can't open pool of %s: %w", name, err)
+	}
+	op.pools = append(op.pools, pool)
+	return regular.New(pool), nil
+}
+
+func (op *opener) cluster(name string, leader Node, followers []Node) (*cluster.Cluster, error) {
+	leaderPool, err := op.node(name+" leader", leader)
+	if err != nil {
+		return nil, err
+	}
+	fellows := make([]cluster.Pool, 0, len(followers))
+	for i, follower := range followers {
+		pool, err := op.node(fmt.Sprintf("%s follower [%d]", name, i), follower)
+		if err != nil {
+			return nil, err
+		}
+		fellows = append(fellows, pool)
+	}
+
+	var opts []cluster.Option
+	if op.cfg.Cluster.Balancer == BalancerRandom {
+		opts = append(opts, cluster.WithLoadBalancer(cluster.RandomLoadBalancer()))
+	}
+	if op.cfg.Cluster.ReadRetries != nil {
+		opts = append(opts, cluster.WithReadRetries(*op.cfg.Cluster.ReadRetries))
+	}
+	if hc, ok := op.opts.collector.(cluster.HedgeCollector); ok && op.cfg.Metrics.Enabled {
+		opts = append(opts, cluster.WithHedgeCollector(hc))
+	}
+	return cluster.New(leaderPool, fellows, opts...), nil
+}
+
+func (op *opener) sharded() (*sharded.Hive, error) {
+	shards := make([]sharded.Pool, 0, len(op.cfg.Sharded.Shards))
+	for i, shard := range op.cfg.Sharded.Shards {
+		name := fmt.Sprintf("shard [%d]", i)
+		var (
+			pool sharded.Pool
+			err  error
+		)
+		if len(shard.Followers) == 0 {
+			pool, err = op.node(name, shard.Node)
+		} else {
+			pool, err = op.cluster(name, shard.Node, shard.Followers)
+		}
+		if err != nil {
+			return nil, err
+		}
+		shards = append(shards, pool)
+	}
+
+	picker := sharded.HashPicker(uint(len(shards)))
+	if op.cfg.Sharded.Picker == PickerJump {
+		picker = sharded.JumpPicker(uint(len(shards)))
+	}
+	return sharded.New(shards, picker), nil
+}
+
+func (settings PoolConfig) apply(cfg *pgxpool.Config) {
+	if settings.MaxConns > 0 {
+		cfg.MaxConns = settings.MaxConns
+	}
+	if settings.MinConns > 0 {
+		cfg.MinConns = settings.MinConns
+	}
+	if settings.MaxConnLifetime > 0 {
+		cfg.MaxConnLifetime = settings.MaxConnLifetime
+	}
+	if settings.MaxConnIdleTime > 0 {
+		cfg.MaxConnIdleTime = settings.MaxConnIdleTime
+	}
+}
@@ -0,0 +1,172 @@
+package topology
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/godepo/elephant/elephanttest"
+	"github.com/godepo/elephant/internal/pkg/lifecycle"
+	"github.com/godepo/elephant/internal/pkg/pgcontext"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type collector struct {
+	tracked int
+}
+
+func (c *collector) TrackQueryMetrics(context.Context, time.Time, error) {
+	c.tracked++
+}
+
+type statsCollector struct {
+	collector
+	stats chan lifecycle.Stats
+}
+
+func (c *statsCollector) TrackPoolStats(stats lifecycle.Stats) {
+	select {
+	case c.stats <- stats:
+	default:
+	}
+}
+
+func (c *statsCollector) PoolStatsInterval() time.Duration {
+	return time.Millisecond
+}
+
+func scalar(srv *elephanttest.Server, sql string) {
+	srv.On(elephanttest.Exact(sql)).ReturnRows(elephanttest.NewRows("n").Add(1))
+}
+
+func queryN(t *testing.T, ctx context.Context, pool Pool, sql string) {
+	t.Helper()
+	var n int
+	require.NoError(t, pool.QueryRow(ctx, sql).Scan(&n))
+	assert.Equal(t, 1, n)
+}
+
+func TestOpen(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should be able to open single node and close it", func(t *testing.T) {
+		srv := elephanttest.NewServer(t)
+		scalar(srv, "SELECT 1")
+		configured := 0
+
+		pool, closeFn, err := Open(ctx, Config{DSN: srv.DSN(), Pool: PoolConfig{MaxConns: 2}},
+			WithPoolConfig(func(cfg *pgxpool.Config) {
+				assert.Equal(t, int32(2), cfg.MaxConns)
+				configured++
+			}),
+		)
+		require.NoError(t, err)
+		assert.Equal(t, 1, configured)
+		queryN(t, ctx, pool, "SELECT 1")
+
+		closeFn()
+		closeFn()
+		require.Error(t, pool.QueryRow(ctx, "SELECT 1").Scan(new(int)))
+	})
+
+	t.Run("should be able to route cluster reads to follower and writes to leader", func(t *testing.T) {
+		leader, follower := elephanttest.NewServer(t), elephanttest.NewServer(t)
+		scalar(leader, "SELECT 1")
+		scalar(follower, "SELECT 1")
+		retries := 0
+
+		pool, closeFn, err := Open(ctx, Config{Cluster: ClusterConfig{
+			Leader:      Node{DSN: leader.DSN()},
+			Followers:   []Node{{DSN: follower.DSN()}},
+			Balancer:    BalancerRandom,
+			ReadRetries: &retries,
+		}})
+		require.NoError(t, err)
+		defer closeFn()
+
+		queryN(t, ctx, pool, "SELECT 1")
+		assert.Empty(t, leader.Received())
+		queryN(t, pgcontext.With(ctx, pgcontext.WithCanWrite), pool, "SELECT 1")
+		assert.Len(t, leader.Received(), 1)
+		assert.Len(t, follower.Received(), 1)
+	})
+
+	t.Run("should be able to route statements to shards", func(t *testing.T) {
+		first, second, replica := elephanttest.NewServer(t), elephanttest.NewServer(t), elephanttest.NewServer(t)
+		scalar(first, "SELECT 1")
+		scalar(replica, "SELECT 1")
+
+		pool, closeFn, err := Open(ctx, Config{Sharded: ShardedConfig{
+			Picker: PickerJump,
+			Shards: []Shard{
+				{Node: Node{DSN: first.DSN()}},
+				{Node: Node{DSN: second.DSN()}, Followers: []Node{{DSN: replica.DSN()}}},
+			},
+		}})
+		require.NoError(t, err)
+		defer closeFn()
+
+		queryN(t, pgcontext.With(ctx, pgcontext.WithShardID(0)), pool, "SELECT 1")
+		queryN(t, pgcontext.With(ctx, pgcontext.WithShardID(1)), pool, "SELECT 1")
+		assert.Len(t, first.Received(), 1)
+		assert.Empty(t, second.Received())
+		assert.Len(t, replica.Received(), 1)
+	})
+
+	t.Run("should be able to wrap pool by metrics", func(t *testing.T) {
+		srv := elephanttest.NewServer(t)
+		scalar(srv, "SELECT 1")
+		col := &collector{}
+
+		pool, closeFn, err := Open(ctx, Config{DSN: srv.DSN(), Metrics: MetricsConfig{Enabled: true}},
+			WithMetrics(col),
+		)
+		require.NoError(t, err)
+		defer closeFn()
+
+		queryN(t, ctx, pool, "SELECT 1")
+		assert.Equal(t, 1, col.tracked)
+	})
+
+	t.Run("should be able to export pool stats until pool is closed", func(t *testing.T) {
+		srv := elephanttest.NewServer(t)
+		col := &statsCollector{stats: make(chan lifecycle.Stats, 1)}
+
+		_, closeFn, err := Open(ctx, Config{DSN: srv.DSN(), Pool: PoolConfig{MaxConns: 3},
+			Metrics: MetricsConfig{Enabled: true}}, WithMetrics(col))
+		require.NoError(t, err)
+
+		stats := <-col.stats
+		require.Len(t, stats, 1)
+		assert.Equal(t, lifecycle.RoleNode, stats[0].Node.Role)
+		assert.Equal(t, int32(3), stats[0].Stat.MaxConns)
+
+		closeFn()
+		select {
+		case <-col.stats:
+		default:
+		}
+		select {
+		case <-col.stats:
+			t.Fatal("stats are exported after close")
+		case <-time.After(20 * time.Millisecond):
+		}
+	})
+
+	t.Run("should be able to fail at invalid config", func(t *testing.T) {
+		_, _, err := Open(ctx, Config{})
+		require.ErrorIs(t, err, ErrInvalidConfig)
+
+		_, _, err = Open(ctx, Config{DSN: "postgres://db", Metrics: MetricsConfig{Enabled: true}})
+		require.ErrorIs(t, err, ErrNoMetricsCollector)
+
+		_, _, err = Open(ctx, Config{Cluster: ClusterConfig{
+			Leader:    Node{DSN: "postgres://leader/db"},
+			Followers: []Node{{DSN: "postgres://replica/db?connect_timeout=many"}},
+		}})
+		require.ErrorIs(t, err, ErrInvalidConfig)
+		assert.ErrorContains(t, err, "cluster follower [0]")
+	})
+}
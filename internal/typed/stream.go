@@ -0,0 +1,145 @@
+package typed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/godepo/elephant/internal/pkg/pgcontext"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const defaultFetchSize = 1000
+
+var cursorSeq atomic.Uint64 //nolint:gochecknoglobals
+
+// Streamer is implemented by every elephant pool and is accepted by Stream.
+type Streamer interface {
+	Query(ctx context.Context, query string, args ...any) (pgx.Rows, error)
+	Exec(ctx context.Context, query string, args ...any) (pgconn.CommandTag, error)
+}
+
+// StreamTracker receives count of streamed rows and time to first row when iteration is finished. Streamer which
+// implements it, like metrics pool, tracks its streams, tracker set by pgcontext.WithStreamTracker overrides it.
+type StreamTracker = pgcontext.StreamTracker
+
+// Stream iterates rows mapped to struct T by column names. When transaction is in context, rows are fetched
+// from server-side cursor by batches of fetch size from context, so memory stays bounded. Otherwise rows are
+// read from single query. Iteration stops after first error, rows or cursor are closed on early break.
+func Stream[T any](ctx context.Context, db Streamer, query string, args ...any) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		st := stream[T]{
+			begin: time.Now(),
+			yield: yield,
+		}
+		if _, ok := pgcontext.TransactionFrom(ctx); ok {
+			st.err = st.cursor(ctx, db, query, args)
+		} else {
+			st.err = st.rows(ctx, db, query, args)
+		}
+
+		if st.err != nil && !st.stopped {
+			var zero T
+			yield(zero, st.err)
+		}
+		if tracker, ok := trackerOf(ctx, db); ok {
+			tracker.TrackStreamMetrics(ctx, st.firstRow, st.count, st.err)
+		}
+	}
+}
+
+// trackerOf returns tracker from context, or db when it tracks streams itself.
+func trackerOf(ctx context.Context, db Streamer) (StreamTracker, bool) {
+	if tracker, ok := pgcontext.StreamTrackerFrom(ctx); ok {
+		return tracker, true
+	}
+	tracker, ok := db.(StreamTracker)
+	return tracker, ok
+}
+
+type stream[T any] struct {
+	begin    time.Time
+	firstRow time.Duration
+	count    int
+	stopped  bool
+	err      error
+	yield    func(T, error) bool
+}
+
+// emit maps every row and yields it, it returns false when iteration is stopped by consumer.
+func (st *stream[T]) emit(rows pgx.Rows) (bool, error) {
+	for rows.Next() {
+		if st.count == 0 {
+			st.firstRow = time.Since(st.begin)
+		}
+		st.count++
+
+		value, err := pgx.RowToStructByName[T](rows)
+		if err != nil {
+			return true, fmt.Errorf("can't map streamed row: %w", err)
+		}
+		if !st.yield(value, nil) {
+			st.stopped = true
+			return false, nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return true, fmt.Errorf("can't read streamed rows: %w", err)
+	}
+	return true, nil
+}
+
+func (st *stream[T]) rows(ctx context.Context, db Streamer, query string, args []any) error {
+	rows, err := db.Query(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("can't query stream: %w", err)
+	}
+	defer rows.Close()
+
+	_, err = st.emit(rows)
+	return err
+}
+
+func (st *stream[T]) cursor(ctx context.Context, db Streamer, query string, args []any) (out error) {
+	size := defaultFetchSize
+	if fetchSize, ok := pgcontext.FetchSizeFrom(ctx); ok && fetchSize > 0 {
+		size = fetchSize
+	}
+
+	name := pgx.Identifier{"elephant_stream_" + strconv.FormatUint(cursorSeq.Add(1), 10)}.Sanitize()
+	if _, err := db.Exec(ctx, "DECLARE "+name+" NO SCROLL CURSOR FOR "+query, args...); err != nil {
+		return fmt.Errorf("can't declare cursor: %w", err)
+	}
+	defer func() {
+		var pgErr *pgconn.PgError
+		if errors.As(out, &pgErr) {
+			// transaction is aborted by error of server, cursor is closed with it
+			return
+		}
+		if _, err := db.Exec(ctx, "CLOSE "+name); err != nil {
+			out = errors.Join(out, fmt.Errorf("can't close cursor: %w", err))
+		}
+	}()
+
+	fetch := "FETCH FORWARD " + strconv.Itoa(size) + " FROM " + name
+	for {
+		rows, err := db.Query(ctx, fetch)
+		if err != nil {
+			return fmt.Errorf("can't fetch cursor: %w", err)
+		}
+		before := st.count
+		more, err := st.emit(rows)
+		rows.Close()
+		if err != nil || !more {
+			return err
+		}
+		if st.count-before < size {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package typed
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/godepo/elephant/elephanttest"
+	"github.com/godepo/elephant/internal/metrics"
+	"github.com/godepo/elephant/internal/pkg/pgcontext"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type tracker struct {
+	firstRow time.Duration
+	rows     int
+	err      error
+	tracked  bool
+}
+
+func (p *tracker) TrackStreamMetrics(_ context.Context, firstRow time.Duration, rows int, err error) {
+	p.firstRow, p.rows, p.err, p.tracked = firstRow, rows, err, true
+}
+
+// streamCollector is collector of metrics pool which tracks stream metrics.
+type streamCollector struct {
+	tracker
+}
+
+func (c *streamCollector) TrackQueryMetrics(context.Context, time.Time, error) {}
+
+func collect[T any](t *testing.T, seq func(yield func(T, error) bool)) ([]T, error) {
+	t.Helper()
+	var out []T
+	for value, err := range seq {
+		if err != nil {
+			return out, err
+		}
+		out = append(out, value)
+	}
+	return out, nil
+}
+
+func TestStream(t *testing.T) {
+	users := func() *elephanttest.Rows {
+		return elephanttest.NewRows("id", "name").Add(int64(1), "bob").Add(int64(2), "alice")
+	}
+
+	t.Run("should be able to stream rows of query outside transaction", func(t *testing.T) {
+		pool := elephanttest.New(t)
+		pool.ExpectQuery(elephanttest.Exact(query)).WithArgs(1).OutsideTx().ReturnRows(users())
+		track := &tracker{}
+		ctx := pgcontext.WithStreamTracker(track)(context.Background())
+
+		out, err := collect(t, Stream[user](ctx, pool, query, 1))
+		require.NoError(t, err)
+		assert.Equal(t, []user{{ID: 1, Name: "bob"}, {ID: 2, Name: "alice"}}, out)
+		assert.True(t, track.tracked)
+		assert.Equal(t, 2, track.rows)
+		assert.Positive(t, track.firstRow)
+		assert.NoError(t, track.err)
+	})
+
+	t.Run("should be able to stream rows without tracker", func(t *testing.T) {
+		pool := elephanttest.New(t)
+		pool.ExpectQuery(elephanttest.Exact(query)).WithArgs(1).ReturnRows(users())
+
+		out, err := collect(t, Stream[user](context.Background(), pool, query, 1))
+		require.NoError(t, err)
+		assert.Len(t, out, 2)
+	})
+
+	t.Run("should be able to track stream by metrics pool without tracker in context", func(t *testing.T) {
+		pool := elephanttest.New(t)
+		pool.ExpectQuery(elephanttest.Exact(query)).WithArgs(1).ReturnRows(users())
+		col := &streamCollector{}
+
+		out, err := collect(t, Stream[user](context.Background(), metrics.New(pool, col), query, 1))
+		require.NoError(t, err)
+		assert.Len(t, out, 2)
+		assert.True(t, col.tracked)
+		assert.Equal(t, 2, col.rows)
+		assert.Positive(t, col.firstRow)
+	})
+
+	t.Run("should be able to override tracker of metrics pool by context", func(t *testing.T) {
+		pool := elephanttest.New(t)
+		pool.ExpectQuery(elephanttest.Exact(query)).WithArgs(1).ReturnRows(users())
+		col, track := &streamCollector{}, &tracker{}
+		ctx := pgcontext.WithStreamTracker(track)(context.Background())
+
+		_, err := collect(t, Stream[user](ctx, metrics.New(pool, col), query, 1))
+		require.NoError(t, err)
+		assert.True(t, track.tracked)
+		assert.False(t, col.tracked)
+	})
+
+	t.Run("should be able to stop on early break", func(t *testing.T) {
+		pool := elephanttest.New(t)
+		pool.ExpectQuery(elephanttest.Exact(query)).WithArgs(1).ReturnRows(users())
+		track := &tracker{}
+		ctx := pgcontext.WithStreamTracker(track)(context.Background())
+
+		for value, err := range Stream[user](ctx, pool, query, 1) {
+			require.NoError(t, err)
+			assert.Equal(t, "bob", value.Name)
+			break
+		}
+		assert.Equal(t, 1, track.rows)
+	})
+
+	t.Run("should be able to yield query error", func(t *testing.T) {
+		pool := elephanttest.New(t)
+		expErr := errors.New(uuid.NewString())
+		pool.ExpectQuery(elephanttest.Exact(query)).WithArgs(1).ReturnError(expErr)
+		track := &tracker{}
+		ctx := pgcontext.WithStreamTracker(track)(context.Background())
+
+		_, err := collect(t, Stream[user](ctx, pool, query, 1))
+		require.ErrorIs(t, err, expErr)
+		assert.ErrorIs(t, track.err, expErr)
+		assert.Zero(t, track.rows)
+	})
+
+	t.Run("should be able to yield mapping error", func(t *testing.T) {
+		pool := elephanttest.New(t)
+		pool.ExpectQuery(elephanttest.Exact(query)).WithArgs(1).
+			ReturnRows(elephanttest.NewRows("id", "email").Add(int64(1), "bob@example.com"))
+
+		_, err := collect(t, Stream[user](context.Background(), pool, query, 1))
+		require.Error(t, err)
+	})
+
+	t.Run("should be able to fetch rows from cursor in transaction by batches", func(t *testing.T) {
+		pool := elephanttest.New(t)
+		pool.ExpectExec(elephanttest.Regexp(`^DECLARE "elephant_stream_\d+" NO SCROLL CURSOR FOR SELECT`)).
+			WithArgs(1).InTx().ReturnTag("DECLARE CURSOR")
+		pool.ExpectQuery(elephanttest.Regexp(`^FETCH FORWARD 2 FROM "elephant_stream_\d+"$`)).InTx().
+			ReturnRows(users()).Times(1)
+		pool.ExpectQuery(elephanttest.Regexp(`^FETCH FORWARD 2 FROM`)).InTx().
+			ReturnRows(elephanttest.NewRows("id", "name").Add(int64(3), "eve")).Times(1)
+		pool.ExpectExec(elephanttest.Regexp(`^CLOSE "elephant_stream_\d+"$`)).InTx().ReturnTag("CLOSE CURSOR")
+
+		ctx := pgcontext.With(context.Background(), pgcontext.WithFetchSize(2))
+		require.NoError(t, pool.Transactional(ctx, func(ctx context.Context) error {
+			out, err := collect(t, Stream[user](ctx, pool, query, 1))
+			assert.Len(t, out, 3)
+			return err
+		}))
+	})
+
+	t.Run("should be able to close cursor on early break", func(t *testing.T) {
+		pool := elephanttest.New(t)
+		pool.ExpectExec(elephanttest.Regexp(`^DECLARE`)).InTx().ReturnTag("DECLARE CURSOR")
+		pool.ExpectQuery(elephanttest.Regexp(`^FETCH FORWARD 1000 FROM`)).InTx().ReturnRows(users())
+		pool.ExpectExec(elephanttest.Regexp(`^CLOSE`)).InTx().ReturnTag("CLOSE CURSOR")
+
+		require.NoError(t, pool.Transactional(context.Background(), func(ctx context.Context) error {
+			for _, err := range Stream[user](ctx, pool, query, 1) {
+				require.NoError(t, err)
+				break
+			}
+			return nil
+		}))
+	})
+
+	t.Run("should be able to close cursor after mapping error", func(t *testing.T) {
+		pool := elephanttest.New(t)
+		pool.ExpectExec(elephanttest.Regexp(`^DECLARE`)).InTx().ReturnTag("DECLARE CURSOR")
+		pool.ExpectQuery(elephanttest.Regexp(`^FETCH FORWARD 1000 FROM`)).InTx().
+			ReturnRows(elephanttest.NewRows("id", "email").Add(int64(1), "bob@example.com"))
+		pool.ExpectExec(elephanttest.Regexp(`^CLOSE`)).InTx().ReturnTag("CLOSE CURSOR")
+
+		err := pool.Transactional(context.Background(), func(ctx context.Context) error {
+			_, err := collect(t, Stream[user](ctx, pool, query, 1))
+			return err
+		})
+		require.ErrorContains(t, err, "can't map streamed row")
+		assert.NotContains(t, err.Error(), "can't close cursor")
+	})
+
+	t.Run("should be able to yield declare and fetch errors", func(t *testing.T) {
+		expErr := &pgconn.PgError{Code: "57014", Message: uuid.NewString()}
+		pool := elephanttest.New(t)
+		pool.ExpectExec(elephanttest.Regexp(`^DECLARE`)).InTx().ReturnError(expErr).Times(1)
+		pool.ExpectExec(elephanttest.Regexp(`^DECLARE`)).InTx().ReturnTag("DECLARE CURSOR").Times(1)
+		pool.ExpectQuery(elephanttest.Regexp(`^FETCH`)).InTx().ReturnError(expErr)
+
+		for range 2 {
+			err := pool.Transactional(context.Background(), func(ctx context.Context) error {
+				_, err := collect(t, Stream[user](ctx, pool, query, 1))
+				return err
+			})
+			require.ErrorIs(t, err, expErr)
+		}
+	})
+}
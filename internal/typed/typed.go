@@ -0,0 +1,84 @@
+// Package typed implements generic helpers which query elephant pools and map rows to Go values. Helpers go
+// through Query of pool, so transaction from context and routing hints are honored.
+package typed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/godepo/elephant/internal/pkg/monads"
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrNotFound    = errors.New("row not found")
+	ErrTooManyRows = errors.New("too many rows")
+)
+
+// Querier is implemented by every elephant pool.
+type Querier interface {
+	Query(ctx context.Context, query string, args ...any) (pgx.Rows, error)
+}
+
+// One returns exactly one row mapped to struct T by column names. ErrNotFound is returned when there are no rows
+// and ErrTooManyRows when there are more than one.
+func One[T any](ctx context.Context, db Querier, query string, args ...any) (T, error) {
+	return exactlyOne(ctx, db, pgx.RowToStructByName[T], query, args)
+}
+
+// Scalar returns value of single column of exactly one row, like count(*) or id returned by insert.
+func Scalar[T any](ctx context.Context, db Querier, query string, args ...any) (T, error) {
+	return exactlyOne(ctx, db, pgx.RowTo[T], query, args)
+}
+
+// All returns every row mapped to struct T by column names.
+func All[T any](ctx context.Context, db Querier, query string, args ...any) ([]T, error) {
+	rows, err := db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("can't query rows: %w", err)
+	}
+	out, err := pgx.CollectRows(rows, pgx.RowToStructByName[T])
+	if err != nil {
+		return nil, fmt.Errorf("can't collect rows: %w", err)
+	}
+	return out, nil
+}
+
+// Optional returns row mapped to struct T by column names, or empty optional when there are no rows.
+// ErrTooManyRows is returned when there are more than one row.
+func Optional[T any](ctx context.Context, db Querier, query string, args ...any) (monads.Optional[T], error) {
+	out, err := One[T](ctx, db, query, args...)
+	if errors.Is(err, ErrNotFound) {
+		return monads.EmptyOf[T](), nil
+	}
+	if err != nil {
+		return monads.EmptyOf[T](), err
+	}
+	return monads.OptionalOf(out), nil
+}
+
+func exactlyOne[T any](
+	ctx context.Context,
+	db Querier,
+	mapper pgx.RowToFunc[T],
+	query string,
+	args []any,
+) (T, error) {
+	var zero T
+
+	rows, err := db.Query(ctx, query, args...)
+	if err != nil {
+		return zero, fmt.Errorf("can't query row: %w", err)
+	}
+	out, err := pgx.CollectExactlyOneRow(rows, mapper)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return zero, fmt.Errorf("%w: %w", ErrNotFound, err)
+	case errors.Is(err, pgx.ErrTooManyRows):
+		return zero, fmt.Errorf("%w: %w", ErrTooManyRows, err)
+	case err != nil:
+		return zero, fmt.Errorf("can't collect row: %w", err)
+	}
+	return out, nil
+}
@@ -0,0 +1,152 @@
+package typed
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/godepo/elephant/elephanttest"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type user struct {
+	ID   int64  `db:"id"`
+	Name string `db:"name"`
+}
+
+const query = "SELECT id, name FROM users WHERE id = $1"
+
+func returnRows(t *testing.T, rows *elephanttest.Rows) *elephanttest.Pool {
+	t.Helper()
+	db := elephanttest.New(t)
+	db.ExpectQuery(elephanttest.Exact(query)).WithArgs(1).ReturnRows(rows)
+	return db
+}
+
+func returnError(t *testing.T, err error) *elephanttest.Pool {
+	t.Helper()
+	db := elephanttest.New(t)
+	db.ExpectQuery(elephanttest.Exact(query)).WithArgs(1).ReturnError(err)
+	return db
+}
+
+func TestOne(t *testing.T) {
+	t.Run("should be able to map single row", func(t *testing.T) {
+		db := returnRows(t, elephanttest.NewRows("id", "name").Add(int64(1), "bob"))
+
+		out, err := One[user](context.Background(), db, query, 1)
+		require.NoError(t, err)
+		assert.Equal(t, user{ID: 1, Name: "bob"}, out)
+	})
+
+	t.Run("should be able to return not found", func(t *testing.T) {
+		db := returnRows(t, elephanttest.NewRows("id", "name"))
+
+		_, err := One[user](context.Background(), db, query, 1)
+		require.ErrorIs(t, err, ErrNotFound)
+		require.ErrorIs(t, err, pgx.ErrNoRows)
+	})
+
+	t.Run("should be able to return too many rows", func(t *testing.T) {
+		db := returnRows(t, elephanttest.NewRows("id", "name").Add(int64(1), "bob").Add(int64(2), "alice"))
+
+		_, err := One[user](context.Background(), db, query, 1)
+		require.ErrorIs(t, err, ErrTooManyRows)
+	})
+
+	t.Run("should be able to return query error", func(t *testing.T) {
+		expErr := errors.New(uuid.NewString())
+		db := returnError(t, expErr)
+
+		_, err := One[user](context.Background(), db, query, 1)
+		require.ErrorIs(t, err, expErr)
+	})
+
+	t.Run("should be able to return mapping error", func(t *testing.T) {
+		db := returnRows(t, elephanttest.NewRows("id", "email").Add(int64(1), "bob@example.com"))
+
+		_, err := One[user](context.Background(), db, query, 1)
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, ErrNotFound)
+	})
+}
+
+func TestScalar(t *testing.T) {
+	t.Run("should be able to return single column", func(t *testing.T) {
+		db := returnRows(t, elephanttest.NewRows("count").Add(int64(42)))
+
+		out, err := Scalar[int64](context.Background(), db, query, 1)
+		require.NoError(t, err)
+		assert.Equal(t, int64(42), out)
+	})
+
+	t.Run("should be able to return not found", func(t *testing.T) {
+		db := returnRows(t, elephanttest.NewRows("count"))
+
+		_, err := Scalar[int64](context.Background(), db, query, 1)
+		require.ErrorIs(t, err, ErrNotFound)
+	})
+}
+
+func TestAll(t *testing.T) {
+	t.Run("should be able to map every row", func(t *testing.T) {
+		db := returnRows(t, elephanttest.NewRows("id", "name").Add(int64(1), "bob").Add(int64(2), "alice"))
+
+		out, err := All[user](context.Background(), db, query, 1)
+		require.NoError(t, err)
+		assert.Equal(t, []user{{ID: 1, Name: "bob"}, {ID: 2, Name: "alice"}}, out)
+	})
+
+	t.Run("should be able to return empty slice", func(t *testing.T) {
+		db := returnRows(t, elephanttest.NewRows("id", "name"))
+
+		out, err := All[user](context.Background(), db, query, 1)
+		require.NoError(t, err)
+		assert.Empty(t, out)
+	})
+
+	t.Run("should be able to return query error", func(t *testing.T) {
+		expErr := errors.New(uuid.NewString())
+		db := returnError(t, expErr)
+
+		_, err := All[user](context.Background(), db, query, 1)
+		require.ErrorIs(t, err, expErr)
+	})
+
+	t.Run("should be able to return mapping error", func(t *testing.T) {
+		db := returnRows(t, elephanttest.NewRows("id", "email").Add(int64(1), "bob@example.com"))
+
+		_, err := All[user](context.Background(), db, query, 1)
+		require.Error(t, err)
+	})
+}
+
+func TestOptional(t *testing.T) {
+	t.Run("should be able to return value", func(t *testing.T) {
+		db := returnRows(t, elephanttest.NewRows("id", "name").Add(int64(1), "bob"))
+
+		out, err := Optional[user](context.Background(), db, query, 1)
+		require.NoError(t, err)
+		require.False(t, out.IsEmpty())
+		assert.Equal(t, user{ID: 1, Name: "bob"}, out.Value)
+	})
+
+	t.Run("should be able to return empty optional", func(t *testing.T) {
+		db := returnRows(t, elephanttest.NewRows("id", "name"))
+
+		out, err := Optional[user](context.Background(), db, query, 1)
+		require.NoError(t, err)
+		assert.True(t, out.IsEmpty())
+	})
+
+	t.Run("should be able to return too many rows", func(t *testing.T) {
+		db := returnRows(t, elephanttest.NewRows("id", "name").Add(int64(1), "bob").Add(int64(2), "alice"))
+
+		out, err := Optional[user](context.Background(), db, query, 1)
+		require.ErrorIs(t, err, ErrTooManyRows)
+		assert.True(t, out.IsEmpty())
+	})
+}
@@ -2,11 +2,15 @@
 package metrics
 
 import (
+	"context"
+
 	"github.com/godepo/elephant"
 	"github.com/godepo/elephant/internal/metrics"
 	"github.com/godepo/elephant/internal/metrics/collector"
 )
 
+// Collector returns builder of metrics collector. Builder implements extension builders of elephant: stream, breaker,
+// hedge, lock and pool metrics builders.
 func Collector() elephant.MetricsBuilder {
 	return collector.New()
 }
@@ -14,3 +18,9 @@ func Collector() elephant.MetricsBuilder {
 func New(pool metrics.Pool, col metrics.Collector) metrics.Pool {
 	return metrics.New(pool, col)
 }
+
+// ExportPoolStats sets pool gauges of collector by stats of pool every interval of collector. It blocks until ctx
+// is done and returns at once, when collector is built without pool gauges.
+func ExportPoolStats(ctx context.Context, pool elephant.Stater, col elephant.MetricsCollector) {
+	metrics.ExportPoolStats(ctx, pool, col)
+}
@@ -5,8 +5,10 @@ import (
 	"errors"
 	"testing"
 
+	"github.com/godepo/elephant"
 	"github.com/godepo/elephant/internal/pkg/pgcontext"
 	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
@@ -14,6 +16,11 @@ import (
 func TestCollector(t *testing.T) {
 	col := Collector()
 	require.NotNil(t, col)
+	assert.Implements(t, (*elephant.StreamMetricsBuilder)(nil), col)
+	assert.Implements(t, (*elephant.BreakerMetricsBuilder)(nil), col)
+	assert.Implements(t, (*elephant.HedgeMetricsBuilder)(nil), col)
+	assert.Implements(t, (*elephant.LockMetricsBuilder)(nil), col)
+	assert.Implements(t, (*elephant.PoolMetricsBuilder)(nil), col)
 }
 
 func TestNew(t *testing.T) {
@@ -34,3 +41,15 @@ func TestNew(t *testing.T) {
 	require.Error(t, err)
 	require.ErrorIs(t, err, expErr)
 }
+
+type stats elephant.PoolStats
+
+func (s stats) Stats() elephant.PoolStats {
+	return elephant.PoolStats(s)
+}
+
+func TestExportPoolStats(t *testing.T) {
+	t.Run("should be able to return at once, when collector has no pool gauges", func(t *testing.T) {
+		ExportPoolStats(context.Background(), stats{}, NewMockCollector(t))
+	})
+}
@@ -0,0 +1,39 @@
+// Package nplusone provides development-mode decorator for elephant pools which reports statements running more
+// times than threshold within one request context or Transactional scope.
+package nplusone
+
+import (
+	"github.com/godepo/elephant/internal/nplusone"
+	"github.com/godepo/elephant/internal/pkg/passthrough"
+)
+
+var (
+	ErrAcquireNotSupported = passthrough.ErrAcquireNotSupported
+	ErrBulkNotSupported    = passthrough.ErrBulkNotSupported
+	ErrListenNotSupported  = passthrough.ErrListenNotSupported
+	ErrLockNotSupported    = passthrough.ErrLockNotSupported
+)
+
+type (
+	Pool     = nplusone.Pool
+	DB       = nplusone.DB
+	Detector = nplusone.Detector
+	Report   = nplusone.Report
+	Reporter = nplusone.Reporter
+	Option   = nplusone.Option
+	TestingT = nplusone.TestingT
+)
+
+// New makes detector which sends reports to reporter, pools are decorated by Detector.Wrap.
+func New(reporter Reporter, opts ...Option) *Detector {
+	return nplusone.New(reporter, opts...)
+}
+
+// FailTest makes reporter which fails test with report.
+func FailTest(t TestingT) Reporter {
+	return nplusone.FailTest(t)
+}
+
+func WithThreshold(n int) Option {
+	return nplusone.WithThreshold(n)
+}
@@ -0,0 +1,61 @@
+package nplusone
+
+import (
+	"context"
+	"testing"
+
+	"github.com/godepo/elephant/elephanttest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	t.Run("should be able to report query per item in request scope", func(t *testing.T) {
+		pool := elephanttest.New(t)
+		pool.ExpectQuery(elephanttest.Fingerprint("SELECT name FROM items WHERE id = 1")).
+			ReturnRows(elephanttest.NewRows("name").Add("pen")).AnyTimes()
+
+		var reports []Report
+		det := New(func(report Report) { reports = append(reports, report) }, WithThreshold(3))
+		db := det.Wrap(pool)
+
+		ctx, finish := det.Scope(context.Background())
+		for id := range 5 {
+			var name string
+			require.NoError(t, db.QueryRow(ctx, "SELECT name FROM items WHERE id = $1", id).Scan(&name))
+		}
+		finish()
+
+		require.Len(t, reports, 1)
+		assert.Equal(t, 5, reports[0].Count)
+		assert.Contains(t, reports[0].Stacks[0], "nplusone_test.go")
+	})
+
+	t.Run("should be able to fail test in transactional scope", func(t *testing.T) {
+		pool := elephanttest.New(t)
+		pool.ExpectExec(elephanttest.Regexp(`^UPDATE`)).ReturnTag("UPDATE 1").AnyTimes()
+
+		ft := &fakeT{}
+		db := New(FailTest(ft), WithThreshold(1)).Wrap(pool)
+
+		require.NoError(t, db.Transactional(context.Background(), func(ctx context.Context) error {
+			for range 2 {
+				if _, err := db.Exec(ctx, "UPDATE items SET n = n + 1"); err != nil {
+					return err
+				}
+			}
+			return nil
+		}))
+		assert.Len(t, ft.errors, 1)
+	})
+}
+
+type fakeT struct {
+	errors []string
+}
+
+func (f *fakeT) Helper() {}
+
+func (f *fakeT) Errorf(format string, _ ...any) {
+	f.errors = append(f.errors, format)
+}
@@ -0,0 +1,66 @@
+package outbox
+
+import (
+	"time"
+
+	"github.com/godepo/elephant/internal/outbox"
+)
+
+var ErrNoTransaction = outbox.ErrNoTransaction
+
+const (
+	StatusPending    = outbox.StatusPending
+	StatusDispatched = outbox.StatusDispatched
+	StatusDead       = outbox.StatusDead
+)
+
+type (
+	DB        = outbox.DB
+	Publisher = outbox.Publisher
+	Message   = outbox.Message
+	Option    = outbox.Option
+	Outbox    = outbox.Outbox
+)
+
+// New makes outbox on db, which is any elephant pool. Messages are written by transaction from context and
+// relayed by Run. For sharded pools Run relays messages from every shard, see WithShards.
+func New(db DB, opts ...Option) *Outbox {
+	return outbox.New(db, opts...)
+}
+
+// Schema returns DDL of outbox table.
+func Schema(table string) string {
+	return outbox.Schema(table)
+}
+
+func WithTable(table string) Option {
+	return outbox.WithTable(table)
+}
+
+func WithBatchSize(size int) Option {
+	return outbox.WithBatchSize(size)
+}
+
+func WithPollInterval(interval time.Duration) Option {
+	return outbox.WithPollInterval(interval)
+}
+
+func WithMaxAttempts(attempts int) Option {
+	return outbox.WithMaxAttempts(attempts)
+}
+
+func WithBackoff(minDelay, maxDelay time.Duration) Option {
+	return outbox.WithBackoff(minDelay, maxDelay)
+}
+
+func WithShards(n uint) Option {
+	return outbox.WithShards(n)
+}
+
+func WithNotifyChannel(channel string) Option {
+	return outbox.WithNotifyChannel(channel)
+}
+
+func WithErrorHandler(handler func(err error)) Option {
+	return outbox.WithErrorHandler(handler)
+}
@@ -0,0 +1,27 @@
+package outbox
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	ob := New(nil,
+		WithTable("events.outbox"),
+		WithBatchSize(10),
+		WithPollInterval(time.Second),
+		WithMaxAttempts(3),
+		WithBackoff(time.Second, time.Minute),
+		WithShards(2),
+		WithNotifyChannel("outbox"),
+		WithErrorHandler(func(error) {}),
+	)
+	require.NotNil(t, ob)
+
+	require.ErrorIs(t, ob.Write(context.Background(), Message{Topic: "orders"}), ErrNoTransaction)
+	assert.Contains(t, Schema("events.outbox"), `"events"."outbox"`)
+}
@@ -0,0 +1,78 @@
+package queue
+
+import (
+	"time"
+
+	"github.com/godepo/elephant/internal/queue"
+)
+
+var (
+	ErrLeaseExpired = queue.ErrLeaseExpired
+	ErrLeaseLost    = queue.ErrLeaseLost
+)
+
+const (
+	StatusPending = queue.StatusPending
+	StatusDone    = queue.StatusDone
+	StatusDead    = queue.StatusDead
+)
+
+type (
+	DB        = queue.DB
+	Handler   = queue.Handler
+	Job       = queue.Job
+	Option    = queue.Option
+	JobOption = queue.JobOption
+	Queue     = queue.Queue
+)
+
+// New makes job queue on db, which is any elephant pool. Jobs are enqueued by transaction from context and
+// processed inside Transactional of db.
+func New(db DB, opts ...Option) *Queue {
+	return queue.New(db, opts...)
+}
+
+// Schema returns DDL of jobs table.
+func Schema(table string) string {
+	return queue.Schema(table)
+}
+
+func WithTable(table string) Option {
+	return queue.WithTable(table)
+}
+
+func WithWorkers(n int) Option {
+	return queue.WithWorkers(n)
+}
+
+func WithPollInterval(interval time.Duration) Option {
+	return queue.WithPollInterval(interval)
+}
+
+func WithVisibilityTimeout(timeout time.Duration) Option {
+	return queue.WithVisibilityTimeout(timeout)
+}
+
+func WithBackoff(minDelay, maxDelay time.Duration) Option {
+	return queue.WithBackoff(minDelay, maxDelay)
+}
+
+func WithErrorHandler(handler func(err error)) Option {
+	return queue.WithErrorHandler(handler)
+}
+
+func WithPriority(priority int) JobOption {
+	return queue.WithPriority(priority)
+}
+
+func WithRunAt(at time.Time) JobOption {
+	return queue.WithRunAt(at)
+}
+
+func WithDelay(delay time.Duration) JobOption {
+	return queue.WithDelay(delay)
+}
+
+func WithMaxAttempts(attempts int) JobOption {
+	return queue.WithMaxAttempts(attempts)
+}
@@ -0,0 +1,26 @@
+package queue
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	q := New(nil,
+		WithTable("work.jobs"),
+		WithWorkers(4),
+		WithPollInterval(time.Second),
+		WithVisibilityTimeout(time.Minute),
+		WithBackoff(time.Second, time.Hour),
+		WithErrorHandler(func(error) {}),
+	)
+	require.NotNil(t, q)
+
+	for _, opt := range []JobOption{WithPriority(1), WithRunAt(time.Now()), WithDelay(time.Second), WithMaxAttempts(3)} {
+		assert.NotNil(t, opt)
+	}
+	assert.Contains(t, Schema("work.jobs"), `"work"."jobs"`)
+}
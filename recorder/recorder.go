@@ -0,0 +1,66 @@
+// Package recorder provides decorator for elephant pools which records statements with normalized SQL, argument
+// types, routing decision, transaction depth and outcome, and helper which compares them with golden file.
+package recorder
+
+import (
+	"github.com/godepo/elephant/internal/pkg/passthrough"
+	"github.com/godepo/elephant/internal/recorder"
+)
+
+var (
+	ErrAcquireNotSupported = passthrough.ErrAcquireNotSupported
+	ErrBulkNotSupported    = passthrough.ErrBulkNotSupported
+	ErrListenNotSupported  = passthrough.ErrListenNotSupported
+	ErrLockNotSupported    = passthrough.ErrLockNotSupported
+)
+
+const (
+	OpQuery    = recorder.OpQuery
+	OpQueryRow = recorder.OpQueryRow
+	OpExec     = recorder.OpExec
+	OpCopy     = recorder.OpCopy
+	OpBegin    = recorder.OpBegin
+	OpCommit   = recorder.OpCommit
+	OpRollback = recorder.OpRollback
+
+	OutcomeOK     = recorder.OutcomeOK
+	OutcomeNoRows = recorder.OutcomeNoRows
+	OutcomeError  = recorder.OutcomeError
+
+	RouteLeader   = recorder.RouteLeader
+	RouteFollower = recorder.RouteFollower
+
+	UpdateGoldenEnv = recorder.UpdateGoldenEnv
+)
+
+type (
+	Pool       = recorder.Pool
+	DB         = recorder.DB
+	Recorder   = recorder.Recorder
+	Entry      = recorder.Entry
+	Option     = recorder.Option
+	NodeOption = recorder.NodeOption
+	TestingT   = recorder.TestingT
+)
+
+// New makes recorder, pools are decorated by Recorder.Wrap.
+func New(opts ...Option) *Recorder {
+	return recorder.New(opts...)
+}
+
+func WithSink(sink func(Entry)) Option {
+	return recorder.WithSink(sink)
+}
+
+func WithRoute(route string) NodeOption {
+	return recorder.WithRoute(route)
+}
+
+func WithShard(id uint) NodeOption {
+	return recorder.WithShard(id)
+}
+
+// AssertGolden compares recorded entries with golden file, which is rewritten when UpdateGoldenEnv is set.
+func AssertGolden(t TestingT, path string, rec *Recorder) bool {
+	return recorder.AssertGolden(t, path, rec)
+}
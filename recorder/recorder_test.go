@@ -0,0 +1,49 @@
+package recorder
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/godepo/elephant/clusterpg"
+	"github.com/godepo/elephant/elephanttest"
+	"github.com/godepo/elephant/internal/pkg/pgcontext"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAssertGolden(t *testing.T) {
+	t.Run("should be able to record routing of cluster statements", func(t *testing.T) {
+		rec := New()
+		leader, follower := elephanttest.New(t), elephanttest.New(t)
+		follower.ExpectQuery(elephanttest.Regexp(`^SELECT`)).
+			ReturnRows(elephanttest.NewRows("name").Add("bob"))
+		leader.ExpectExec(elephanttest.Regexp(`^UPDATE`)).InTx().ReturnTag("UPDATE 1").Times(2)
+
+		db, err := clusterpg.New().
+			Leader(func() (clusterpg.Pool, error) {
+				return rec.Wrap(leader, WithRoute(RouteLeader)), nil
+			}).
+			Follower(func() (clusterpg.Pool, error) {
+				return rec.Wrap(follower, WithRoute(RouteFollower)), nil
+			}).
+			Go()
+		require.NoError(t, err)
+
+		ctx := context.Background()
+		var name string
+		require.NoError(t, db.QueryRow(ctx, "SELECT name FROM users WHERE id = $1", 42).Scan(&name))
+
+		wctx := pgcontext.With(ctx, pgcontext.WithCanWrite)
+		require.NoError(t, db.Transactional(wctx, func(ctx context.Context) error {
+			if _, err := db.Exec(ctx, "UPDATE users SET name = $1 WHERE id = $2", name, 42); err != nil {
+				return err
+			}
+			return db.Transactional(ctx, func(ctx context.Context) error {
+				_, err := db.Exec(ctx, "UPDATE users SET visits = visits + 1 WHERE id = $1", 42)
+				return err
+			})
+		}))
+
+		AssertGolden(t, filepath.Join("testdata", "cluster.golden"), rec)
+	})
+}
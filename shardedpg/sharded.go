@@ -6,6 +6,7 @@ import (
 	"errors"
 	"reflect"
 
+	"github.com/godepo/elephant/internal/pkg/passthrough"
 	"github.com/godepo/elephant/internal/sharded"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
@@ -16,6 +17,18 @@ var (
 	ErrNoShardPickerProvided   = errors.New("sharded pg: no sharded picker provided")
 	ErrNotEnoughShardsProvided = errors.New("sharded pg: provided less shards than pool size")
 	ErrNilShardProvided        = errors.New("sharded pg: nil shard provided")
+
+	ErrBulkNotSupported  = passthrough.ErrBulkNotSupported
+	ErrBulkInTransaction = sharded.ErrBulkInTransaction
+	ErrUnknownShard      = sharded.ErrUnknownShard
+	ErrShardUnavailable  = sharded.ErrShardUnavailable
+)
+
+type (
+	RowKey      = sharded.RowKey
+	QueryKey    = sharded.QueryKey
+	ShardResult = sharded.ShardResult
+	BulkResults = sharded.BulkResults
 )
 
 type Pool interface {
@@ -35,6 +48,16 @@ type Builder interface {
 
 type ShardPicker func(ctx context.Context, key string) uint
 
+// HashPicker picks shard by FNV-1a hash of sharding key modulo count of shards.
+func HashPicker(shards uint) ShardPicker {
+	return ShardPicker(sharded.HashPicker(shards))
+}
+
+// JumpPicker picks shard by jump consistent hash of sharding key, only 1/n of keys move when n-th shard is added.
+func JumpPicker(shards uint) ShardPicker {
+	return ShardPicker(sharded.JumpPicker(shards))
+}
+
 type builder struct {
 	size   uint
 	shards map[uint]Pool
@@ -412,3 +412,13 @@ func TestNew(t *testing.T) {
 			})
 	})
 }
+
+func TestPickers(t *testing.T) {
+	t.Run("should be able to pick shard in range of pool size", func(t *testing.T) {
+		for _, pick := range []ShardPicker{HashPicker(3), JumpPicker(3)} {
+			id := pick(context.Background(), "user-42")
+			assert.Less(t, id, uint(3))
+			assert.Equal(t, id, pick(context.Background(), "user-42"))
+		}
+	})
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"testing"
 
+	"github.com/godepo/elephant"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
@@ -24,4 +26,10 @@ func TestNew(t *testing.T) {
 		})
 		require.NoError(t, err)
 	})
+	t.Run("should be able to expose listener and advisory locks by type assertion", func(t *testing.T) {
+		db := New(NewMockPool(t))
+
+		assert.Implements(t, (*elephant.Listener)(nil), db)
+		assert.Implements(t, (*elephant.AdvisoryLocker)(nil), db)
+	})
 }
@@ -0,0 +1,106 @@
+package tenantpg
+
+import (
+	"context"
+	"errors"
+	"reflect"
+
+	"github.com/godepo/elephant/internal/pkg/passthrough"
+	"github.com/godepo/elephant/internal/tenant"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+var (
+	ErrNoTenantsProvided     = errors.New("tenant pg: no shared pool or tenant database provided")
+	ErrNoSchemaNamerProvided = errors.New("tenant pg: no schema namer provided for shared pool")
+	ErrNilPoolProvided       = errors.New("tenant pg: nil pool provided")
+
+	ErrNoTenant            = tenant.ErrNoTenant
+	ErrUnknownTenant       = tenant.ErrUnknownTenant
+	ErrTenantMismatch      = tenant.ErrTenantMismatch
+	ErrAcquireNotSupported = passthrough.ErrAcquireNotSupported
+	ErrBulkNotSupported    = passthrough.ErrBulkNotSupported
+	ErrListenNotSupported  = passthrough.ErrListenNotSupported
+	ErrLockNotSupported    = passthrough.ErrLockNotSupported
+)
+
+// SchemaNamer returns schema of tenant in shared database, empty schema means that tenant is unknown.
+type SchemaNamer = tenant.SchemaNamer
+
+type Pool interface {
+	BeginTx(ctx context.Context, opts pgx.TxOptions) (pgx.Tx, error)
+	Begin(ctx context.Context) (pgx.Tx, error)
+	Query(ctx context.Context, query string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, query string, args ...interface{}) pgx.Row
+	Exec(ctx context.Context, query string, args ...interface{}) (pgconn.CommandTag, error)
+	Transactional(ctx context.Context, fn func(ctx context.Context) error) (out error)
+}
+
+type Builder interface {
+	Schemas(shared Pool, schema SchemaNamer) Builder
+	Database(tenant string, pool Pool) Builder
+	Go() (*tenant.Router, error)
+}
+
+type builder struct {
+	shared    Pool
+	schema    SchemaNamer
+	databases map[string]Pool
+}
+
+func New() Builder {
+	return &builder{
+		databases: map[string]Pool{},
+	}
+}
+
+// SchemaPrefix names schema of tenant by prefix and tenant ID.
+func SchemaPrefix(prefix string) SchemaNamer {
+	return func(tenant string) string {
+		return prefix + tenant
+	}
+}
+
+// Schemas sets shared pool of tenants which live in schemas named by schema.
+func (b *builder) Schemas(shared Pool, schema SchemaNamer) Builder {
+	b.shared = shared
+	b.schema = schema
+	return b
+}
+
+// Database sets pool of tenant which lives in dedicated database, it takes precedence over shared pool.
+func (b *builder) Database(tenant string, pool Pool) Builder {
+	b.databases[tenant] = pool
+	return b
+}
+
+func (b *builder) Go() (*tenant.Router, error) {
+	if b.shared == nil && len(b.databases) == 0 {
+		return nil, ErrNoTenantsProvided
+	}
+	var shared tenant.Pool
+	if b.shared != nil {
+		if isNil(b.shared) {
+			return nil, ErrNilPoolProvided
+		}
+		if b.schema == nil {
+			return nil, ErrNoSchemaNamerProvided
+		}
+		shared = b.shared
+	}
+
+	databases := make(map[string]tenant.Pool, len(b.databases))
+	for id, pool := range b.databases {
+		if pool == nil || isNil(pool) {
+			return nil, ErrNilPoolProvided
+		}
+		databases[id] = pool
+	}
+	return tenant.New(shared, b.schema, databases), nil
+}
+
+func isNil(pool Pool) bool {
+	val := reflect.ValueOf(pool)
+	return val.Kind() == reflect.Pointer && val.IsNil()
+}
@@ -0,0 +1,61 @@
+package tenantpg
+
+import (
+	"context"
+	"testing"
+
+	"github.com/godepo/elephant"
+	"github.com/godepo/elephant/elephanttest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type User struct {
+	ID int64
+}
+
+func TestBuilder(t *testing.T) {
+	t.Run("should be able to route tenants to schema or dedicated database", func(t *testing.T) {
+		shared, acme := elephanttest.New(t), elephanttest.New(t)
+		shared.ExpectExec(elephanttest.Exact(`SET LOCAL search_path TO "tenant_globex"`)).InTx()
+		shared.ExpectQuery(elephanttest.Exact("SELECT id FROM users")).InTx().
+			ReturnRows(elephanttest.NewRows("id").Add(int64(1)))
+		acme.ExpectQuery(elephanttest.Exact("SELECT id FROM users")).OutsideTx().
+			ReturnRows(elephanttest.NewRows("id").Add(int64(2)))
+
+		router, err := New().
+			Schemas(shared, SchemaPrefix("tenant_")).
+			Database("acme", acme).
+			Go()
+		require.NoError(t, err)
+
+		globex := elephant.With(context.Background(), elephant.WithTenant("globex"))
+		users, err := elephant.All[User](globex, router, "SELECT id FROM users")
+		require.NoError(t, err)
+		assert.Equal(t, []User{{ID: 1}}, users)
+		assert.Len(t, shared.Committed(), 2)
+
+		acmeCtx := elephant.With(context.Background(), elephant.WithTenant("acme"))
+		users, err = elephant.All[User](acmeCtx, router, "SELECT id FROM users")
+		require.NoError(t, err)
+		assert.Equal(t, []User{{ID: 2}}, users)
+
+		_, err = elephant.All[User](context.Background(), router, "SELECT id FROM users")
+		require.ErrorIs(t, err, ErrNoTenant)
+	})
+
+	t.Run("should be able to fail on invalid configuration", func(t *testing.T) {
+		var nilPool *elephanttest.Pool
+
+		_, err := New().Go()
+		require.ErrorIs(t, err, ErrNoTenantsProvided)
+		_, err = New().Schemas(elephanttest.New(t), nil).Go()
+		require.ErrorIs(t, err, ErrNoSchemaNamerProvided)
+		_, err = New().Schemas(nilPool, SchemaPrefix("tenant_")).Go()
+		require.ErrorIs(t, err, ErrNilPoolProvided)
+		_, err = New().Database("acme", nil).Go()
+		require.ErrorIs(t, err, ErrNilPoolProvided)
+		_, err = New().Database("acme", nilPool).Go()
+		require.ErrorIs(t, err, ErrNilPoolProvided)
+	})
+}